@@ -1,13 +1,32 @@
 package api
 
 import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+
 	"github.com/jtejido/go-sphinx/decoder/adaptation"
+	"github.com/jtejido/go-sphinx/decoder/scorer"
+	"github.com/jtejido/go-sphinx/decoder/search"
+	"github.com/jtejido/go-sphinx/frontend"
+	"github.com/jtejido/go-sphinx/frontend/auto"
+	feutil "github.com/jtejido/go-sphinx/frontend/util"
 	"github.com/jtejido/go-sphinx/recognizer"
+	"github.com/jtejido/go-sphinx/util"
+)
+
+const (
+	// The grid of the warp factor search of EstimateWarpFactor.
+	MIN_WARP_FACTOR  = 0.80
+	MAX_WARP_FACTOR  = 1.20
+	WARP_FACTOR_STEP = 0.02
 )
 
 // Base struct for high-level speech recognizers.
 type BaseSpeechRecognizer struct {
 	context              *Context
+	frontEnd             *frontend.FrontEnd
 	recognizer           *recognizer.Recognizer
 	clusters             *adaptation.ClusteredDensityFileData
 	speechSourceProvider *SpeechSourceProvider
@@ -15,15 +34,16 @@ type BaseSpeechRecognizer struct {
 
 // Returns result of the recognition.
 //
-// recognition result or nil if there is no result, e.g., because the microphone or input stream has been closed
-func (br *BaseSpeechRecognizer) GetResult() *SpeechResult {
-	result := br.recognizer.Recognize(nil)
+// recognition result or nil if there is no result, e.g., because the microphone or input stream has been closed, with
+// the error which stopped the recognition if any
+func (br *BaseSpeechRecognizer) GetResult() (*SpeechResult, error) {
+	result, err := br.recognizer.Recognize(nil)
 
 	if result == nil {
-		return nil
+		return nil, err
 	}
 
-	return NewSpeechResult(result)
+	return NewSpeechResult(result), err
 
 }
 
@@ -47,3 +67,141 @@ func (br *BaseSpeechRecognizer) LoadTransform(path string, numClass int) error {
 	br.context.GetLoader().Update(transform, br.clusters)
 	return nil
 }
+
+// Sets the vocal tract length normalization warp factor used for the following
+// recognitions, as estimated by EstimateWarpFactor.
+// Accepts the factor of the piecewise linear warp of the mel filterbank,
+// below 1 for speakers with a shorter vocal tract, 1 for no warping.
+// Returns an error if the factor is not positive or the acoustic model has no
+// mel cepstrum to warp.
+func (br *BaseSpeechRecognizer) SetWarpFactor(warpFactor float64) error {
+	return auto.SetWarpFactor(br.frontEnd, warpFactor)
+}
+
+// Estimates the vocal tract length normalization warp factor of a speaker.
+//
+// The audio is decoded once without warping, and the HMM states of the best
+// path give an alignment of its frames. The features are then computed again
+// for each warp factor of the grid, and the factor under which the aligned
+// states score the highest acoustic likelihood is chosen and set for the
+// following recognitions with SetWarpFactor.
+//
+// Accepts the audio of the speaker, in the format of StartRecognition, and the
+// warp factors to try, nil for MIN_WARP_FACTOR to MAX_WARP_FACTOR by
+// WARP_FACTOR_STEP.
+func (br *BaseSpeechRecognizer) EstimateWarpFactor(stream io.Reader, warpFactors []float64) (float64, error) {
+	if len(warpFactors) == 0 {
+		for factor := MIN_WARP_FACTOR; factor <= MAX_WARP_FACTOR+WARP_FACTOR_STEP/2; factor += WARP_FACTOR_STEP {
+			warpFactors = append(warpFactors, factor)
+		}
+	}
+
+	audio, err := io.ReadAll(stream)
+	if err != nil {
+		return 0, err
+	}
+
+	// first pass, without warping
+	if err := br.SetWarpFactor(1); err != nil {
+		return 0, err
+	}
+	if br.recognizer.State() == recognizer.DEALLOCATED {
+		if err := br.recognizer.Allocate(); err != nil {
+			return 0, err
+		}
+		defer br.recognizer.Deallocate()
+	}
+	if err := br.context.SetSpeechSource(bytes.NewReader(audio), util.INFINITE); err != nil {
+		return 0, err
+	}
+	result, err := br.recognizer.Recognize(nil)
+	if err != nil {
+		return 0, err
+	}
+	if result == nil || result.GetBestToken() == nil {
+		return 0, errors.New("no recognition result to align the warp factor with")
+	}
+	alignment := alignmentOf(result.GetBestToken())
+
+	bestFactor, bestScore := 1.0, math.Inf(-1)
+	for _, factor := range warpFactors {
+		score, err := br.alignmentScore(audio, alignment, factor)
+		if err != nil {
+			return 0, err
+		}
+		if score > bestScore {
+			bestFactor, bestScore = factor, score
+		}
+	}
+
+	return bestFactor, br.SetWarpFactor(bestFactor)
+}
+
+// alignmentOf maps the first sample of each frame of the best path to the
+// state that scored it.
+func alignmentOf(token *search.Token) map[int64]scorer.ScoreProvider {
+	alignment := make(map[int64]scorer.ScoreProvider)
+	for ; token != nil; token = token.GetPredecessor() {
+		if !token.IsEmitting() {
+			continue
+		}
+		state, ok := token.GetSearchState().(scorer.ScoreProvider)
+		feature, hasFrame := token.GetData().(interface{ FirstSampleNumber() int64 })
+		if ok && hasFrame {
+			alignment[feature.FirstSampleNumber()] = state
+		}
+	}
+	return alignment
+}
+
+// alignmentScore computes the features of the audio with the given warp
+// factor and sums their scores against the aligned states. The features come
+// from the batch front end, so that no frame of the alignment is dropped by
+// the voice activity detector.
+func (br *BaseSpeechRecognizer) alignmentScore(audio []byte, alignment map[int64]scorer.ScoreProvider, warpFactor float64) (float64, error) {
+	frontEnd, err := auto.NewFrontEnd(br.context.GetLoader())
+	if err != nil {
+		return 0, err
+	}
+	if err := auto.SetWarpFactor(frontEnd, warpFactor); err != nil {
+		return 0, err
+	}
+
+	// read the audio the way the recognizer does
+	source := br.context.GetInstance("dataSource").(*feutil.StreamDataSource)
+	format := source.AudioFormat()
+	dataSource := feutil.NewDefaultStreamDataSource()
+	if err := dataSource.SetAudioFormat(&format); err != nil {
+		return 0, err
+	}
+	dataSource.SetChannel(source.Channel())
+	dataSource.Initialize()
+	if err := dataSource.SetAudioStream(bytes.NewReader(audio), util.INFINITE); err != nil {
+		return 0, err
+	}
+	frontEnd.SetDataSource(dataSource)
+
+	var score float64
+	var frames int
+	for {
+		data, err := frontEnd.GetData()
+		if err != nil {
+			return 0, err
+		}
+		if data == nil {
+			break
+		}
+		feature, ok := data.(*frontend.FloatData)
+		if !ok {
+			continue
+		}
+		if state, ok := alignment[feature.FirstSampleNumber()]; ok {
+			score += state.GetScore(feature)
+			frames++
+		}
+	}
+	if frames == 0 {
+		return 0, errors.New("no aligned frame to score the warp factor with")
+	}
+	return score, nil
+}
@@ -13,8 +13,12 @@ type Configuration struct {
 	GrammarPath string
 	// grammar name
 	GrammarName string
-	// The configured sample rate.
+	// The configured sample rate. Audio at another rate than the acoustic
+	// model's is resampled by the front end.
 	SampleRate int
+	// The channel of multi-channel audio to decode, starting at 1. Zero mixes
+	// all the channels down to mono.
+	Channel int
 	// Whether fixed grammar should be used instead of language model.
 	UseGrammar bool
 }
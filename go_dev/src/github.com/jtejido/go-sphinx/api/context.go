@@ -1,27 +1,42 @@
 package api
 
 import (
+	"fmt"
 	"io"
+	"path/filepath"
 	"strconv"
+	"strings"
 
-	"github.com/jtejido/go-sphinx/frontend/util"
+	"github.com/jtejido/go-sphinx/frontend"
+	"github.com/jtejido/go-sphinx/frontend/auto"
+	feutil "github.com/jtejido/go-sphinx/frontend/util"
+	"github.com/jtejido/go-sphinx/linguist/acoustic"
 	"github.com/jtejido/go-sphinx/linguist/acoustic/tiedstate"
+	"github.com/jtejido/go-sphinx/linguist/dictionary"
+	"github.com/jtejido/go-sphinx/linguist/language/ngram"
+	"github.com/jtejido/go-sphinx/util"
 	"github.com/jtejido/go-sphinx/util/props"
 )
 
+// The language model of the lexTreeLinguist unless SetLanguageModel selects another.
+const DEFAULT_LANGUAGE_MODEL = "simpleNGramModel"
+
 type Context struct {
 	configurationManager *props.ConfigurationManager
+	languageModel        string
 }
 
 // Constructs builder that uses default XML configuration.
-func NewDefaultContext(config *Configuration) *Context {
+func NewDefaultContext(config *Configuration) (*Context, error) {
 	return NewContext("default.config.yml", config)
 }
 
 // Constructs builder using user-supplied toml configuration.
-func NewContext(path string, config *Configuration) *Context {
+// Returns an error if the language model has an unknown format.
+func NewContext(path string, config *Configuration) (*Context, error) {
 	ctx := new(Context)
 	ctx.configurationManager = props.NewConfigurationManager(path)
+	ctx.languageModel = DEFAULT_LANGUAGE_MODEL
 
 	ctx.SetAcousticModel(config.AcousticModelPath)
 	ctx.SetDictionary(config.DictionaryPath)
@@ -31,22 +46,26 @@ func NewContext(path string, config *Configuration) *Context {
 	}
 
 	if len(config.LanguageModelPath) > 0 && !config.UseGrammar {
-		ctx.SetLanguageModel(config.LanguageModelPath)
+		if err := ctx.SetLanguageModel(config.LanguageModelPath); err != nil {
+			return nil, err
+		}
 	}
 
 	ctx.SetSampleRate(config.SampleRate)
-	return ctx
+	ctx.SetChannel(config.Channel)
+	return ctx, nil
 }
 
 // Sets acoustic model location.
 //
-// It also reads feat.params which should be located at the root of
-// acoustic model and sets corresponding parameters of MelFrequencyFilterBank2 instance.
+// The loader also reads feat.params which should be located at the root of
+// acoustic model; the front end (auto.AutoCepstrum) configures its
+// MelFrequencyFilterBank2, DCT and lifter from it.
 //
 // Accepts path to directory with acoustic model files.
 func (ctx *Context) SetAcousticModel(path string) {
 	ctx.SetLocalProperty("acousticModelLoader->location", path)
-	ctx.SetLocalProperty("dictionary->fillerPath", util.PathJoin(path, "noisedict"))
+	ctx.SetLocalProperty("dictionary->fillerPath", filepath.Join(path, "noisedict"))
 }
 
 // Sets dictionary.
@@ -56,58 +75,101 @@ func (ctx *Context) SetDictionary(path string) {
 }
 
 // Sets sampleRate.
-// Accepts sample rate of the input stream.
+// Accepts sample rate of headerless input streams. It need not match the
+// acoustic model: the front end of NewFrontEnd resamples to the -samprate of
+// feat.params.
 func (ctx *Context) SetSampleRate(sampleRate int) {
 	ctx.SetLocalProperty("dataSource->sampleRate", strconv.Itoa(sampleRate))
 }
 
+// Sets the channel of multi-channel input to decode.
+// Accepts the channel starting at 1, or 0 to mix all channels down.
+func (ctx *Context) SetChannel(channel int) {
+	ctx.GetInstance("dataSource").(*feutil.StreamDataSource).SetChannel(channel - 1)
+}
+
 // Sets path to the grammar files.
 //
 // Enables static grammar and disables probabilistic language model.
-// JSGF and GrXML formats are supported.
+// JSGF and GrXML formats are supported: a name with the ".grxml" extension
+// selects a GrXML grammar (grXmlGrammar), any other a JSGF one (jsgfGrammar).
 //
 // Accepts path to the grammar files and name of the main grammar to use.
 func (ctx *Context) SetGrammar(path, name string) {
+	grammar := "jsgfGrammar"
+	if strings.ToLower(filepath.Ext(name)) == ".grxml" {
+		grammar = "grXmlGrammar"
+	}
 
-	ctx.SetLocalProperty("jsgfGrammar->grammarLocation", path)
-	ctx.SetLocalProperty("jsgfGrammar->grammarName", name)
-	ctx.SetLocalProperty("flatLinguist->grammar", "jsgfGrammar")
+	ctx.SetLocalProperty(grammar+"->grammarLocation", path)
+	ctx.SetLocalProperty(grammar+"->grammarName", name)
+	ctx.SetLocalProperty("flatLinguist->grammar", grammar)
 	ctx.SetLocalProperty("decoder->searchManager", "simpleSearchManager")
 }
 
 // Sets path to the language model.
 //
 // Enables probabilistic language model and disables static grammar.
-// Currently it supports ".lm", ".dmp" and ".bin" file formats.
+// The model is selected from the file extension: ".lm" for an ARPA model
+// (simpleNGramModel), ".dmp" for a Sphinx-3 DMP model (largeTrigramModel)
+// and ".bin" for a binary trie model (trieNgramModel).
 //
 // Accepts path to the language model file.
-func (ctx *Context) SetLanguageModel(path string) {
-	// if (path.endsWith(".lm")) {
-	//     setLocalProperty("simpleNGramModel->location", path);
-	//     setLocalProperty(
-	//         "lexTreeLinguist->languageModel", "simpleNGramModel");
-	// } else if (path.endsWith(".dmp")) {
-	//     setLocalProperty("largeTrigramModel->location", path);
-	//     setLocalProperty(
-	//         "lexTreeLinguist->languageModel", "largeTrigramModel");
-	// } else if (path.endsWith(".bin")) {
-	//     setLocalProperty("trieNgramModel->location", path);
-	//     setLocalProperty(
-	//         "lexTreeLinguist->languageModel", "trieNgramModel");
-	// } else {
-	//     throw new IllegalArgumentException(
-	//         "Unknown format extension: " + path);
-	// }
-
-	ctx.SetLocalProperty("trieNgramModel->location", path)
-	ctx.SetLocalProperty("lexTreeLinguist->languageModel", "trieNgramModel")
+// Returns an error for any other extension.
+func (ctx *Context) SetLanguageModel(path string) error {
+	var model string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".lm":
+		model = "simpleNGramModel"
+	case ".dmp":
+		model = "largeTrigramModel"
+	case ".bin":
+		model = "trieNgramModel"
+	default:
+		return fmt.Errorf("unknown format extension: %s", path)
+	}
+
+	ctx.SetLocalProperty(model+"->location", path)
+	ctx.SetLocalProperty("lexTreeLinguist->languageModel", model)
+	ctx.languageModel = model
+	return nil
 }
 
 // Sets byte stream as the speech source.
-func (ctx *Context) SetSpeechSource(stream io.Reader, timeFrame *util.TimeFrame) {
-	ds := ctx.GetInstance("dataSource").(util.StreamDataSource)
-	ds.SetInputStream(stream, timeFrame)
-	ctx.SetLocalProperty("trivialScorer->frontend", "liveFrontEnd")
+//
+// WAV and AIFF streams configure the data source from their header; anything else is read as headerless audio at
+// the configured sample rate.
+func (ctx *Context) SetSpeechSource(stream io.Reader, timeFrame *util.TimeFrame) error {
+	ds := ctx.GetInstance("dataSource").(*feutil.StreamDataSource)
+	return ds.SetAudioStream(stream, timeFrame)
+}
+
+// Builds the front end the acoustic model was trained with, as configured by
+// its feat.params, reading from the data source of the speech source. The
+// acoustic model is loaded first if it isn't yet.
+// Returns an error if the model fails to load, feat.params holds invalid
+// values or the features do not fit the model.
+func (ctx *Context) NewFrontEnd() (*frontend.FrontEnd, error) {
+	return ctx.newFrontEnd(auto.NewFrontEnd)
+}
+
+// Builds the front end of NewFrontEnd behind a voice activity detector, which
+// drops the audio outside of speech and splits the stream into utterances.
+func (ctx *Context) NewLiveFrontEnd() (*frontend.FrontEnd, error) {
+	return ctx.newFrontEnd(auto.NewLiveFrontEnd)
+}
+
+func (ctx *Context) newFrontEnd(build func(auto.Loader) (*frontend.FrontEnd, error)) (*frontend.FrontEnd, error) {
+	loader := ctx.GetLoader()
+	if err := loader.Load(); err != nil {
+		return nil, err
+	}
+	frontEnd, err := build(loader)
+	if err != nil {
+		return nil, err
+	}
+	frontEnd.SetDataSource(ctx.GetInstance("dataSource").(*feutil.StreamDataSource))
+	return frontEnd, nil
 }
 
 // Sets property within a "component" tag in configuration.
@@ -137,3 +199,23 @@ func (ctx *Context) GetInstance(c string) interface{} {
 func (ctx *Context) GetLoader() tiedstate.Loader {
 	return ctx.configurationManager.Lookup("acousticModelLoader").(tiedstate.Loader)
 }
+
+// Returns the acoustic model.
+func (ctx *Context) GetAcousticModel() acoustic.AcousticModel {
+	return ctx.configurationManager.Lookup("acousticModel").(acoustic.AcousticModel)
+}
+
+// Returns the unit manager shared by the acoustic model and the dictionary.
+func (ctx *Context) GetUnitManager() *acoustic.UnitManager {
+	return ctx.configurationManager.Lookup("unitManager").(*acoustic.UnitManager)
+}
+
+// Returns the dictionary.
+func (ctx *Context) GetDictionary() dictionary.Dictionary {
+	return ctx.configurationManager.Lookup("dictionary").(dictionary.Dictionary)
+}
+
+// Returns the language model selected by SetLanguageModel.
+func (ctx *Context) GetLanguageModel() ngram.LanguageModel {
+	return ctx.configurationManager.Lookup(ctx.languageModel).(ngram.LanguageModel)
+}
@@ -3,37 +3,57 @@ package api
 import (
 	"io"
 
+	"github.com/jtejido/go-sphinx/decoder"
+	"github.com/jtejido/go-sphinx/decoder/search"
 	"github.com/jtejido/go-sphinx/recognizer"
 	"github.com/jtejido/go-sphinx/util"
 )
 
 // Speech recognizer that works with audio resources.
+//
+// The audio goes through the live front end of the acoustic model, whose voice
+// activity detector drops the silence: each call of GetResult decodes the next
+// utterance of the stream.
 type StreamSpeechRecognizer struct {
 	BaseSpeechRecognizer
 }
 
 // Constructs new stream recognizer.
-func NewDefaultStreamSpeechRecognizer(configuration *Configuration) *StreamSpeechRecognizer {
+func NewDefaultStreamSpeechRecognizer(configuration *Configuration) (*StreamSpeechRecognizer, error) {
+	context, err := NewDefaultContext(configuration)
+	if err != nil {
+		return nil, err
+	}
+	frontEnd, err := context.NewLiveFrontEnd()
+	if err != nil {
+		return nil, err
+	}
 	ssr := new(StreamSpeechRecognizer)
-	ssr.context = NewDefaultContext(configuration)
-	// ssr.Recognizer = ssr.Context.GetInstance("recognizer").(recognizer.Recognizer)
+	ssr.context = context
+	ssr.frontEnd = frontEnd
 
-	ssr.recognizer = recognizer.NewDefaultRecognizer() // default hell starts here
+	searchManager := search.NewDefaultWordPruningBreadthFirstLookaheadSearchManager(context.GetAcousticModel(),
+		context.GetUnitManager(), context.GetLanguageModel(), context.GetDictionary(), frontEnd)
+	ssr.recognizer = recognizer.NewDefaultRecognizer(decoder.NewDefaultDecoder(searchManager))
 	ssr.speechSourceProvider = &SpeechSourceProvider{}
-	return ssr
+	return ssr, nil
 }
 
-func (ssr *StreamSpeechRecognizer) StartRecognition(stream io.Reader) {
-	ssr.recognizer.Allocate()
-	ssr.context.SetSpeechSource(stream, util.INFINITE)
+// Starts recognition process.
+//
+// The stream may be a WAV or AIFF file, or headerless audio in the configured format.
+func (ssr *StreamSpeechRecognizer) StartRecognition(stream io.Reader) error {
+	return ssr.StartRecognitionLimit(stream, util.INFINITE)
 }
 
 // Starts recognition process.
 //
 // Starts recognition process and optionally clears previous data.
-func (ssr *StreamSpeechRecognizer) StartRecognitionLimit(stream io.Reader, timeFrame *util.TimeFrame) {
-	ssr.recognizer.Allocate()
-	ssr.context.SetSpeechSource(stream, timeFrame)
+func (ssr *StreamSpeechRecognizer) StartRecognitionLimit(stream io.Reader, timeFrame *util.TimeFrame) error {
+	if err := ssr.recognizer.Allocate(); err != nil {
+		return err
+	}
+	return ssr.context.SetSpeechSource(stream, timeFrame)
 }
 
 // Stops recognition process.
@@ -13,8 +13,10 @@ type BaseDecoder struct {
 	name                string
 }
 
-func (bd *BaseDecoder) Allocate() {
+// Allocates the search manager, returning the error which left it unallocated if any.
+func (bd *BaseDecoder) Allocate() error {
 	bd.searchManager.Allocate()
+	return bd.searchManager.Err()
 }
 
 func (bd *BaseDecoder) Deallocate() {
@@ -19,10 +19,12 @@ type Decoder struct {
 	featureBlockSize int
 }
 
-func NewDefaultDecoder() *Decoder {
+// Creates a decoder of the given search manager that neither allocates it nor
+// fires non-final results, and decodes whole utterances at once.
+func NewDefaultDecoder(searchManager search.SearchManager) *Decoder {
 	// this doesn't autoallocate
 	d := new(Decoder)
-	d.searchManager = search.NewDefaultWordPruningBreadthFirstLookaheadSearchManager()
+	d.searchManager = searchManager
 	d.fireNonFinalResults = DEFAULT_FIRE_NON_FINAL_RESULTS
 
 	d.featureBlockSize = DEFAULT_FEATURE_BLOCK_SIZE
@@ -45,7 +47,9 @@ func NewDecoder(searchManager search.SearchManager, fireNonFinalResults, autoAll
 
 }
 
-func (d *Decoder) Decode(referenceText io.Reader) (result *result.Result) {
+// Decodes an utterance, returning its final result, or nil with the error
+// which stopped the search manager.
+func (d *Decoder) Decode(referenceText io.Reader) (result *result.Result, err error) {
 	d.searchManager.StartRecognition()
 	for {
 		result = d.searchManager.Recognize(d.featureBlockSize)
@@ -59,5 +63,5 @@ func (d *Decoder) Decode(referenceText io.Reader) (result *result.Result) {
 		}
 	}
 	d.searchManager.StopRecognition()
-	return
+	return result, d.searchManager.Err()
 }
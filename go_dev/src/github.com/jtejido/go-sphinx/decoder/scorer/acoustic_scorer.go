@@ -19,10 +19,12 @@ type AcousticScorer interface {
 	// stops the scorer
 	StopRecognition()
 
-	// Scores the given set of states over previously stored acoustic data if any or a new one
-	CalculateScores([]Scoreable) frontend.Data
+	// Scores the given set of states over previously stored acoustic data if any or a new one. Returns the best
+	// scoring state, the signal ending the utterance, or nil at the end of the stream, and the error of the frontend
+	// if the data couldn't be read
+	CalculateScores([]Scoreable) (frontend.Data, error)
 
 	// Scores the given set of states over previously acoustic data from frontend
 	// and stores latter in the queue
-	CalculateScoresAndStoreData([]Scoreable) frontend.Data
+	CalculateScoresAndStoreData([]Scoreable) (frontend.Data, error)
 }
@@ -5,5 +5,5 @@ package scorer
 type ScoreNormalizer interface {
 
 	// Normalizes the scores of a set of Tokens.
-	Normalize([]Scoreable, Scoreable)
+	Normalize([]Scoreable, Scoreable) Scoreable
 }
@@ -0,0 +1,15 @@
+package scorer
+
+import (
+	"github.com/jtejido/go-sphinx/frontend"
+)
+
+// Thing that can provide the score
+type ScoreProvider interface {
+
+	// Provides the score
+	GetScore(feature frontend.Data) float64
+
+	// Provides component score
+	GetComponentScore(feature frontend.Data) []float64
+}
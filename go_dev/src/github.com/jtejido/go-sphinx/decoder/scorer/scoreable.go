@@ -17,15 +17,15 @@ type Scoreable interface {
 	/**
 	 * Calculates a score against the given data. The score can be retrieved with get score
 	 */
-	CalculateScore(frontend.Data) float32
+	CalculateScore(frontend.Data) float64
 
 	/**
 	 * Retrieves a previously calculated (and possibly normalized) score
 	 */
-	GetScore() float32
+	GetScore() float64
 
 	/**
 	 * Normalizes a previously calculated score
 	 */
-	NormalizeScore(maxScore float32) float64
+	NormalizeScore(maxScore float64) float64
 }
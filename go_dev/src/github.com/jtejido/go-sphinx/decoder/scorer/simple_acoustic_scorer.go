@@ -1,9 +1,10 @@
 package scorer
 
 import (
+	"math"
+
 	fe "github.com/jtejido/go-sphinx/frontend"
 	"github.com/jtejido/go-sphinx/frontend/util"
-	"math"
 )
 
 // Implements some basic scorer functionality, including a simple default
@@ -19,12 +20,14 @@ type SimpleAcousticScorer struct {
 	// will be returned unchanged.
 	scoreNormalizer ScoreNormalizer
 	storedData      []fe.Data
-	seenEnd         bool
+	inUtterance     bool
 }
 
-func NewDefaultSimpleAcousticScorer() *SimpleAcousticScorer {
+// Creates a scorer of the features of the given front end, without score
+// normalization.
+func NewDefaultSimpleAcousticScorer(frontEnd *fe.FrontEnd) *SimpleAcousticScorer {
 	sas := new(SimpleAcousticScorer)
-	sas.frontEnd = fe.NewDefaultFrontend()
+	sas.frontEnd = frontEnd
 	sas.storedData = make([]fe.Data, 0)
 
 	return sas
@@ -39,92 +42,69 @@ func NewSimpleAcousticScorer(frontEnd *fe.FrontEnd, scoreNormalizer ScoreNormali
 	return sas
 }
 
-func (sas *SimpleAcousticScorer) CalculateScores(scoreableList []Scoreable) fe.Data {
+func (sas *SimpleAcousticScorer) CalculateScores(scoreableList []Scoreable) (fe.Data, error) {
 	var data fe.Data
 
 	if len(sas.storedData) == 0 {
-
-		for {
-			data = sas.getNextData()
-			_, ok := data.(fe.Signal)
-
-			if !ok {
-				break
-			}
-
-			_, ses_ok := data.(fe.SpeechEndSignal)
-
-			if ses_ok {
-				seenEnd = true
-				break
-			}
-
-			_, des_ok := data.(fe.SpeechEndSignal)
-
-			if des_ok {
-				if sas.seenEnd {
-					return nil
-				} else {
-					break
-				}
-			}
-		}
+		var err error
+		data, err = sas.nextData()
 		if data == nil {
-			return nil
+			return nil, err
 		}
 	} else {
 		data = sas.storedData[0]
 		// remove head
 		copy(sas.storedData[0:], sas.storedData[1:])
 		sas.storedData[len(sas.storedData)-1] = nil
-		sas.storedData = sas.storedData[:len(wpbflsm.ciScores)-1]
+		sas.storedData = sas.storedData[:len(sas.storedData)-1]
 	}
 
-	return sas.calculateScoresForData(scoreableList, data)
+	return sas.calculateScoresForData(scoreableList, data), nil
 }
 
-func (sas *SimpleAcousticScorer) CalculateScoresAndStoreData(scoreableList []Scoreable) fe.Data {
-	var data fe.Data
-
-	for {
-		data = sas.getNextData()
-		_, ok := data.(fe.Signal)
+func (sas *SimpleAcousticScorer) CalculateScoresAndStoreData(scoreableList []Scoreable) (fe.Data, error) {
+	data, err := sas.nextData()
+	if data == nil {
+		return nil, err
+	}
 
-		if !ok {
-			break
-		}
+	sas.storedData = append(sas.storedData, data)
 
-		_, ses_ok := data.(fe.SpeechEndSignal)
+	return sas.calculateScoresForData(scoreableList, data), nil
+}
 
-		if ses_ok {
-			seenEnd = true
-			break
+// Skips over signals until actual data arrives. A SpeechEndSignal or DataEndSignal ends the current utterance and is
+// returned as-is so that the search can finish it; an end signal without data before it (such as the DataEndSignal
+// following the last SpeechEndSignal of a stream) is skipped, so the next utterance or the end of the stream follows.
+// Returns nil with the error of the frontend if the data couldn't be read.
+func (sas *SimpleAcousticScorer) nextData() (fe.Data, error) {
+	for {
+		data, err := sas.frontEnd.GetData()
+		if err != nil {
+			return nil, err
 		}
 
-		_, des_ok := data.(fe.SpeechEndSignal)
-
-		if des_ok {
-			if sas.seenEnd {
-				return nil
-			} else {
-				break
+		switch data.(type) {
+		case nil:
+			return nil, nil
+		case *fe.SpeechEndSignal, *fe.DataEndSignal:
+			if !sas.inUtterance {
+				continue
 			}
+			sas.inUtterance = false
+			return data, nil
+		case fe.Signal:
+			continue
 		}
 
+		sas.inUtterance = true
+		return data, nil
 	}
-	if data == nil {
-		return nil
-	}
-
-	sas.storedData = append(sas.storedData, data)
-
-	return sas.calculateScoresForData(scoreableList, data)
 }
 
 func (sas *SimpleAcousticScorer) calculateScoresForData(scoreableList []Scoreable, data fe.Data) fe.Data {
-	var dutil util.DataUtil
-	_, ses_ok := data.(fe.SpeechEndSignal)
-	_, des_ok := data.(fe.SpeechEndSignal)
+	_, ses_ok := data.(*fe.SpeechEndSignal)
+	_, des_ok := data.(*fe.DataEndSignal)
 
 	if ses_ok || des_ok {
 		return data
@@ -134,10 +114,10 @@ func (sas *SimpleAcousticScorer) calculateScoresForData(scoreableList []Scoreabl
 		return nil
 	}
 
-	ddat, ddat_ok := data.(fe.DoubleData)
+	ddat, ddat_ok := data.(*fe.DoubleData)
 	// convert the data to FloatData if not yet done
 	if ddat_ok {
-		data = dutil.DoubleData2FloatData(ddat)
+		data = util.DoubleData2FloatData(ddat)
 	}
 
 	bestToken := sas.doScoring(scoreableList, data)
@@ -151,12 +131,9 @@ func (sas *SimpleAcousticScorer) calculateScoresForData(scoreableList []Scoreabl
 	return bestToken
 }
 
-func (sas *SimpleAcousticScorer) getNextData() fe.Data {
-	return sas.frontEnd.GetData()
-}
-
 func (sas *SimpleAcousticScorer) StartRecognition() {
-	sas.storedData.Clear()
+	sas.storedData = sas.storedData[:0]
+	sas.inUtterance = false
 }
 
 func (sas *SimpleAcousticScorer) StopRecognition() {}
@@ -164,7 +141,7 @@ func (sas *SimpleAcousticScorer) StopRecognition() {}
 func (sas *SimpleAcousticScorer) doScoring(scoreableList []Scoreable, data fe.Data) Scoreable {
 
 	var best Scoreable
-	bestScore := -Float.MaxFloat64
+	bestScore := -math.MaxFloat64
 
 	for _, item := range scoreableList {
 		item.CalculateScore(data)
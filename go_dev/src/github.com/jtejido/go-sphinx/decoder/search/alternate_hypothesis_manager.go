@@ -1,6 +1,26 @@
 package search
 
+// Manages the alternate hypotheses of the lattice: the predecessors which lost to the best predecessor of a word
+// token in the Viterbi search.
 type AlternateHypothesisManager struct {
 	viterbiLoserMap map[*Token][]*Token
 	maxEdges        int
 }
+
+// Creates an alternate hypotheses manager keeping at most maxEdges predecessors per token, the best included.
+func NewAlternateHypothesisManager(maxEdges int) *AlternateHypothesisManager {
+	return &AlternateHypothesisManager{
+		viterbiLoserMap: make(map[*Token][]*Token),
+		maxEdges:        maxEdges,
+	}
+}
+
+// Collects an alternate predecessor of a token.
+func (ahm *AlternateHypothesisManager) AddAlternatePredecessor(token, predecessor *Token) {
+	ahm.viterbiLoserMap[token] = append(ahm.viterbiLoserMap[token], predecessor)
+}
+
+// Returns the alternate predecessors of a token, nil if it has none.
+func (ahm *AlternateHypothesisManager) GetAlternatePredecessors(token *Token) []*Token {
+	return ahm.viterbiLoserMap[token]
+}
@@ -0,0 +1,106 @@
+package search
+
+import (
+	"fmt"
+
+	"github.com/jtejido/go-sphinx/frontend"
+	"github.com/jtejido/go-sphinx/linguist/kws"
+	"github.com/jtejido/go-sphinx/util"
+)
+
+// The default beam of the keyword search, relative to the best score of the frame.
+const DEFAULT_KWS_RELATIVE_BEAM_WIDTH = kws.DEFAULT_RELATIVE_BEAM_WIDTH
+
+// Spots the keyphrases of a KeywordSpottingLinguist in an unbounded stream of frames with a kws.KeywordSpotter.
+//
+// Recognize returns a final result per detection, with the keyphrase, its frames and its confidence, or a partial
+// result if none was detected in the frames processed. It returns nil once the stream ended.
+type KeywordSpottingSearchManager struct {
+	linguist  *kws.KeywordSpottingLinguist
+	spotter   *kws.KeywordSpotter
+	logger    util.Logger
+	allocated bool
+	err       error
+}
+
+// Creates a KeywordSpottingSearchManager with the default beam.
+func NewDefaultKeywordSpottingSearchManager(linguist *kws.KeywordSpottingLinguist,
+	frontend frontend.DataProcessor) *KeywordSpottingSearchManager {
+	return NewKeywordSpottingSearchManager(linguist, frontend, DEFAULT_KWS_RELATIVE_BEAM_WIDTH, nil)
+}
+
+// Creates a KeywordSpottingSearchManager.
+//
+// Accepts the linguist of the keyphrases, the front end providing the features, the linear beam relative to the best
+// score of a frame, and an optional logger.
+func NewKeywordSpottingSearchManager(linguist *kws.KeywordSpottingLinguist, frontend frontend.DataProcessor,
+	relativeBeamWidth float64, logger util.Logger) *KeywordSpottingSearchManager {
+	return &KeywordSpottingSearchManager{
+		linguist: linguist,
+		spotter:  kws.NewKeywordSpotter(linguist, frontend, relativeBeamWidth, logger),
+		logger:   logger,
+	}
+}
+
+// Allocates the linguist. A linguist that can't be allocated leaves the search manager unallocated, recognizing
+// nothing, and Err returns its error.
+func (sm *KeywordSpottingSearchManager) Allocate() {
+	sm.err = nil
+	if err := sm.linguist.Allocate(); err != nil {
+		sm.err = fmt.Errorf("keyword search: %w", err)
+		if sm.logger != nil {
+			sm.logger.Errorf("%v", sm.err)
+		}
+		return
+	}
+	sm.allocated = true
+}
+
+func (sm *KeywordSpottingSearchManager) Deallocate() {
+	if !sm.allocated {
+		return
+	}
+	sm.allocated = false
+	sm.linguist.Deallocate()
+}
+
+// Starts spotting: every keyphrase and every phone of the loop is entered at the first frame.
+func (sm *KeywordSpottingSearchManager) StartRecognition() {
+	if !sm.allocated {
+		return
+	}
+	sm.linguist.StartRecognition()
+	sm.spotter.Start()
+}
+
+func (sm *KeywordSpottingSearchManager) StopRecognition() {
+	if !sm.allocated {
+		return
+	}
+	sm.linguist.StopRecognition()
+	sm.spotter.Stop()
+}
+
+// Processes frames until a keyphrase is detected, at most nFrames of them. Returns nil if the search manager isn't
+// allocated, or if the features couldn't be read, in which case Err returns the error.
+func (sm *KeywordSpottingSearchManager) Recognize(nFrames int) *Result {
+	if !sm.allocated || sm.spotter.Err() != nil {
+		return nil
+	}
+	if detection := sm.spotter.Spot(nFrames); detection != nil {
+		return NewKeywordResult(detection.Keyphrase.Phrase, detection.StartFrame, detection.EndFrame,
+			detection.Confidence, detection.CollectTime)
+	}
+	if sm.spotter.IsStreamEnd() || sm.spotter.Err() != nil {
+		return nil
+	}
+	return NewKeywordResult("", 0, 0, 0, sm.spotter.GetCollectTime())
+}
+
+// Returns the error which left the search manager unallocated or stopped spotting, nil if none.
+func (sm *KeywordSpottingSearchManager) Err() error {
+	if sm.err != nil {
+		return sm.err
+	}
+	return sm.spotter.Err()
+}
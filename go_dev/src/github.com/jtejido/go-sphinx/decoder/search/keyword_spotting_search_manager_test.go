@@ -0,0 +1,71 @@
+package search
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jtejido/go-sphinx/frontend"
+	"github.com/jtejido/go-sphinx/linguist/acoustic"
+	"github.com/jtejido/go-sphinx/linguist/acoustic/acoustictest"
+	"github.com/jtejido/go-sphinx/linguist/kws"
+)
+
+func newTestKeywordSpottingSearchManager(t *testing.T, source *frameSource,
+	keyphrases ...kws.Keyphrase) *KeywordSpottingSearchManager {
+	d := acoustictest.NewDictionary(t, "hello HH AH L OW\nworld W ER L D\n")
+	unitManager := d.UnitManager()
+	var units []*acoustic.Unit
+	for _, name := range phones {
+		units = append(units, unitManager.Unit(name, name == acoustic.SILENCE_NAME))
+	}
+	am := &acoustictest.AcousticModel{ContextSize: 1, Units: units, Scorer: scoreFrame}
+	frontEnd := frontend.NewDefaultFrontEnd()
+	frontEnd.SetDataSource(source)
+	return NewDefaultKeywordSpottingSearchManager(kws.NewDefaultKeywordSpottingLinguist(am, unitManager, d, keyphrases),
+		frontEnd)
+}
+
+func TestKeywordSpottingSearchManagerError(t *testing.T) {
+	var frames []string
+	for _, phone := range []string{"SIL", "HH", "AH", "L", "OW"} {
+		for i := 0; i < 3; i++ {
+			frames = append(frames, phone)
+		}
+	}
+	err := errors.New("read error")
+	sm := newTestKeywordSpottingSearchManager(t, &frameSource{frames: frames, err: err}, kws.Keyphrase{Phrase: "hello", Threshold: 1})
+	sm.Allocate()
+	if sm.Err() != nil {
+		t.Fatal(sm.Err())
+	}
+	defer sm.Deallocate()
+
+	sm.StartRecognition()
+	defer sm.StopRecognition()
+	for i := 0; i <= len(frames); i++ {
+		if result := sm.Recognize(1); result == nil {
+			break
+		}
+	}
+	if result := sm.Recognize(1); result != nil {
+		t.Error("got a result after the error")
+	}
+	if sm.Err() != err {
+		t.Errorf("got error %v, want %v", sm.Err(), err)
+	}
+}
+
+func TestKeywordSpottingSearchManagerAllocateError(t *testing.T) {
+	sm := newTestKeywordSpottingSearchManager(t, &frameSource{frames: []string{"SIL"}})
+	sm.Allocate()
+	if sm.Err() == nil {
+		t.Fatal("got no error allocating a linguist without keyphrase")
+	}
+	sm.StartRecognition()
+	if result := sm.Recognize(1); result != nil {
+		t.Error("got a result from an unallocated search manager")
+	}
+	if sm.Err() == nil {
+		t.Error("the allocation error was lost")
+	}
+}
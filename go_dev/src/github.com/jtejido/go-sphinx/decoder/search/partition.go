@@ -2,18 +2,20 @@ package search
 
 import (
 	"math"
+	"sort"
 )
 
 const (
 	DEFAULT_MAX_DEPTH = 50
 )
 
-//  Partitions a list of tokens according to the token score, used
-//  in PartitionActiveListFactory.
+// Partitions a list of tokens according to the token score, used
+// in PartitionActiveListFactory.
 type Partitioner interface {
 	// Partitions the given array of tokens in place, so that the highest scoring n token will be at the beginning of
 	// the array, not in any order.
-	Partition(tokens []*Token, size, n int)
+	// Returns the index of the last of them.
+	Partition(tokens []*Token, size, n int) int
 }
 
 type DefaultPartitioner struct {
@@ -66,7 +68,7 @@ func (part DefaultPartitioner) midPointPartition(tokens []*Token, start, end int
 }
 
 func (part DefaultPartitioner) Partition(tokens []*Token, size, n int) int {
-	if len(tokens) > n {
+	if size > n {
 		return part.midPointSelect(tokens, 0, size-1, n, 0)
 	} else {
 		return part.findBest(tokens, size)
@@ -77,7 +79,7 @@ func (part DefaultPartitioner) Partition(tokens []*Token, size, n int) int {
 func (part DefaultPartitioner) findBest(tokens []*Token, size int) int {
 	r := -1
 	lowestScore := math.MaxFloat64
-	for i := 0; i < len(tokens); i++ {
+	for i := 0; i < size; i++ {
 		currentScore := tokens[i].GetScore()
 		if currentScore <= lowestScore {
 			lowestScore = currentScore
@@ -124,6 +126,7 @@ func (part DefaultPartitioner) midPointSelect(tokens []*Token, start, end, targe
 
 // Fallback method to get the partition
 func (part DefaultPartitioner) simplePointSelect(tokens []*Token, start, end, targetSize int) int {
-	sort.Sort(scorer.ByScore(tokens[start : end+1]))
+	sorted := tokens[start : end+1]
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].GetScore() > sorted[j].GetScore() })
 	return start + targetSize - 1
 }
@@ -34,17 +34,19 @@ func (pal *PartitionActiveList) Add(token *Token) {
 		pal.doubleCapacity()
 		pal.Add(token)
 	}
-	if pal.bestToken == nil || token.GetScore() > bestToken.GetScore() {
+	if pal.bestToken == nil || token.GetScore() > pal.bestToken.GetScore() {
 		pal.bestToken = token
 	}
 }
 
 func (pal *PartitionActiveList) doubleCapacity() {
-	pal.tokenList = make([]*Token, len(pal.tokenList)*2)
+	tokenList := make([]*Token, len(pal.tokenList)*2+1)
+	copy(tokenList, pal.tokenList)
+	pal.tokenList = tokenList
 }
 
 // Purges excess members. Remove all nodes that fall below the relativeBeamWidth
-func (pal *PartitionActiveList) Purge() *PartitionActiveList {
+func (pal *PartitionActiveList) Purge() ActiveList {
 	if pal.absoluteBeamWidth > 0 {
 		// if we have an absolute beam, then we will
 		// need to sort the tokens to apply the beam
@@ -59,7 +61,7 @@ func (pal *PartitionActiveList) Purge() *PartitionActiveList {
 
 // Gets the set of all tokens
 func (pal PartitionActiveList) GetTokens() []*Token {
-	return pal.tokenList
+	return pal.tokenList[:pal.size]
 }
 
 // Returns the number of tokens on this active list
@@ -99,6 +101,6 @@ func (pal *PartitionActiveList) GetBestToken() *Token {
 }
 
 // Creates new instance with same properties
-func (pal *PartitionActiveList) NewInstance() *PartitionActiveList {
+func (pal *PartitionActiveList) NewInstance() ActiveList {
 	return NewPartitionActiveList(pal.absoluteBeamWidth, pal.logRelativeBeamWidth)
 }
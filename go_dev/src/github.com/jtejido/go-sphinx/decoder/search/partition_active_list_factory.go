@@ -1,7 +1,7 @@
 package search
 
 import (
-	"github.com/jtejido/go-sphinx/utils"
+	"github.com/jtejido/go-sphinx/util"
 )
 
 type PartitionActiveListFactory struct {
@@ -11,17 +11,17 @@ type PartitionActiveListFactory struct {
 func NewDefaultPartitionActiveListFactory() *PartitionActiveListFactory {
 	palf := new(PartitionActiveListFactory)
 	palf.absoluteBeamWidth = 20000
-	palf.logRelativeBeamWidth = utils.LogMath.LinearToLog(1e-60)
+	palf.logRelativeBeamWidth = float64(util.GetLogMath().LinearToLog(1e-60))
 	return palf
 }
 
 func NewPartitionActiveListFactory(absoluteBeamWidth int, relativeBeamWidth float64) *PartitionActiveListFactory {
 	palf := new(PartitionActiveListFactory)
 	palf.absoluteBeamWidth = absoluteBeamWidth
-	palf.logRelativeBeamWidth = utils.LogMath.LinearToLog(relativeBeamWidth)
+	palf.logRelativeBeamWidth = float64(util.GetLogMath().LinearToLog(relativeBeamWidth))
 	return palf
 }
 
-func (palf *PartitionActiveListFactory) NewInstance() *PartitionActiveList {
+func (palf *PartitionActiveListFactory) NewInstance() ActiveList {
 	return NewPartitionActiveList(palf.absoluteBeamWidth, palf.logRelativeBeamWidth)
 }
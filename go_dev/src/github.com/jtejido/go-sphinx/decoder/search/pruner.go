@@ -1,8 +1,4 @@
-package pruner
-
-import (
-	"github.com/jtejido/go-sphinx/decoder/search"
-)
+package search
 
 // Provides a mechanism for pruning a set of StateTokens
 type Pruner interface {
@@ -10,7 +6,7 @@ type Pruner interface {
 	StartRecognition()
 
 	// prunes the given set of states
-	Prune(search.ActiveList) search.ActiveList
+	Prune(ActiveList) ActiveList
 
 	// Performs post-recognition cleanup.
 	StopRecognition()
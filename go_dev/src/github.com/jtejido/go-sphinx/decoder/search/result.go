@@ -0,0 +1,121 @@
+package search
+
+import (
+	"github.com/jtejido/go-sphinx/util"
+)
+
+/**
+ * Provides recognition results. Results can be partial or final. A result
+ * should not be modified before it is a final result. Note that a result may
+ * not contain all possible information.
+ * <p>
+ * The following methods are not yet defined but should be:
+ *
+ * <pre>
+ * public Result getDAG(int compressionLevel);
+ * </pre>
+ */
+type Result struct {
+	activeList                 ActiveList
+	resultList                 []*Token
+	alternateHypothesisManager *AlternateHypothesisManager
+	isFinal                    bool
+	wordTokenFirst             bool
+	currentCollectTime         int64
+	reference                  string
+	logMath                    *util.LogMath
+	toCreateLattice            bool
+
+	// the keyphrase detected by a keyword search, between its start and end frames
+	keyphrase            string
+	startFrame, endFrame int
+	confidence           float64
+}
+
+// Creates a result of the token search.
+//
+// Accepts the alternate hypotheses of the lattice, the active list and the final tokens of the last frame, its collect
+// time, whether the result is final, the order of the word and data tokens of the search graph, and whether a lattice
+// should be created from the result.
+func NewResult(alternateHypothesisManager *AlternateHypothesisManager, activeList ActiveList, resultList []*Token,
+	collectTime int64, isFinal, wordTokenFirst, toCreateLattice bool) *Result {
+	return &Result{
+		activeList:                 activeList,
+		resultList:                 resultList,
+		alternateHypothesisManager: alternateHypothesisManager,
+		isFinal:                    isFinal,
+		wordTokenFirst:             wordTokenFirst,
+		currentCollectTime:         collectTime,
+		logMath:                    util.GetLogMath(),
+		toCreateLattice:            toCreateLattice,
+	}
+}
+
+// Creates the result of a keyword search. A final result holds the keyphrase detected between the start and end
+// frames, with its confidence in the LogMath log domain: how much more likely the keyphrase is than the garbage model
+// over the same frames. A partial result, without keyphrase, tells the frames up to the collect time held none.
+func NewKeywordResult(keyphrase string, startFrame, endFrame int, confidence float64, collectTime int64) *Result {
+	return &Result{
+		isFinal:            keyphrase != "",
+		currentCollectTime: collectTime,
+		logMath:            util.GetLogMath(),
+		keyphrase:          keyphrase,
+		startFrame:         startFrame,
+		endFrame:           endFrame,
+		confidence:         confidence,
+	}
+}
+
+// Returns whether the result is final.
+func (r *Result) IsFinal() bool {
+	return r.isFinal
+}
+
+// Returns the time of the last frame of the result.
+func (r *Result) GetCollectTime() int64 {
+	return r.currentCollectTime
+}
+
+// Returns the keyphrase detected by a keyword search, empty if none.
+func (r *Result) GetKeyphrase() string {
+	return r.keyphrase
+}
+
+// Returns the first frame of the keyphrase detected.
+func (r *Result) GetStartFrame() int {
+	return r.startFrame
+}
+
+// Returns the last frame of the keyphrase detected.
+func (r *Result) GetEndFrame() int {
+	return r.endFrame
+}
+
+// Returns the confidence of the keyphrase detected, in the LogMath log domain.
+func (r *Result) GetConfidence() float64 {
+	return r.confidence
+}
+
+// Returns the best scoring final token in the result. A final token is a token that has reached a final state in the
+// current frame, or nil if there is none.
+func (r *Result) GetBestFinalToken() *Token {
+	var bestToken *Token
+	for _, token := range r.resultList {
+		if bestToken == nil || token.GetScore() > bestToken.GetScore() {
+			bestToken = token
+		}
+	}
+	return bestToken
+}
+
+// Returns the best scoring token in the result. First, the best final token is retrieved. If there is no final
+// token, the best token of the active list is returned, or nil if the result holds no token at all.
+func (r *Result) GetBestToken() *Token {
+	if bestToken := r.GetBestFinalToken(); bestToken != nil {
+		return bestToken
+	}
+	if r.activeList == nil {
+		return nil
+	}
+	return r.activeList.GetBestToken()
+}
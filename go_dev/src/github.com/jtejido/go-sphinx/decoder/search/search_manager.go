@@ -1,16 +1,12 @@
 package search
 
-import (
-	"github.com/jtejido/go-sphinx/result"
-)
-
 // Defines the interface for the SearchManager. The SearchManager's primary role is to execute the search for a given
 // number of frames. The SearchManager will return interim results as the recognition proceeds and when recognition
 // completes a final result will be returned.
 type SearchManager interface {
 
 	// Allocates the resources necessary for this search. This should be called once before an recognitions are
-	// performed
+	// performed. If the resources can't be allocated, the search manager stays unallocated and Err returns why.
 	Allocate()
 
 	// Deallocates resources necessary for this search. This should be called once after all recognitions are completed
@@ -34,5 +30,8 @@ type SearchManager interface {
 	//
 	// Returns the recognition result, the result may be a partial or a final result; or return null if no frames are
 	// arrived.
-	Recognize(int) *result.Result
+	Recognize(int) *Result
+
+	// Returns the error which left the search manager unallocated or stopped the recognition, nil if none.
+	Err() error
 }
@@ -1,7 +1,6 @@
 package search
 
 import (
-	"github.com/jtejido/go-sphinx/decoder/scorer"
 	"math"
 	"sort"
 )
@@ -41,20 +40,18 @@ func NewSimpleActiveList(absoluteBeamWidth int, logRelativeBeamWidth float64) *S
 // Adds the given token to the list
 func (sal *SimpleActiveList) Add(token *Token) {
 	sal.tokenList = append(sal.tokenList, token)
-	if sal.bestToken == nil || token.GetScore() > bestToken.GetScore() {
+	if sal.bestToken == nil || token.GetScore() > sal.bestToken.GetScore() {
 		sal.bestToken = token
 	}
 }
 
 // Purges excess members. Remove all nodes that fall below the relativeBeamWidth
-func (sal *SimpleActiveList) Purge() *SimpleActiveList {
+func (sal *SimpleActiveList) Purge() ActiveList {
 	if sal.absoluteBeamWidth > 0 && len(sal.tokenList) > sal.absoluteBeamWidth {
-		sort.Sort(scorer.ByScore(tokenList))
-		copy(sal.tokenList[0:], sal.tokenList[sal.absoluteBeamWidth:])
-		for k, n := len(sal.tokenList)-sal.absoluteBeamWidth, len(sal.tokenList); k < n; k++ {
-			sal.tokenList[k] = nil
-		}
-		sal.tokenList = sal.tokenList[:len(sal.tokenList)-sal.absoluteBeamWidth]
+		sort.SliceStable(sal.tokenList, func(i, j int) bool {
+			return sal.tokenList[i].GetScore() > sal.tokenList[j].GetScore()
+		})
+		sal.tokenList = sal.tokenList[:sal.absoluteBeamWidth]
 	}
 
 	return sal
@@ -77,7 +74,7 @@ func (sal SimpleActiveList) GetBeamThreshold() float64 {
 
 // Gets the best score in the list
 func (sal SimpleActiveList) GetBestScore() float64 {
-	bestScore = -math.MaxFloat64
+	bestScore := -math.MaxFloat64
 	if sal.bestToken != nil {
 		bestScore = sal.bestToken.GetScore()
 	}
@@ -96,6 +93,6 @@ func (sal *SimpleActiveList) GetBestToken() *Token {
 }
 
 // Creates new instance with same properties
-func (sal *SimpleActiveList) NewInstance() *SimpleActiveList {
+func (sal *SimpleActiveList) NewInstance() ActiveList {
 	return NewSimpleActiveList(sal.absoluteBeamWidth, sal.logRelativeBeamWidth)
 }
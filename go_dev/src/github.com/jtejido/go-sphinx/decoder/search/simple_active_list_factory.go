@@ -1,7 +1,7 @@
 package search
 
 import (
-	"github.com/jtejido/go-sphinx/utils"
+	"github.com/jtejido/go-sphinx/util"
 )
 
 type SimpleActiveListFactory struct {
@@ -11,17 +11,17 @@ type SimpleActiveListFactory struct {
 func NewDefaultSimpleActiveListFactory() *SimpleActiveListFactory {
 	salf := new(SimpleActiveListFactory)
 	salf.absoluteBeamWidth = -1
-	salf.logRelativeBeamWidth = utils.LogMath.LinearToLog(1E-80)
+	salf.logRelativeBeamWidth = float64(util.GetLogMath().LinearToLog(1e-80))
 	return salf
 }
 
 func NewSimpleActiveListFactory(absoluteBeamWidth int, relativeBeamWidth float64) *SimpleActiveListFactory {
 	salf := new(SimpleActiveListFactory)
 	salf.absoluteBeamWidth = absoluteBeamWidth
-	salf.logRelativeBeamWidth = utils.LogMath.LinearToLog(relativeBeamWidth)
+	salf.logRelativeBeamWidth = float64(util.GetLogMath().LinearToLog(relativeBeamWidth))
 	return salf
 }
 
-func (salf *SimpleActiveListFactory) NewInstance() *SimpleActiveList {
+func (salf *SimpleActiveListFactory) NewInstance() ActiveList {
 	return NewSimpleActiveList(salf.absoluteBeamWidth, salf.logRelativeBeamWidth)
 }
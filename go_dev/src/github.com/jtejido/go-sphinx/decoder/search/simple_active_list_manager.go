@@ -66,7 +66,7 @@ func (salm *SimpleActiveListManager) createActiveLists() {
 func (salm *SimpleActiveListManager) Add(token *Token) {
 	activeList := salm.findListFor(token)
 	if activeList == nil {
-		panic("Cannot find ActiveList for %s", token.GetSearchState())
+		panic(fmt.Sprintf("Cannot find ActiveList for %s", token.GetSearchState()))
 	}
 
 	activeList.Add(token)
@@ -88,7 +88,7 @@ func (salm *SimpleActiveListManager) ClearEmittingList() {
 	salm.currentActiveLists[len(salm.currentActiveLists)-1] = list.NewInstance()
 }
 
-func (salm *SimpleActiveListManager) GetNonEmittingListIterator() *nonEmittingListIterator {
+func (salm *SimpleActiveListManager) GetNonEmittingListIterator() ActiveListIterator {
 	return (newNonEmittingListIterator(salm))
 }
 
@@ -100,7 +100,7 @@ func (salm *SimpleActiveListManager) Dump() {
 }
 
 func dumpList(al ActiveList) {
-	fmt.Println("Size: %d Best token: %s", al.Size(), al.GetBestToken())
+	fmt.Printf("Size: %d Best token: %s\n", al.Size(), al.GetBestToken())
 }
 
 type nonEmittingListIterator struct {
@@ -135,9 +135,9 @@ func (neli *nonEmittingListIterator) Next() ActiveList {
 
 func (neli *nonEmittingListIterator) checkPriorLists() {
 	for i := 0; i < neli.listPtr; i++ {
-		activeList = neli.listManager.currentActiveLists[i]
+		activeList := neli.listManager.currentActiveLists[i]
 		if activeList.Size() > 0 {
-			panic("At while processing state order %d, state order %d not empty", neli.listPtr, i)
+			panic(fmt.Sprintf("At while processing state order %d, state order %d not empty", neli.listPtr, i))
 		}
 	}
 }
@@ -1,8 +1,4 @@
-package pruner
-
-import (
-	"github.com/jtejido/go-sphinx/decoder/search"
-)
+package search
 
 // Performs the default pruning behavior which is to invoke the purge on the active list
 type SimplePruner struct {
@@ -15,7 +11,7 @@ func NewDefaultSimplePruner() *SimplePruner {
 
 func (sp *SimplePruner) StartRecognition() {}
 
-func (sp *SimplePruner) Prune(activeList search.ActiveList) search.ActiveList {
+func (sp *SimplePruner) Prune(activeList ActiveList) ActiveList {
 	return activeList.Purge()
 }
 
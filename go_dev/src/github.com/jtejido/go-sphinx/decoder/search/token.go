@@ -2,7 +2,8 @@ package search
 
 import (
 	"fmt"
-	"github.com/jtejido/go-sphinx/decoder/scorer"
+	"strings"
+
 	"github.com/jtejido/go-sphinx/frontend"
 	"github.com/jtejido/go-sphinx/linguist"
 	"github.com/jtejido/go-sphinx/linguist/dictionary"
@@ -60,10 +61,10 @@ func (tok *Token) GetCollectTime() int64 {
 // Sets the feature for this Token.
 func (tok *Token) SetData(data frontend.Data) {
 	tok.data = data
-	fd, ok := data.(frontend.FloatData)
+	fd, ok := data.(*frontend.FloatData)
 
 	if ok {
-		tok.collectTime = fd.GetCollectTime()
+		tok.collectTime = fd.CollectTime()
 	}
 }
 
@@ -77,11 +78,12 @@ func (tok *Token) GetScore() float64 {
 	return tok.logTotalScore
 }
 
-// Calculates a score against the given feature. The score can be retrieved
-// with get score. The token will keep a reference to the scored feature-vector.
+// Calculates a score against the given feature with the HMM state of the
+// search state. The score can be retrieved with get score. The token will keep
+// a reference to the scored feature-vector.
 func (tok *Token) CalculateScore(feature frontend.Data) float64 {
 
-	tok.logAcousticScore = tok.searchState.(scorer.ScoreProvider).GetScore(feature)
+	tok.logAcousticScore = float64(tok.searchState.(linguist.HMMSearchState).GetHMMState().Score(feature))
 
 	tok.logTotalScore += tok.logAcousticScore
 
@@ -90,8 +92,8 @@ func (tok *Token) CalculateScore(feature frontend.Data) float64 {
 	return tok.logTotalScore
 }
 
-func (tok *Token) CalculateComponentScore(feature frontend.Data) []float64 {
-	return searchState.(scorer.ScoreProvider).GetComponentScore(feature)
+func (tok *Token) CalculateComponentScore(feature frontend.Data) []float32 {
+	return tok.searchState.(linguist.HMMSearchState).GetHMMState().CalculateComponentScore(feature)
 }
 
 // Normalizes a previously calculated score
@@ -132,14 +134,14 @@ func (tok *Token) GetSearchState() linguist.SearchState {
 }
 
 // Determines if this token is associated with an emitting state. An emitting state is a state that can be scored
-// acoustically.
+// acoustically. The tokens holding the scores of the result list have no state, and aren't emitting.
 func (tok *Token) IsEmitting() bool {
-	return tok.searchState.IsEmitting()
+	return tok.searchState != nil && tok.searchState.IsEmitting()
 }
 
 // Determines if this token is associated with a final SentenceHMM state.
 func (tok *Token) IsFinal() bool {
-	return tok.searchState.IsFinal()
+	return tok.searchState != nil && tok.searchState.IsFinal()
 }
 
 // Determines if this token marks the end of a word
@@ -169,14 +171,15 @@ func (tok *Token) DumpTokenPath(includeHMMStates bool) {
 		token = list[i]
 		_, ok := token.GetSearchState().(linguist.HMMSearchState)
 		if includeHMMStates || (!ok) {
-			fmt.Println("   %s", token)
+			fmt.Printf("   %s\n", token)
 		}
 	}
 	fmt.Println()
 }
 
-// Returns the string of words leading up to this token.
-func (tok *Token) GetWordPath(wantFiller, wantPronunciations bool) string {
+// Returns the string of words leading up to this token, with the filler words if wantFiller is set and the units
+// of their pronunciations if wantPronunciations is set.
+func (tok *Token) GetWordPathWith(wantFiller, wantPronunciations bool) string {
 	var sb string
 	token := tok
 
@@ -187,35 +190,31 @@ func (tok *Token) GetWordPath(wantFiller, wantPronunciations bool) string {
 			word := wordState.GetPronunciation().GetWord()
 
 			if wantFiller || !word.IsFiller() {
+				entry := word.GetSpelling()
 				if wantPronunciations {
-					sb += "]"
-					u = pron.GetUnits()
-					for i := len(u) - 1; i >= 0; i-- {
-						if i < len(u)-1 {
-							sb += ","
-						}
-						sb += u[i].GetName()
+					var names []string
+					for _, u := range pron.GetUnits() {
+						names = append(names, u.Name())
 					}
-					sb += "["
+					entry += "[" + strings.Join(names, ",") + "]"
 				}
-				sb += word.GetSpelling()
-				sb += " "
+				sb = " " + entry + sb
 			}
 		}
 		token = token.GetPredecessor()
 	}
 
-	return sb
+	return strings.TrimSpace(sb)
 }
 
 // Returns the string of words for this token, with no embedded filler words
 func (tok *Token) GetWordPathNoFiller() string {
-	return tok.GetWordPath(false, false)
+	return tok.GetWordPathWith(false, false)
 }
 
 // Returns the string of words for this token, with embedded silences
 func (tok *Token) GetWordPath() string {
-	return tok.GetWordPath(true, false)
+	return tok.GetWordPathWith(true, false)
 }
 
 // Returns the string of words and units for this token, with embedded silences.
@@ -229,10 +228,10 @@ func (tok *Token) GetWordUnitPath() string {
 		uss, ok_uss := searchState.(linguist.UnitSearchState)
 		if ok_wss {
 			word := wss.GetPronunciation().GetWord()
-			sb += " " + word.GetSpelling()
+			sb = " " + word.GetSpelling() + sb
 		} else if ok_uss {
 			unit := uss.GetUnit()
-			sb += " " + unit.GetName()
+			sb = " " + unit.Name() + sb
 		}
 		token = token.GetPredecessor()
 	}
@@ -243,7 +242,7 @@ func (tok *Token) GetWordUnitPath() string {
 // WordSearchState, return null.
 func (tok *Token) GetWord() *dictionary.Word {
 	if tok.IsWord() {
-		wordState := searchState.(linguist.WordSearchState)
+		wordState := tok.searchState.(linguist.WordSearchState)
 		return wordState.GetPronunciation().GetWord()
 	}
 
@@ -252,7 +251,7 @@ func (tok *Token) GetWord() *dictionary.Word {
 
 // Shows the token count
 func (tok *Token) ShowCount() {
-	fmt.Println("Cur count: %d new %d", tok.curCount, (tok.curCount - tok.lastCount))
+	fmt.Printf("Cur count: %d new %d\n", tok.curCount, (tok.curCount - tok.lastCount))
 	tok.lastCount = tok.curCount
 }
 
@@ -261,7 +260,7 @@ func (tok *Token) Validate() bool {
 	return true
 }
 
-func (tok *Token) Update(predecessor *Token, nextState liguist.SearchState, logEntryScore, insertionProbability, languageProbability float64, collectTime int64) {
+func (tok *Token) Update(predecessor *Token, nextState linguist.SearchState, logEntryScore, insertionProbability, languageProbability float64, collectTime int64) {
 	tok.predecessor = predecessor
 	tok.searchState = nextState
 	tok.logTotalScore = logEntryScore
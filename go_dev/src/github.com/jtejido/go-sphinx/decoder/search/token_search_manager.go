@@ -48,12 +48,13 @@ func (tsm *TokenSearchManager) getResultListPredecessor(token *Token) *Token {
 	logLanguageScore := 0.0
 	logInsertionScore := 0.0
 
-	for token != nil && !token.IsWord() {
-		logAcousticScore += token.GetAcousticScore()
-		logLanguageScore += token.GetLanguageScore()
-		logInsertionScore += token.GetInsertionScore()
-		token = token.GetPredecessor()
+	predecessor := token
+	for predecessor != nil && !predecessor.IsWord() {
+		logAcousticScore += predecessor.GetAcousticScore()
+		logLanguageScore += predecessor.GetLanguageScore()
+		logInsertionScore += predecessor.GetInsertionScore()
+		predecessor = predecessor.GetPredecessor()
 	}
 
-	return NewToken(token, token.GetScore(), logInsertionScore, logAcousticScore, logLanguageScore)
+	return NewTokenWithScores(predecessor, token.GetScore(), logAcousticScore, logInsertionScore, logLanguageScore)
 }
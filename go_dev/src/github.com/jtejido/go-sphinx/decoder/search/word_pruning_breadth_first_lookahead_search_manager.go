@@ -2,14 +2,17 @@ package search
 
 import (
 	"fmt"
-	"github.com/jtejido/go-sphinx/decoder/pruner"
+	"math"
 
 	"github.com/jtejido/go-sphinx/decoder/scorer"
+	fe "github.com/jtejido/go-sphinx/frontend"
 	"github.com/jtejido/go-sphinx/linguist"
-	"github.com/jtejido/go-sphinx/linguist/acoustic/tiedstate"
+	"github.com/jtejido/go-sphinx/linguist/acoustic"
 	"github.com/jtejido/go-sphinx/linguist/allphone"
+	"github.com/jtejido/go-sphinx/linguist/dictionary"
+	"github.com/jtejido/go-sphinx/linguist/language/ngram"
 	"github.com/jtejido/go-sphinx/linguist/lextree"
-	"github.com/jtejido/go-sphinx/utils"
+	"github.com/jtejido/go-sphinx/util"
 )
 
 const (
@@ -28,6 +31,7 @@ func NewFrameCiScores(scores []float64, maxScore float64) *FrameCiScores {
 	fcs := new(FrameCiScores)
 	fcs.scores = scores
 	fcs.maxScore = maxScore
+	return fcs
 }
 
 // Provides the breadth first search with fast match heuristic included to
@@ -36,9 +40,8 @@ func NewFrameCiScores(scores []float64, maxScore float64) *FrameCiScores {
 // All scores and probabilities are maintained in the log math log domain.
 type WordPruningBreadthFirstLookaheadSearchManager struct {
 	WordPruningBreadthFirstSearchManager
-	fastmatchLinguist           linguist.Liguist
-	loader                      tiedstate.Loader
-	fastmatchActiveListFactory  *ActiveListFactory
+	fastmatchLinguist           linguist.Linguist
+	fastmatchActiveListFactory  ActiveListFactory
 	lookaheadWindow             int
 	lookaheadWeight             float64
 	penalties                   map[int]float64
@@ -49,13 +52,19 @@ type WordPruningBreadthFirstLookaheadSearchManager struct {
 	fastmatchStreamEnd          bool
 }
 
-func NewDefaultWordPruningBreadthFirstLookaheadSearchManager() *WordPruningBreadthFirstLookaheadSearchManager {
+// Creates a search manager with the default pruning, lookahead and lattice
+// options, searching the lex tree of the dictionary words under the language
+// model with a phone loop of the acoustic model as fast match, and scoring
+// the features of the given front end against the acoustic model.
+func NewDefaultWordPruningBreadthFirstLookaheadSearchManager(acousticModel acoustic.AcousticModel,
+	unitManager *acoustic.UnitManager, languageModel ngram.LanguageModel, dictionary dictionary.Dictionary,
+	frontEnd *fe.FrontEnd) *WordPruningBreadthFirstLookaheadSearchManager {
 	wpbflsm := new(WordPruningBreadthFirstLookaheadSearchManager)
-	wpbflsm.logMath = utils.LogMath{}
-	wpbflsm.linguist = lextree.NewDefaultLexTreeLinguist()
-	wpbflsm.pruner = pruner.NewDefaultSimplePruner()
-	wpbflsm.scorer = scorer.NewDefaultSimpleAcousticScorer()
-	wpbflsm.activeListManager = search.NewDefaultSimpleActiveListManager()
+	wpbflsm.logMath = util.GetLogMath()
+	wpbflsm.linguist = lextree.NewDefaultLexTreeLinguist(acousticModel, unitManager, languageModel, dictionary)
+	wpbflsm.pruner = NewDefaultSimplePruner()
+	wpbflsm.scorer = scorer.NewDefaultSimpleAcousticScorer(frontEnd)
+	wpbflsm.activeListManager = NewDefaultSimpleActiveListManager()
 	wpbflsm.showTokenCount = DEFAULT_SHOW_TOKEN_COUNT
 	wpbflsm.growSkipInterval = DEFAULT_GROW_SKIP_INTERVAL
 	wpbflsm.checkStateOrder = DEFAULT_CHECK_STATE_ORDER
@@ -63,36 +72,29 @@ func NewDefaultWordPruningBreadthFirstLookaheadSearchManager() *WordPruningBread
 	wpbflsm.maxLatticeEdges = DEFAULT_MAX_LATTICE_EDGES
 	wpbflsm.acousticLookaheadFrames = 1.7
 	wpbflsm.keepAllTokens = DEFAULT_KEEP_ALL_TOKENS
-	wpbflsm.relativeBeamWidth = wpbflsm.logMath.LinearToLog(1e-60)
+	wpbflsm.relativeBeamWidth = float64(wpbflsm.logMath.LinearToLog(1e-60))
+	wpbflsm.collectSuccessors = wpbflsm.collectSuccessorTokens
 
-	wpbflsm.loader = tiedstate.NewDefaultSphinx3Loader()
-	wpbflsm.fastmatchLinguist = allphone.NewDefaultAllPhoneLinguist()
+	wpbflsm.fastmatchLinguist = allphone.NewDefaultAllPhoneLinguist(acousticModel, unitManager)
 	wpbflsm.fastmatchActiveListFactory = NewDefaultPartitionActiveListFactory()
 	wpbflsm.lookaheadWindow = DEFAULT_LOOKAHEAD_WINDOW
 	wpbflsm.lookaheadWeight = 6
 
-	if lookaheadWindow < 1 || lookaheadWindow > 10 {
-		panic(fmt.Sprintf("Unsupported lookahead window size: %d. Value in range [1..10] is expected", lookaheadWindow))
-	}
-
 	wpbflsm.ciScores = make([]*FrameCiScores, 0)
 	wpbflsm.penalties = make(map[int]float64)
-	l, ok := wpbflsm.loader.(tiedstate.Sphinx3Loader)
-	if ok && l.HasTiedMixtures() {
-		l.SetGauScoresQueueLength(wpbflsm.lookaheadWindow + 2)
-	}
 
 	return wpbflsm
 }
 
-func NewWordPruningBreadthFirstLookaheadSearchManager(linguist, fastmatchLinguist linguist.Liguist, loader tiedstate.Loader,
-	pruner pruner.Pruner, scorer scorer.AcousticScorer, activeListManager ActiveListManager,
-	fastmatchActiveListFactory *ActiveListFactory, showTokenCount bool, relativeWordBeamWidth float64,
+func NewWordPruningBreadthFirstLookaheadSearchManager(linguist, fastmatchLinguist linguist.Linguist,
+	pruner Pruner, scorer scorer.AcousticScorer, activeListManager ActiveListManager,
+	fastmatchActiveListFactory ActiveListFactory, showTokenCount bool, relativeWordBeamWidth float64,
 	growSkipInterval int, checkStateOrder bool, buildWordLattice bool, lookaheadWindow int, lookaheadWeight float64,
-	maxLatticeEdges int, acousticLookaheadFrames float64, keepAllTokens bool) *WordPruningBreadthFirstLookaheadSearchManager {
+	maxLatticeEdges int, acousticLookaheadFrames float64, keepAllTokens bool,
+	logger util.Logger) *WordPruningBreadthFirstLookaheadSearchManager {
 	wpbflsm := new(WordPruningBreadthFirstLookaheadSearchManager)
 
-	wpbflsm.logMath = utils.LogMath{}
+	wpbflsm.logMath = util.GetLogMath()
 	wpbflsm.linguist = linguist
 	wpbflsm.pruner = pruner
 	wpbflsm.scorer = scorer
@@ -104,9 +106,10 @@ func NewWordPruningBreadthFirstLookaheadSearchManager(linguist, fastmatchLinguis
 	wpbflsm.maxLatticeEdges = maxLatticeEdges
 	wpbflsm.acousticLookaheadFrames = acousticLookaheadFrames
 	wpbflsm.keepAllTokens = keepAllTokens
-	wpbflsm.relativeBeamWidth = wpbflsm.logMath.LinearToLog(relativeWordBeamWidth)
+	wpbflsm.logger = logger
+	wpbflsm.relativeBeamWidth = float64(wpbflsm.logMath.LinearToLog(relativeWordBeamWidth))
+	wpbflsm.collectSuccessors = wpbflsm.collectSuccessorTokens
 
-	wpbflsm.loader = loader
 	wpbflsm.fastmatchLinguist = fastmatchLinguist
 	wpbflsm.fastmatchActiveListFactory = fastmatchActiveListFactory
 	wpbflsm.lookaheadWindow = lookaheadWindow
@@ -118,40 +121,71 @@ func NewWordPruningBreadthFirstLookaheadSearchManager(linguist, fastmatchLinguis
 
 	wpbflsm.ciScores = make([]*FrameCiScores, 0)
 	wpbflsm.penalties = make(map[int]float64)
-	l, ok := wpbflsm.loader.(tiedstate.Sphinx3Loader)
-	if ok && l.HasTiedMixtures() {
-		l.SetGauScoresQueueLength(wpbflsm.lookaheadWindow + 2)
+
+	return wpbflsm
+}
+
+// Allocates the fast match linguist along with the search manager. A linguist
+// that can't be allocated leaves the search manager unallocated, and Err
+// returns its error.
+func (wpbflsm *WordPruningBreadthFirstLookaheadSearchManager) Allocate() {
+	wpbflsm.WordPruningBreadthFirstSearchManager.Allocate()
+	if !wpbflsm.allocated {
+		return
+	}
+	if err := wpbflsm.fastmatchLinguist.Allocate(); err != nil {
+		wpbflsm.WordPruningBreadthFirstSearchManager.Deallocate()
+		wpbflsm.err = fmt.Errorf("fast match: %w", err)
+		if wpbflsm.logger != nil {
+			wpbflsm.logger.Errorf("%v", wpbflsm.err)
+		}
 	}
 }
 
-func (wpbflsm *WordPruningBreadthFirstLookaheadSearchManager) Recognize(nFrames int) *result.Result {
+func (wpbflsm *WordPruningBreadthFirstLookaheadSearchManager) Deallocate() {
+	if wpbflsm.allocated {
+		wpbflsm.fastmatchLinguist.Deallocate()
+	}
+	wpbflsm.WordPruningBreadthFirstSearchManager.Deallocate()
+}
+
+// Performs the recognition for the given number of frames, the fast match
+// looking ahead of the search. Returns nil if the search manager isn't
+// allocated, or if the features couldn't be read, in which case Err returns
+// the error.
+func (wpbflsm *WordPruningBreadthFirstLookaheadSearchManager) Recognize(nFrames int) (res *Result) {
+	if !wpbflsm.allocated || wpbflsm.err != nil {
+		return nil
+	}
 	done := false
-	result := nil
 	wpbflsm.streamEnd = false
 
 	for i := 0; i < nFrames && !done; i++ {
 		if !wpbflsm.fastmatchStreamEnd {
 			wpbflsm.fastMatchRecognize()
 		}
+		if wpbflsm.err != nil {
+			return nil
+		}
 		wpbflsm.penalties = make(map[int]float64)
 
 		// remove head
-		copy(wpbflsm.ciScores[i:], wpbflsm.ciScores[i+1:])
-		wpbflsm.ciScores[len(wpbflsm.ciScores)-1] = nil
-		wpbflsm.ciScores = wpbflsm.ciScores[:len(wpbflsm.ciScores)-1]
+		if len(wpbflsm.ciScores) > 0 {
+			wpbflsm.ciScores = wpbflsm.ciScores[1:]
+		}
 
 		done = wpbflsm.recognize()
 	}
 
-	if !streamEnd {
-		result = result.NewResult(wpbflsm.loserManager, wpbflsm.activeList, wpbflsm.resultList, wpbflsm.currentCollectTime, done, wpbflsm.linguist.GetSearchGraph().GetWordTokenFirst(), true)
+	if !wpbflsm.streamEnd && wpbflsm.err == nil {
+		res = NewResult(wpbflsm.loserManager, wpbflsm.activeList, wpbflsm.resultList, wpbflsm.currentCollectTime, done, wpbflsm.linguist.GetSearchGraph().GetWordTokenFirst(), true)
 	}
 
 	if wpbflsm.showTokenCount {
-		wpbflsm.showTokenCount()
+		wpbflsm.printTokenCount()
 	}
 
-	return result
+	return res
 }
 
 func (wpbflsm *WordPruningBreadthFirstLookaheadSearchManager) fastMatchRecognize() {
@@ -173,16 +207,36 @@ func (wpbflsm *WordPruningBreadthFirstLookaheadSearchManager) createFastMatchBes
 	wpbflsm.fastMatchBestTokenMap = make(map[linguist.SearchState]*Token, mapSize)
 }
 
+// Called at the start of recognition. Gets the search manager and its fast
+// match ready to recognize
+func (wpbflsm *WordPruningBreadthFirstLookaheadSearchManager) StartRecognition() {
+	if !wpbflsm.allocated {
+		return
+	}
+	wpbflsm.err = nil
+	wpbflsm.linguist.StartRecognition()
+	wpbflsm.fastmatchLinguist.StartRecognition()
+	wpbflsm.pruner.StartRecognition()
+	wpbflsm.scorer.StartRecognition()
+	wpbflsm.localStart()
+}
+
+// Terminates a recognition
+func (wpbflsm *WordPruningBreadthFirstLookaheadSearchManager) StopRecognition() {
+	if !wpbflsm.allocated {
+		return
+	}
+	wpbflsm.WordPruningBreadthFirstSearchManager.StopRecognition()
+	wpbflsm.fastmatchLinguist.StopRecognition()
+}
+
 func (wpbflsm *WordPruningBreadthFirstLookaheadSearchManager) localStart() {
 	wpbflsm.currentFastMatchFrameNumber = 0
-	l, ok := wpbflsm.loader.(tiedstate.Sphinx3Loader)
-	if ok && l.HasTiedMixtures() {
-		l.ClearGauScores()
-	}
+	wpbflsm.ciScores = wpbflsm.ciScores[:0]
 	// prepare fast match active list
-	wpbflsm.fastmatchActiveList = wpbflsm.fastmatchActiveListFactory.newInstance() //CHECK THIS
+	wpbflsm.fastmatchActiveList = wpbflsm.fastmatchActiveListFactory.NewInstance()
 	fmInitState := wpbflsm.fastmatchLinguist.GetSearchGraph().GetInitialState()
-	wpbflsm.fastmatchActiveList.Add(NewToken(fmInitState, wpbflsm.currentFastMatchFrameNumber))
+	wpbflsm.fastmatchActiveList.Add(NewToken(fmInitState, int64(wpbflsm.currentFastMatchFrameNumber)))
 	wpbflsm.createFastMatchBestTokenMap()
 	wpbflsm.growFastmatchBranches()
 	wpbflsm.fastmatchStreamEnd = false
@@ -190,39 +244,22 @@ func (wpbflsm *WordPruningBreadthFirstLookaheadSearchManager) localStart() {
 		wpbflsm.fastMatchRecognize()
 	}
 
-	// CHECK FROM EMBEDDED
-	// super.localStart()
-
-	searchGraph := wpbflsm.linguist.GetSearchGraph()
-	wpbflsm.currentFrameNumber = 0
-	wpbflsm.curTokensScored.Value = 0
-	wpbflsm.numStateOrder = searchGraph.GetNumStateOrder()
-	wpbflsm.activeListManager.SetNumStateOrder(wpbflsm.numStateOrder)
-	if buildWordLattice {
-		wpbflsm.loserManager = NewAlternateHypothesisManager(wpbflsm.maxLatticeEdges)
-	}
-
-	state := searchGraph.GetInitialState()
-
-	wpbflsm.activeList = wpbflsm.activeListManager.GetEmittingList()
-	wpbflsm.activeList.Add(NewToken(state, -1))
-
-	wpbflsm.clearCollectors()
-
-	wpbflsm.growBranches()
-	wpbflsm.growNonEmittingBranches()
+	wpbflsm.WordPruningBreadthFirstSearchManager.localStart()
 }
 
 func (wpbflsm *WordPruningBreadthFirstLookaheadSearchManager) growFastmatchBranches() {
 	wpbflsm.growTimer.Start()
 	oldActiveList := wpbflsm.fastmatchActiveList
-	wpbflsm.fastmatchActiveList = wpbflsm.fastmatchActiveListFactory.newInstance()
+	wpbflsm.fastmatchActiveList = wpbflsm.fastmatchActiveListFactory.NewInstance()
 	fastmathThreshold := oldActiveList.GetBeamThreshold()
 	// TODO more precise range of baseIds, remove magic number
 	frameCiScores := make([]float64, 1024)
+	for i := range frameCiScores {
+		frameCiScores[i] = -math.MaxFloat64
+	}
 
 	frameMaxCiScore := -math.MaxFloat64
-	for _, token := range oldActiveList {
+	for _, token := range oldActiveList.GetTokens() {
 		tokenScore := token.GetScore()
 		if tokenScore < fastmathThreshold {
 			continue
@@ -248,12 +285,15 @@ func (wpbflsm *WordPruningBreadthFirstLookaheadSearchManager) growFastmatchBranc
 func (wpbflsm *WordPruningBreadthFirstLookaheadSearchManager) scoreFastMatchTokens() bool {
 	var moreTokens bool
 	wpbflsm.scoreTimer.Start()
-	data := wpbflsm.scorer.CalculateScoresAndStoreData(wpbflsm.fastmatchActiveList.GetTokens())
+	data, err := wpbflsm.scorer.CalculateScoresAndStoreData(scoreables(wpbflsm.fastmatchActiveList.GetTokens()))
 	wpbflsm.scoreTimer.Stop()
 
-	bestToken := nil
+	var bestToken *Token
 	d, ok := data.(*Token)
-	if ok {
+	if err != nil {
+		wpbflsm.err = err
+		wpbflsm.fastmatchStreamEnd = true
+	} else if ok {
 		bestToken = d
 	} else {
 		wpbflsm.fastmatchStreamEnd = true
@@ -270,8 +310,8 @@ func (wpbflsm *WordPruningBreadthFirstLookaheadSearchManager) scoreFastMatchToke
 
 	// System.out.println("BEST " + bestToken);
 
-	wpbflsm.curTokensScored.value += wpbflsm.fastmatchActiveList.Size()
-	wpbflsm.totalTokensScored.value += wpbflsm.fastmatchActiveList.Size()
+	wpbflsm.curTokensScored.Value += float64(wpbflsm.fastmatchActiveList.Size())
+	wpbflsm.totalTokensScored.Value += float64(wpbflsm.fastmatchActiveList.Size())
 
 	return moreTokens
 }
@@ -317,8 +357,8 @@ func (wpbflsm *WordPruningBreadthFirstLookaheadSearchManager) collectFastMatchSu
 		// intervening emitting nodes. This can happen with nasty
 		// jsgf grammars such as ((foo*)*)*
 		if !nextState.IsEmitting() {
-			newTok := newToken(predecessor, nextState, logEntryScore, arc.GetInsertionProbability(), arc.GetLanguageProbability(), wpbflsm.currentFastMatchFrameNumber)
-			wpbflsm.tokensCreated.value++
+			newTok := newToken(predecessor, nextState, logEntryScore, arc.GetInsertionProbability(), arc.GetLanguageProbability(), int64(wpbflsm.currentFastMatchFrameNumber))
+			wpbflsm.tokensCreated.Value++
 			if !wpbflsm.isVisited(newTok) {
 				wpbflsm.collectFastMatchSuccessorTokens(newTok)
 			}
@@ -327,13 +367,13 @@ func (wpbflsm *WordPruningBreadthFirstLookaheadSearchManager) collectFastMatchSu
 
 		bestToken := wpbflsm.getFastMatchBestToken(nextState)
 		if bestToken == nil {
-			newTok := newToken(predecessor, nextState, logEntryScore, arc.GetInsertionProbability(), arc.GetLanguageProbability(), wpbflsm.currentFastMatchFrameNumber)
-			wpbflsm.tokensCreated.value++
+			newTok := newToken(predecessor, nextState, logEntryScore, arc.GetInsertionProbability(), arc.GetLanguageProbability(), int64(wpbflsm.currentFastMatchFrameNumber))
+			wpbflsm.tokensCreated.Value++
 			wpbflsm.setFastMatchBestToken(newTok, nextState)
 			wpbflsm.fastmatchActiveList.Add(newTok)
 		} else {
 			if bestToken.GetScore() <= logEntryScore {
-				bestToken.Update(predecessor, nextState, logEntryScore, arc.GetInsertionProbability(), arc.GetLanguageProbability(), wpbflsm.currentFastMatchFrameNumber)
+				bestToken.Update(predecessor, nextState, logEntryScore, arc.GetInsertionProbability(), arc.GetLanguageProbability(), int64(wpbflsm.currentFastMatchFrameNumber))
 			}
 		}
 	}
@@ -343,7 +383,7 @@ func (wpbflsm *WordPruningBreadthFirstLookaheadSearchManager) collectSuccessorTo
 	// If this is a final state, add it to the final list
 
 	if token.IsFinal() {
-		wpbflsm.resultList.Add(wpbflsm.getResultListPredecessor(token))
+		wpbflsm.resultList = append(wpbflsm.resultList, wpbflsm.getResultListPredecessor(token))
 		return
 	}
 
@@ -377,22 +417,20 @@ func (wpbflsm *WordPruningBreadthFirstLookaheadSearchManager) collectSuccessorTo
 	beamThreshold := wpbflsm.activeList.GetBeamThreshold()
 	stateProducesPhoneHmms := false
 
-	switch v := state.(type) {
+	switch state.(type) {
 	case lextree.LexTreeNonEmittingHMMState, lextree.LexTreeWordState, lextree.LexTreeEndUnitState:
 		stateProducesPhoneHmms = true
-	default:
-		stateProducesPhoneHmms = false
 	}
 	for _, arc := range arcs {
 		nextState := arc.GetState()
 
 		// prune states using lookahead heuristics
 		if stateProducesPhoneHmms {
-			lt, ok := nextState.LexTreeHMMState
+			lt, ok := nextState.(lextree.LexTreeHMMState)
 			if ok {
-				penalty := 0.
-				baseId := lt.GetHMMState().GetHMM().GetBaseUnit().GetBaseID()
-				if wpbflsm.penalties.Get(baseId) == nil {
+				baseId := lt.GetHMMState().HMM().BaseUnit().BaseID()
+				penalty, ok := wpbflsm.penalties[baseId]
+				if !ok {
 					penalty = wpbflsm.updateLookaheadPenalty(baseId)
 				}
 				if (tokenScore + wpbflsm.lookaheadWeight*penalty) < beamThreshold {
@@ -401,8 +439,8 @@ func (wpbflsm *WordPruningBreadthFirstLookaheadSearchManager) collectSuccessorTo
 			}
 		}
 
-		if checkStateOrder {
-			wpbflsm.checkStateOrder(state, nextState)
+		if wpbflsm.checkStateOrder {
+			wpbflsm.checkOrder(state, nextState)
 		}
 
 		// We're actually multiplying the variables, but since
@@ -411,11 +449,11 @@ func (wpbflsm *WordPruningBreadthFirstLookaheadSearchManager) collectSuccessorTo
 
 		bestToken := wpbflsm.getBestToken(nextState)
 
-		_, wss_ok := nextState.(WordSearchState)
+		_, wss_ok := nextState.(linguist.WordSearchState)
 
 		if bestToken == nil {
-			newBestToken = newToken(predecessor, nextState, logEntryScore, arc.GetInsertionProbability(), arc.GetLanguageProbability(), wpbflsm.currentCollectTime)
-			wpbflsm.tokensCreated.value++
+			newBestToken := newToken(predecessor, nextState, logEntryScore, arc.GetInsertionProbability(), arc.GetLanguageProbability(), wpbflsm.currentCollectTime)
+			wpbflsm.tokensCreated.Value++
 			wpbflsm.setBestToken(newBestToken, nextState)
 			wpbflsm.activeListAdd(newBestToken)
 		} else if bestToken.GetScore() < logEntryScore {
@@ -424,12 +462,12 @@ func (wpbflsm *WordPruningBreadthFirstLookaheadSearchManager) collectSuccessorTo
 			oldPredecessor := bestToken.GetPredecessor()
 			bestToken.Update(predecessor, nextState, logEntryScore, arc.GetInsertionProbability(), arc.GetLanguageProbability(), wpbflsm.currentCollectTime)
 
-			if buildWordLattice && wss_ok {
-				wpbfsm.loserManager.AddAlternatePredecessor(bestToken, oldPredecessor)
+			if wpbflsm.buildWordLattice && wss_ok {
+				wpbflsm.loserManager.AddAlternatePredecessor(bestToken, oldPredecessor)
 			}
-		} else if buildWordLattice && wss_ok {
+		} else if wpbflsm.buildWordLattice && wss_ok {
 			if predecessor != nil {
-				wpbfsm.loserManager.AddAlternatePredecessor(bestToken, predecessor)
+				wpbflsm.loserManager.AddAlternatePredecessor(bestToken, predecessor)
 			}
 		}
 	}
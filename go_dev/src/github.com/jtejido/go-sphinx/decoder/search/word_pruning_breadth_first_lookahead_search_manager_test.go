@@ -0,0 +1,159 @@
+package search
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/jtejido/go-sphinx/frontend"
+	"github.com/jtejido/go-sphinx/linguist/acoustic"
+	"github.com/jtejido/go-sphinx/linguist/acoustic/acoustictest"
+	"github.com/jtejido/go-sphinx/linguist/language/ngram"
+)
+
+const arpa = `\data\
+ngram 1=4
+ngram 2=3
+
+\1-grams:
+-1.0 <s> -0.5
+-1.0 </s>
+-0.6 hello -0.3
+-0.6 world -0.3
+
+\2-grams:
+-0.1 <s> hello
+-0.1 hello world
+-0.1 world </s>
+
+\end\
+`
+
+var phones = []string{"AH", "D", "ER", "HH", "L", "OW", "W", acoustic.SILENCE_NAME}
+
+// frameSource gives a 10ms frame per phone of its list, holding the index of the phone in phones, between the data
+// start and end signals. With an error, the error follows the frames instead.
+type frameSource struct {
+	frontend.BaseDataProcessor
+	frames         []string
+	err            error
+	position       int
+	started, ended bool
+}
+
+func (s *frameSource) GetData() (frontend.Data, error) {
+	if !s.started {
+		s.started = true
+		return frontend.NewDataStartSignal(16000, 0), nil
+	}
+	if s.position == len(s.frames) {
+		if s.err != nil {
+			return nil, s.err
+		}
+		if s.ended {
+			return nil, nil
+		}
+		s.ended = true
+		return frontend.NewDataEndSignal(int64(s.position*10), int64(s.position*10)), nil
+	}
+	i := s.position
+	s.position++
+	for index, phone := range phones {
+		if phone == s.frames[i] {
+			return frontend.NewFloatDataWithCollectTime([]float32{float32(index)}, 100, int64(i*10), int64(i)), nil
+		}
+	}
+	panic("unknown phone " + s.frames[i])
+}
+
+// scoreFrame scores the frames of the phone of the unit at 0, and the others far below.
+func scoreFrame(unit *acoustic.Unit, data frontend.Data) float32 {
+	if phones[int(data.(*frontend.FloatData).Values()[0])] == unit.BaseUnit().Name() {
+		return 0
+	}
+	return -50000
+}
+
+func newTestSearchManager(t *testing.T, source *frameSource, languageModel string) *WordPruningBreadthFirstLookaheadSearchManager {
+	d := acoustictest.NewDictionary(t, "hello HH AH L OW\nworld W ER L D\n")
+	unitManager := d.UnitManager()
+	var units []*acoustic.Unit
+	for _, name := range phones {
+		units = append(units, unitManager.Unit(name, name == acoustic.SILENCE_NAME))
+	}
+	am := &acoustictest.AcousticModel{ContextSize: 1, Units: units, Scorer: scoreFrame}
+	lm := ngram.NewDefaultSimpleNGramModel()
+	lm.SetLocation(filepath.Join(acoustictest.WriteFiles(t, map[string]string{"test.lm": languageModel}), "test.lm"))
+
+	frontEnd := frontend.NewDefaultFrontEnd()
+	frontEnd.SetDataSource(source)
+	return NewDefaultWordPruningBreadthFirstLookaheadSearchManager(am, unitManager, lm, d, frontEnd)
+}
+
+func TestWordPruningBreadthFirstLookaheadSearchManager(t *testing.T) {
+	var frames []string
+	for _, phone := range []string{"SIL", "HH", "AH", "L", "OW", "W", "ER", "L", "D", "SIL"} {
+		for i := 0; i < 4; i++ {
+			frames = append(frames, phone)
+		}
+	}
+	sm := newTestSearchManager(t, &frameSource{frames: frames}, arpa)
+	sm.Allocate()
+	if sm.Err() != nil {
+		t.Fatal(sm.Err())
+	}
+	defer sm.Deallocate()
+
+	sm.StartRecognition()
+	defer sm.StopRecognition()
+	var result *Result
+	for result == nil || !result.IsFinal() {
+		if result = sm.Recognize(10); result == nil {
+			t.Fatal("the stream ended without a final result")
+		}
+	}
+	token := result.GetBestToken()
+	if token == nil {
+		t.Fatal("the final result has no token")
+	}
+	if actual := token.GetWordPathNoFiller(); actual != "hello world" {
+		t.Errorf("got %q, want %q", actual, "hello world")
+	}
+}
+
+func TestWordPruningBreadthFirstLookaheadSearchManagerError(t *testing.T) {
+	for _, frames := range [][]string{{"SIL", "HH"}, {"SIL", "HH", "AH", "L", "OW", "W", "ER", "L", "D"}} {
+		err := errors.New("read error")
+		sm := newTestSearchManager(t, &frameSource{frames: frames, err: err}, arpa)
+		sm.Allocate()
+		sm.StartRecognition()
+		for i := 0; i < len(frames); i++ {
+			if result := sm.Recognize(1); result == nil {
+				break
+			}
+		}
+		if result := sm.Recognize(1); result != nil {
+			t.Errorf("%d frames: got a result after the error", len(frames))
+		}
+		if sm.Err() != err {
+			t.Errorf("%d frames: got error %v, want %v", len(frames), sm.Err(), err)
+		}
+		sm.StopRecognition()
+		sm.Deallocate()
+	}
+}
+
+func TestWordPruningBreadthFirstLookaheadSearchManagerAllocateError(t *testing.T) {
+	sm := newTestSearchManager(t, &frameSource{frames: []string{"SIL"}}, "not a language model\n")
+	sm.Allocate()
+	if sm.Err() == nil {
+		t.Fatal("got no error allocating a linguist without language model")
+	}
+	sm.StartRecognition()
+	if result := sm.Recognize(1); result != nil {
+		t.Error("got a result from an unallocated search manager")
+	}
+	if sm.Err() == nil {
+		t.Error("the allocation error was lost")
+	}
+}
@@ -2,18 +2,14 @@ package search
 
 import (
 	"fmt"
-	"github.com/jtejido/go-sphinx/decoder/pruner"
+	"math"
+
 	"github.com/jtejido/go-sphinx/decoder/scorer"
 	"github.com/jtejido/go-sphinx/linguist"
-	"github.com/jtejido/go-sphinx/result"
-	"github.com/jtejido/go-sphinx/utils"
-	"math"
+	"github.com/jtejido/go-sphinx/util"
 )
 
 const (
-	// The property that specifies the relative beam width
-	DEFAULT_RELATIVE_BEAM_WIDTH = 0.0
-
 	// The property that controls the amount of simple acoustic lookahead
 	// performed. Setting the property to zero (the default) disables simple
 	// acoustic lookahead. The lookahead need not be an integer.
@@ -56,14 +52,15 @@ type WordPruningBreadthFirstSearchManager struct {
 	linguist linguist.Linguist
 
 	// pruner to drop tokens
-	pruner pruner.Pruner
+	pruner Pruner
 
 	// scorer to estimate token probability
 	scorer scorer.AcousticScorer
 
 	// active list manager to store tokens
 	activeListManager ActiveListManager
-	logMath           utils.LogMath
+	logMath           *util.LogMath
+	logger            util.Logger
 
 	// -----------------------------------
 	// Configuration data
@@ -90,8 +87,8 @@ type WordPruningBreadthFirstSearchManager struct {
 	// -----------------------------------
 	// Instrumentation
 	// -----------------------------------
-	scoreTimer, pruneTimer, growTimer                 *utils.Timer
-	totalTokensScored, curTokensScored, tokensCreated *utils.StatisticsVariable
+	scoreTimer, pruneTimer, growTimer                 *util.Timer
+	totalTokensScored, curTokensScored, tokensCreated *util.StatisticsVariable
 	tokenSum                                          int64
 	tokenCount                                        int
 	// -----------------------------------
@@ -113,14 +110,24 @@ type WordPruningBreadthFirstSearchManager struct {
 	loserManager  *AlternateHypothesisManager
 	numStateOrder int
 	streamEnd     bool
+
+	// the error which left the search manager unallocated or stopped the recognition
+	err error
+
+	// whether the linguist, the pruner and the scorer were allocated
+	allocated bool
+
+	// collects the successors of a token, which the lookahead search manager
+	// prunes with its fast match
+	collectSuccessors func(token *Token)
 }
 
-func NewWordPruningBreadthFirstSearchManager(linguist linguist.Linguist, pruner pruner.Pruner, scorer scorer.AcousticScorer,
+func NewWordPruningBreadthFirstSearchManager(linguist linguist.Linguist, pruner Pruner, scorer scorer.AcousticScorer,
 	activeListManager ActiveListManager, showTokenCount bool, relativeWordBeamWidth float64, growSkipInterval int,
 	checkStateOrder bool, buildWordLattice bool, maxLatticeEdges int, acousticLookaheadFrames float64,
-	keepAllTokens bool) *WordPruningBreadthFirstSearchManager {
+	keepAllTokens bool, logger util.Logger) *WordPruningBreadthFirstSearchManager {
 	wpbfsm := new(WordPruningBreadthFirstSearchManager)
-	wpbfsm.logMath = utils.LogMath{}
+	wpbfsm.logMath = util.GetLogMath()
 	wpbfsm.linguist = linguist
 	wpbfsm.pruner = pruner
 	wpbfsm.scorer = scorer
@@ -132,27 +139,43 @@ func NewWordPruningBreadthFirstSearchManager(linguist linguist.Linguist, pruner
 	wpbfsm.maxLatticeEdges = maxLatticeEdges
 	wpbfsm.acousticLookaheadFrames = acousticLookaheadFrames
 	wpbfsm.keepAllTokens = keepAllTokens
+	wpbfsm.logger = logger
+	wpbfsm.collectSuccessors = wpbfsm.collectSuccessorTokens
 
-	wpbfsm.relativeBeamWidth = wpbfsm.logMath.LinearToLog(relativeWordBeamWidth)
+	wpbfsm.relativeBeamWidth = float64(wpbfsm.logMath.LinearToLog(relativeWordBeamWidth))
 	return wpbfsm
 }
 
+// Allocates the linguist, the pruner and the scorer. A linguist that can't be allocated leaves the search manager
+// unallocated, recognizing nothing, and Err returns its error.
 func (wpbfsm *WordPruningBreadthFirstSearchManager) Allocate() {
+	wpbfsm.err = nil
 
-	wpbfsm.scoreTimer = utils.NewTimer("Score")
-	wpbfsm.pruneTimer = utils.NewTimer("Prune")
-	wpbfsm.growTimer = utils.NewTimer("Grow")
+	wpbfsm.scoreTimer = util.NewTimer("Score")
+	wpbfsm.pruneTimer = util.NewTimer("Prune")
+	wpbfsm.growTimer = util.NewTimer("Grow")
 
-	wpbfsm.totalTokensScored = &utils.StatisticsVariable{Name: "totalTokensScored"}
-	wpbfsm.curTokensScored = &utils.StatisticsVariable{Name: "curTokensScored"}
-	wpbfsm.tokensCreated = &utils.StatisticsVariable{Name: "tokensCreated"}
+	wpbfsm.totalTokensScored = &util.StatisticsVariable{Name: "totalTokensScored"}
+	wpbfsm.curTokensScored = &util.StatisticsVariable{Name: "curTokensScored"}
+	wpbfsm.tokensCreated = &util.StatisticsVariable{Name: "tokensCreated"}
 
-	wpbfsm.linguist.Allocate()
+	if err := wpbfsm.linguist.Allocate(); err != nil {
+		wpbfsm.err = fmt.Errorf("search manager: %w", err)
+		if wpbfsm.logger != nil {
+			wpbfsm.logger.Errorf("%v", wpbfsm.err)
+		}
+		return
+	}
 	wpbfsm.pruner.Allocate()
 	wpbfsm.scorer.Allocate()
+	wpbfsm.allocated = true
 }
 
 func (wpbfsm *WordPruningBreadthFirstSearchManager) Deallocate() {
+	if !wpbfsm.allocated {
+		return
+	}
+	wpbfsm.allocated = false
 	wpbfsm.scorer.Deallocate()
 	wpbfsm.pruner.Deallocate()
 	wpbfsm.linguist.Deallocate()
@@ -160,14 +183,22 @@ func (wpbfsm *WordPruningBreadthFirstSearchManager) Deallocate() {
 
 // Called at the start of recognition. Gets the search manager ready to recognize
 func (wpbfsm *WordPruningBreadthFirstSearchManager) StartRecognition() {
+	if !wpbfsm.allocated {
+		return
+	}
+	wpbfsm.err = nil
 	wpbfsm.linguist.StartRecognition()
 	wpbfsm.pruner.StartRecognition()
 	wpbfsm.scorer.StartRecognition()
 	wpbfsm.localStart()
 }
 
-// Performs the recognition for the given number of frames.
-func (wpbfsm *WordPruningBreadthFirstSearchManager) Recognize(nFrames int) (res *result.Result) {
+// Performs the recognition for the given number of frames. Returns nil if the search manager isn't allocated, or if
+// the features couldn't be read, in which case Err returns the error.
+func (wpbfsm *WordPruningBreadthFirstSearchManager) Recognize(nFrames int) (res *Result) {
+	if !wpbfsm.allocated || wpbfsm.err != nil {
+		return nil
+	}
 	done := false
 	wpbfsm.streamEnd = false
 
@@ -175,16 +206,21 @@ func (wpbfsm *WordPruningBreadthFirstSearchManager) Recognize(nFrames int) (res
 		done = wpbfsm.recognize()
 	}
 
-	if !wpbfsm.streamEnd {
-		res = result.NewResult(wpbfsm.loserManager, wpbfsm.activeList, wpbfsm.resultList, wpbfsm.currentCollectTime, done, wpbfsm.linguist.GetSearchGraph().GetWordTokenFirst(), true)
+	if !wpbfsm.streamEnd && wpbfsm.err == nil {
+		res = NewResult(wpbfsm.loserManager, wpbfsm.activeList, wpbfsm.resultList, wpbfsm.currentCollectTime, done, wpbfsm.linguist.GetSearchGraph().GetWordTokenFirst(), true)
 	}
 
 	if wpbfsm.showTokenCount {
-		wpbfsm.showTokenCount()
+		wpbfsm.printTokenCount()
 	}
 	return res
 }
 
+// Returns the error which left the search manager unallocated or stopped the recognition, nil if none.
+func (wpbfsm *WordPruningBreadthFirstSearchManager) Err() error {
+	return wpbfsm.err
+}
+
 func (wpbfsm *WordPruningBreadthFirstSearchManager) recognize() bool {
 
 	wpbfsm.activeList = wpbfsm.activeListManager.GetEmittingList()
@@ -220,6 +256,9 @@ func (wpbfsm *WordPruningBreadthFirstSearchManager) createBestTokenMap() {
 
 // Terminates a recognition
 func (wpbfsm *WordPruningBreadthFirstSearchManager) StopRecognition() {
+	if !wpbfsm.allocated {
+		return
+	}
 	//wpbfsm.localStop() this doesn't have any
 	wpbfsm.scorer.StopRecognition()
 	wpbfsm.pruner.StopRecognition()
@@ -233,7 +272,7 @@ func (wpbfsm *WordPruningBreadthFirstSearchManager) localStart() {
 	wpbfsm.curTokensScored.Value = 0
 	wpbfsm.numStateOrder = searchGraph.GetNumStateOrder()
 	wpbfsm.activeListManager.SetNumStateOrder(wpbfsm.numStateOrder)
-	if buildWordLattice {
+	if wpbfsm.buildWordLattice {
 		wpbfsm.loserManager = NewAlternateHypothesisManager(wpbfsm.maxLatticeEdges)
 	}
 
@@ -259,10 +298,9 @@ func (wpbfsm *WordPruningBreadthFirstSearchManager) growBranches() {
 	//     logger.fine("Frame: " + currentFrameNumber + " thresh : " + relativeBeamThreshold + " bs "
 	//             + activeList.getBestScore() + " tok " + activeList.getBestToken());
 	// }
-	aList := wpbfsm.activeList.GetList()
-	for _, token := range aList {
-		if token.GetScore() >= relativeBeamThreshold && wpbfsm.allowExpansion(token) {
-			wpbfsm.collectSuccessorTokens(token)
+	for _, token := range wpbfsm.activeList.GetTokens() {
+		if token.GetScore() >= relativeBeamThreshold {
+			wpbfsm.collectSuccessors(token)
 		}
 	}
 	wpbfsm.growTimer.Stop()
@@ -287,7 +325,7 @@ func (wpbfsm *WordPruningBreadthFirstSearchManager) growEmittingBranches() {
 	relativeBeamThreshold := bestScore + wpbfsm.relativeBeamWidth
 	for _, t := range toks {
 		if t.GetScore()+t.GetAcousticScore()*wpbfsm.acousticLookaheadFrames > relativeBeamThreshold {
-			wpbfsm.collectSuccessorTokens(t)
+			wpbfsm.collectSuccessors(t)
 		}
 	}
 	wpbfsm.growTimer.Stop()
@@ -313,19 +351,17 @@ func (wpbfsm *WordPruningBreadthFirstSearchManager) growNonEmittingBranches() {
 func (wpbfsm *WordPruningBreadthFirstSearchManager) scoreTokens() bool {
 	var moreTokens bool
 	wpbfsm.scoreTimer.Start()
-	data := wpbfsm.scorer.CalculateScores(wpbfsm.activeList.GetTokens())
+	data, err := wpbfsm.scorer.CalculateScores(scoreables(wpbfsm.activeList.GetTokens()))
 	wpbfsm.scoreTimer.Stop()
 
 	var bestToken *Token
 
-	if data == nil {
-		streamEnd = true
-	} else {
-
-		_, ok := data.(Token)
-		if ok {
-			bestToken = data
-		}
+	if err != nil {
+		wpbfsm.err = err
+	} else if data == nil {
+		wpbfsm.streamEnd = true
+	} else if token, ok := data.(*Token); ok {
+		bestToken = token
 	}
 
 	if bestToken != nil {
@@ -335,18 +371,27 @@ func (wpbfsm *WordPruningBreadthFirstSearchManager) scoreTokens() bool {
 	moreTokens = (bestToken != nil)
 	wpbfsm.activeList.SetBestToken(bestToken)
 
-	wpbfsm.monitorStates(activeList)
+	wpbfsm.monitorStates(wpbfsm.activeList)
 
-	wpbfsm.curTokensScored.Value += wpbfsm.activeList.Size()
-	wpbfsm.totalTokensScored.Value += wpbfsm.activeList.Size()
+	wpbfsm.curTokensScored.Value += float64(wpbfsm.activeList.Size())
+	wpbfsm.totalTokensScored.Value += float64(wpbfsm.activeList.Size())
 
 	return moreTokens
 }
 
+// Returns the tokens as the scoreables of the scorer.
+func scoreables(tokens []*Token) []scorer.Scoreable {
+	scoreables := make([]scorer.Scoreable, len(tokens))
+	for i, token := range tokens {
+		scoreables[i] = token
+	}
+	return scoreables
+}
+
 // Keeps track of and reports statistics about the number of active states
 func (wpbfsm *WordPruningBreadthFirstSearchManager) monitorStates(activeList ActiveList) {
 
-	wpbfsm.tokenSum += wpbfsm.activeList.Size()
+	wpbfsm.tokenSum += int64(activeList.Size())
 	wpbfsm.tokenCount++
 
 	// if (wpbfsm.tokenCount % 1000) == 0 {
@@ -362,18 +407,18 @@ func (wpbfsm *WordPruningBreadthFirstSearchManager) pruneBranches() {
 }
 
 // Gets the best token for this state
-func (wpbfsm *WordPruningBreadthFirstSearchManager) getBestToken(state) *Token {
-	return wpbfsm.bestTokenMap.Get(state)
+func (wpbfsm *WordPruningBreadthFirstSearchManager) getBestToken(state linguist.SearchState) *Token {
+	return wpbfsm.bestTokenMap[state]
 }
 
 // Sets the best token for a given state
 func (wpbfsm *WordPruningBreadthFirstSearchManager) setBestToken(token *Token, state linguist.SearchState) {
-	wpbfsm.bestTokenMap.Put(state, token)
+	wpbfsm.bestTokenMap[state] = token
 }
 
 // Checks that the given two states are in legitimate order.
-func (wpbfsm *WordPruningBreadthFirstSearchManager) checkStateOrder(fromState, toState linguist.SearchState) {
-	if fromState.GetOrder() == numStateOrder-1 {
+func (wpbfsm *WordPruningBreadthFirstSearchManager) checkOrder(fromState, toState linguist.SearchState) {
+	if fromState.GetOrder() == wpbfsm.numStateOrder-1 {
 		return
 	}
 
@@ -388,7 +433,7 @@ func (wpbfsm *WordPruningBreadthFirstSearchManager) collectSuccessorTokens(token
 
 	// If this is a final state, add it to the final list
 	if token.IsFinal() {
-		wpbfsm.resultList.Add(wpbfsm.getResultListPredecessor(token))
+		wpbfsm.resultList = append(wpbfsm.resultList, wpbfsm.getResultListPredecessor(token))
 		return
 	}
 
@@ -422,7 +467,7 @@ func (wpbfsm *WordPruningBreadthFirstSearchManager) collectSuccessorTokens(token
 		nextState := arc.GetState()
 
 		if wpbfsm.checkStateOrder {
-			wpbfsm.checkStateOrder(state, nextState)
+			wpbfsm.checkOrder(state, nextState)
 		}
 
 		// We're actually multiplying the variables, but since
@@ -430,15 +475,15 @@ func (wpbfsm *WordPruningBreadthFirstSearchManager) collectSuccessorTokens(token
 		logEntryScore := token.GetScore() + arc.GetProbability()
 
 		bestToken := wpbfsm.getBestToken(nextState)
-		_, ok := nextState.(WordSearchState)
+		_, ok := nextState.(linguist.WordSearchState)
 		if bestToken == nil {
 			newBestToken := newToken(predecessor, nextState, logEntryScore, arc.GetInsertionProbability(), arc.GetLanguageProbability(), wpbfsm.currentCollectTime)
-			wpbfsm.tokensCreated.value++
+			wpbfsm.tokensCreated.Value++
 			wpbfsm.setBestToken(newBestToken, nextState)
 			wpbfsm.activeListAdd(newBestToken)
 		} else if bestToken.GetScore() < logEntryScore {
 			oldPredecessor := bestToken.GetPredecessor()
-			bestToken.update(predecessor, nextState, logEntryScore, arc.GetInsertionProbability(), arc.GetLanguageProbability(), wpbfsm.currentCollectTime)
+			bestToken.Update(predecessor, nextState, logEntryScore, arc.GetInsertionProbability(), arc.GetLanguageProbability(), wpbfsm.currentCollectTime)
 
 			if wpbfsm.buildWordLattice && ok {
 				wpbfsm.loserManager.AddAlternatePredecessor(bestToken, oldPredecessor)
@@ -459,7 +504,7 @@ func (wpbfsm *WordPruningBreadthFirstSearchManager) isVisited(t *Token) bool {
 	t = t.GetPredecessor()
 
 	for t != nil && !t.IsEmitting() {
-		if curState.Equals(t.GetSearchState()) {
+		if curState == t.GetSearchState() {
 			// System.out.println("CS " + curState + " match " + t.getSearchState());
 			return true
 		}
@@ -475,17 +520,17 @@ func (wpbfsm *WordPruningBreadthFirstSearchManager) activeListAdd(token *Token)
 
 // Counts all the tokens in the active list (and displays them). This is an
 // expensive operation.
-func (wpbfsm *WordPruningBreadthFirstSearchManager) showTokenCount() {
+func (wpbfsm *WordPruningBreadthFirstSearchManager) printTokenCount() {
 	tokenSet := make([]*Token, 0)
 
-	for _, token := range wpbfsm.activeList {
+	for _, token := range wpbfsm.activeList.GetTokens() {
 		for token != nil {
 			tokenSet = append(tokenSet, token)
 			token = token.GetPredecessor()
 		}
 	}
 
-	fmt.Println("Token Lattice size: %d", len(tokenSet))
+	fmt.Printf("Token Lattice size: %d\n", len(tokenSet))
 
 	tokenSet = make([]*Token, 0)
 
@@ -496,5 +541,5 @@ func (wpbfsm *WordPruningBreadthFirstSearchManager) showTokenCount() {
 		}
 	}
 
-	fmt.Println("Result Lattice size: %d", len(tokenSet))
+	fmt.Printf("Result Lattice size: %d\n", len(tokenSet))
 }
@@ -0,0 +1,340 @@
+package auto
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jtejido/go-sphinx/frontend"
+	"github.com/jtejido/go-sphinx/frontend/denoise"
+	"github.com/jtejido/go-sphinx/frontend/filter"
+	"github.com/jtejido/go-sphinx/frontend/frequencywarp"
+	"github.com/jtejido/go-sphinx/frontend/transform"
+	"github.com/jtejido/go-sphinx/frontend/window"
+	"github.com/jtejido/go-sphinx/util"
+)
+
+const (
+	// The sample rate of the models when feat.params doesn't give one.
+	DEFAULT_SAMPLE_RATE = 16000
+)
+
+/**
+ * The part of an acoustic model loader the front end is configured from. tiedstate.Loader satisfies it.
+ */
+type Loader interface {
+	/**
+	 * @return the model properties, read from feat.params
+	 */
+	Properties() *util.Properties
+}
+
+/**
+ * Cepstrum is an auto-configurable DataProcessor which is used to compute a specific cepstrum (for a target acoustic
+ * model) given the spectrum. The Cepstrum is computed using a pipeline of front end components which are selected,
+ * customized or ignored depending on the feat.params file which characterizes the target acoustic model for which
+ * this cepstrum is computed. A typical legacy MFCC Cepstrum will use a MelFrequencyFilterBank2, followed by a
+ * DiscreteCosineTransform. Models trained with -transform dct use a DiscreteCosineTransform2 instead, usually
+ * followed by a Lifter component. Models trained on PLP cepstra, selected with -cepstrum plp, use a
+ * PLPFrequencyFilterBank followed by a PLPCepstrumProducer instead of the filter bank and the DCT.
+ *
+ * The parameters honoured are -alpha, -samprate, -frate, -wlen, -nfft, -nfilt, -lowerf, -upperf, -round_filters,
+ * -unit_area, -transform (legacy or dct), -cepstrum (mfcc or plp), -lpc_order, -ncep and -lifter. For PLP, -nfilt,
+ * -lowerf and -upperf give the number and the center frequencies of the critical band filters. The mel filter bank
+ * is warped for vocal tract length normalization if -warp_params is given: "a b" for the affine -warp_type, "factor"
+ * or "factor cutoff" for piecewise_linear, the default.
+ *
+ * Two noise robustness stages can be switched on: -dither yes adds a Dither, seeded with -seed (a fixed default seed
+ * if negative), in front of the pipeline, and -remove_noise yes a Denoise between the DFT and the filter bank.
+ */
+type AutoCepstrum struct {
+	frontend.BaseDataProcessor
+	loader                 Loader
+	params                 *FeatParams
+	filterBank             *frequencywarp.MelFrequencyFilterBank2
+	selectedDataProcessors []frontend.DataProcessor
+}
+
+/**
+ * Constructs an AutoCepstrum from the feat.params of the loaded acoustic model.
+ *
+ * @param loader the loader of the acoustic model
+ * @throws error if feat.params holds invalid values
+ */
+func NewAutoCepstrum(loader Loader) (*AutoCepstrum, error) {
+	ac := &AutoCepstrum{
+		loader: loader,
+		params: NewFeatParams(loader.Properties()),
+	}
+	if err := ac.initDataProcessors(); err != nil {
+		return nil, err
+	}
+	return ac, nil
+}
+
+func (ac *AutoCepstrum) initDataProcessors() error {
+	alpha, err := ac.params.Float("-alpha", filter.DEFAULT_PREEMPHASIS_FACTOR)
+	if err != nil {
+		return err
+	}
+	sampleRate, err := ac.params.SampleRate()
+	if err != nil {
+		return err
+	}
+	frameRate, err := ac.params.Int("-frate", 100)
+	if err != nil {
+		return err
+	}
+	windowLength, err := ac.params.Float("-wlen", window.DEFAULT_WINDOW_SIZE_MS/1000)
+	if err != nil {
+		return err
+	}
+	nfft, err := ac.params.Int("-nfft", 0)
+	if err != nil {
+		return err
+	}
+	cepstrumSize, err := ac.params.Int("-ncep", transform.DEFAULT_CEPSTRUM_LENGTH)
+	if err != nil {
+		return err
+	}
+	lifterValue, err := ac.params.Int("-lifter", 0)
+	if err != nil {
+		return err
+	}
+	dither, err := ac.params.Bool("-dither", false)
+	if err != nil {
+		return err
+	}
+	seed, err := ac.params.Int("-seed", filter.DEFAULT_DITHER_SEED)
+	if err != nil {
+		return err
+	}
+	removeNoise, err := ac.params.Bool("-remove_noise", false)
+	if err != nil {
+		return err
+	}
+	if frameRate <= 0 {
+		return fmt.Errorf("feat.params: -frate must be positive, got %d", frameRate)
+	}
+	if nfft != 0 && nfft&(nfft-1) != 0 {
+		return fmt.Errorf("feat.params: -nfft must be a power of 2, got %d", nfft)
+	}
+
+	if dither {
+		if seed < 0 {
+			seed = filter.DEFAULT_DITHER_SEED
+		}
+		ac.selectedDataProcessors = append(ac.selectedDataProcessors, filter.NewDither(filter.DEFAULT_MAX_DITHER, int64(seed)))
+	}
+	ac.selectedDataProcessors = append(ac.selectedDataProcessors,
+		filter.NewPreemphasizer(alpha),
+		window.NewRaisedCosineWindower(window.DEFAULT_ALPHA, windowLength*1000, 1000/float64(frameRate)))
+
+	if nfft == 0 {
+		ac.selectedDataProcessors = append(ac.selectedDataProcessors, transform.NewDefaultDiscreteFourierTransform())
+	} else {
+		ac.selectedDataProcessors = append(ac.selectedDataProcessors, transform.NewDiscreteFourierTransform(nfft, false))
+	}
+	if removeNoise {
+		ac.selectedDataProcessors = append(ac.selectedDataProcessors, denoise.NewDefaultDenoise())
+	}
+
+	switch cepstrum := ac.params.String("-cepstrum", "mfcc"); cepstrum {
+	case "mfcc":
+		err = ac.initMfcc(sampleRate, cepstrumSize)
+	case "plp":
+		err = ac.initPlp(sampleRate, cepstrumSize)
+	default:
+		err = fmt.Errorf("feat.params: unsupported -cepstrum %q", cepstrum)
+	}
+	if err != nil {
+		return err
+	}
+
+	if lifterValue != 0 {
+		ac.selectedDataProcessors = append(ac.selectedDataProcessors, transform.NewLifter(lifterValue))
+	}
+
+	for i := 1; i < len(ac.selectedDataProcessors); i++ {
+		ac.selectedDataProcessors[i].SetPredecessor(ac.selectedDataProcessors[i-1])
+	}
+	return nil
+}
+
+// initMfcc appends the mel filterbank and the DCT of the MFCC cepstrum.
+func (ac *AutoCepstrum) initMfcc(sampleRate, cepstrumSize int) error {
+	numberFilters, err := ac.params.Int("-nfilt", frequencywarp.DEFAULT_NUMBER_FILTERS)
+	if err != nil {
+		return err
+	}
+	lowerf, err := ac.params.Float("-lowerf", frequencywarp.DEFAULT_MIN_FREQ)
+	if err != nil {
+		return err
+	}
+	upperf, err := ac.params.Float("-upperf", frequencywarp.DEFAULT_MAX_FREQ)
+	if err != nil {
+		return err
+	}
+	roundFilters, err := ac.params.Bool("-round_filters", true)
+	if err != nil {
+		return err
+	}
+	unitArea, err := ac.params.Bool("-unit_area", true)
+	if err != nil {
+		return err
+	}
+	if upperf > float64(sampleRate)/2 {
+		return fmt.Errorf("feat.params: -upperf %v is above the Nyquist frequency of %dHz audio", upperf, sampleRate)
+	}
+
+	ac.filterBank = frequencywarp.NewMelFrequencyFilterBank2(lowerf, upperf, numberFilters)
+	ac.filterBank.SetRoundFilters(roundFilters)
+	ac.filterBank.SetUnitArea(unitArea)
+	warp, err := ac.frequencyWarp()
+	if err != nil {
+		return err
+	}
+	ac.filterBank.SetFrequencyWarp(warp)
+	ac.selectedDataProcessors = append(ac.selectedDataProcessors, ac.filterBank)
+
+	switch dct := ac.params.String("-transform", "legacy"); dct {
+	case "legacy":
+		ac.selectedDataProcessors = append(ac.selectedDataProcessors,
+			transform.NewDiscreteCosineTransform(numberFilters, cepstrumSize))
+	case "dct":
+		ac.selectedDataProcessors = append(ac.selectedDataProcessors,
+			transform.NewDiscreteCosineTransform2(numberFilters, cepstrumSize))
+	default:
+		return fmt.Errorf("feat.params: unsupported -transform %q", dct)
+	}
+	return nil
+}
+
+// initPlp appends the critical band filterbank and the LPC cepstrum of the PLP cepstrum.
+func (ac *AutoCepstrum) initPlp(sampleRate, cepstrumSize int) error {
+	numberFilters, err := ac.params.Int("-nfilt", frequencywarp.DEFAULT_PLP_NUMBER_FILTERS)
+	if err != nil {
+		return err
+	}
+	lowerf, err := ac.params.Float("-lowerf", frequencywarp.DEFAULT_PLP_MIN_FREQ)
+	if err != nil {
+		return err
+	}
+	upperf, err := ac.params.Float("-upperf", frequencywarp.DEFAULT_PLP_MAX_FREQ)
+	if err != nil {
+		return err
+	}
+	lpcOrder, err := ac.params.Int("-lpc_order", frequencywarp.DEFAULT_LPC_ORDER)
+	if err != nil {
+		return err
+	}
+	if upperf > float64(sampleRate)/2 {
+		return fmt.Errorf("feat.params: -upperf %v is above the Nyquist frequency of %dHz audio", upperf, sampleRate)
+	}
+	if ac.params.Has("-warp_params") {
+		return fmt.Errorf("feat.params: -warp_params is not supported for PLP cepstra")
+	}
+	if lpcOrder <= 0 {
+		return fmt.Errorf("feat.params: -lpc_order must be positive, got %d", lpcOrder)
+	}
+
+	ac.selectedDataProcessors = append(ac.selectedDataProcessors,
+		frequencywarp.NewPLPFrequencyFilterBank(lowerf, upperf, numberFilters),
+		frequencywarp.NewPLPCepstrumProducer(cepstrumSize, lpcOrder))
+	return nil
+}
+
+// frequencyWarp reads the VTLN warp of -warp_type and -warp_params, nil if there is none.
+func (ac *AutoCepstrum) frequencyWarp() (frequencywarp.FrequencyWarp, error) {
+	if !ac.params.Has("-warp_params") {
+		return nil, nil
+	}
+	var params []float64
+	for _, field := range strings.Fields(ac.params.String("-warp_params", "")) {
+		param, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil, fmt.Errorf("feat.params: -warp_params: %v", err)
+		}
+		params = append(params, param)
+	}
+
+	var warp frequencywarp.FrequencyWarp
+	var err error
+	switch warpType := ac.params.String("-warp_type", "piecewise_linear"); {
+	case warpType == "affine" && len(params) == 2:
+		warp, err = frequencywarp.NewAffineWarp(params[0], params[1])
+	case warpType == "piecewise_linear" && len(params) == 1:
+		warp, err = frequencywarp.NewPiecewiseLinearWarp(params[0], 0)
+	case warpType == "piecewise_linear" && len(params) == 2:
+		warp, err = frequencywarp.NewPiecewiseLinearWarp(params[0], params[1])
+	case warpType == "affine" || warpType == "piecewise_linear":
+		return nil, fmt.Errorf("feat.params: wrong number of -warp_params for %s: %v", warpType, params)
+	default:
+		return nil, fmt.Errorf("feat.params: unsupported -warp_type %q", warpType)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("feat.params: %v", err)
+	}
+	return warp, nil
+}
+
+/**
+ * Warps the frequency axis of the mel filter bank for vocal tract length normalization, overriding the warp of
+ * feat.params.
+ *
+ * @param warp the frequency warp, or nil for none
+ * @throws error if the cepstrum has no mel filter bank
+ */
+func (ac *AutoCepstrum) SetFrequencyWarp(warp frequencywarp.FrequencyWarp) error {
+	if ac.filterBank == nil {
+		return fmt.Errorf("frequency warping needs a mel filter bank")
+	}
+	ac.filterBank.SetFrequencyWarp(warp)
+	return nil
+}
+
+/**
+ * @return the feat.params of the acoustic model
+ */
+func (ac *AutoCepstrum) Params() *FeatParams {
+	return ac.params
+}
+
+/**
+ * @return the mel filter bank of the pipeline, nil for PLP cepstra
+ */
+func (ac *AutoCepstrum) FilterBank() *frequencywarp.MelFrequencyFilterBank2 {
+	return ac.filterBank
+}
+
+/**
+ * @return the front end components of the pipeline, in order
+ */
+func (ac *AutoCepstrum) DataProcessors() []frontend.DataProcessor {
+	return ac.selectedDataProcessors
+}
+
+func (ac *AutoCepstrum) SetPredecessor(predecessor frontend.DataProcessor) {
+	ac.BaseDataProcessor.SetPredecessor(predecessor)
+	ac.selectedDataProcessors[0].SetPredecessor(predecessor)
+}
+
+func (ac *AutoCepstrum) Initialize() {
+	for _, dataProcessor := range ac.selectedDataProcessors {
+		dataProcessor.Initialize()
+	}
+}
+
+/**
+ * Returns the processed Data output, basically calls getData() on the last processor.
+ *
+ * @return a Data object that has been processed by the cepstrum.
+ * @throws error if a data processor error occurs
+ */
+func (ac *AutoCepstrum) GetData() (frontend.Data, error) {
+	return ac.selectedDataProcessors[len(ac.selectedDataProcessors)-1].GetData()
+}
+
+func (ac *AutoCepstrum) String() string {
+	return fmt.Sprintf("AutoCepstrum %v", ac.selectedDataProcessors)
+}
@@ -0,0 +1,299 @@
+package auto
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jtejido/go-sphinx/frontend"
+	"github.com/jtejido/go-sphinx/frontend/denoise"
+	"github.com/jtejido/go-sphinx/frontend/filter"
+	"github.com/jtejido/go-sphinx/frontend/frequencywarp"
+	"github.com/jtejido/go-sphinx/frontend/transform"
+	feutil "github.com/jtejido/go-sphinx/frontend/util"
+	"github.com/jtejido/go-sphinx/util"
+)
+
+type propertiesLoader struct {
+	props *util.Properties
+}
+
+func (l *propertiesLoader) Properties() *util.Properties {
+	return l.props
+}
+
+func newLoader(params map[string]string) *propertiesLoader {
+	props := util.NewProperties()
+	for k, v := range params {
+		props.SetProperty(k, v)
+	}
+	return &propertiesLoader{props: props}
+}
+
+func sineStream(sampleRate, count int) ([]float64, []byte) {
+	samples := make([]float64, count)
+	var b bytes.Buffer
+	for i := range samples {
+		s := int16(8000*math.Sin(2*math.Pi*440*float64(i)/float64(sampleRate)) + 1000*math.Sin(2*math.Pi*2500*float64(i)/float64(sampleRate)))
+		samples[i] = float64(s)
+		binary.Write(&b, binary.LittleEndian, s)
+	}
+	return samples, b.Bytes()
+}
+
+func cepstra(t *testing.T, loader Loader, pcm []byte) [][]float64 {
+	cepstrum, err := NewAutoCepstrum(loader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sds := feutil.NewDefaultStreamDataSource()
+	sds.SetInputStream(bytes.NewReader(pcm), util.INFINITE)
+	fe := frontend.NewFrontEnd([]frontend.DataProcessor{sds, cepstrum})
+
+	var frames [][]float64
+	for {
+		data, err := fe.GetData()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data == nil {
+			return frames
+		}
+		if dd, ok := data.(*frontend.DoubleData); ok {
+			frames = append(frames, dd.Values())
+		}
+	}
+}
+
+// TestMfccFirstFrame checks the pipeline against a direct evaluation of the sphinxbase MFCC formulas.
+func TestMfccFirstFrame(t *testing.T) {
+	samples, pcm := sineStream(16000, 16000)
+	frames := cepstra(t, newLoader(map[string]string{"-transform": "dct", "-lifter": "22"}), pcm)
+
+	// (16000 - 410) / 160 + 1 windows of 410 samples
+	if len(frames) != 98 {
+		t.Fatalf("got %d frames, want 98", len(frames))
+	}
+	for _, frame := range frames {
+		if len(frame) != 13 {
+			t.Fatalf("got %d cepstra, want 13", len(frame))
+		}
+	}
+
+	// preemphasis and Hamming window
+	frame := make([]float64, 410)
+	for i := range frame {
+		prior := 0.0
+		if i > 0 {
+			prior = samples[i-1]
+		}
+		frame[i] = (samples[i] - 0.97*prior) * (0.54 - 0.46*math.Cos(2*math.Pi*float64(i)/409))
+	}
+
+	// naive DFT power spectrum over 512 points
+	spectrum := make([]float64, 257)
+	for k := range spectrum {
+		var re, im float64
+		for n, x := range frame {
+			re += x * math.Cos(2*math.Pi*float64(k*n)/512)
+			im -= x * math.Sin(2*math.Pi*float64(k*n)/512)
+		}
+		spectrum[k] = re*re + im*im
+	}
+
+	// rounded, unit area triangles equally spaced on the mel scale
+	mel := func(f float64) float64 { return 2595 * math.Log10(1+f/700) }
+	melinv := func(m float64) float64 { return 700 * (math.Pow(10, m/2595) - 1) }
+	melMin, melMax := mel(133.33334), mel(6855.4976)
+	logspec := make([]float64, 40)
+	for i := range logspec {
+		var freqs [3]float64
+		for j := range freqs {
+			freqs[j] = math.Floor(melinv(melMin+float64(i+j)*(melMax-melMin)/41)/31.25+0.5) * 31.25
+		}
+		for k := 0; k < 256; k++ {
+			hz := float64(k) * 31.25
+			if hz < freqs[0] || hz > freqs[2] {
+				continue
+			}
+			weight := math.Min((hz-freqs[0])/(freqs[1]-freqs[0]), (freqs[2]-hz)/(freqs[2]-freqs[1])) * 2 / (freqs[2] - freqs[0])
+			logspec[i] += weight * spectrum[k]
+		}
+		logspec[i] = math.Log(logspec[i] + 1e-4)
+	}
+
+	for i := 0; i < 13; i++ {
+		var c float64
+		for j, v := range logspec {
+			c += v * math.Cos(math.Pi*float64(i)*(float64(j)+0.5)/40)
+		}
+		if i == 0 {
+			c *= math.Sqrt(1.0 / 40)
+		} else {
+			c *= math.Sqrt(2.0 / 40)
+		}
+		c *= 1 + 11*math.Sin(float64(i)*math.Pi/22)
+		if math.Abs(c-frames[0][i]) > 1e-6*math.Max(1, math.Abs(c)) {
+			t.Errorf("c[%d] = %v, want %v", i, frames[0][i], c)
+		}
+	}
+}
+
+// loadFeatParams reads a feat.params file: one parameter and its value per line.
+func loadFeatParams(t *testing.T, path string) *propertiesLoader {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	props := util.NewProperties()
+	for _, line := range strings.Split(string(content), "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 {
+			props.SetProperty(fields[0], fields[1])
+		}
+	}
+	return &propertiesLoader{props: props}
+}
+
+// TestMfccReference compares the cepstra of a WAV file with the ones of the sphinxbase front end, see
+// testdata/generate.py.
+func TestMfccReference(t *testing.T) {
+	loader := loadFeatParams(t, filepath.Join("testdata", "feat.params"))
+	cepstrum, err := NewAutoCepstrum(loader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wav, err := os.Open(filepath.Join("testdata", "vowel.wav"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sds := feutil.NewDefaultStreamDataSource()
+	if err := sds.SetAudioStream(wav, util.INFINITE); err != nil {
+		t.Fatal(err)
+	}
+	fe := frontend.NewFrontEnd([]frontend.DataProcessor{sds, cepstrum})
+	var frames [][]float64
+	for {
+		data, err := fe.GetData()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data == nil {
+			break
+		}
+		if dd, ok := data.(*frontend.DoubleData); ok {
+			frames = append(frames, dd.Values())
+		}
+	}
+
+	mfc, err := os.ReadFile(filepath.Join("testdata", "vowel.mfc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reference, err := feutil.ReadFeatureFile(mfc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// sphinxbase zero pads the samples after the last whole window into one more frame, which the windower drops
+	if len(reference)/13 != len(frames)+1 {
+		t.Fatalf("got %d frames, want the %d of the reference but its last", len(frames), len(reference)/13)
+	}
+	for i, frame := range frames {
+		for j, c := range frame {
+			want := float64(reference[13*i+j])
+			if math.Abs(c-want) > 1e-3*math.Max(1, math.Abs(want)) {
+				t.Errorf("frame %d: c[%d] = %v, want %v", i, j, c, want)
+			}
+		}
+	}
+}
+
+func TestSampleRate(t *testing.T) {
+	for value, expected := range map[string]int{"16000.0": 16000, "8000": 8000, "11025.000000": 11025} {
+		sampleRate, err := NewFeatParams(newLoader(map[string]string{"-samprate": value}).Properties()).SampleRate()
+		if err != nil || sampleRate != expected {
+			t.Errorf("-samprate %s gives %d, %v, want %d", value, sampleRate, err, expected)
+		}
+	}
+	for _, value := range []string{"16000.5", "-8000", "16k"} {
+		if _, err := NewAutoCepstrum(newLoader(map[string]string{"-samprate": value})); err == nil {
+			t.Errorf("expected an error for -samprate %s", value)
+		}
+	}
+}
+
+func TestInvalidTransform(t *testing.T) {
+	if _, err := NewAutoCepstrum(newLoader(map[string]string{"-transform": "htk"})); err == nil {
+		t.Fatal("expected an error for an unknown -transform")
+	}
+}
+
+func TestPlpCepstrum(t *testing.T) {
+	_, pcm := sineStream(16000, 16000)
+	frames := cepstra(t, newLoader(map[string]string{"-cepstrum": "plp", "-upperf": "6800"}), pcm)
+	if len(frames) != 98 {
+		t.Fatalf("got %d frames, want 98", len(frames))
+	}
+	for i, frame := range frames {
+		if len(frame) != 13 {
+			t.Fatalf("frame %d has %d cepstra, want 13", i, len(frame))
+		}
+		for _, c := range frame {
+			if math.IsNaN(c) || math.IsInf(c, 0) {
+				t.Fatalf("frame %d holds %v", i, frame)
+			}
+		}
+	}
+
+	if _, err := NewAutoCepstrum(newLoader(map[string]string{"-cepstrum": "rasta"})); err == nil {
+		t.Fatal("expected an error for an unknown -cepstrum")
+	}
+}
+
+func TestWarpParams(t *testing.T) {
+	ac, err := NewAutoCepstrum(newLoader(map[string]string{"-warp_type": "affine", "-warp_params": "1.1 20"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ac.FilterBank().FrequencyWarp().(*frequencywarp.AffineWarp); !ok {
+		t.Fatalf("filter bank warped by %v, want an affine warp", ac.FilterBank().FrequencyWarp())
+	}
+
+	for _, params := range []map[string]string{
+		{"-warp_type": "inverse_linear", "-warp_params": "1.1"},
+		{"-warp_type": "affine", "-warp_params": "1.1"},
+		{"-warp_params": "0"},
+		{"-cepstrum": "plp", "-warp_params": "0.9"},
+	} {
+		if _, err := NewAutoCepstrum(newLoader(params)); err == nil {
+			t.Fatalf("expected an error for %v", params)
+		}
+	}
+}
+
+// TestNoiseRobustness checks where -dither and -remove_noise put their stages; filter and denoise test the stages.
+func TestNoiseRobustness(t *testing.T) {
+	ac, err := NewAutoCepstrum(newLoader(map[string]string{"-dither": "yes", "-remove_noise": "yes"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	processors := ac.DataProcessors()
+	if _, ok := processors[0].(*filter.Dither); !ok {
+		t.Fatalf("pipeline starts with %T, want a Dither", processors[0])
+	}
+	for i, processor := range processors {
+		if _, ok := processor.(*denoise.Denoise); ok {
+			if _, ok := processors[i-1].(*transform.DiscreteFourierTransform); !ok {
+				t.Fatalf("Denoise follows %T, want the DFT", processors[i-1])
+			}
+			if processors[i+1] != ac.FilterBank() {
+				t.Fatalf("Denoise precedes %T, want the filter bank", processors[i+1])
+			}
+			return
+		}
+	}
+	t.Fatalf("no Denoise in %v", processors)
+}
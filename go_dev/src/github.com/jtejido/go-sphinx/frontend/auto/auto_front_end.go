@@ -0,0 +1,251 @@
+package auto
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jtejido/go-sphinx/frontend"
+	"github.com/jtejido/go-sphinx/frontend/endpoint"
+	"github.com/jtejido/go-sphinx/frontend/feature"
+	"github.com/jtejido/go-sphinx/frontend/filter"
+	"github.com/jtejido/go-sphinx/frontend/frequencywarp"
+	"github.com/jtejido/go-sphinx/frontend/transform"
+)
+
+/**
+ * Builds the front end an acoustic model was trained with: a Resampler to the -samprate of the model, the cepstrum
+ * of AutoCepstrum, followed by the cepstral mean normalization selected by -cmn and the dynamic features selected by
+ * -feat. If the model ships a feature transform (LDA/MLLT), it is applied last, keeping -ldadim rows of it. The
+ * returned FrontEnd has no data source yet.
+ *
+ * If the loader reports the vector length of the model, it is checked against the layout of the features, so that
+ * a mismatching model fails here rather than in the middle of decoding.
+ *
+ * @param loader the loader of the acoustic model
+ * @return the front end
+ * @throws error if feat.params holds invalid values or the features do not fit the model
+ */
+func NewFrontEnd(loader Loader) (*frontend.FrontEnd, error) {
+	resampler, err := newResampler(loader)
+	if err != nil {
+		return nil, err
+	}
+	features, err := featureProcessors(loader)
+	if err != nil {
+		return nil, err
+	}
+	return frontend.NewFrontEnd(append([]frontend.DataProcessor{resampler}, features...)), nil
+}
+
+/**
+ * Builds the front end of NewFrontEnd behind a voice activity detector: the audio is cut into blocks, classified as
+ * speech or not, and only the regions marked by SpeechStartSignal and SpeechEndSignal reach the feature extraction.
+ * One stream can thus yield several utterances.
+ *
+ * @param loader the loader of the acoustic model
+ * @return the front end
+ * @throws error if feat.params holds invalid values or the features do not fit the model
+ */
+func NewLiveFrontEnd(loader Loader) (*frontend.FrontEnd, error) {
+	resampler, err := newResampler(loader)
+	if err != nil {
+		return nil, err
+	}
+	features, err := featureProcessors(loader)
+	if err != nil {
+		return nil, err
+	}
+	frontEndList := []frontend.DataProcessor{
+		resampler,
+		frontend.NewDefaultDataBlocker(),
+		endpoint.NewDefaultSpeechClassifier(),
+		endpoint.NewDefaultSpeechMarker(),
+		endpoint.NewNonSpeechDataFilter(),
+	}
+	return frontend.NewFrontEnd(append(frontEndList, features...)), nil
+}
+
+/**
+ * Warps the mel filter bank of a front end built here for vocal tract length normalization, with a piecewise linear
+ * warp of the given factor that overrides the warp of feat.params. The following frames are computed with the new
+ * warp.
+ *
+ * @param frontEnd   the front end
+ * @param warpFactor the warp factor, below 1 for speakers with a shorter vocal tract, 1 for no warping
+ * @throws error if the factor is not positive or the front end has no mel cepstrum
+ */
+func SetWarpFactor(frontEnd *frontend.FrontEnd, warpFactor float64) error {
+	warp, err := frequencywarp.NewPiecewiseLinearWarp(warpFactor, 0)
+	if err != nil {
+		return err
+	}
+	for _, dataProcessor := range frontEnd.Elements() {
+		if cepstrum, ok := dataProcessor.(*AutoCepstrum); ok {
+			return cepstrum.SetFrequencyWarp(warp)
+		}
+	}
+	return fmt.Errorf("front end has no cepstrum to warp")
+}
+
+// newResampler converts the input to the -samprate of the model; input at that rate passes through untouched.
+func newResampler(loader Loader) (*filter.Resampler, error) {
+	sampleRate, err := NewFeatParams(loader.Properties()).SampleRate()
+	if err != nil {
+		return nil, err
+	}
+	return filter.NewDefaultResampler(sampleRate), nil
+}
+
+func featureProcessors(loader Loader) ([]frontend.DataProcessor, error) {
+	cepstrum, err := NewAutoCepstrum(loader)
+	if err != nil {
+		return nil, err
+	}
+	features, err := cepstrumProcessors(loader, cepstrum.Params())
+	if err != nil {
+		return nil, err
+	}
+	return append([]frontend.DataProcessor{cepstrum}, features...), nil
+}
+
+/**
+ * Builds the front end that decodes precomputed cepstra, read from Sphinx feature files: the cepstral mean
+ * normalization, dynamic features and feature transform of NewFrontEnd, without any of its signal processing. The
+ * returned FrontEnd has no data source yet; a FeatureFileDataSource for -ncep cepstra is meant to feed it.
+ *
+ * @param loader the loader of the acoustic model
+ * @return the front end
+ * @throws error if feat.params holds invalid values or the features do not fit the model
+ */
+func NewFeatureFileFrontEnd(loader Loader) (*frontend.FrontEnd, error) {
+	features, err := cepstrumProcessors(loader, NewFeatParams(loader.Properties()))
+	if err != nil {
+		return nil, err
+	}
+	return frontend.NewFrontEnd(features), nil
+}
+
+// cepstrumProcessors builds the processors that turn cepstra into the features of the model.
+func cepstrumProcessors(loader Loader, params *FeatParams) ([]frontend.DataProcessor, error) {
+	var frontEndList []frontend.DataProcessor
+
+	cmn, err := NewCMN(params)
+	if err != nil {
+		return nil, err
+	}
+	if cmn != nil {
+		frontEndList = append(frontEndList, cmn)
+	}
+
+	extractor, err := feature.NewDeltasFeatureExtractor(params.String("-feat", feature.FEATURE_1S_C_D_DD))
+	if err != nil {
+		return nil, fmt.Errorf("feat.params: %v", err)
+	}
+	frontEndList = append(frontEndList, extractor)
+
+	cepstrumSize, err := params.Int("-ncep", transform.DEFAULT_CEPSTRUM_LENGTH)
+	if err != nil {
+		return nil, err
+	}
+	vectorLength := extractor.VectorLength(cepstrumSize)
+
+	featureTransform, err := newFeatureTransform(loader, params, vectorLength)
+	if err != nil {
+		return nil, err
+	}
+	if featureTransform != nil {
+		frontEndList = append(frontEndList, featureTransform)
+		vectorLength = []int{featureTransform.Rows()}
+	}
+
+	if err := checkVectorLength(loader, vectorLength); err != nil {
+		return nil, err
+	}
+
+	return frontEndList, nil
+}
+
+/**
+ * Selects the cepstral mean normalization from -cmn: "live" (or "prior") gives a LiveCMN starting from -cmninit,
+ * "current" (or "batch") a BatchCMN, and "none" no normalization at all, in which case nil is returned.
+ *
+ * @param params the feat.params of the acoustic model
+ * @return the CMN processor, or nil
+ * @throws error if -cmn or -cmninit are invalid
+ */
+func NewCMN(params *FeatParams) (frontend.DataProcessor, error) {
+	switch cmn := params.String("-cmn", "live"); cmn {
+	case "none":
+		return nil, nil
+	case "current", "batch":
+		return feature.NewBatchCMN(), nil
+	case "live", "prior":
+		initialMean := []float64{feature.DEFAULT_INITIAL_MEAN}
+		if params.Has("-cmninit") {
+			var err error
+			if initialMean, err = parseMean(params.String("-cmninit", "")); err != nil {
+				return nil, err
+			}
+		}
+		return feature.NewLiveCMN(initialMean, feature.DEFAULT_CMN_WINDOW, feature.DEFAULT_CMN_SHIFT_WINDOW), nil
+	default:
+		return nil, fmt.Errorf("feat.params: unsupported -cmn %q", cmn)
+	}
+}
+
+// newFeatureTransform returns the transform of the model, or nil if the loader has none.
+func newFeatureTransform(loader Loader, params *FeatParams, vectorLength []int) (*feature.FeatureTransform, error) {
+	model, ok := loader.(interface{ TransformMatrix() [][]float32 })
+	if !ok || model.TransformMatrix() == nil {
+		return nil, nil
+	}
+
+	rows, err := params.Int("-ldadim", 0)
+	if err != nil {
+		return nil, err
+	}
+	featureTransform, err := feature.NewFeatureTransform(model.TransformMatrix(), rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(vectorLength) != 1 || vectorLength[0] != featureTransform.Values() {
+		return nil, fmt.Errorf("feature transform applies to features of length %d, front end produces %v",
+			featureTransform.Values(), vectorLength)
+	}
+	return featureTransform, nil
+}
+
+// checkVectorLength compares the stream layout of the features with the one of the model, if the loader knows it.
+func checkVectorLength(loader Loader, vectorLength []int) error {
+	model, ok := loader.(interface{ VectorLength() []int })
+	if !ok || model.VectorLength() == nil {
+		return nil
+	}
+	expected := model.VectorLength()
+	if len(expected) != len(vectorLength) {
+		return fmt.Errorf("front end produces %d streams %v, acoustic model expects %d streams %v",
+			len(vectorLength), vectorLength, len(expected), expected)
+	}
+	for i := range expected {
+		if expected[i] != vectorLength[i] {
+			return fmt.Errorf("front end produces features of length %v, acoustic model expects %v", vectorLength, expected)
+		}
+	}
+	return nil
+}
+
+// parseMean reads a comma separated cepstral mean such as "40,3,-1".
+func parseMean(value string) ([]float64, error) {
+	fields := strings.Split(value, ",")
+	mean := make([]float64, len(fields))
+	for i, field := range fields {
+		f, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+		if err != nil {
+			return nil, fmt.Errorf("feat.params: -cmninit: %v", err)
+		}
+		mean[i] = f
+	}
+	return mean, nil
+}
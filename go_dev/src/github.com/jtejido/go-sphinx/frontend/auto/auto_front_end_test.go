@@ -0,0 +1,310 @@
+package auto
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jtejido/go-sphinx/frontend"
+	feutil "github.com/jtejido/go-sphinx/frontend/util"
+	"github.com/jtejido/go-sphinx/util"
+)
+
+type modelLoader struct {
+	propertiesLoader
+	vectorLength []int
+}
+
+func (l *modelLoader) VectorLength() []int {
+	return l.vectorLength
+}
+
+func TestFrontEndFeatures(t *testing.T) {
+	_, pcm := sineStream(16000, 16000)
+	loader := &modelLoader{*newLoader(map[string]string{"-cmn": "current"}), []int{39}}
+	fe, err := NewFrontEnd(loader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sds := feutil.NewDefaultStreamDataSource()
+	sds.SetInputStream(bytes.NewReader(pcm), util.INFINITE)
+	fe.SetDataSource(sds)
+
+	frames := 0
+	for {
+		data, err := fe.GetData()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data == nil {
+			break
+		}
+		if fd, ok := data.(*frontend.FloatData); ok {
+			if len(fd.Values()) != 39 {
+				t.Fatalf("got a feature of length %d, want 39", len(fd.Values()))
+			}
+			frames++
+		}
+	}
+	if frames != 98 {
+		t.Fatalf("got %d features, want 98", frames)
+	}
+
+	loader = &modelLoader{*newLoader(map[string]string{"-feat": "s2_4x"}), []int{39}}
+	if _, err := NewFrontEnd(loader); err == nil {
+		t.Fatal("expected s2_4x features to mismatch a single stream model")
+	}
+}
+
+type ldaLoader struct {
+	modelLoader
+	transform [][]float32
+}
+
+func (l *ldaLoader) TransformMatrix() [][]float32 {
+	return l.transform
+}
+
+// TestFrontEndFeatureTransform runs a model shipping a feature transform through NewFrontEnd, the front end the
+// recognizer scores: its features are the ones of the same model without transform, multiplied by the first -ldadim
+// rows of the matrix.
+func TestFrontEndFeatureTransform(t *testing.T) {
+	transform := make([][]float32, 39)
+	for i := range transform {
+		transform[i] = make([]float32, 39)
+		transform[i][i] = 2
+		transform[i][(i+1)%39] = -0.5
+	}
+	plain := loadFeatParams(t, filepath.Join("testdata", "feat.params"))
+	model := loadFeatParams(t, filepath.Join("testdata", "feat.params"))
+	model.props.SetProperty("-ldadim", "32")
+
+	expected := wavFeatures(t, &modelLoader{*plain, []int{39}})
+	actual := wavFeatures(t, &ldaLoader{modelLoader{*model, []int{32}}, transform})
+	if len(actual) != len(expected) || len(actual) == 0 {
+		t.Fatalf("got %d features, want %d", len(actual), len(expected))
+	}
+	for f := range actual {
+		if len(actual[f]) != 32 {
+			t.Fatalf("got a feature of length %d, want 32", len(actual[f]))
+		}
+		for i, value := range actual[f] {
+			var want float64
+			for j, x := range expected[f] {
+				want += float64(transform[i][j]) * float64(x)
+			}
+			if math.Abs(float64(value)-want) > 1e-4*math.Max(1, math.Abs(want)) {
+				t.Fatalf("frame %d: feature[%d] = %v, want %v", f, i, value, want)
+			}
+		}
+	}
+}
+
+// wavFeatures reads testdata/vowel.wav through the front end NewFrontEnd builds for the model.
+func wavFeatures(t *testing.T, loader Loader) [][]float32 {
+	fe, err := NewFrontEnd(loader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return wavFrontEndFeatures(t, fe)
+}
+
+// wavFrontEndFeatures reads testdata/vowel.wav through the given front end.
+func wavFrontEndFeatures(t *testing.T, fe *frontend.FrontEnd) [][]float32 {
+	wav, err := os.Open(filepath.Join("testdata", "vowel.wav"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wav.Close()
+	sds := feutil.NewDefaultStreamDataSource()
+	if err := sds.SetAudioStream(wav, util.INFINITE); err != nil {
+		t.Fatal(err)
+	}
+	fe.SetDataSource(sds)
+	return features(t, fe)
+}
+
+func TestLiveFrontEndUtterances(t *testing.T) {
+	// two tone bursts separated by low noise
+	var b bytes.Buffer
+	for i := 0; i < 16000*3; i++ {
+		s := 20 * math.Sin(float64(i)*1.3)
+		if sec := float64(i) / 16000; (sec > 0.5 && sec < 1.0) || (sec > 2.0 && sec < 2.5) {
+			s += 8000 * math.Sin(2*math.Pi*300*sec)
+		}
+		binary.Write(&b, binary.LittleEndian, int16(s))
+	}
+
+	fe, err := NewLiveFrontEnd(newLoader(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sds := feutil.NewDefaultStreamDataSource()
+	sds.SetInputStream(bytes.NewReader(b.Bytes()), util.INFINITE)
+	fe.SetDataSource(sds)
+
+	var starts, ends, frames int
+	for {
+		data, err := fe.GetData()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data == nil {
+			break
+		}
+		switch data.(type) {
+		case *frontend.SpeechStartSignal:
+			starts++
+		case *frontend.SpeechEndSignal:
+			ends++
+		case *frontend.FloatData:
+			frames++
+		}
+	}
+	if starts != 2 || ends != 2 {
+		t.Fatalf("got %d speech starts and %d ends, want 2 of each", starts, ends)
+	}
+	// each utterance holds the burst, the leader and the end silence, but not the second of silence between
+	if frames < 100 || frames > 200 {
+		t.Fatalf("got %d frames of speech", frames)
+	}
+}
+
+func TestFrontEndResamples(t *testing.T) {
+	_, pcm := sineStream(8000, 8000)
+	fe, err := NewFrontEnd(newLoader(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sds, err := feutil.NewStreamDataSource(8000, feutil.DEFAULT_BYTES_PER_READ, 16, false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sds.SetInputStream(bytes.NewReader(pcm), util.INFINITE)
+	fe.SetDataSource(sds)
+
+	frames := 0
+	for {
+		data, err := fe.GetData()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data == nil {
+			break
+		}
+		if _, ok := data.(*frontend.FloatData); ok {
+			frames++
+		}
+	}
+	// one second of 8kHz audio decodes like one second of 16kHz audio
+	if frames != 98 {
+		t.Fatalf("got %d features, want 98", frames)
+	}
+}
+
+func features(t *testing.T, fe *frontend.FrontEnd) [][]float32 {
+	var frames [][]float32
+	for {
+		data, err := fe.GetData()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data == nil {
+			return frames
+		}
+		if fd, ok := data.(*frontend.FloatData); ok {
+			frames = append(frames, fd.Values())
+		}
+	}
+}
+
+// TestFeatureFileFrontEnd checks that dumped cepstra decode to the features computed from the audio.
+func TestFeatureFileFrontEnd(t *testing.T) {
+	loader := newLoader(map[string]string{"-cmn": "current"})
+	_, pcm := sineStream(16000, 8000)
+
+	// compute the features from audio, dumping the cepstra on the way
+	cepstrum, err := NewAutoCepstrum(loader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mfc bytes.Buffer
+	sds := feutil.NewDefaultStreamDataSource()
+	sds.SetInputStream(bytes.NewReader(pcm), util.INFINITE)
+	frontEndList := []frontend.DataProcessor{sds, cepstrum, feutil.NewFeatureFileWriter(&mfc, true)}
+	post, err := cepstrumProcessors(loader, cepstrum.Params())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromAudio := features(t, frontend.NewFrontEnd(append(frontEndList, post...)))
+
+	// decode the dumped cepstra
+	ffds := feutil.NewDefaultFeatureFileDataSource()
+	if err := ffds.SetInputStream(&mfc); err != nil {
+		t.Fatal(err)
+	}
+	fe, err := NewFeatureFileFrontEnd(loader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fe.SetDataSource(ffds)
+	fromFile := features(t, fe)
+
+	if len(fromFile) != len(fromAudio) {
+		t.Fatalf("got %d features from the file, want %d", len(fromFile), len(fromAudio))
+	}
+	for i := range fromFile {
+		for j := range fromFile[i] {
+			if math.Abs(float64(fromFile[i][j]-fromAudio[i][j])) > 1e-4 {
+				t.Fatalf("feature %d differs at %d: %v, want %v", i, j, fromFile[i][j], fromAudio[i][j])
+			}
+		}
+	}
+}
+
+func TestSetWarpFactor(t *testing.T) {
+	plain := loadFeatParams(t, filepath.Join("testdata", "feat.params"))
+	warped := loadFeatParams(t, filepath.Join("testdata", "feat.params"))
+	warped.props.SetProperty("-warp_params", "0.9")
+	expected := wavFeatures(t, warped)
+	unwarped := wavFeatures(t, plain)
+
+	fe, err := NewFrontEnd(plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SetWarpFactor(fe, 0.9); err != nil {
+		t.Fatal(err)
+	}
+	actual := wavFrontEndFeatures(t, fe)
+
+	if len(actual) != len(expected) {
+		t.Fatalf("got %d features, want %d", len(actual), len(expected))
+	}
+	var difference float64
+	for f := range actual {
+		for i := range actual[f] {
+			if actual[f][i] != expected[f][i] {
+				t.Fatalf("frame %d: feature[%d] = %v, want %v as warped by feat.params", f, i, actual[f][i], expected[f][i])
+			}
+			difference += math.Abs(float64(actual[f][i] - unwarped[f][i]))
+		}
+	}
+	if difference == 0 {
+		t.Fatal("warping left the features unchanged")
+	}
+
+	if err := SetWarpFactor(fe, 0); err == nil {
+		t.Fatal("expected an error for a zero warp factor")
+	}
+	plp, err := NewFrontEnd(newLoader(map[string]string{"-cepstrum": "plp"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SetWarpFactor(plp, 0.9); err == nil {
+		t.Fatal("expected an error for a PLP front end")
+	}
+}
@@ -0,0 +1,126 @@
+package auto
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/jtejido/go-sphinx/util"
+)
+
+/**
+ * Gives typed access to the front end parameters an acoustic model was trained with, as found in the feat.params
+ * file at the root of the model. Missing parameters fall back to the defaults of the sphinxbase front end.
+ */
+type FeatParams struct {
+	props *util.Properties
+}
+
+/**
+ * Wraps the given model properties. A nil properties object yields only defaults.
+ *
+ * @param props the model properties
+ */
+func NewFeatParams(props *util.Properties) *FeatParams {
+	if props == nil {
+		props = util.NewProperties()
+	}
+	return &FeatParams{props: props}
+}
+
+/**
+ * @param key the parameter name, including its leading dash
+ * @return whether the model sets the parameter
+ */
+func (p *FeatParams) Has(key string) bool {
+	_, ok := p.props.GetProperty(key)
+	return ok
+}
+
+/**
+ * @param key          the parameter name, including its leading dash
+ * @param defaultValue the value used if the parameter is missing
+ * @return the parameter value
+ */
+func (p *FeatParams) String(key, defaultValue string) string {
+	if value, ok := p.props.GetProperty(key); ok {
+		return value
+	}
+	return defaultValue
+}
+
+/**
+ * @param key          the parameter name, including its leading dash
+ * @param defaultValue the value used if the parameter is missing
+ * @return the parameter value
+ * @throws error if the parameter is not a number
+ */
+func (p *FeatParams) Float(key string, defaultValue float64) (float64, error) {
+	value, ok := p.props.GetProperty(key)
+	if !ok {
+		return defaultValue, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("feat.params: %s: %v", key, err)
+	}
+	return f, nil
+}
+
+/**
+ * @param key          the parameter name, including its leading dash
+ * @param defaultValue the value used if the parameter is missing
+ * @return the parameter value
+ * @throws error if the parameter is not an integer
+ */
+func (p *FeatParams) Int(key string, defaultValue int) (int, error) {
+	value, ok := p.props.GetProperty(key)
+	if !ok {
+		return defaultValue, nil
+	}
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("feat.params: %s: %v", key, err)
+	}
+	return i, nil
+}
+
+/**
+ * Reads -samprate. Sphinx writes it as a float, such as 16000.0, but the front end works on whole sample rates.
+ *
+ * @return the sample rate of the model, in Hz
+ * @throws error if -samprate is not a positive whole number
+ */
+func (p *FeatParams) SampleRate() (int, error) {
+	sampleRate, err := p.Float("-samprate", DEFAULT_SAMPLE_RATE)
+	if err != nil {
+		return 0, err
+	}
+	if sampleRate <= 0 || sampleRate != math.Trunc(sampleRate) || sampleRate > math.MaxInt32 {
+		return 0, fmt.Errorf("feat.params: -samprate must be a positive whole number, got %v", sampleRate)
+	}
+	return int(sampleRate), nil
+}
+
+/**
+ * Reads a yes/no flag.
+ *
+ * @param key          the parameter name, including its leading dash
+ * @param defaultValue the value used if the parameter is missing
+ * @return the parameter value
+ * @throws error if the parameter is not a boolean
+ */
+func (p *FeatParams) Bool(key string, defaultValue bool) (bool, error) {
+	value, ok := p.props.GetProperty(key)
+	if !ok {
+		return defaultValue, nil
+	}
+	switch strings.ToLower(value) {
+	case "yes", "true", "1":
+		return true, nil
+	case "no", "false", "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("feat.params: %s: invalid flag %q", key, value)
+}
@@ -0,0 +1,149 @@
+#!/usr/bin/env python3
+"""Generates vowel.wav and its reference cepstra vowel.mfc.
+
+vowel.wav is half a second of a synthetic vowel (a gliding pitch through three formants, with a little noise) at
+16kHz, 16-bit mono. vowel.mfc holds its cepstra for the parameters of feat.params in this directory, as computed by
+the floating point front end of sphinxbase (fe_sigproc.c), ported below step by step. The port keeps the float32
+quantities of sphinxbase (filter coefficients, DCT table, pre-emphasis factor) in float32 so that it rounds the same
+way.
+
+sphinx_fe was not at hand when the file was made. To check the reference against a real sphinxbase, regenerate it
+with
+
+    sphinx_fe -i vowel.wav -mswav yes -o vowel.mfc -remove_silence no -dither no \
+        -lowerf 130 -upperf 6800 -nfilt 25 -transform dct -lifter 22
+
+sphinx_fe zero pads the samples left after the last whole frame into one more frame, and so does the port.
+"""
+
+import math
+import struct
+
+SAMPLE_RATE = 16000
+FRAME_SHIFT = 160        # -frate 100
+FRAME_SIZE = 410         # -wlen 0.025625
+FFT_SIZE = 512
+ALPHA = 0.97
+LOWERF, UPPERF, NFILT = 130.0, 6800.0, 25
+NCEP, LIFTER = 13, 22
+
+
+def f32(x):
+    return struct.unpack('f', struct.pack('f', x))[0]
+
+
+def vowel():
+    samples = []
+    phase = 0.0
+    seed = 12345
+    count = SAMPLE_RATE // 2
+    for n in range(count):
+        t = n / SAMPLE_RATE
+        f0 = 120 + 60 * t / 0.5
+        phase += 2 * math.pi * f0 / SAMPLE_RATE
+        value = 0.0
+        for h in range(1, 40):
+            freq = h * f0
+            if freq > 7500:
+                break
+            gain = sum(a / (1 + ((freq - f) / bw) ** 2) for f, bw, a in ((700, 110, 1.0), (1220, 120, 0.5),
+                                                                           (2600, 160, 0.25)))
+            value += gain * math.sin(h * phase)
+        envelope = min(1.0, n / 800, (count - n) / 800)
+        seed = (seed * 1103515245 + 12345) % (1 << 31)
+        noise = (seed / (1 << 31) - 0.5) * 100
+        samples.append(max(-32768, min(32767, int(round(6000 * envelope * value + noise)))))
+    return samples
+
+
+def write_wav(path, samples):
+    data = struct.pack('<%dh' % len(samples), *samples)
+    with open(path, 'wb') as f:
+        f.write(b'RIFF' + struct.pack('<I', 36 + len(data)) + b'WAVEfmt ')
+        f.write(struct.pack('<IHHIIHH', 16, 1, 1, SAMPLE_RATE, SAMPLE_RATE * 2, 2, 16))
+        f.write(b'data' + struct.pack('<I', len(data)) + data)
+
+
+def mel(x):
+    return f32(2595.0 * math.log10(1.0 + x / 700.0))
+
+
+def melinv(x):
+    return f32(700.0 * (math.pow(10.0, x / 2595.0) - 1.0))
+
+
+def mel_filters():
+    """fe_build_melfilters, with -round_filters yes and -unit_area yes."""
+    melmax, melmin = mel(UPPERF), mel(LOWERF)
+    dmelbw = f32((melmax - melmin) / (NFILT + 1))
+    fftfreq = f32(SAMPLE_RATE / FFT_SIZE)
+    filters = []
+    for i in range(NFILT):
+        freqs = [f32(int(melinv(f32(dmelbw * (i + j) + melmin)) / fftfreq + 0.5) * fftfreq) for j in range(3)]
+        start, coeffs = -1, []
+        for j in range(FFT_SIZE // 2 + 1):
+            hz = f32(j * fftfreq)
+            if hz < freqs[0]:
+                continue
+            if hz > freqs[2] or j == FFT_SIZE // 2:
+                break
+            if start == -1:
+                start = j
+            loslope = f32((hz - freqs[0]) / (freqs[1] - freqs[0]))
+            hislope = f32((freqs[2] - hz) / (freqs[2] - freqs[1]))
+            loslope = f32(loslope * f32(2 / (freqs[2] - freqs[0])))
+            hislope = f32(hislope * f32(2 / (freqs[2] - freqs[0])))
+            coeffs.append(min(loslope, hislope))
+        filters.append((start, coeffs))
+    return filters
+
+
+def cepstrum(frame, filters, hamming, cosine, lifter):
+    # fe_hamming_window, then fe_spec_magnitude over a zero padded FFT
+    frame = [x * w for x, w in zip(frame, hamming)] + [0.0] * (FFT_SIZE - FRAME_SIZE)
+    spec = []
+    for k in range(FFT_SIZE // 2 + 1):
+        re = im = 0.0
+        for n in range(FRAME_SIZE):
+            re += frame[n] * math.cos(2 * math.pi * k * n / FFT_SIZE)
+            im -= frame[n] * math.sin(2 * math.pi * k * n / FFT_SIZE)
+        spec.append(re * re + im * im)
+    # fe_mel_spec, fe_mel_cep
+    logspec = [math.log(sum(spec[start + i] * c for i, c in enumerate(coeffs)) + 1e-4) for start, coeffs in filters]
+    cep = [sum(logspec) * f32(math.sqrt(1.0 / NFILT))]
+    for i in range(1, NCEP):
+        cep.append(sum(v * cosine[i][j] for j, v in enumerate(logspec)) * f32(math.sqrt(2.0 / NFILT)))
+    # fe_lifter
+    return [f32(c * l) for c, l in zip(cep, lifter)]
+
+
+def mfc(samples):
+    filters = mel_filters()
+    hamming = [0.54 - 0.46 * math.cos(2 * math.pi * i / (FRAME_SIZE - 1.0)) for i in range(FRAME_SIZE)]
+    cosine = [[f32(math.cos(math.pi * i * (j + 0.5) / NFILT)) for j in range(NFILT)] for i in range(NCEP)]
+    lifter = [f32(1 + LIFTER / 2 * math.sin(i * math.pi / LIFTER)) for i in range(NCEP)]
+    alpha = f32(ALPHA)
+
+    frames, prior, start = [], 0.0, 0
+    while start < len(samples):
+        # fe_process_frames reads whole frames; fe_end_utt zero pads the leftover samples into a last frame
+        chunk = samples[start:start + FRAME_SIZE]
+        emphasized = [x - alpha * (chunk[i - 1] if i else prior) for i, x in enumerate(chunk)]
+        prior = chunk[FRAME_SHIFT - 1] if len(chunk) >= FRAME_SHIFT else chunk[-1]
+        frames.append(cepstrum(emphasized + [0.0] * (FRAME_SIZE - len(chunk)), filters, hamming, cosine, lifter))
+        if len(chunk) < FRAME_SIZE:
+            break
+        start += FRAME_SHIFT
+    return frames
+
+
+def write_mfc(path, frames):
+    values = [c for frame in frames for c in frame]
+    with open(path, 'wb') as f:
+        f.write(struct.pack('<i', len(values)) + struct.pack('<%df' % len(values), *values))
+
+
+if __name__ == '__main__':
+    samples = vowel()
+    write_wav('vowel.wav', samples)
+    write_mfc('vowel.mfc', mfc(samples))
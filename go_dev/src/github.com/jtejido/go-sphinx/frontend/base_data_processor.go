@@ -0,0 +1,30 @@
+package frontend
+
+/**
+ * An abstract DataProcessor implementing elements common to all concrete DataProcessors, such as name and predecessor.
+ * Concrete processors embed it and provide their own GetData.
+ */
+type BaseDataProcessor struct {
+	predecessor DataProcessor
+}
+
+/** Initializes this DataProcessor. The base implementation does nothing. */
+func (p *BaseDataProcessor) Initialize() {}
+
+/**
+ * Returns the predecessor DataProcessor.
+ *
+ * @return the predecessor
+ */
+func (p *BaseDataProcessor) Predecessor() DataProcessor {
+	return p.predecessor
+}
+
+/**
+ * Sets the predecessor DataProcessor. This method allows dynamic reconfiguration of the front end.
+ *
+ * @param predecessor the new predecessor of this DataProcessor
+ */
+func (p *BaseDataProcessor) SetPredecessor(predecessor DataProcessor) {
+	p.predecessor = predecessor
+}
@@ -0,0 +1,117 @@
+package frontend
+
+import (
+	"math"
+)
+
+const (
+	// The default block size, in milliseconds.
+	DEFAULT_BLOCK_SIZE_MS = 10.0
+)
+
+/**
+ * A DataProcessor which wraps incoming DoubleData-objects into equally size blocks of defined length. Voice activity
+ * detection works on such blocks, whatever the size of the reads of the data source. The samples left over at a
+ * Signal or at the end of the stream are emitted as a shorter block before the Signal.
+ */
+type DataBlocker struct {
+	BaseDataProcessor
+	blockSizeMs      float64
+	blockSizeSamples int
+	sampleRate       int
+	buffer           []float64
+	firstSample      int64
+	pendingSignal    Data
+	streamEnded      bool
+}
+
+/** Constructs a DataBlocker with the default block size. */
+func NewDefaultDataBlocker() *DataBlocker {
+	return NewDataBlocker(DEFAULT_BLOCK_SIZE_MS)
+}
+
+/**
+ * Constructs a DataBlocker.
+ *
+ * @param blockSizeMs the size of the blocks, in milliseconds
+ */
+func NewDataBlocker(blockSizeMs float64) *DataBlocker {
+	return &DataBlocker{blockSizeMs: blockSizeMs}
+}
+
+func (db *DataBlocker) Initialize() {
+	db.buffer = nil
+	db.pendingSignal = nil
+	db.streamEnded = false
+}
+
+/** @return the size of the blocks, in milliseconds */
+func (db *DataBlocker) BlockSizeMs() float64 {
+	return db.blockSizeMs
+}
+
+func (db *DataBlocker) setSampleRate(sampleRate int) {
+	db.sampleRate = sampleRate
+	db.blockSizeSamples = int(math.Round(float64(sampleRate) * db.blockSizeMs / 1000))
+	if db.blockSizeSamples < 1 {
+		db.blockSizeSamples = 1
+	}
+}
+
+func (db *DataBlocker) GetData() (Data, error) {
+	if len(db.buffer) == 0 {
+		if db.pendingSignal != nil {
+			signal := db.pendingSignal
+			db.pendingSignal = nil
+			return signal, nil
+		}
+		if db.streamEnded {
+			db.streamEnded = false
+			return nil, nil
+		}
+	}
+
+	for db.pendingSignal == nil && !db.streamEnded && (db.blockSizeSamples == 0 || len(db.buffer) < db.blockSizeSamples) {
+		data, err := db.Predecessor().GetData()
+		if err != nil {
+			return nil, err
+		}
+
+		switch d := data.(type) {
+		case *DoubleData:
+			if d.SampleRate() != db.sampleRate {
+				db.setSampleRate(d.SampleRate())
+			}
+			if len(db.buffer) == 0 {
+				db.firstSample = d.FirstSampleNumber()
+			}
+			db.buffer = append(db.buffer, d.Values()...)
+		case nil:
+			if len(db.buffer) == 0 {
+				return nil, nil
+			}
+			db.streamEnded = true
+		default:
+			if start, ok := d.(*DataStartSignal); ok {
+				db.setSampleRate(start.SampleRate())
+			}
+			if len(db.buffer) == 0 {
+				return data, nil
+			}
+			db.pendingSignal = data
+		}
+	}
+
+	// now we are ready to cut a block off the buffered samples
+	size := len(db.buffer)
+	if db.blockSizeSamples > 0 && size > db.blockSizeSamples {
+		size = db.blockSizeSamples
+	}
+	block := make([]float64, size)
+	copy(block, db.buffer)
+	db.buffer = append(db.buffer[:0], db.buffer[size:]...)
+
+	output := NewDoubleDataWithSampleRate(block, db.sampleRate, db.firstSample)
+	db.firstSample += int64(size)
+	return output, nil
+}
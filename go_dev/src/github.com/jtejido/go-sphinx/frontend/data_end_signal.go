@@ -0,0 +1,37 @@
+package frontend
+
+import "fmt"
+
+/**
+ * A signal that indicates the end of data.
+ *
+ * @see DataStartSignal
+ */
+type DataEndSignal struct {
+	signal
+	duration int64
+}
+
+/**
+ * Constructs a DataEndSignal with the given creation time.
+ *
+ * @param duration the duration of the entire data stream in milliseconds
+ * @param time     the creation time of the DataEndSignal
+ */
+func NewDataEndSignal(duration, time int64) *DataEndSignal {
+	return &DataEndSignal{
+		signal:   signal{time: time},
+		duration: duration,
+	}
+}
+
+/**
+ * @return the duration of the entire data stream in milliseconds
+ */
+func (s *DataEndSignal) Duration() int64 {
+	return s.duration
+}
+
+func (s *DataEndSignal) String() string {
+	return fmt.Sprintf("DataEndSignal: creation time: %d, duration: %dms", s.time, s.duration)
+}
@@ -0,0 +1,41 @@
+package frontend
+
+/**
+ * A processor that performs a signal processing function.
+ *
+ * Since a DataProcessor usually belongs to a particular front end pipeline, you can name the pipeline it belongs to
+ * in the configuration. A DataProcessor obtains its input from its predecessor by calling GetData on it, processes
+ * the returned Data and hands it over to whoever calls its own GetData.
+ *
+ * @see FrontEnd
+ */
+type DataProcessor interface {
+
+	/**
+	 * Initializes this DataProcessor. This is typically called after the DataProcessor has been configured and
+	 * chained to its predecessor.
+	 */
+	Initialize()
+
+	/**
+	 * Returns the processed Data output.
+	 *
+	 * @return an Data object that has been processed by this DataProcessor
+	 * @throws error if a data processor error occurs
+	 */
+	GetData() (Data, error)
+
+	/**
+	 * Returns the predecessor DataProcessor.
+	 *
+	 * @return the predecessor
+	 */
+	Predecessor() DataProcessor
+
+	/**
+	 * Sets the predecessor DataProcessor. This method allows dynamic reconfiguration of the front end.
+	 *
+	 * @param predecessor the new predecessor of this DataProcessor
+	 */
+	SetPredecessor(predecessor DataProcessor)
+}
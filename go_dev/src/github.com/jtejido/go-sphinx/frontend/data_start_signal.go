@@ -0,0 +1,37 @@
+package frontend
+
+import "fmt"
+
+/**
+ * A signal that indicates the start of data.
+ *
+ * @see DataEndSignal
+ */
+type DataStartSignal struct {
+	signal
+	sampleRate int
+}
+
+/**
+ * Constructs a DataStartSignal.
+ *
+ * @param sampleRate The sampling rate of the started data stream.
+ * @param time       the time this DataStartSignal is created
+ */
+func NewDataStartSignal(sampleRate int, time int64) *DataStartSignal {
+	return &DataStartSignal{
+		signal:     signal{time: time},
+		sampleRate: sampleRate,
+	}
+}
+
+/**
+ * @return the sampling rate of the started data stream.
+ */
+func (s *DataStartSignal) SampleRate() int {
+	return s.sampleRate
+}
+
+func (s *DataStartSignal) String() string {
+	return fmt.Sprintf("DataStartSignal: creation time: %d", s.time)
+}
@@ -0,0 +1,224 @@
+package denoise
+
+import (
+	"math"
+
+	"github.com/jtejido/go-sphinx/frontend"
+)
+
+const (
+	// The default smoothing factor of the power.
+	DEFAULT_LAMBDA_POWER = 0.7
+
+	// The default adaptation factor of the noise estimate when the power rises above it.
+	DEFAULT_LAMBDA_A = 0.995
+
+	// The default adaptation factor of the noise estimate when the power falls below it.
+	DEFAULT_LAMBDA_B = 0.97
+
+	// The default decay of the temporal masking peaks.
+	DEFAULT_LAMBDA_T = 0.85
+
+	// The default level of the temporal masking, relative to the peak.
+	DEFAULT_MU_T = 0.2
+
+	// The default maximum gain, and inverse of the minimum gain.
+	DEFAULT_MAX_GAIN = 20.0
+
+	// The default half width of the gain smoothing window, in spectrum bins.
+	DEFAULT_SMOOTH_WINDOW = 4
+)
+
+/**
+ * Removes quasi-stationary noise, such as fans or air conditioning, from a power spectrum by spectral subtraction. It
+ * is meant to run between the DiscreteFourierTransform and the filterbank, but works on any power spectrum.
+ *
+ * The noise is tracked per bin with minimum statistics: the lower envelope of the smoothed power, which follows the
+ * power quickly when it falls (lambdaB) and slowly when it rises (lambdaA), so that speech barely lifts the estimate.
+ * The noise is subtracted from the smoothed power, the result is floored by its own lower envelope to avoid musical
+ * noise, and temporal masking attenuates the decay after strong onsets. The ratio of the cleaned to the smoothed
+ * power gives a gain per bin, bounded by maxGain, which is smoothed across neighboring bins and applied to the input
+ * spectrum.
+ *
+ * The noise statistics are initialized from the first spectrum of each stream, which should be noise only.
+ */
+type Denoise struct {
+	frontend.BaseDataProcessor
+	lambdaPower  float64
+	lambdaA      float64
+	lambdaB      float64
+	lambdaT      float64
+	muT          float64
+	maxGain      float64
+	smoothWindow int
+
+	power, noise, floor, peak []float64
+}
+
+/** Constructs a Denoise with the default parameters. */
+func NewDefaultDenoise() *Denoise {
+	return NewDenoise(DEFAULT_LAMBDA_POWER, DEFAULT_LAMBDA_A, DEFAULT_LAMBDA_B, DEFAULT_LAMBDA_T, DEFAULT_MU_T,
+		DEFAULT_MAX_GAIN, DEFAULT_SMOOTH_WINDOW)
+}
+
+/**
+ * Constructs a Denoise.
+ *
+ * @param lambdaPower  the smoothing factor of the power
+ * @param lambdaA      the adaptation factor of the noise estimate when the power is above it
+ * @param lambdaB      the adaptation factor of the noise estimate when the power is below it
+ * @param lambdaT      the decay of the temporal masking peaks
+ * @param muT          the level of the temporal masking, relative to the peak
+ * @param maxGain      the maximum gain, and inverse of the minimum gain
+ * @param smoothWindow the half width of the gain smoothing window, in spectrum bins
+ */
+func NewDenoise(lambdaPower, lambdaA, lambdaB, lambdaT, muT, maxGain float64, smoothWindow int) *Denoise {
+	return &Denoise{
+		lambdaPower:  lambdaPower,
+		lambdaA:      lambdaA,
+		lambdaB:      lambdaB,
+		lambdaT:      lambdaT,
+		muT:          muT,
+		maxGain:      maxGain,
+		smoothWindow: smoothWindow,
+	}
+}
+
+func (d *Denoise) Initialize() {
+	d.power = nil
+}
+
+/** @return the current noise estimate, one value per spectrum bin, or nil before the first spectrum */
+func (d *Denoise) Noise() []float64 {
+	return d.noise
+}
+
+/**
+ * Returns the next DoubleData object, which is the denoised power spectrum of the input. Signals are returned
+ * unmodified.
+ *
+ * @return the next available Data object, returns null if no Data is available
+ * @throws error if there is a data processing error
+ */
+func (d *Denoise) GetData() (frontend.Data, error) {
+	input, err := d.Predecessor().GetData()
+	if err != nil {
+		return nil, err
+	}
+
+	switch data := input.(type) {
+	case *frontend.DoubleData:
+		d.process(data.Values())
+	case *frontend.DataStartSignal:
+		d.power = nil
+	}
+
+	return input, nil
+}
+
+/**
+ * Removes the noise of the given power spectrum, in place.
+ *
+ * @param input a power spectrum
+ */
+func (d *Denoise) process(input []float64) {
+	if d.power == nil || len(d.power) != len(input) {
+		d.initStatistics(input)
+	}
+
+	d.updatePower(input)
+	d.estimateEnvelope(d.power, d.noise)
+
+	signal := make([]float64, len(input))
+	for i := range signal {
+		signal[i] = math.Max(d.power[i]-d.noise[i], 0)
+	}
+	d.estimateEnvelope(signal, d.floor)
+	d.tempMasking(signal)
+	d.powerBoosting(signal)
+
+	gain := make([]float64, len(input))
+	for i := range gain {
+		if d.power[i] > 0 {
+			gain[i] = signal[i] / d.power[i]
+		} else {
+			gain[i] = 1
+		}
+		gain[i] = math.Min(math.Max(gain[i], 1/d.maxGain), d.maxGain)
+	}
+
+	smoothGain := d.smooth(gain)
+	for i := range input {
+		input[i] *= smoothGain[i]
+	}
+}
+
+func (d *Denoise) initStatistics(input []float64) {
+	d.power = append([]float64(nil), input...)
+	d.noise = append([]float64(nil), input...)
+	d.floor = make([]float64, len(input))
+	d.peak = make([]float64, len(input))
+	for i, value := range input {
+		d.floor[i] = value / d.maxGain
+	}
+}
+
+func (d *Denoise) updatePower(input []float64) {
+	for i, value := range input {
+		d.power[i] = d.lambdaPower*d.power[i] + (1-d.lambdaPower)*value
+	}
+}
+
+// estimateEnvelope tracks the lower envelope of the signal, slowly upwards and quickly downwards.
+func (d *Denoise) estimateEnvelope(signal, envelope []float64) {
+	for i, value := range signal {
+		if value > envelope[i] {
+			envelope[i] = d.lambdaA*envelope[i] + (1-d.lambdaA)*value
+		} else {
+			envelope[i] = d.lambdaB*envelope[i] + (1-d.lambdaB)*value
+		}
+	}
+}
+
+// tempMasking keeps the signal from dropping faster than the decay of its last peak.
+func (d *Denoise) tempMasking(signal []float64) {
+	for i, value := range signal {
+		d.peak[i] *= d.lambdaT
+		if signal[i] < d.lambdaT*d.peak[i] {
+			signal[i] = d.peak[i] * d.muT
+		}
+		if value > d.peak[i] {
+			d.peak[i] = value
+		}
+	}
+}
+
+// powerBoosting floors the signal by its lower envelope.
+func (d *Denoise) powerBoosting(signal []float64) {
+	for i := range signal {
+		if signal[i] < d.floor[i] {
+			signal[i] = d.floor[i]
+		}
+	}
+}
+
+// smooth averages the gain over smoothWindow bins on each side.
+func (d *Denoise) smooth(gain []float64) []float64 {
+	result := make([]float64, len(gain))
+	for i := range gain {
+		start := i - d.smoothWindow
+		if start < 0 {
+			start = 0
+		}
+		end := i + d.smoothWindow + 1
+		if end > len(gain) {
+			end = len(gain)
+		}
+		var sum float64
+		for j := start; j < end; j++ {
+			sum += gain[j]
+		}
+		result[i] = sum / float64(end-start)
+	}
+	return result
+}
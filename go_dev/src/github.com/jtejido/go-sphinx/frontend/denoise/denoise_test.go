@@ -0,0 +1,63 @@
+package denoise
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/jtejido/go-sphinx/frontend"
+)
+
+type spectrumSource struct {
+	frontend.BaseDataProcessor
+	spectra [][]float64
+}
+
+func (s *spectrumSource) GetData() (frontend.Data, error) {
+	if len(s.spectra) == 0 {
+		return nil, nil
+	}
+	spectrum := s.spectra[0]
+	s.spectra = s.spectra[1:]
+	return frontend.NewDoubleData(spectrum), nil
+}
+
+func TestDenoiseRemovesStationaryNoise(t *testing.T) {
+	const bins, tone = 65, 20
+	random := rand.New(rand.NewSource(1))
+	var spectra [][]float64
+	for frame := 0; frame < 200; frame++ {
+		spectrum := make([]float64, bins)
+		for i := range spectrum {
+			spectrum[i] = 100 * (0.5 + random.Float64())
+		}
+		if frame >= 150 {
+			spectrum[tone] += 100000
+		}
+		spectra = append(spectra, spectrum)
+	}
+
+	d := NewDefaultDenoise()
+	d.SetPredecessor(&spectrumSource{spectra: spectra})
+	var last []float64
+	for {
+		data, err := d.GetData()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data == nil {
+			break
+		}
+		last = data.(*frontend.DoubleData).Values()
+	}
+
+	// far from the tone, only noise remains, and it is attenuated
+	if last[bins-1] > 50 {
+		t.Fatalf("noise bin kept at %v", last[bins-1])
+	}
+	if last[tone] < 10000 {
+		t.Fatalf("tone attenuated to %v", last[tone])
+	}
+	if noise := d.Noise()[bins-1]; noise < 50 || noise > 150 {
+		t.Fatalf("noise estimated at %v, want about 100", noise)
+	}
+}
@@ -0,0 +1,109 @@
+package frontend
+
+import "fmt"
+
+/**
+ * A Data object that holds data of primitive type double.
+ *
+ * @see Data
+ */
+type DoubleData struct {
+	values            []float64
+	sampleRate        int
+	firstSampleNumber int64
+	collectTime       int64
+}
+
+/**
+ * Constructs a Data object with the given values, no sample rate information and no position information.
+ *
+ * @param values the data values
+ */
+func NewDoubleData(values []float64) *DoubleData {
+	return NewDoubleDataWithCollectTime(values, 0, 0, 0)
+}
+
+/**
+ * Constructs a Data object with the given values, sample rate and first sample number. The collect time is derived
+ * from the first sample number.
+ *
+ * @param values            the data values
+ * @param sampleRate        the sample rate of the data
+ * @param firstSampleNumber the position of the first sample in the original data
+ */
+func NewDoubleDataWithSampleRate(values []float64, sampleRate int, firstSampleNumber int64) *DoubleData {
+	var collectTime int64
+	if sampleRate > 0 {
+		collectTime = firstSampleNumber * 1000 / int64(sampleRate)
+	}
+	return NewDoubleDataWithCollectTime(values, sampleRate, collectTime, firstSampleNumber)
+}
+
+/**
+ * Constructs a Data object with the given values, sample rate, collect time, and first sample number.
+ *
+ * @param values            the data values
+ * @param sampleRate        the sample rate of the data
+ * @param collectTime       the time at which this data is collected
+ * @param firstSampleNumber the position of the first sample in the original data
+ */
+func NewDoubleDataWithCollectTime(values []float64, sampleRate int,
+	collectTime, firstSampleNumber int64) *DoubleData {
+	this := new(DoubleData)
+	this.values = values
+	this.sampleRate = sampleRate
+	this.collectTime = collectTime
+	this.firstSampleNumber = firstSampleNumber
+	return this
+}
+
+/**
+ * @return the values of this data.
+ */
+func (d *DoubleData) Values() []float64 {
+	return d.values
+}
+
+/**
+ * @param values the new values of this data.
+ */
+func (d *DoubleData) SetValues(values []float64) {
+	d.values = values
+}
+
+/**
+ * @return the sample rate of the data.
+ */
+func (d *DoubleData) SampleRate() int {
+	return d.sampleRate
+}
+
+/**
+ * @return the position of the first sample in the original data. The very first sample number is zero.
+ */
+func (d *DoubleData) FirstSampleNumber() int64 {
+	return d.firstSampleNumber
+}
+
+/**
+ * Returns the time in milliseconds at which the audio data is collected.
+ *
+ * @return the difference, in milliseconds, between the time the audio data is collected and midnight, January 1,
+ *         1970
+ */
+func (d *DoubleData) CollectTime() int64 {
+	return d.collectTime
+}
+
+/**
+ * @return a copy of this data with its own values slice.
+ */
+func (d *DoubleData) Clone() *DoubleData {
+	values := make([]float64, len(d.values))
+	copy(values, d.values)
+	return NewDoubleDataWithCollectTime(values, d.sampleRate, d.collectTime, d.firstSampleNumber)
+}
+
+func (d *DoubleData) String() string {
+	return fmt.Sprintf("DoubleData: %dHz, first sample #: %d, collect time: %d", d.sampleRate, d.firstSampleNumber, d.collectTime)
+}
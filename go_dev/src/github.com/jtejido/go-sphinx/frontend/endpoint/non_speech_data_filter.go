@@ -0,0 +1,64 @@
+package endpoint
+
+import (
+	"github.com/jtejido/go-sphinx/frontend"
+)
+
+/**
+ * Given a sequence of Data, filters out the non-speech regions. The sequence of Data should have the speech and
+ * non-speech regions marked out by the SpeechStartSignal and SpeechEndSignal, using and endpointer like the
+ * SpeechMarker.
+ *
+ * All Data between a SpeechEndSignal and the next SpeechStartSignal is dropped, except the DataStartSignal and
+ * DataEndSignal that delimit the stream.
+ */
+type NonSpeechDataFilter struct {
+	frontend.BaseDataProcessor
+	inSpeech bool
+}
+
+func NewNonSpeechDataFilter() *NonSpeechDataFilter {
+	return new(NonSpeechDataFilter)
+}
+
+func (f *NonSpeechDataFilter) Initialize() {
+	f.inSpeech = false
+}
+
+/**
+ * Returns the next Data object.
+ *
+ * @return the next Data object, or null if none available
+ * @throws error if a data processing error occurs
+ */
+func (f *NonSpeechDataFilter) GetData() (frontend.Data, error) {
+	for {
+		data, err := f.readData()
+		if err != nil || data == nil {
+			return nil, err
+		}
+
+		switch data.(type) {
+		case *frontend.DataStartSignal, *frontend.DataEndSignal, *frontend.SpeechStartSignal, *frontend.SpeechEndSignal:
+			return data, nil
+		}
+		if f.inSpeech {
+			return data, nil
+		}
+	}
+}
+
+func (f *NonSpeechDataFilter) readData() (frontend.Data, error) {
+	data, err := f.Predecessor().GetData()
+	if err != nil {
+		return nil, err
+	}
+
+	switch data.(type) {
+	case *frontend.SpeechStartSignal:
+		f.inSpeech = true
+	case *frontend.SpeechEndSignal, *frontend.DataStartSignal:
+		f.inSpeech = false
+	}
+	return data, nil
+}
@@ -0,0 +1,93 @@
+package endpoint
+
+import (
+	"fmt"
+
+	"github.com/jtejido/go-sphinx/frontend"
+)
+
+/**
+ * A container for DoubleData class that indicates whether the contained DoubleData is speech or not.
+ */
+type SpeechClassifiedData struct {
+	data     *frontend.DoubleData
+	isSpeech bool
+}
+
+/**
+ * Constructs a SpeechClassifiedData object.
+ *
+ * @param doubleData the DoubleData
+ * @param isSpeech   indicates whether the DoubleData is speech
+ */
+func NewSpeechClassifiedData(doubleData *frontend.DoubleData, isSpeech bool) *SpeechClassifiedData {
+	return &SpeechClassifiedData{data: doubleData, isSpeech: isSpeech}
+}
+
+/**
+ * Sets whether this SpeechClassifiedData is speech or not.
+ *
+ * @param isSpeech true if this is speech, false otherwise
+ */
+func (scd *SpeechClassifiedData) SetSpeech(isSpeech bool) {
+	scd.isSpeech = isSpeech
+}
+
+/**
+ * Returns whether this is classified as speech.
+ *
+ * @return true if this is classified as speech, false otherwise
+ */
+func (scd *SpeechClassifiedData) IsSpeech() bool {
+	return scd.isSpeech
+}
+
+/**
+ * Returns the data values.
+ *
+ * @return the data values
+ */
+func (scd *SpeechClassifiedData) Values() []float64 {
+	return scd.data.Values()
+}
+
+/**
+ * Returns the sample rate of the data.
+ *
+ * @return the sample rate of the data
+ */
+func (scd *SpeechClassifiedData) SampleRate() int {
+	return scd.data.SampleRate()
+}
+
+/**
+ * Returns the time in milliseconds at which the audio data is collected.
+ *
+ * @return the difference, in milliseconds, between the time the audio data is collected and midnight, January 1,
+ *         1970
+ */
+func (scd *SpeechClassifiedData) CollectTime() int64 {
+	return scd.data.CollectTime()
+}
+
+/**
+ * Returns the position of the first sample in the original data. The very first sample number is zero.
+ *
+ * @return the position of the first sample in the original data
+ */
+func (scd *SpeechClassifiedData) FirstSampleNumber() int64 {
+	return scd.data.FirstSampleNumber()
+}
+
+/**
+ * Returns the DoubleData contained by this SpeechClassifiedData.
+ *
+ * @return the DoubleData contained by this SpeechClassifiedData
+ */
+func (scd *SpeechClassifiedData) DoubleData() *frontend.DoubleData {
+	return scd.data
+}
+
+func (scd *SpeechClassifiedData) String() string {
+	return fmt.Sprintf("SpeechClassifiedData containing %d samples, isSpeech: %v", len(scd.data.Values()), scd.isSpeech)
+}
@@ -0,0 +1,187 @@
+package endpoint
+
+import (
+	"math"
+
+	"github.com/jtejido/go-sphinx/frontend"
+)
+
+const (
+	// The default adjustment of the background level towards the current level, per frame.
+	DEFAULT_ADJUSTMENT = 0.003
+
+	// The default minimum level difference between the signal and the background to be speech, in decibels.
+	DEFAULT_THRESHOLD = 10.0
+
+	// The default minimum level of a frame to be taken into account, in decibels.
+	DEFAULT_MIN_SIGNAL = 0.0
+)
+
+/**
+ * Implements a level tracking endpointer invented by Bent Schmidt Nielsen.
+ *
+ * This endpointer is composed of two main steps.
+ *
+ * 1. classification of audio into speech and non-speech
+ * 2. inserting SPEECH_START and SPEECH_END signals around speech and removing non-speech regions
+ *
+ * The first step, classification of audio into speech and non-speech, uses Bent Schmidt Nielsen's algorithm. Each
+ * time audio comes in, the average signal level and the background noise level are updated, using the signal level
+ * of the current audio. If the average signal level is greater than the background noise level by a certain
+ * threshold value (configurable), then the current audio is marked as speech. Otherwise, it is marked as non-speech.
+ * The background level adapts slowly upwards and immediately downwards, so the threshold follows the noise floor of
+ * the recording.
+ *
+ * The second step of this endpointer is documented in the class SpeechMarker. The audio is expected in blocks of a
+ * few milliseconds, as made by a DataBlocker.
+ *
+ * @see SpeechMarker
+ */
+type SpeechClassifier struct {
+	frontend.BaseDataProcessor
+	averageNumber float64
+	adjustment    float64
+	level         float64 // average signal level
+	background    float64 // background signal level
+	minSignal     float64 // minimum valid signal level
+	threshold     float64
+	isSpeech      bool
+
+	/* Statistics */
+	speechFrames, backgroundFrames         int64
+	totalBackgroundLevel, totalSpeechLevel float64
+}
+
+/** Constructs a SpeechClassifier with the default threshold, adjustment and minimum signal. */
+func NewDefaultSpeechClassifier() *SpeechClassifier {
+	return NewSpeechClassifier(DEFAULT_ADJUSTMENT, DEFAULT_THRESHOLD, DEFAULT_MIN_SIGNAL)
+}
+
+/**
+ * Constructs a SpeechClassifier.
+ *
+ * @param adjustment the adjustment of the background level towards the current level, per frame
+ * @param threshold  the minimum level difference between the signal and the background to be speech, in decibels
+ * @param minSignal  the minimum level of a frame to be taken into account, in decibels
+ */
+func NewSpeechClassifier(adjustment, threshold, minSignal float64) *SpeechClassifier {
+	sc := &SpeechClassifier{
+		averageNumber: 1,
+		adjustment:    adjustment,
+		threshold:     threshold,
+		minSignal:     minSignal,
+	}
+	sc.reset()
+	return sc
+}
+
+func (sc *SpeechClassifier) Initialize() {
+	sc.reset()
+}
+
+/** Resets this SpeechClassifier to a starting state. */
+func (sc *SpeechClassifier) reset() {
+	sc.level = 0
+	sc.background = 300
+	sc.resetStats()
+}
+
+func (sc *SpeechClassifier) resetStats() {
+	sc.backgroundFrames = 1
+	sc.speechFrames = 1
+	sc.totalSpeechLevel = 0
+	sc.totalBackgroundLevel = 0
+}
+
+/**
+ * Returns the logarithm base 10 of the root mean square of the given samples.
+ *
+ * @param samples the samples
+ * @return the calculated log root mean square in log 10
+ */
+func LogRootMeanSquare(samples []float64) float64 {
+	sumOfSquares := 0.0
+	for _, sample := range samples {
+		sumOfSquares += sample * sample
+	}
+	rootMeanSquare := 0.0
+	if len(samples) > 0 {
+		rootMeanSquare = math.Sqrt(sumOfSquares / float64(len(samples)))
+	}
+	rootMeanSquare = math.Max(rootMeanSquare, 1)
+	return math.Log10(rootMeanSquare) * 20
+}
+
+/**
+ * Classifies the given audio frame as speech or not, and updates the endpointing parameters.
+ *
+ * @param audio the audio frame
+ * @return Data with classification flag
+ */
+func (sc *SpeechClassifier) classify(audio *frontend.DoubleData) *SpeechClassifiedData {
+	current := LogRootMeanSquare(audio.Values())
+	sc.isSpeech = false
+	if current >= sc.minSignal {
+		sc.level = ((sc.level * sc.averageNumber) + current) / (sc.averageNumber + 1)
+		if current < sc.background {
+			sc.background = current
+		} else {
+			sc.background += (current - sc.background) * sc.adjustment
+		}
+		if sc.level < sc.background {
+			sc.level = sc.background
+		}
+		sc.isSpeech = sc.level-sc.background > sc.threshold
+	}
+
+	if sc.isSpeech {
+		sc.totalSpeechLevel += current
+		sc.speechFrames++
+	} else {
+		sc.totalBackgroundLevel += current
+		sc.backgroundFrames++
+	}
+
+	return NewSpeechClassifiedData(audio, sc.isSpeech)
+}
+
+/**
+ * Returns the next Data object.
+ *
+ * @return the next Data object, or null if none available
+ * @throws error if a data processing error occurs
+ */
+func (sc *SpeechClassifier) GetData() (frontend.Data, error) {
+	audio, err := sc.Predecessor().GetData()
+	if err != nil {
+		return nil, err
+	}
+
+	switch data := audio.(type) {
+	case *frontend.DataStartSignal:
+		sc.reset()
+	case *frontend.DoubleData:
+		return sc.classify(data), nil
+	}
+	return audio, nil
+}
+
+/** @return whether the last classified frame was speech */
+func (sc *SpeechClassifier) IsSpeech() bool {
+	return sc.isSpeech
+}
+
+/** @return the average level of the speech frames since the start of the data, in decibels */
+func (sc *SpeechClassifier) SpeechLevel() float64 {
+	return sc.totalSpeechLevel / float64(sc.speechFrames)
+}
+
+/** @return the average level of the non-speech frames since the start of the data, in decibels */
+func (sc *SpeechClassifier) BackgroundLevel() float64 {
+	return sc.totalBackgroundLevel / float64(sc.backgroundFrames)
+}
+
+/** @return the estimated signal to noise ratio, in decibels */
+func (sc *SpeechClassifier) SNR() float64 {
+	return sc.SpeechLevel() - sc.BackgroundLevel()
+}
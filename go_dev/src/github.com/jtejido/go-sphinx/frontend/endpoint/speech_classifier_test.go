@@ -0,0 +1,66 @@
+package endpoint
+
+import (
+	"math"
+	"testing"
+
+	"github.com/jtejido/go-sphinx/frontend"
+)
+
+// toneSource gives 10ms blocks at 16kHz, a loud tone for the blocks of its pattern marked 'S' and faint noise for
+// the others.
+type toneSource struct {
+	frontend.BaseDataProcessor
+	pattern  string
+	position int
+	seed     uint32
+}
+
+func (s *toneSource) GetData() (frontend.Data, error) {
+	if s.position == len(s.pattern) {
+		return nil, nil
+	}
+	first := s.position * 160
+	values := make([]float64, 160)
+	for i := range values {
+		s.seed = s.seed*1664525 + 1013904223
+		values[i] = float64(s.seed>>24) / 16
+		if s.pattern[s.position] == 'S' {
+			values[i] += 3000 * math.Sin(2*math.Pi*440*float64(first+i)/16000)
+		}
+	}
+	s.position++
+	return frontend.NewDoubleDataWithSampleRate(values, 16000, int64(first)), nil
+}
+
+func TestSpeechClassifier(t *testing.T) {
+	input := pattern(".", 30, "S", 30, ".", 30)
+	classifier := NewDefaultSpeechClassifier()
+	classifier.SetPredecessor(&toneSource{pattern: input})
+
+	var classes []byte
+	for {
+		data, err := classifier.GetData()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data == nil {
+			break
+		}
+		if data.(*SpeechClassifiedData).IsSpeech() {
+			classes = append(classes, 'S')
+		} else {
+			classes = append(classes, '.')
+		}
+	}
+
+	// the level is smoothed over frames, so the speech outlasts the tone by a few frames
+	for i := range input {
+		if input[i] == 'S' && classes[i] != 'S' || i < 30 && classes[i] == 'S' || i >= 65 && classes[i] == 'S' {
+			t.Fatalf("got %s, want %s", classes, input)
+		}
+	}
+	if snr := classifier.SNR(); snr < DEFAULT_THRESHOLD {
+		t.Fatalf("got an SNR of %v dB, want more than %v dB", snr, DEFAULT_THRESHOLD)
+	}
+}
@@ -0,0 +1,197 @@
+package endpoint
+
+import (
+	"github.com/jtejido/go-sphinx/frontend"
+)
+
+const (
+	// The default amount of time in speech (in milliseconds) to be considered as utterance start.
+	DEFAULT_START_SPEECH = 200
+
+	// The default amount of time in silence (in milliseconds) to be considered as utterance end.
+	DEFAULT_END_SILENCE = 200
+
+	// The default amount of time (in milliseconds) of audio kept before the start of speech.
+	DEFAULT_SPEECH_LEADER = 50
+)
+
+/**
+ * Converts a stream of SpeechClassifiedData objects, marked as speech and non-speech, and mark out the regions that
+ * are considered speech. This is done by inserting SPEECH_START and SPEECH_END signals into the stream.
+ *
+ * The algorithm for inserting the two signals is as follows.
+ *
+ * The algorithm is always in one of two states: 'in-speech' and 'out-of-speech'. If 'out-of-speech', it will read in
+ * audio until we hit audio that is speech. If we have read more than 'startSpeech' amount of continuous speech, we
+ * consider that speech has started, and insert a SPEECH_START at 'speechLeader' time before speech first started.
+ * The state of the algorithm changes to 'in-speech'.
+ *
+ * Now consider the case when the algorithm is in 'in-speech' state. If it read an audio that is speech, it is
+ * scheduled for output. If the audio is non-speech, we read ahead until we have 'endSilence' amount of continuous
+ * non-speech. At the point we consider that speech has ended. The silence read so far trails the speech, a
+ * SPEECH_END signal is inserted after it, and the state of the algorithm changes to 'out-of-speech'.
+ *
+ * Audio that is not part of a speech region is dropped. The SpeechClassifiedData objects are unwrapped, the output
+ * carries plain DoubleData.
+ */
+type SpeechMarker struct {
+	frontend.BaseDataProcessor
+	startSpeechTime, endSilenceTime, speechLeader int
+
+	inputQueue                                              []*SpeechClassifiedData // Audio objects are added to the end
+	outputQueue                                             []frontend.Data         // Audio objects are added to the end
+	inSpeech                                                bool
+	speechCount, silenceCount                               int
+	startSpeechFrames, endSilenceFrames, speechLeaderFrames int
+}
+
+/** Constructs a SpeechMarker with the default timings. */
+func NewDefaultSpeechMarker() *SpeechMarker {
+	return NewSpeechMarker(DEFAULT_START_SPEECH, DEFAULT_END_SILENCE, DEFAULT_SPEECH_LEADER)
+}
+
+/**
+ * Constructs a SpeechMarker.
+ *
+ * @param startSpeechTime the amount of continuous speech that starts an utterance, in milliseconds
+ * @param endSilenceTime  the amount of continuous silence that ends an utterance, in milliseconds
+ * @param speechLeader    the amount of audio kept before the start of speech, in milliseconds
+ */
+func NewSpeechMarker(startSpeechTime, endSilenceTime, speechLeader int) *SpeechMarker {
+	sm := &SpeechMarker{
+		startSpeechTime: startSpeechTime,
+		endSilenceTime:  endSilenceTime,
+		speechLeader:    speechLeader,
+	}
+	sm.reset()
+	return sm
+}
+
+/** Initializes this SpeechMarker */
+func (sm *SpeechMarker) Initialize() {
+	sm.reset()
+}
+
+/** Resets this SpeechMarker to a starting state. */
+func (sm *SpeechMarker) reset() {
+	sm.inSpeech = false
+	sm.speechCount = 0
+	sm.silenceCount = 0
+	sm.startSpeechFrames = -1
+	sm.inputQueue = nil
+	sm.outputQueue = nil
+}
+
+/**
+ * Converts the timings to frames, using the duration of the given frame.
+ *
+ * @param frame a classified frame
+ */
+func (sm *SpeechMarker) initFrames(frame *SpeechClassifiedData) {
+	frameMs := 10
+	if frame.SampleRate() > 0 && len(frame.Values()) > 0 {
+		frameMs = len(frame.Values()) * 1000 / frame.SampleRate()
+	}
+	if frameMs < 1 {
+		frameMs = 1
+	}
+	sm.startSpeechFrames = sm.startSpeechTime / frameMs
+	sm.endSilenceFrames = sm.endSilenceTime / frameMs
+	sm.speechLeaderFrames = sm.speechLeader / frameMs
+	if sm.startSpeechFrames < 1 {
+		sm.startSpeechFrames = 1
+	}
+	if sm.endSilenceFrames < 1 {
+		sm.endSilenceFrames = 1
+	}
+}
+
+/**
+ * Returns the next Data object.
+ *
+ * @return the next Data object, or null if none available
+ * @throws error if a data processing error occurs
+ */
+func (sm *SpeechMarker) GetData() (frontend.Data, error) {
+	for len(sm.outputQueue) == 0 {
+		data, err := sm.Predecessor().GetData()
+		if err != nil {
+			return nil, err
+		}
+		if data == nil {
+			break
+		}
+
+		switch d := data.(type) {
+		case *frontend.DataStartSignal:
+			sm.reset()
+			sm.outputQueue = append(sm.outputQueue, data)
+		case *frontend.DataEndSignal:
+			if sm.inSpeech {
+				sm.outputQueue = append(sm.outputQueue, frontend.NewSpeechEndSignal(d.Time()))
+			}
+			sm.inSpeech = false
+			sm.inputQueue = nil
+			sm.outputQueue = append(sm.outputQueue, data)
+		case *SpeechClassifiedData:
+			sm.process(d)
+		default:
+			sm.outputQueue = append(sm.outputQueue, data)
+		}
+	}
+
+	// If we have something left, return that
+	if len(sm.outputQueue) == 0 {
+		return nil, nil
+	}
+	data := sm.outputQueue[0]
+	sm.outputQueue = sm.outputQueue[1:]
+	if cdata, ok := data.(*SpeechClassifiedData); ok {
+		data = cdata.DoubleData()
+	}
+	return data, nil
+}
+
+func (sm *SpeechMarker) process(cdata *SpeechClassifiedData) {
+	if sm.startSpeechFrames < 0 {
+		sm.initFrames(cdata)
+	}
+
+	if cdata.IsSpeech() {
+		sm.speechCount++
+		sm.silenceCount = 0
+	} else {
+		sm.speechCount = 0
+		sm.silenceCount++
+	}
+
+	if sm.inSpeech {
+		sm.outputQueue = append(sm.outputQueue, cdata)
+	} else {
+		sm.inputQueue = append(sm.inputQueue, cdata)
+		if len(sm.inputQueue) > sm.startSpeechFrames+sm.speechLeaderFrames {
+			sm.inputQueue = sm.inputQueue[1:]
+		}
+	}
+
+	if !sm.inSpeech && sm.speechCount == sm.startSpeechFrames {
+		sm.inSpeech = true
+		sm.outputQueue = append(sm.outputQueue, frontend.NewSpeechStartSignal(sm.inputQueue[0].CollectTime()))
+		for _, queued := range sm.inputQueue {
+			sm.outputQueue = append(sm.outputQueue, queued)
+		}
+		sm.inputQueue = nil
+	}
+
+	if sm.inSpeech && sm.silenceCount == sm.endSilenceFrames {
+		sm.inSpeech = false
+		sm.outputQueue = append(sm.outputQueue, frontend.NewSpeechEndSignal(cdata.CollectTime()))
+	}
+}
+
+/**
+ * @return true if this SpeechMarker thinks it is currently in speech, false otherwise
+ */
+func (sm *SpeechMarker) InSpeech() bool {
+	return sm.inSpeech
+}
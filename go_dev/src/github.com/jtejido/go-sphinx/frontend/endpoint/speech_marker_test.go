@@ -0,0 +1,119 @@
+package endpoint
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jtejido/go-sphinx/frontend"
+)
+
+// classifiedSource gives one 10ms frame per character of its pattern: 'S' for speech, '.' for non-speech.
+type classifiedSource struct {
+	frontend.BaseDataProcessor
+	pattern        string
+	position       int
+	started, ended bool
+}
+
+func (s *classifiedSource) GetData() (frontend.Data, error) {
+	if !s.started {
+		s.started = true
+		return frontend.NewDataStartSignal(16000, 0), nil
+	}
+	if s.position == len(s.pattern) {
+		if s.ended {
+			return nil, nil
+		}
+		s.ended = true
+		return frontend.NewDataEndSignal(int64(s.position*10), int64(s.position*10)), nil
+	}
+	i := s.position
+	s.position++
+	audio := frontend.NewDoubleDataWithCollectTime(make([]float64, 160), 16000, int64(i*10), int64(i*160))
+	return NewSpeechClassifiedData(audio, s.pattern[i] == 'S'), nil
+}
+
+// mark runs the pattern through a SpeechMarker with the default timings and describes its output: '<' and '>' for
+// the data start and end, "[t" and "t]" for a speech start and end at t milliseconds, and the number of the frames
+// between them, such as "< [50 5-59 590] >".
+func mark(t *testing.T, pattern string) string {
+	marker := NewDefaultSpeechMarker()
+	marker.SetPredecessor(&classifiedSource{pattern: pattern})
+
+	var out []string
+	first, last := -1, -1
+	flush := func() {
+		if first >= 0 {
+			out = append(out, fmt.Sprintf("%d-%d", first, last))
+			first = -1
+		}
+	}
+	for {
+		data, err := marker.GetData()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data == nil {
+			return strings.Join(out, " ")
+		}
+		switch d := data.(type) {
+		case *frontend.DoubleData:
+			frame := int(d.FirstSampleNumber() / 160)
+			if first >= 0 && frame != last+1 {
+				flush()
+			}
+			if first < 0 {
+				first = frame
+			}
+			last = frame
+			continue
+		case *SpeechClassifiedData:
+			t.Fatalf("got a classified frame %v, want plain DoubleData", d)
+		}
+		flush()
+		switch d := data.(type) {
+		case *frontend.DataStartSignal:
+			out = append(out, "<")
+		case *frontend.DataEndSignal:
+			out = append(out, ">")
+		case *frontend.SpeechStartSignal:
+			out = append(out, fmt.Sprintf("[%d", d.Time()))
+		case *frontend.SpeechEndSignal:
+			out = append(out, fmt.Sprintf("%d]", d.Time()))
+		default:
+			t.Fatalf("unexpected %v", data)
+		}
+	}
+}
+
+func pattern(parts ...interface{}) string {
+	var b strings.Builder
+	for i := 0; i < len(parts); i += 2 {
+		b.WriteString(strings.Repeat(parts[i].(string), parts[i+1].(int)))
+	}
+	return b.String()
+}
+
+func TestSpeechMarker(t *testing.T) {
+	for _, test := range []struct {
+		name, pattern, expected string
+	}{
+		// 200ms of speech start an utterance 50ms before the speech, 200ms of silence end it
+		{"utterance", pattern(".", 10, "S", 30, ".", 40), "< [50 5-59 590] >"},
+		// the leader is cut short by the start of the data
+		{"short leader", pattern(".", 2, "S", 30, ".", 30), "< [0 0-51 510] >"},
+		// speech shorter than 200ms is dropped
+		{"click", pattern(".", 10, "S", 19, ".", 30), "< >"},
+		// a pause shorter than 200ms stays in the utterance
+		{"pause", pattern(".", 10, "S", 30, ".", 19, "S", 30, ".", 30), "< [50 5-108 1080] >"},
+		// a longer one splits it
+		{"two utterances", pattern(".", 10, "S", 30, ".", 30, "S", 30, ".", 30), "< [50 5-59 590] [650 65-119 1190] >"},
+		// the end of the data ends the utterance
+		{"cut", pattern(".", 10, "S", 30), "< [50 5-39 400] >"},
+	} {
+		if actual := mark(t, test.pattern); actual != test.expected {
+			t.Errorf("%s: got %q, want %q", test.name, actual, test.expected)
+		}
+	}
+}
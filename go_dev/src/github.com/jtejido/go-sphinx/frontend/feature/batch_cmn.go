@@ -0,0 +1,136 @@
+package feature
+
+import (
+	"fmt"
+
+	"github.com/jtejido/go-sphinx/frontend"
+)
+
+/**
+ * Applies cepstral mean normalization (CMN), sometimes called channel mean normalization, to incoming cepstral data.
+ *
+ * Its goal is to reduce the distortion caused by the transmission channel. The output is mean normalized cepstral
+ * data.
+ *
+ * The CMN processing subtracts the mean from all the Data objects between a DataStartSignal and a DataEndSignal or
+ * between a SpeechStartSignal and a SpeechEndSignal. BatchCMN will read in all the Data objects, calculate the mean,
+ * and subtract this mean from all the Data objects. For a given utterance, it will only produce an output after
+ * reading all the incoming data for the utterance. As a result, this process can introduce a significant processing
+ * delay, which is acceptable for batch processing, but not for live mode. In the latter case, one should use the
+ * LiveCMN.
+ *
+ * CMN is a technique used to reduce distortions that are introduced by the transfer function of the transmission
+ * channel (e.g., the microphone). Using a transmission channel to transmit the input speech translates to
+ * multiplying the spectrum of the input speech with the transfer function of the channel (the distortion). Since the
+ * cepstrum is the Fourier Transform of the log spectrum, the logarithm turns the multiplication into a summation.
+ * Averaging over time, the mean is an estimate of the channel, which remains roughly constant. The channel is thus
+ * removed from the cepstrum by subtracting the mean cepstral vector. Intuitively, the mean cepstral vector
+ * approximately describes the spectral characteristics of the transmission channel (e.g., microphone).
+ *
+ * @see LiveCMN
+ */
+type BatchCMN struct {
+	frontend.BaseDataProcessor
+	sums              []float64 // array of current sums
+	cepstraList       []frontend.Data
+	numberDataCepstra int
+}
+
+func NewBatchCMN() *BatchCMN {
+	return &BatchCMN{cepstraList: make([]frontend.Data, 0)}
+}
+
+/** Initializes this BatchCMN. */
+func (cmn *BatchCMN) Initialize() {
+	cmn.sums = nil
+	cmn.cepstraList = cmn.cepstraList[:0]
+}
+
+/** Initializes the sums array and clears the cepstra list. */
+func (cmn *BatchCMN) reset() {
+	cmn.sums = nil // clears the sums array
+	cmn.numberDataCepstra = 0
+}
+
+/**
+ * Returns the next Data object, which is a normalized cepstrum. Signal objects are returned unmodified.
+ *
+ * @return the next available Data object, returns null if no Data object is available
+ * @throws error if there is an error processing data
+ */
+func (cmn *BatchCMN) GetData() (frontend.Data, error) {
+	if len(cmn.cepstraList) == 0 {
+		cmn.reset()
+		// read the cepstra of the entire utterance, calculate and apply the cepstral mean
+		n, err := cmn.readUtterance()
+		if err != nil {
+			return nil, err
+		}
+		if n > 0 {
+			cmn.normalizeList()
+		}
+	}
+
+	if len(cmn.cepstraList) == 0 {
+		return nil, nil
+	}
+
+	output := cmn.cepstraList[0]
+	cmn.cepstraList = cmn.cepstraList[1:]
+	return output, nil
+}
+
+/**
+ * Reads the cepstra of the entire Utterance into the cepstraList.
+ *
+ * @return the number cepstra (with Data) read
+ * @throws error if an error occurred reading the data
+ */
+func (cmn *BatchCMN) readUtterance() (int, error) {
+	for {
+		input, err := cmn.Predecessor().GetData()
+		if err != nil {
+			return 0, err
+		}
+		if input == nil {
+			break
+		}
+
+		cmn.cepstraList = append(cmn.cepstraList, input)
+
+		switch data := input.(type) {
+		case *frontend.DoubleData:
+			cmn.numberDataCepstra++
+			cepstrumData := data.Values()
+			if cmn.sums == nil {
+				cmn.sums = make([]float64, len(cepstrumData))
+			} else if len(cmn.sums) != len(cepstrumData) {
+				return 0, fmt.Errorf("inconsistent cepstrum lengths: sums: %d, cepstrum: %d", len(cmn.sums), len(cepstrumData))
+			}
+			for j, v := range cepstrumData {
+				cmn.sums[j] += v
+			}
+		case *frontend.DataEndSignal, *frontend.SpeechEndSignal:
+			return cmn.numberDataCepstra, nil
+		}
+	}
+
+	return cmn.numberDataCepstra, nil
+}
+
+/** Normalizes the list of Data. */
+func (cmn *BatchCMN) normalizeList() {
+	// calculate the mean first
+	for i := range cmn.sums {
+		cmn.sums[i] /= float64(cmn.numberDataCepstra)
+	}
+
+	for _, data := range cmn.cepstraList {
+		if dd, ok := data.(*frontend.DoubleData); ok {
+			cepstrum := dd.Values()
+			for j := range cepstrum {
+				cepstrum[j] -= cmn.sums[j] // sums[] is now the means[]
+			}
+		}
+	}
+}
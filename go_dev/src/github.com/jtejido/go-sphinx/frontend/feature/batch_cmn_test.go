@@ -0,0 +1,96 @@
+package feature
+
+import (
+	"math"
+	"testing"
+
+	"github.com/jtejido/go-sphinx/frontend"
+)
+
+// dataSource returns the given data, then nil.
+type dataSource struct {
+	frontend.BaseDataProcessor
+	data []frontend.Data
+}
+
+func (s *dataSource) GetData() (frontend.Data, error) {
+	if len(s.data) == 0 {
+		return nil, nil
+	}
+	data := s.data[0]
+	s.data = s.data[1:]
+	return data, nil
+}
+
+// utterance wraps cepstra between a DataStartSignal and a DataEndSignal.
+func utterance(cepstra ...[]float64) []frontend.Data {
+	data := []frontend.Data{frontend.NewDataStartSignal(16000, 0)}
+	for _, cepstrum := range cepstra {
+		data = append(data, frontend.NewDoubleData(append([]float64(nil), cepstrum...)))
+	}
+	return append(data, frontend.NewDataEndSignal(0, 0))
+}
+
+// readAll returns the output of a processor until nil.
+func readAll(t *testing.T, processor frontend.DataProcessor) []frontend.Data {
+	var output []frontend.Data
+	for {
+		data, err := processor.GetData()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data == nil {
+			return output
+		}
+		output = append(output, data)
+	}
+}
+
+// checkCepstra compares the cepstra of data with the expected ones, skipping the signals.
+func checkCepstra(t *testing.T, data []frontend.Data, expected ...[]float64) {
+	t.Helper()
+	var cepstra [][]float64
+	for _, d := range data {
+		if dd, ok := d.(*frontend.DoubleData); ok {
+			cepstra = append(cepstra, dd.Values())
+		}
+	}
+	if len(cepstra) != len(expected) {
+		t.Fatalf("got %d cepstra, want %d", len(cepstra), len(expected))
+	}
+	for i := range expected {
+		for j := range expected[i] {
+			if math.Abs(cepstra[i][j]-expected[i][j]) > 1e-9 {
+				t.Fatalf("cepstrum %d is %v, want %v", i, cepstra[i], expected[i])
+			}
+		}
+	}
+}
+
+func TestBatchCMN(t *testing.T) {
+	cmn := NewBatchCMN()
+	input := append(utterance([]float64{1, 2}, []float64{3, 4}, []float64{5, 12}),
+		utterance([]float64{10, 10}, []float64{20, 20})...)
+	cmn.SetPredecessor(&dataSource{data: input})
+	cmn.Initialize()
+
+	output := readAll(t, cmn)
+	if len(output) != len(input) {
+		t.Fatalf("got %d data, want %d", len(output), len(input))
+	}
+	for i := range input {
+		if _, ok := input[i].(frontend.Signal); ok && output[i] != input[i] {
+			t.Fatalf("data %d is %v, want the signal %v", i, output[i], input[i])
+		}
+	}
+	// each utterance is normalized by its own mean, (3, 6) then (15, 15)
+	checkCepstra(t, output, []float64{-2, -4}, []float64{0, -2}, []float64{2, 6}, []float64{-5, -5}, []float64{5, 5})
+}
+
+func TestBatchCMNInconsistentLength(t *testing.T) {
+	cmn := NewBatchCMN()
+	cmn.SetPredecessor(&dataSource{data: utterance([]float64{1, 2}, []float64{3})})
+	if _, err := cmn.GetData(); err == nil {
+		t.Fatal("expected an error for cepstra of different lengths")
+	}
+}
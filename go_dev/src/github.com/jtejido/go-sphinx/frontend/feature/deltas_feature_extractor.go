@@ -0,0 +1,249 @@
+package feature
+
+import (
+	"fmt"
+
+	"github.com/jtejido/go-sphinx/frontend"
+)
+
+const (
+	// Single stream of cepstra, deltas and double deltas.
+	FEATURE_1S_C_D_DD = "1s_c_d_dd"
+
+	// Single stream of cepstra and double deltas.
+	FEATURE_1S_C_DD = "1s_c_dd"
+
+	// The four streams of Sphinx-II semi-continuous models: cepstra without c0, short and long term deltas, the
+	// power terms (c0 and its deltas), and double deltas without c0.
+	FEATURE_S2_4X = "s2_4x"
+)
+
+/**
+ * Computes the delta and double delta of input cepstrum (or plp or ...). The delta is the first order derivative and
+ * the double delta (a.k.a. delta delta) is the second order derivative of the original cepstrum. They help model the
+ * speech signal dynamics. The output data is a FloatData object with a float array holding the streams of the
+ * feature type, one after the other:
+ *
+ * 1s_c_d_dd: the cepstrum, then the delta mfc[t+2] - mfc[t-2], then the double delta
+ * (mfc[t+3] - mfc[t-1]) - (mfc[t+1] - mfc[t-3]).
+ *
+ * 1s_c_dd: the cepstrum, then the double delta.
+ *
+ * s2_4x: the cepstrum without c0; the delta and the long term delta mfc[t+4] - mfc[t-4] without c0; c0 with its delta
+ * and double delta; the double delta without c0.
+ *
+ * At the start and at the end of an utterance, the first and the last cepstrum are replicated as often as the
+ * derivatives look around them, so that every input cepstrum gives exactly one feature.
+ */
+type DeltasFeatureExtractor struct {
+	frontend.BaseDataProcessor
+	featureType     string
+	window          int
+	cepstraBuffer   []*frontend.DoubleData
+	currentPosition int
+	outputQueue     []frontend.Data
+}
+
+/** Constructs a DeltasFeatureExtractor for 1s_c_d_dd features. */
+func NewDefaultDeltasFeatureExtractor() *DeltasFeatureExtractor {
+	dfe, _ := NewDeltasFeatureExtractor(FEATURE_1S_C_D_DD)
+	return dfe
+}
+
+/**
+ * Constructs a DeltasFeatureExtractor.
+ *
+ * @param featureType the feature type, as given by -feat in feat.params
+ * @throws error if the feature type is not supported
+ */
+func NewDeltasFeatureExtractor(featureType string) (*DeltasFeatureExtractor, error) {
+	dfe := &DeltasFeatureExtractor{featureType: featureType}
+	switch featureType {
+	case FEATURE_1S_C_D_DD, FEATURE_1S_C_DD:
+		dfe.window = 3
+	case FEATURE_S2_4X:
+		dfe.window = 4
+	default:
+		return nil, fmt.Errorf("unsupported feature type %q", featureType)
+	}
+	return dfe, nil
+}
+
+func (dfe *DeltasFeatureExtractor) Initialize() {
+	dfe.cepstraBuffer = nil
+	dfe.outputQueue = nil
+}
+
+/**
+ * @return the feature type
+ */
+func (dfe *DeltasFeatureExtractor) FeatureType() string {
+	return dfe.featureType
+}
+
+/**
+ * Returns the length of each stream of the features computed from cepstra of the given size, the layout acoustic
+ * model loaders report as their vector length.
+ *
+ * @param cepstrumSize the number of cepstra per frame
+ * @return the length of each stream
+ */
+func (dfe *DeltasFeatureExtractor) VectorLength(cepstrumSize int) []int {
+	switch dfe.featureType {
+	case FEATURE_1S_C_DD:
+		return []int{2 * cepstrumSize}
+	case FEATURE_S2_4X:
+		return []int{cepstrumSize - 1, 2 * (cepstrumSize - 1), 3, cepstrumSize - 1}
+	}
+	return []int{3 * cepstrumSize}
+}
+
+/**
+ * Returns the next Data object produced by this DeltasFeatureExtractor.
+ *
+ * @return the next available Data object, returns null if no Data is available
+ * @throws error if there is a data processing error
+ */
+func (dfe *DeltasFeatureExtractor) GetData() (frontend.Data, error) {
+	for len(dfe.outputQueue) == 0 {
+		input, err := dfe.Predecessor().GetData()
+		if err != nil {
+			return nil, err
+		}
+
+		switch data := input.(type) {
+		case nil:
+			// the stream ended without an end signal, flush what is left
+			dfe.replicateLastCepstrum()
+			if len(dfe.outputQueue) == 0 {
+				return nil, nil
+			}
+		case *frontend.DoubleData:
+			if err := dfe.addCepstrum(data); err != nil {
+				return nil, err
+			}
+		case *frontend.DataStartSignal, *frontend.SpeechStartSignal:
+			dfe.replicateLastCepstrum()
+			dfe.outputQueue = append(dfe.outputQueue, input)
+		case *frontend.DataEndSignal, *frontend.SpeechEndSignal:
+			// when the end signal is right at the boundary
+			dfe.replicateLastCepstrum()
+			dfe.outputQueue = append(dfe.outputQueue, input)
+		default:
+			dfe.outputQueue = append(dfe.outputQueue, input)
+		}
+	}
+
+	output := dfe.outputQueue[0]
+	dfe.outputQueue = dfe.outputQueue[1:]
+	return output, nil
+}
+
+/**
+ * Adds the given cepstrum to the buffer, and computes the features that have enough right context now. The first
+ * cepstrum of an utterance is replicated to the left.
+ *
+ * @param cepstrum the cepstrum to add
+ * @throws error if the cepstrum size changes within an utterance
+ */
+func (dfe *DeltasFeatureExtractor) addCepstrum(cepstrum *frontend.DoubleData) error {
+	if len(dfe.cepstraBuffer) == 0 {
+		for i := 0; i < dfe.window; i++ {
+			dfe.cepstraBuffer = append(dfe.cepstraBuffer, cepstrum)
+		}
+		dfe.currentPosition = dfe.window
+	} else if len(cepstrum.Values()) != len(dfe.cepstraBuffer[0].Values()) {
+		return fmt.Errorf("inconsistent cepstrum lengths: %d, %d", len(dfe.cepstraBuffer[0].Values()), len(cepstrum.Values()))
+	}
+	dfe.cepstraBuffer = append(dfe.cepstraBuffer, cepstrum)
+	dfe.computeFeatures()
+	return nil
+}
+
+/**
+ * Replicates the last cepstrum to the right to compute the features of the end of the utterance, and empties the
+ * buffer.
+ */
+func (dfe *DeltasFeatureExtractor) replicateLastCepstrum() {
+	if len(dfe.cepstraBuffer) == 0 {
+		return
+	}
+	last := dfe.cepstraBuffer[len(dfe.cepstraBuffer)-1]
+	for i := 0; i < dfe.window; i++ {
+		dfe.cepstraBuffer = append(dfe.cepstraBuffer, last)
+	}
+	dfe.computeFeatures()
+	dfe.cepstraBuffer = nil
+}
+
+/**
+ * Computes the features of all the buffered cepstra that have a full right context, and drops the cepstra no
+ * longer needed as left context.
+ */
+func (dfe *DeltasFeatureExtractor) computeFeatures() {
+	for dfe.currentPosition+dfe.window < len(dfe.cepstraBuffer) {
+		dfe.outputQueue = append(dfe.outputQueue, dfe.computeNextFeature())
+		dfe.currentPosition++
+	}
+	if drop := dfe.currentPosition - dfe.window; drop > 0 {
+		dfe.cepstraBuffer = append(dfe.cepstraBuffer[:0], dfe.cepstraBuffer[drop:]...)
+		dfe.currentPosition = dfe.window
+	}
+}
+
+/**
+ * Computes the next feature. Advances the pointers as well.
+ *
+ * @return the feature Data computed
+ */
+func (dfe *DeltasFeatureExtractor) computeNextFeature() frontend.Data {
+	currentCepstrum := dfe.cepstraBuffer[dfe.currentPosition]
+	mfc := func(offset int) []float64 {
+		return dfe.cepstraBuffer[dfe.currentPosition+offset].Values()
+	}
+	mfc0 := mfc(0)
+	n := len(mfc0)
+
+	var feature []float32
+	switch dfe.featureType {
+	case FEATURE_S2_4X:
+		feature = make([]float32, 0, 4*n-1)
+		// CEP; skip C0
+		for k := 1; k < n; k++ {
+			feature = append(feature, float32(mfc0[k]))
+		}
+		// DCEP: mfc[2] - mfc[-2]; DCEP: mfc[4] - mfc[-4]
+		for k := 1; k < n; k++ {
+			feature = append(feature, float32(mfc(2)[k]-mfc(-2)[k]))
+		}
+		for k := 1; k < n; k++ {
+			feature = append(feature, float32(mfc(4)[k]-mfc(-4)[k]))
+		}
+		// POW: C0, DC0, D2C0
+		feature = append(feature, float32(mfc0[0]), float32(mfc(2)[0]-mfc(-2)[0]),
+			float32((mfc(3)[0]-mfc(-1)[0])-(mfc(1)[0]-mfc(-3)[0])))
+		// D2CEP: (mfc[3] - mfc[-1]) - (mfc[1] - mfc[-3])
+		for k := 1; k < n; k++ {
+			feature = append(feature, float32((mfc(3)[k]-mfc(-1)[k])-(mfc(1)[k]-mfc(-3)[k])))
+		}
+	default:
+		feature = make([]float32, 0, 3*n)
+		// CEP; copy all the cepstrum data
+		for _, val := range mfc0 {
+			feature = append(feature, float32(val))
+		}
+		// DCEP: mfc[2] - mfc[-2]
+		if dfe.featureType == FEATURE_1S_C_D_DD {
+			for k := 0; k < n; k++ {
+				feature = append(feature, float32(mfc(2)[k]-mfc(-2)[k]))
+			}
+		}
+		// D2CEP: (mfc[3] - mfc[-1]) - (mfc[1] - mfc[-3])
+		for k := 0; k < n; k++ {
+			feature = append(feature, float32((mfc(3)[k]-mfc(-1)[k])-(mfc(1)[k]-mfc(-3)[k])))
+		}
+	}
+
+	return frontend.NewFloatDataWithCollectTime(feature, currentCepstrum.SampleRate(),
+		currentCepstrum.CollectTime(), currentCepstrum.FirstSampleNumber())
+}
@@ -0,0 +1,117 @@
+package feature
+
+import (
+	"testing"
+
+	"github.com/jtejido/go-sphinx/frontend"
+)
+
+// features returns the features of a processor, skipping the signals.
+func features(t *testing.T, processor frontend.DataProcessor) [][]float32 {
+	var output [][]float32
+	for _, data := range readAll(t, processor) {
+		if fd, ok := data.(*frontend.FloatData); ok {
+			output = append(output, fd.Values())
+		}
+	}
+	return output
+}
+
+// quadratic returns n cepstra of the given size, c0 being t*t at frame t and the other cepstra constant.
+func quadratic(n, size int) [][]float64 {
+	cepstra := make([][]float64, n)
+	for t := range cepstra {
+		cepstra[t] = make([]float64, size)
+		cepstra[t][0] = float64(t * t)
+		for i := 1; i < size; i++ {
+			cepstra[t][i] = float64(i)
+		}
+	}
+	return cepstra
+}
+
+func TestDeltas(t *testing.T) {
+	dfe := NewDefaultDeltasFeatureExtractor()
+	input := append(utterance(quadratic(10, 2)...), utterance(quadratic(10, 2)...)...)
+	dfe.SetPredecessor(&dataSource{data: input})
+	dfe.Initialize()
+
+	output := readAll(t, dfe)
+	if len(output) != len(input) {
+		t.Fatalf("got %d data, want %d", len(output), len(input))
+	}
+	var frames [][]float32
+	for i, data := range output {
+		switch d := data.(type) {
+		case *frontend.FloatData:
+			frames = append(frames, d.Values())
+		case frontend.Signal:
+			if data != input[i] {
+				t.Fatalf("data %d is %v, want the signal %v", i, data, input[i])
+			}
+		}
+	}
+	if len(frames) != 20 {
+		t.Fatalf("got %d features, want 20", len(frames))
+	}
+
+	for utt := 0; utt < 2; utt++ {
+		for frame := 0; frame < 10; frame++ {
+			feature := frames[10*utt+frame]
+			if len(feature) != 6 || feature[0] != float32(frame*frame) || feature[1] != 1 {
+				t.Fatalf("feature %d of utterance %d is %v", frame, utt, feature)
+			}
+			// the constant cepstrum has no derivatives
+			if feature[3] != 0 || feature[5] != 0 {
+				t.Fatalf("feature %d of utterance %d is %v", frame, utt, feature)
+			}
+		}
+		// (t+2)^2 - (t-2)^2 = 8t, and ((t+3)^2 - (t-1)^2) - ((t+1)^2 - (t-3)^2) = 16 with a full context
+		for frame := 3; frame <= 6; frame++ {
+			if feature := frames[10*utt+frame]; feature[2] != float32(8*frame) || feature[4] != 16 {
+				t.Fatalf("feature %d of utterance %d is %v", frame, utt, feature)
+			}
+		}
+		// the first cepstrum of each utterance is replicated to the left: c(2) - c(0), (c(3) - c(0)) - (c(1) - c(0))
+		if feature := frames[10*utt]; feature[2] != 4 || feature[4] != 8 {
+			t.Fatalf("first feature of utterance %d is %v", utt, feature)
+		}
+	}
+}
+
+func TestDeltasFeatureTypes(t *testing.T) {
+	for featureType, length := range map[string]int{FEATURE_1S_C_D_DD: 39, FEATURE_1S_C_DD: 26, FEATURE_S2_4X: 51} {
+		dfe, err := NewDeltasFeatureExtractor(featureType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		total := 0
+		for _, stream := range dfe.VectorLength(13) {
+			total += stream
+		}
+		if total != length {
+			t.Fatalf("%s streams %v, want %d values", featureType, dfe.VectorLength(13), length)
+		}
+
+		dfe.SetPredecessor(&dataSource{data: utterance(quadratic(10, 13)...)})
+		frames := features(t, dfe)
+		if len(frames) != 10 || len(frames[5]) != length {
+			t.Fatalf("%s gives %d features of %d values, want 10 of %d", featureType, len(frames), len(frames[5]), length)
+		}
+	}
+
+	// s2_4x moves c0 and its derivatives to the third stream
+	dfe, _ := NewDeltasFeatureExtractor(FEATURE_S2_4X)
+	dfe.SetPredecessor(&dataSource{data: utterance(quadratic(10, 13)...)})
+	feature := features(t, dfe)[5]
+	if pow := feature[36:39]; pow[0] != 25 || pow[1] != 40 || pow[2] != 16 {
+		t.Fatalf("power stream is %v, want [25 40 16]", pow)
+	}
+	if feature[0] != 1 || feature[12] != 0 || feature[24] != 0 {
+		t.Fatalf("cepstrum and delta streams are %v", feature[:36])
+	}
+
+	if _, err := NewDeltasFeatureExtractor("1s_12c_12d_3p_12dd"); err == nil {
+		t.Fatal("expected an error for an unsupported feature type")
+	}
+}
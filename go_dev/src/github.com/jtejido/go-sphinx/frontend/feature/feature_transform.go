@@ -0,0 +1,94 @@
+package feature
+
+import (
+	"fmt"
+
+	"github.com/jtejido/go-sphinx/frontend"
+)
+
+/**
+ * Implements a linear feature transformation transformation.
+ *
+ * It might be a dimension reduction or just a decorrelation transform. This component requires a special model
+ * trained with LDA/MLLT transform. Each feature frame x is replaced by the product Ax of the transform matrix A and
+ * the frame, so the output has as many values as the matrix has rows.
+ */
+type FeatureTransform struct {
+	frontend.BaseDataProcessor
+	transform [][]float32
+	rows      int
+	values    int
+}
+
+/**
+ * Constructs a FeatureTransform.
+ *
+ * @param transform the transform matrix, one row per output value
+ * @param rows      the number of rows of the matrix to use, e.g. -ldadim of feat.params; zero or less keeps them all
+ * @throws error if the matrix is empty or ragged
+ */
+func NewFeatureTransform(transform [][]float32, rows int) (*FeatureTransform, error) {
+	if len(transform) == 0 || len(transform[0]) == 0 {
+		return nil, fmt.Errorf("feature transform matrix is empty")
+	}
+	if rows <= 0 || rows > len(transform) {
+		rows = len(transform)
+	}
+	values := len(transform[0])
+	for _, row := range transform {
+		if len(row) != values {
+			return nil, fmt.Errorf("feature transform matrix is not rectangular")
+		}
+	}
+	return &FeatureTransform{transform: transform[:rows], rows: rows, values: values}, nil
+}
+
+/**
+ * @return the length of the transformed features
+ */
+func (ft *FeatureTransform) Rows() int {
+	return ft.rows
+}
+
+/**
+ * @return the length of the features the transform applies to
+ */
+func (ft *FeatureTransform) Values() int {
+	return ft.values
+}
+
+/**
+ * Returns the next Data object being processed by this FeatureTransform, or if it is a Signal, it is returned
+ * without modification.
+ *
+ * @return the next available Data object, returns null if no Data object is available
+ * @throws error if there is a problem processing the data or the feature does not fit the transform
+ */
+func (ft *FeatureTransform) GetData() (frontend.Data, error) {
+	data, err := ft.Predecessor().GetData()
+	if err != nil {
+		return nil, err
+	}
+
+	floatData, ok := data.(*frontend.FloatData)
+	if !ok {
+		return data, nil
+	}
+
+	features := floatData.Values()
+	if len(features) != ft.values {
+		return nil, fmt.Errorf("dimension mismatch: feature of length %d, transform of %d", len(features), ft.values)
+	}
+
+	result := make([]float32, ft.rows)
+	for i, row := range ft.transform {
+		var sum float32
+		for j, feature := range features {
+			sum += row[j] * feature
+		}
+		result[i] = sum
+	}
+
+	return frontend.NewFloatDataWithCollectTime(result, floatData.SampleRate(),
+		floatData.CollectTime(), floatData.FirstSampleNumber()), nil
+}
@@ -0,0 +1,200 @@
+package feature
+
+import (
+	"fmt"
+
+	"github.com/jtejido/go-sphinx/frontend"
+)
+
+const (
+	// The default initial cepstral mean, applied to c0 only.
+	DEFAULT_INITIAL_MEAN = 12.0
+
+	// The default size of the window over which the cepstral mean is estimated, in frames.
+	DEFAULT_CMN_WINDOW = 100
+
+	// The default number of frames after which the cepstral mean is updated.
+	DEFAULT_CMN_SHIFT_WINDOW = 160
+)
+
+/**
+ * Subtracts the mean of all the input so far from the Data objects. Unlike the BatchCMN, it does not read in the
+ * entire stream of Data objects before it calculates the mean. It estimates the mean from already seen data and
+ * subtracts the mean from the Data objects on the fly. Therefore, there is no delay introduced by LiveCMN in general.
+ * The only real issue is an initial CMN estimation, for that some amount of frames are read initially and cmn
+ * estimation is calculated from them.
+ *
+ * The properties that affect this processor are defined by the fields cmnWindow and cmnShiftWindow. Please follow
+ * the link "Constant Field Values" below to see the actual name of the Sphinx properties.
+ *
+ * The mean of all the input cepstrum so far is not reestimated for each cepstrum. This mean is recalculated after
+ * every cmnShiftWindow cepstra. This mean is estimated by dividing the sum of all input cepstrum so far. After
+ * obtaining the mean, the sum is exponentially decayed by multiplying it by the ratio: cmnWindow/(cmnWindow +
+ * number of frames since the last recalculation).
+ *
+ * By default the running mean carries over from one utterance to the next, which is what a single speaker talking
+ * to a live recognizer wants. Turn persistence off to restart from the initial mean at every utterance, or save and
+ * restore the mean of each speaker with CurrentMean and SetCurrentMean.
+ *
+ * @see BatchCMN
+ */
+type LiveCMN struct {
+	frontend.BaseDataProcessor
+	initialMean    []float64 // the initial mean, used for the first utterance(s)
+	currentMean    []float64 // array of current means
+	sum            []float64 // array of current sums
+	numberFrame    int       // total number of input Cepstrum
+	cmnShiftWindow int       // # of Cepstrum to recalculate mean
+	cmnWindow      int
+	persistent     bool
+}
+
+/** Constructs a LiveCMN with the default initial mean, window and shift. */
+func NewDefaultLiveCMN() *LiveCMN {
+	return NewLiveCMN([]float64{DEFAULT_INITIAL_MEAN}, DEFAULT_CMN_WINDOW, DEFAULT_CMN_SHIFT_WINDOW)
+}
+
+/**
+ * Constructs a LiveCMN.
+ *
+ * @param initialMean    the initial cepstral mean; missing coefficients default to zero
+ * @param cmnWindow      the size of the window over which the mean is estimated, in frames
+ * @param cmnShiftWindow the number of frames after which the mean is updated
+ */
+func NewLiveCMN(initialMean []float64, cmnWindow, cmnShiftWindow int) *LiveCMN {
+	return &LiveCMN{
+		initialMean:    initialMean,
+		cmnWindow:      cmnWindow,
+		cmnShiftWindow: cmnShiftWindow,
+		persistent:     true,
+	}
+}
+
+/** Initializes this LiveCMN. */
+func (cmn *LiveCMN) Initialize() {
+	cmn.sum = nil
+	cmn.currentMean = nil
+}
+
+/**
+ * @param persistent whether the running mean is kept from one utterance to the next
+ */
+func (cmn *LiveCMN) SetPersistent(persistent bool) {
+	cmn.persistent = persistent
+}
+
+/**
+ * @return a copy of the current cepstral mean, or nil if no cepstrum was normalized yet
+ */
+func (cmn *LiveCMN) CurrentMean() []float64 {
+	if cmn.currentMean == nil {
+		return nil
+	}
+	return append([]float64(nil), cmn.currentMean...)
+}
+
+/**
+ * Restarts the estimation from the given mean, as if a full window of cepstra with that mean had been seen. Use it
+ * to restore the mean saved for a speaker.
+ *
+ * @param mean the cepstral mean
+ */
+func (cmn *LiveCMN) SetCurrentMean(mean []float64) {
+	cmn.initMeansSums(len(mean))
+	copy(cmn.currentMean, mean)
+	for i := range cmn.sum {
+		cmn.sum[i] = cmn.currentMean[i] * float64(cmn.cmnWindow)
+	}
+}
+
+/** Initializes the currentMean and sum arrays with the given cepstrum length. */
+func (cmn *LiveCMN) initMeansSums(cepstrumLength int) {
+	cmn.currentMean = make([]float64, cepstrumLength)
+	copy(cmn.currentMean, cmn.initialMean)
+	cmn.sum = make([]float64, cepstrumLength)
+	for i := range cmn.sum {
+		cmn.sum[i] = cmn.currentMean[i] * float64(cmn.cmnWindow)
+	}
+	cmn.numberFrame = cmn.cmnWindow
+}
+
+/**
+ * Returns the next Data object, which is a normalized Data produced by this class. Signals are returned unmodified.
+ *
+ * @return the next available Data object, returns null if no Data object is available
+ * @throws error if there is a data processing error
+ */
+func (cmn *LiveCMN) GetData() (frontend.Data, error) {
+	input, err := cmn.Predecessor().GetData()
+	if err != nil {
+		return nil, err
+	}
+
+	switch data := input.(type) {
+	case *frontend.DoubleData:
+		if err := cmn.normalize(data); err != nil {
+			return nil, err
+		}
+	case *frontend.DataStartSignal:
+		if !cmn.persistent {
+			cmn.sum = nil
+			cmn.currentMean = nil
+		}
+	case *frontend.DataEndSignal, *frontend.SpeechEndSignal:
+		cmn.updateMeanSumBuffers()
+	}
+
+	return input, nil
+}
+
+/**
+ * Normalizes the given Data with using the currentMean array. Updates the sum array with the given Data.
+ *
+ * @param cepstrumObject the Data object to normalize
+ * @throws error if the cepstrum length changed
+ */
+func (cmn *LiveCMN) normalize(cepstrumObject *frontend.DoubleData) error {
+	cepstrum := cepstrumObject.Values()
+
+	if cmn.sum == nil {
+		cmn.initMeansSums(len(cepstrum))
+	} else if len(cmn.sum) != len(cepstrum) {
+		return fmt.Errorf("inconsistent cepstrum lengths: sum: %d, cepstrum: %d", len(cmn.sum), len(cepstrum))
+	}
+
+	for j := range cepstrum {
+		cmn.sum[j] += cepstrum[j]
+		cepstrum[j] -= cmn.currentMean[j]
+	}
+
+	cmn.numberFrame++
+
+	if cmn.numberFrame > cmn.cmnShiftWindow {
+		cmn.updateMeanSumBuffers()
+	}
+	return nil
+}
+
+/**
+ * Updates the currentMean buffer with the values in the sum buffer. Then decay the sum buffer exponentially, i.e.,
+ * divide the sum with numberFrames.
+ */
+func (cmn *LiveCMN) updateMeanSumBuffers() {
+	if cmn.numberFrame <= 0 || cmn.sum == nil {
+		return
+	}
+
+	// update the currentMean buffer with the sum buffer
+	sf := 1.0 / float64(cmn.numberFrame)
+	for i := range cmn.sum {
+		cmn.currentMean[i] = cmn.sum[i] * sf
+	}
+
+	// decay the sum buffer exponentially
+	if cmn.numberFrame >= cmn.cmnShiftWindow {
+		for i := range cmn.sum {
+			cmn.sum[i] *= sf * float64(cmn.cmnWindow)
+		}
+		cmn.numberFrame = cmn.cmnWindow
+	}
+}
@@ -0,0 +1,45 @@
+package feature
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLiveCMNWindow(t *testing.T) {
+	// the initial mean counts as 4 frames of c0 = 10, and the mean is updated once more than 6 frames were counted
+	cmn := NewLiveCMN([]float64{10}, 4, 6)
+	frames := [][]float64{{20, 2}, {20, 2}, {20, 2}, {20, 2}}
+	cmn.SetPredecessor(&dataSource{data: utterance(frames...)})
+	cmn.Initialize()
+
+	output := readAll(t, cmn)
+	// the third frame brings the sums to (100, 6) over 7 frames, after it is normalized
+	checkCepstra(t, output, []float64{10, 2}, []float64{10, 2}, []float64{10, 2}, []float64{20 - 100.0/7, 2 - 6.0/7})
+
+	// the update decayed the sums to a window of 4 frames, the fourth frame makes 5 of them
+	mean := cmn.CurrentMean()
+	expected := []float64{(100.0/7*4 + 20) / 5, (6.0/7*4 + 2) / 5}
+	for i := range expected {
+		if math.Abs(mean[i]-expected[i]) > 1e-9 {
+			t.Fatalf("mean after the utterance is %v, want %v", mean, expected)
+		}
+	}
+
+	// the mean carries over to the next utterance
+	cmn.SetPredecessor(&dataSource{data: utterance([]float64{20, 2})})
+	checkCepstra(t, readAll(t, cmn), []float64{20 - expected[0], 2 - expected[1]})
+}
+
+func TestLiveCMNNotPersistent(t *testing.T) {
+	cmn := NewLiveCMN([]float64{10}, 4, 6)
+	cmn.SetPersistent(false)
+	cmn.SetPredecessor(&dataSource{data: append(utterance([]float64{30, 4}), utterance([]float64{30, 4})...)})
+	cmn.Initialize()
+	checkCepstra(t, readAll(t, cmn), []float64{20, 4}, []float64{20, 4})
+
+	// a saved mean is restored as a full window
+	cmn.SetPersistent(true)
+	cmn.SetCurrentMean([]float64{5, 1})
+	cmn.SetPredecessor(&dataSource{data: utterance([]float64{30, 4})})
+	checkCepstra(t, readAll(t, cmn), []float64{25, 3})
+}
@@ -0,0 +1,95 @@
+package filter
+
+import (
+	"math/rand"
+
+	"github.com/jtejido/go-sphinx/frontend"
+)
+
+const (
+	// The default maximum amplitude of the dithering noise, in 16-bit sample units.
+	DEFAULT_MAX_DITHER = 2.0
+
+	// The default seed of the dithering noise.
+	DEFAULT_DITHER_SEED = 12345
+)
+
+/**
+ * Implements a dither for the incoming audio data. Dithering adds a small amount of uniform random noise, between
+ * -maxDither and maxDither, to every sample. It avoids zero energy frames in digitally silent audio, whose logarithm
+ * would otherwise dominate the cepstra, and masks quantization artifacts of low level recordings.
+ *
+ * The noise generator is seeded again at each DataStartSignal, so that the same audio always yields the same
+ * features. Other Data objects are passed along unchanged.
+ */
+type Dither struct {
+	frontend.BaseDataProcessor
+	maxDither float64
+	seed      int64
+	random    *rand.Rand
+}
+
+/** Constructs a Dither with the default amplitude and seed. */
+func NewDefaultDither() *Dither {
+	return NewDither(DEFAULT_MAX_DITHER, DEFAULT_DITHER_SEED)
+}
+
+/**
+ * Constructs a Dither.
+ *
+ * @param maxDither the maximum amplitude of the noise
+ * @param seed      the seed of the noise generator
+ */
+func NewDither(maxDither float64, seed int64) *Dither {
+	return &Dither{
+		maxDither: maxDither,
+		seed:      seed,
+		random:    rand.New(rand.NewSource(seed)),
+	}
+}
+
+func (d *Dither) Initialize() {
+	d.random.Seed(d.seed)
+}
+
+/** @return the maximum amplitude of the noise */
+func (d *Dither) MaxDither() float64 {
+	return d.maxDither
+}
+
+/**
+ * Returns the next Data object being processed by this Dither, or if it is a Signal, it is returned without
+ * modification.
+ *
+ * @return the next available Data object, returns null if no Data object is available
+ * @throws error if there is a problem processing the data
+ */
+func (d *Dither) GetData() (frontend.Data, error) {
+	input, err := d.Predecessor().GetData()
+	if err != nil {
+		return nil, err
+	}
+
+	switch data := input.(type) {
+	case *frontend.DoubleData:
+		d.applyDither(data.Values())
+	case *frontend.DataStartSignal:
+		d.random.Seed(d.seed)
+	}
+
+	return input, nil
+}
+
+/**
+ * Adds the dithering noise to the given audio, in place.
+ *
+ * @param in audio data
+ */
+func (d *Dither) applyDither(in []float64) {
+	if d.maxDither == 0 {
+		return
+	}
+	for i := range in {
+		in[i] += d.maxDither * (2*d.random.Float64() - 1)
+	}
+}
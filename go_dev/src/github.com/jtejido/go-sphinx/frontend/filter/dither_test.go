@@ -0,0 +1,46 @@
+package filter
+
+import (
+	"math"
+	"testing"
+
+	"github.com/jtejido/go-sphinx/frontend"
+)
+
+func TestDitherIsReproducible(t *testing.T) {
+	dithered := func() []float64 {
+		d := NewDefaultDither()
+		d.SetPredecessor(&sineSource{sampleRate: 16000, count: 4000, blockSize: 1000, frequency: 1000})
+		var output []float64
+		for {
+			data, err := d.GetData()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if data == nil {
+				return output
+			}
+			if dd, ok := data.(*frontend.DoubleData); ok {
+				output = append(output, dd.Values()...)
+			}
+		}
+	}
+
+	first, second := dithered(), dithered()
+	var changed bool
+	for i := range first {
+		clean := 1000 * math.Sin(2*math.Pi*1000*float64(i)/16000)
+		if math.Abs(first[i]-clean) > DEFAULT_MAX_DITHER {
+			t.Fatalf("sample %d dithered by %v", i, first[i]-clean)
+		}
+		if first[i] != clean {
+			changed = true
+		}
+		if first[i] != second[i] {
+			t.Fatalf("sample %d dithered to %v, then to %v", i, first[i], second[i])
+		}
+	}
+	if !changed {
+		t.Fatal("no dithering noise added")
+	}
+}
@@ -0,0 +1,89 @@
+package filter
+
+import (
+	"github.com/jtejido/go-sphinx/frontend"
+)
+
+const (
+	// The default preemphasis factor.
+	DEFAULT_PREEMPHASIS_FACTOR = 0.97
+)
+
+/**
+ * Implements a high-pass filter that compensates for attenuation in the audio data. Speech signals have an attenuation
+ * (a decrease in intensity of a signal) of 20 dB/dec. It increases the relative magnitude of the higher frequencies
+ * with respect to the lower frequencies.
+ *
+ * The Preemphasizer takes a DoubleData object that usually represents audio data as input, and outputs the same
+ * DoubleData object, but with preemphasis applied. For each value X[i] in the input Data object X, the following
+ * formula is applied to obtain the output Data object Y:
+ *
+ * Y[i] = X[i] - (X[i-1] * preemphasisFactor)
+ *
+ * where 'i' denotes time. The preemphasis factor has a value defined by the field preemphasisFactor. A common value
+ * for this factor is something around 0.97. Other Data objects are passed along unchanged through this Preemphasizer.
+ * The last sample of a block is remembered as the prior of the next block, and forgotten at the end of an utterance.
+ */
+type Preemphasizer struct {
+	frontend.BaseDataProcessor
+	preemphasisFactor float64
+	prior             float64
+}
+
+/** Constructs a Preemphasizer with the default preemphasis factor. */
+func NewDefaultPreemphasizer() *Preemphasizer {
+	return NewPreemphasizer(DEFAULT_PREEMPHASIS_FACTOR)
+}
+
+/**
+ * Constructs a Preemphasizer.
+ *
+ * @param preemphasisFactor the preemphasis factor, zero disables preemphasis
+ */
+func NewPreemphasizer(preemphasisFactor float64) *Preemphasizer {
+	return &Preemphasizer{preemphasisFactor: preemphasisFactor}
+}
+
+/**
+ * Returns the next Data object being processed by this Preemphasizer, or if it is a Signal, it is returned without
+ * modification.
+ *
+ * @return the next available Data object, returns null if no Data object is available
+ * @throws error if there is a problem processing the data
+ */
+func (p *Preemphasizer) GetData() (frontend.Data, error) {
+	input, err := p.Predecessor().GetData()
+	if err != nil {
+		return nil, err
+	}
+
+	switch data := input.(type) {
+	case *frontend.DoubleData:
+		p.applyPreemphasis(data.Values())
+	case *frontend.DataEndSignal, *frontend.SpeechEndSignal:
+		p.prior = 0
+	}
+
+	return input, nil
+}
+
+/**
+ * Applies pre-emphasis filter to the given Audio. The preemphasis is applied in place.
+ *
+ * @param in audio data
+ */
+func (p *Preemphasizer) applyPreemphasis(in []float64) {
+	// set the prior value for the next Audio
+	if len(in) == 0 || p.preemphasisFactor == 0.0 {
+		return
+	}
+
+	previous := in[0]
+	in[0] = previous - p.preemphasisFactor*p.prior
+	for i := 1; i < len(in); i++ {
+		current := in[i]
+		in[i] = current - p.preemphasisFactor*previous
+		previous = current
+	}
+	p.prior = previous
+}
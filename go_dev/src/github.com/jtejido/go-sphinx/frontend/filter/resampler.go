@@ -0,0 +1,300 @@
+package filter
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/jtejido/go-sphinx/frontend"
+)
+
+const (
+	// The default number of zero crossings of the interpolation kernel on each side of its center.
+	DEFAULT_ZERO_CROSSINGS = 16
+
+	// The default cutoff of the anti-aliasing filter, relative to the lower of the two Nyquist frequencies.
+	DEFAULT_ROLLOFF = 0.95
+
+	// The default shape parameter of the Kaiser window of the kernel.
+	DEFAULT_KAISER_BETA = 8.0
+
+	// The largest interpolation factor, after reduction of the two rates, for which a filter table is built.
+	MAX_PHASES = 4096
+)
+
+/**
+ * Converts audio to a target sample rate with a polyphase windowed-sinc filter, so that recordings made at 8, 44.1 or
+ * 48 kHz can be decoded against models trained at another rate.
+ *
+ * The ratio of the two rates is reduced to L/M. Each output sample is the sum of the input samples around its
+ * position, weighted by a Kaiser windowed sinc kernel whose cutoff is just below the lower of the two Nyquist
+ * frequencies, which both interpolates and removes aliasing. The kernel is tabulated for the L possible phases of the
+ * output samples relative to the input samples.
+ *
+ * Audio that is already at the target rate passes through unchanged, so the Resampler can always be part of the
+ * front end. DataStartSignals are replaced with signals carrying the target rate. Each utterance is resampled on its
+ * own, the filter state is flushed at every DataEndSignal.
+ */
+type Resampler struct {
+	frontend.BaseDataProcessor
+	targetSampleRate int
+	zeroCrossings    int
+	rolloff          float64
+	beta             float64
+
+	sourceSampleRate int
+	upFactor         int64 // L
+	downFactor       int64 // M
+	halfWidth        int   // H, the number of input samples on each side of an output sample
+	coefficients     [][]float64
+
+	buffer      []float64 // input samples from bufferStart on
+	bufferStart int64
+	inputCount  int64
+	outputCount int64
+	outputQueue []frontend.Data
+}
+
+/**
+ * Constructs a Resampler with the default filter quality.
+ *
+ * @param targetSampleRate the sample rate of the output, in Hz
+ */
+func NewDefaultResampler(targetSampleRate int) *Resampler {
+	return NewResampler(targetSampleRate, DEFAULT_ZERO_CROSSINGS, DEFAULT_ROLLOFF, DEFAULT_KAISER_BETA)
+}
+
+/**
+ * Constructs a Resampler.
+ *
+ * @param targetSampleRate the sample rate of the output, in Hz
+ * @param zeroCrossings    the number of zero crossings of the kernel on each side of its center
+ * @param rolloff          the cutoff of the filter, relative to the lower of the two Nyquist frequencies
+ * @param beta             the shape parameter of the Kaiser window
+ */
+func NewResampler(targetSampleRate, zeroCrossings int, rolloff, beta float64) *Resampler {
+	return &Resampler{
+		targetSampleRate: targetSampleRate,
+		zeroCrossings:    zeroCrossings,
+		rolloff:          rolloff,
+		beta:             beta,
+	}
+}
+
+func (r *Resampler) Initialize() {
+	r.sourceSampleRate = 0
+	r.outputQueue = nil
+	r.reset()
+}
+
+/** @return the sample rate of the output, in Hz */
+func (r *Resampler) TargetSampleRate() int {
+	return r.targetSampleRate
+}
+
+func (r *Resampler) reset() {
+	r.buffer = nil
+	r.bufferStart = 0
+	r.inputCount = 0
+	r.outputCount = 0
+}
+
+/**
+ * Returns the next Data object, which is a block of resampled audio, or a Signal.
+ *
+ * @return the next available Data object, returns null if no Data object is available
+ * @throws error if the sample rate ratio is not supported
+ */
+func (r *Resampler) GetData() (frontend.Data, error) {
+	for len(r.outputQueue) == 0 {
+		input, err := r.Predecessor().GetData()
+		if err != nil {
+			return nil, err
+		}
+
+		switch data := input.(type) {
+		case nil:
+			r.flush()
+			if len(r.outputQueue) == 0 {
+				return nil, nil
+			}
+		case *frontend.DataStartSignal:
+			r.reset()
+			if err := r.setSourceSampleRate(data.SampleRate()); err != nil {
+				return nil, err
+			}
+			if data.SampleRate() != r.targetSampleRate {
+				input = frontend.NewDataStartSignal(r.targetSampleRate, data.Time())
+			}
+			r.outputQueue = append(r.outputQueue, input)
+		case *frontend.DoubleData:
+			if data.SampleRate() != r.sourceSampleRate {
+				if err := r.setSourceSampleRate(data.SampleRate()); err != nil {
+					return nil, err
+				}
+			}
+			if r.isPassThrough() {
+				return input, nil
+			}
+			r.process(data.Values(), false)
+		case *frontend.DataEndSignal:
+			r.flush()
+			r.reset()
+			r.outputQueue = append(r.outputQueue, input)
+		default:
+			r.outputQueue = append(r.outputQueue, input)
+		}
+	}
+
+	output := r.outputQueue[0]
+	r.outputQueue = r.outputQueue[1:]
+	return output, nil
+}
+
+func (r *Resampler) isPassThrough() bool {
+	return r.sourceSampleRate == r.targetSampleRate || r.sourceSampleRate <= 0
+}
+
+/**
+ * Builds the polyphase filter table for the given input sample rate.
+ *
+ * @param sampleRate the sample rate of the input, in Hz
+ * @throws error if the ratio of the two rates needs too many phases
+ */
+func (r *Resampler) setSourceSampleRate(sampleRate int) error {
+	if sampleRate == r.sourceSampleRate && (r.coefficients != nil || r.isPassThrough()) {
+		return nil
+	}
+	r.sourceSampleRate = sampleRate
+	r.coefficients = nil
+	if r.isPassThrough() {
+		return nil
+	}
+
+	g := gcd(int64(r.targetSampleRate), int64(sampleRate))
+	r.upFactor = int64(r.targetSampleRate) / g
+	r.downFactor = int64(sampleRate) / g
+	if r.upFactor > MAX_PHASES {
+		return fmt.Errorf("cannot resample from %dHz to %dHz: the ratio needs %d filter phases",
+			sampleRate, r.targetSampleRate, r.upFactor)
+	}
+
+	// cutoff in cycles per input sample
+	cutoff := 0.5 * r.rolloff * math.Min(1, float64(r.upFactor)/float64(r.downFactor))
+	r.halfWidth = int(math.Ceil(float64(r.zeroCrossings) / (2 * cutoff)))
+
+	// coefficients[phase][j] weighs the input sample i0 - H + 1 + j for an output sample at position i0 + phase / L
+	r.coefficients = make([][]float64, r.upFactor)
+	for phase := range r.coefficients {
+		taps := make([]float64, 2*r.halfWidth)
+		for j := range taps {
+			// distance between the output sample and the input sample, in input samples
+			t := float64(phase)/float64(r.upFactor) + float64(r.halfWidth-1-j)
+			taps[j] = 2 * cutoff * sinc(2*cutoff*t) * r.kaiser(t/float64(r.halfWidth))
+		}
+		r.coefficients[phase] = taps
+	}
+	return nil
+}
+
+/**
+ * Appends the given samples to the input and computes all the output samples that have enough input on their right.
+ * At the end of an utterance the input is padded with silence, and the output is made as long as the input.
+ *
+ * @param samples the input samples
+ * @param end     whether this is the end of the utterance
+ */
+func (r *Resampler) process(samples []float64, end bool) {
+	r.buffer = append(r.buffer, samples...)
+	r.inputCount += int64(len(samples))
+	available := r.bufferStart + int64(len(r.buffer))
+	H := int64(r.halfWidth)
+
+	var lastOutput int64 // exclusive
+	if end {
+		lastOutput = (r.inputCount*r.upFactor + r.downFactor - 1) / r.downFactor
+	} else {
+		// the output sample n needs the input up to floor(n * M / L) + H
+		lastOutput = ((available-H)*r.upFactor + r.downFactor - 1) / r.downFactor
+		if available-H < 0 {
+			lastOutput = 0
+		}
+	}
+
+	var output []float64
+	firstSampleNumber := r.outputCount
+	for ; r.outputCount < lastOutput; r.outputCount++ {
+		position := r.outputCount * r.downFactor
+		i0 := position / r.upFactor
+		taps := r.coefficients[position%r.upFactor]
+
+		var sum float64
+		for j, weight := range taps {
+			i := i0 - H + 1 + int64(j) - r.bufferStart
+			if i >= 0 && i < int64(len(r.buffer)) {
+				sum += weight * r.buffer[i]
+			}
+		}
+		output = append(output, sum)
+	}
+
+	// keep the input still needed by the next output samples
+	nextFirstInput := (r.outputCount*r.downFactor)/r.upFactor - H + 1
+	if drop := nextFirstInput - r.bufferStart; drop > 0 {
+		if drop > int64(len(r.buffer)) {
+			drop = int64(len(r.buffer))
+		}
+		r.buffer = append(r.buffer[:0], r.buffer[drop:]...)
+		r.bufferStart += drop
+	}
+
+	if len(output) > 0 {
+		r.outputQueue = append(r.outputQueue, frontend.NewDoubleDataWithSampleRate(output, r.targetSampleRate, firstSampleNumber))
+	}
+}
+
+/** Computes the output samples left at the end of an utterance. */
+func (r *Resampler) flush() {
+	if r.isPassThrough() || r.coefficients == nil {
+		return
+	}
+	r.process(nil, true)
+	r.buffer = nil
+}
+
+/**
+ * Evaluates the Kaiser window.
+ *
+ * @param x the position in the window, from -1 to 1
+ * @return the window value
+ */
+func (r *Resampler) kaiser(x float64) float64 {
+	if x < -1 || x > 1 {
+		return 0
+	}
+	return besselI0(r.beta*math.Sqrt(1-x*x)) / besselI0(r.beta)
+}
+
+// besselI0 computes the modified Bessel function of the first kind, of order zero, by its power series.
+func besselI0(x float64) float64 {
+	sum, term := 1.0, 1.0
+	halfX := x / 2
+	for k := 1; term > 1e-12*sum; k++ {
+		term *= (halfX / float64(k)) * (halfX / float64(k))
+		sum += term
+	}
+	return sum
+}
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
+
+func gcd(a, b int64) int64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
@@ -0,0 +1,83 @@
+package filter
+
+import (
+	"math"
+	"testing"
+
+	"github.com/jtejido/go-sphinx/frontend"
+)
+
+type sineSource struct {
+	frontend.BaseDataProcessor
+	sampleRate, count, blockSize int
+	frequency                    float64
+	position                     int
+	started, ended               bool
+}
+
+func (s *sineSource) GetData() (frontend.Data, error) {
+	if !s.started {
+		s.started = true
+		return frontend.NewDataStartSignal(s.sampleRate, 0), nil
+	}
+	if s.position >= s.count {
+		if s.ended {
+			return nil, nil
+		}
+		s.ended = true
+		return frontend.NewDataEndSignal(0, 0), nil
+	}
+	n := s.blockSize
+	if s.count-s.position < n {
+		n = s.count - s.position
+	}
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = 1000 * math.Sin(2*math.Pi*s.frequency*float64(s.position+i)/float64(s.sampleRate))
+	}
+	first := s.position
+	s.position += n
+	return frontend.NewDoubleDataWithSampleRate(values, s.sampleRate, int64(first)), nil
+}
+
+func TestResampler(t *testing.T) {
+	for _, sourceRate := range []int{8000, 44100, 48000} {
+		source := &sineSource{sampleRate: sourceRate, count: sourceRate / 2, blockSize: 1000, frequency: 1000}
+		r := NewDefaultResampler(16000)
+		r.SetPredecessor(source)
+		r.Initialize()
+
+		var output []float64
+		for {
+			data, err := r.GetData()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if data == nil {
+				break
+			}
+			switch d := data.(type) {
+			case *frontend.DataStartSignal:
+				if d.SampleRate() != 16000 {
+					t.Fatalf("%dHz: start signal at %dHz", sourceRate, d.SampleRate())
+				}
+			case *frontend.DoubleData:
+				if d.FirstSampleNumber() != int64(len(output)) {
+					t.Fatalf("%dHz: block starts at %d, want %d", sourceRate, d.FirstSampleNumber(), len(output))
+				}
+				output = append(output, d.Values()...)
+			}
+		}
+
+		if len(output) != 8000 {
+			t.Fatalf("%dHz: got %d samples, want 8000", sourceRate, len(output))
+		}
+		// away from the edges, the output is the same sine sampled at 16kHz
+		for i := 200; i < len(output)-200; i++ {
+			want := 1000 * math.Sin(2*math.Pi*1000*float64(i)/16000)
+			if math.Abs(output[i]-want) > 5 {
+				t.Fatalf("%dHz: sample %d is %v, want %v", sourceRate, i, output[i], want)
+			}
+		}
+	}
+}
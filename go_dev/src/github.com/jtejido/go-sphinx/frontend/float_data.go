@@ -1,5 +1,7 @@
 package frontend
 
+import "fmt"
+
 /**
  * A Data object that holds data of primitive type float.
  *
@@ -47,3 +49,31 @@ func NewFloatDataWithCollectTime(values []float32, sampleRate int,
 func (d *FloatData) Values() []float32 {
 	return d.values
 }
+
+/**
+ * @return the sample rate of the data.
+ */
+func (d *FloatData) SampleRate() int {
+	return d.sampleRate
+}
+
+/**
+ * @return the position of the first sample in the original data. The very first sample number is zero.
+ */
+func (d *FloatData) FirstSampleNumber() int64 {
+	return d.firstSampleNumber
+}
+
+/**
+ * Returns the time in milliseconds at which the audio data is collected.
+ *
+ * @return the difference, in milliseconds, between the time the audio data is collected and midnight, January 1,
+ *         1970
+ */
+func (d *FloatData) CollectTime() int64 {
+	return d.collectTime
+}
+
+func (d *FloatData) String() string {
+	return fmt.Sprintf("FloatData: %dHz, first sample #: %d, collect time: %d", d.sampleRate, d.firstSampleNumber, d.collectTime)
+}
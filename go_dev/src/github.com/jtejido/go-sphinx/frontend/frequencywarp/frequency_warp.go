@@ -0,0 +1,128 @@
+package frequencywarp
+
+import "fmt"
+
+const (
+	// The default cutoff of the piecewise linear warp, as a fraction of the Nyquist frequency.
+	DEFAULT_WARP_CUTOFF = 0.85
+)
+
+/**
+ * Warps the frequency axis of a filterbank, for vocal tract length normalization (VTLN). A frequency of the speaker
+ * is mapped to the frequency the reference speaker would have produced it at, so that a filterbank built on the
+ * warped axis compensates for the length of the vocal tract of the speaker. Both mappings must be monotonic and
+ * inverse of each other.
+ */
+type FrequencyWarp interface {
+	/**
+	 * @param freq    the frequency of the speaker, in Hertz
+	 * @param nyquist the Nyquist frequency of the audio, in Hertz
+	 * @return the warped frequency
+	 */
+	Warp(freq, nyquist float64) float64
+
+	/**
+	 * @param freq    the warped frequency, in Hertz
+	 * @param nyquist the Nyquist frequency of the audio, in Hertz
+	 * @return the frequency of the speaker
+	 */
+	Unwarp(freq, nyquist float64) float64
+}
+
+/**
+ * An affine frequency warp, f' = a * f + b, the "affine" -warp_type of sphinxbase.
+ */
+type AffineWarp struct {
+	a, b float64
+}
+
+/**
+ * Constructs an AffineWarp.
+ *
+ * @param a the slope of the warp
+ * @param b the offset of the warp, in Hertz
+ * @throws error if the slope is not positive
+ */
+func NewAffineWarp(a, b float64) (*AffineWarp, error) {
+	if a <= 0 {
+		return nil, fmt.Errorf("slope of an affine warp must be positive, got %v", a)
+	}
+	return &AffineWarp{a: a, b: b}, nil
+}
+
+func (w *AffineWarp) Warp(freq, nyquist float64) float64 {
+	return w.a*freq + w.b
+}
+
+func (w *AffineWarp) Unwarp(freq, nyquist float64) float64 {
+	return (freq - w.b) / w.a
+}
+
+func (w *AffineWarp) String() string {
+	return fmt.Sprintf("affine %v %v", w.a, w.b)
+}
+
+/**
+ * A piecewise linear frequency warp, the "piecewise_linear" -warp_type of sphinxbase and the usual VTLN warp:
+ * frequencies below the cutoff are scaled by the warp factor, the segment above the cutoff is bent so that the
+ * Nyquist frequency maps to itself. If the warped cutoff would pass the Nyquist frequency, the cutoff is lowered to
+ * DEFAULT_WARP_CUTOFF of the Nyquist frequency divided by the warp factor.
+ */
+type PiecewiseLinearWarp struct {
+	factor, cutoff float64
+}
+
+/**
+ * Constructs a PiecewiseLinearWarp.
+ *
+ * @param factor the warp factor, below 1 for speakers with a shorter vocal tract than the reference, such as children
+ * @param cutoff the frequency where the warp bends, in Hertz, or 0 for DEFAULT_WARP_CUTOFF of the Nyquist frequency
+ * @throws error if the warp factor is not positive or the cutoff negative
+ */
+func NewPiecewiseLinearWarp(factor, cutoff float64) (*PiecewiseLinearWarp, error) {
+	if factor <= 0 {
+		return nil, fmt.Errorf("warp factor must be positive, got %v", factor)
+	}
+	if cutoff < 0 {
+		return nil, fmt.Errorf("warp cutoff must not be negative, got %v", cutoff)
+	}
+	return &PiecewiseLinearWarp{factor: factor, cutoff: cutoff}, nil
+}
+
+/** @return the warp factor */
+func (w *PiecewiseLinearWarp) Factor() float64 {
+	return w.factor
+}
+
+func (w *PiecewiseLinearWarp) cutoffOf(nyquist float64) float64 {
+	cutoff := w.cutoff
+	if cutoff == 0 {
+		cutoff = DEFAULT_WARP_CUTOFF * nyquist
+	}
+	if cutoff*w.factor >= nyquist || cutoff >= nyquist {
+		cutoff = DEFAULT_WARP_CUTOFF * nyquist / w.factor
+	}
+	return cutoff
+}
+
+func (w *PiecewiseLinearWarp) Warp(freq, nyquist float64) float64 {
+	cutoff := w.cutoffOf(nyquist)
+	if freq <= cutoff {
+		return w.factor * freq
+	}
+	warpedCutoff := w.factor * cutoff
+	return warpedCutoff + (freq-cutoff)*(nyquist-warpedCutoff)/(nyquist-cutoff)
+}
+
+func (w *PiecewiseLinearWarp) Unwarp(freq, nyquist float64) float64 {
+	cutoff := w.cutoffOf(nyquist)
+	warpedCutoff := w.factor * cutoff
+	if freq <= warpedCutoff {
+		return freq / w.factor
+	}
+	return cutoff + (freq-warpedCutoff)*(nyquist-cutoff)/(nyquist-warpedCutoff)
+}
+
+func (w *PiecewiseLinearWarp) String() string {
+	return fmt.Sprintf("piecewise_linear %v %v", w.factor, w.cutoff)
+}
@@ -0,0 +1,82 @@
+package frequencywarp
+
+import (
+	"math"
+	"testing"
+
+	"github.com/jtejido/go-sphinx/frontend"
+)
+
+func TestPiecewiseLinearWarp(t *testing.T) {
+	const nyquist = 8000.0
+	for _, factor := range []float64{0.8, 1, 1.2, 1.4} {
+		warp, err := NewPiecewiseLinearWarp(factor, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := warp.Warp(nyquist, nyquist); math.Abs(got-nyquist) > 1e-9 {
+			t.Fatalf("factor %v maps the Nyquist frequency to %v", factor, got)
+		}
+		if got := warp.Warp(1000, nyquist); math.Abs(got-1000*factor) > 1e-9 {
+			t.Fatalf("factor %v maps 1000Hz to %v", factor, got)
+		}
+		previous := -1.0
+		for f := 0.0; f <= nyquist; f += 50 {
+			warped := warp.Warp(f, nyquist)
+			if warped <= previous {
+				t.Fatalf("factor %v is not monotonic at %vHz", factor, f)
+			}
+			previous = warped
+			if back := warp.Unwarp(warped, nyquist); math.Abs(back-f) > 1e-6 {
+				t.Fatalf("factor %v unwarps %vHz to %v", factor, f, back)
+			}
+		}
+	}
+}
+
+func TestWarpedFilterBank(t *testing.T) {
+	filterBank := func(warp FrequencyWarp) [][]float64 {
+		fb := NewDefaultMelFrequencyFilterBank2()
+		fb.SetFrequencyWarp(warp)
+		if err := fb.buildFilterbank(512, 16000); err != nil {
+			t.Fatal(err)
+		}
+		return fb.filterWeights
+	}
+
+	neutral, _ := NewPiecewiseLinearWarp(1, 0)
+	plain, warped := filterBank(nil), filterBank(neutral)
+	for i := range plain {
+		for j := range plain[i] {
+			if len(plain[i]) != len(warped[i]) || math.Abs(plain[i][j]-warped[i][j]) > 1e-12 {
+				t.Fatalf("filter %d changed with a warp factor of 1", i)
+			}
+		}
+	}
+
+	// a speaker with a shorter vocal tract has the same formants higher up: the filters move up in frequency
+	fb := NewDefaultMelFrequencyFilterBank2()
+	warp, _ := NewPiecewiseLinearWarp(0.8, 0)
+	fb.SetFrequencyWarp(warp)
+	spectrum := make([]float64, 257)
+	spectrum[64] = 1 // 2kHz
+	output, err := fb.process(frontend.NewDoubleDataWithSampleRate(spectrum, 16000, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	unwarped := NewDefaultMelFrequencyFilterBank2()
+	reference, _ := unwarped.process(frontend.NewDoubleDataWithSampleRate(spectrum, 16000, 0))
+	if peak(output.Values()) >= peak(reference.Values()) {
+		t.Fatalf("2kHz peaks in filter %d with a warp factor of 0.8, %d without", peak(output.Values()), peak(reference.Values()))
+	}
+}
+
+func peak(values []float64) int {
+	best := 0
+	for i, v := range values {
+		if v > values[best] {
+			best = i
+		}
+	}
+	return best
+}
@@ -0,0 +1,24 @@
+package frequencywarp
+
+import "math"
+
+/**
+ * Converts a frequency from Hertz to the Bark scale, the critical band rate scale of the PLP front end:
+ * bark = 6 * asinh(f / 600).
+ *
+ * @param hertz the frequency in Hertz
+ * @return the frequency in Bark
+ */
+func HertzToBark(hertz float64) float64 {
+	return 6.0 * math.Asinh(hertz/600.0)
+}
+
+/**
+ * Converts a frequency from the Bark scale to Hertz, the inverse of HertzToBark.
+ *
+ * @param bark the frequency in Bark
+ * @return the frequency in Hertz
+ */
+func BarkToHertz(bark float64) float64 {
+	return 600.0 * math.Sinh(bark/6.0)
+}
@@ -0,0 +1,119 @@
+package frequencywarp
+
+import (
+	"fmt"
+	"math"
+)
+
+/**
+ * Computes the linear prediction (LPC) model of a signal from its autocorrelation, with the Levinson-Durbin
+ * recursion, and the cepstrum of that all-pole model.
+ *
+ * The prediction error filter is A(z) = a[0] + a[1] z^-1 + ... + a[p] z^-p with a[0] = 1, so that the model of the
+ * signal is G / A(z), G^2 being the prediction error.
+ */
+type LinearPredictor struct {
+	order            int
+	arParameters     []float64
+	reflectionCoeffs []float64
+	predictionError  float64
+}
+
+/**
+ * Constructs a LinearPredictor.
+ *
+ * @param order the order of the model, the number of predictor coefficients
+ */
+func NewLinearPredictor(order int) *LinearPredictor {
+	return &LinearPredictor{order: order}
+}
+
+/** @return the order of the model */
+func (lp *LinearPredictor) Order() int {
+	return lp.order
+}
+
+/**
+ * Computes the prediction error filter of the given autocorrelation with the Levinson-Durbin recursion. Should the
+ * recursion become unstable, which only happens through rounding errors, the remaining coefficients are left at
+ * zero.
+ *
+ * @param autocor the autocorrelation of the signal, at least order + 1 lags
+ * @return the coefficients of A(z), a[0] being 1
+ * @throws error if the autocorrelation is too short or the signal has no energy
+ */
+func (lp *LinearPredictor) ARFilter(autocor []float64) ([]float64, error) {
+	if len(autocor) <= lp.order {
+		return nil, fmt.Errorf("autocorrelation of %d lags for a model of order %d", len(autocor), lp.order)
+	}
+	if autocor[0] <= 0 {
+		return nil, fmt.Errorf("autocorrelation of a signal without energy")
+	}
+
+	lp.arParameters = make([]float64, lp.order+1)
+	lp.reflectionCoeffs = make([]float64, lp.order+1)
+	backward := make([]float64, lp.order+1)
+	lp.arParameters[0] = 1
+	lp.predictionError = autocor[0]
+
+	for i := 1; i <= lp.order; i++ {
+		var acc float64
+		for j := 0; j < i; j++ {
+			acc += lp.arParameters[j] * autocor[i-j]
+		}
+		k := -acc / lp.predictionError
+		if k <= -1 || k >= 1 {
+			break
+		}
+		lp.reflectionCoeffs[i] = k
+
+		copy(backward, lp.arParameters[:i+1])
+		for j := 1; j <= i; j++ {
+			lp.arParameters[j] += k * backward[i-j]
+		}
+		lp.predictionError *= 1 - k*k
+	}
+	return lp.arParameters, nil
+}
+
+/** @return the reflection coefficients of the last ARFilter, k[0] being unused */
+func (lp *LinearPredictor) ReflectionCoefficients() []float64 {
+	return lp.reflectionCoeffs
+}
+
+/** @return the prediction error of the last ARFilter, the squared gain of the model */
+func (lp *LinearPredictor) PredictionError() float64 {
+	return lp.predictionError
+}
+
+/**
+ * Computes the cepstrum of the model of the last ARFilter with the recursion
+ *
+ * c[n] = -a[n] - sum_{k=1}^{n-1} (k / n) * c[k] * a[n-k],
+ *
+ * where a[n] is zero above the order of the model. c[0] is the logarithm of the prediction error.
+ *
+ * @param cepstrumLength the number of cepstra, c[0] included
+ * @return the cepstrum
+ */
+func (lp *LinearPredictor) Cepstrum(cepstrumLength int) []float64 {
+	cepstrum := make([]float64, cepstrumLength)
+	if cepstrumLength == 0 || lp.arParameters == nil {
+		return cepstrum
+	}
+	cepstrum[0] = math.Log(lp.predictionError)
+
+	for n := 1; n < cepstrumLength; n++ {
+		var sum float64
+		if n <= lp.order {
+			sum = lp.arParameters[n]
+		}
+		for k := 1; k < n; k++ {
+			if n-k <= lp.order {
+				sum += float64(k) / float64(n) * cepstrum[k] * lp.arParameters[n-k]
+			}
+		}
+		cepstrum[n] = -sum
+	}
+	return cepstrum
+}
@@ -0,0 +1,48 @@
+package frequencywarp
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+func TestLinearPredictorAR2(t *testing.T) {
+	// autocorrelation of x[n] = 1.3 x[n-1] - 0.6 x[n-2] + e[n]
+	autocor := []float64{1, 1.3 / 1.6, 0, 0}
+	for k := 2; k < len(autocor); k++ {
+		autocor[k] = 1.3*autocor[k-1] - 0.6*autocor[k-2]
+	}
+
+	lp := NewLinearPredictor(3)
+	a, err := lp.ARFilter(autocor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []float64{1, -1.3, 0.6, 0} {
+		if math.Abs(a[i]-want) > 1e-9 {
+			t.Fatalf("a[%d] = %v, want %v", i, a[i], want)
+		}
+	}
+
+	// the cepstrum of 1/A(z) is the cosine series of -log|A|
+	cepstrum := lp.Cepstrum(8)
+	if math.Abs(cepstrum[0]-math.Log(lp.PredictionError())) > 1e-12 {
+		t.Fatalf("c[0] = %v, want the log of the prediction error %v", cepstrum[0], math.Log(lp.PredictionError()))
+	}
+	const steps = 4096
+	for n := 1; n < len(cepstrum); n++ {
+		var want float64
+		for i := 0; i < steps; i++ {
+			w := math.Pi * (float64(i) + 0.5) / steps
+			var response complex128
+			for k, ak := range a {
+				response += complex(ak, 0) * cmplx.Exp(complex(0, -w*float64(k)))
+			}
+			want -= math.Log(cmplx.Abs(response)) * math.Cos(float64(n)*w)
+		}
+		want *= 2.0 / steps
+		if math.Abs(cepstrum[n]-want) > 1e-6 {
+			t.Fatalf("c[%d] = %v, want %v", n, cepstrum[n], want)
+		}
+	}
+}
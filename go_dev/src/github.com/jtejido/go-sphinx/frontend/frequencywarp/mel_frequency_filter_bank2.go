@@ -0,0 +1,251 @@
+package frequencywarp
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/jtejido/go-sphinx/frontend"
+)
+
+const (
+	// The default number of mel filters.
+	DEFAULT_NUMBER_FILTERS = 40
+
+	// The default minimum frequency of the filterbank, in Hertz.
+	DEFAULT_MIN_FREQ = 133.33334
+
+	// The default maximum frequency of the filterbank, in Hertz.
+	DEFAULT_MAX_FREQ = 6855.4976
+)
+
+/**
+ * Filters an input power spectrum through a bank of number of mel-filters. The output of this processor is the mel
+ * spectrum, a vector of as many values as there are filters.
+ *
+ * This filterbank is built the same way as the one in sphinxbase (and thus pocketsphinx and sphinxtrain): the edges
+ * of the triangular filters are equally spaced on the mel scale between the minimum and maximum frequency, the
+ * triangles themselves are linear in Hertz. By default the edges are rounded to the nearest DFT point and each
+ * triangle is normalized to unit area, matching -round_filters yes and -unit_area yes of feat.params.
+ *
+ * The frequency axis can be warped for vocal tract length normalization with SetFrequencyWarp: the filter edges are
+ * then equally spaced on the mel scale of the warped frequencies, as with -warp_type and -warp_params of sphinxbase.
+ *
+ * The filterbank is built from the first spectrum it receives, since the number of FFT points and the sample rate
+ * are only known then.
+ */
+type MelFrequencyFilterBank2 struct {
+	frontend.BaseDataProcessor
+	minFreq, maxFreq float64
+	numberFilters    int
+	roundFilters     bool
+	unitArea         bool
+	warp             FrequencyWarp
+
+	sampleRate      int
+	numberFftPoints int
+	specStart       []int
+	filterWeights   [][]float64
+}
+
+/** Constructs a MelFrequencyFilterBank2 with the sphinxbase defaults for 16kHz audio. */
+func NewDefaultMelFrequencyFilterBank2() *MelFrequencyFilterBank2 {
+	return NewMelFrequencyFilterBank2(DEFAULT_MIN_FREQ, DEFAULT_MAX_FREQ, DEFAULT_NUMBER_FILTERS)
+}
+
+/**
+ * Constructs a MelFrequencyFilterBank2 with rounded, unit area filters.
+ *
+ * @param minFreq       the minimum frequency covered by the filterbank, in Hertz
+ * @param maxFreq       the maximum frequency covered by the filterbank, in Hertz
+ * @param numberFilters the number of filters
+ */
+func NewMelFrequencyFilterBank2(minFreq, maxFreq float64, numberFilters int) *MelFrequencyFilterBank2 {
+	return &MelFrequencyFilterBank2{
+		minFreq:       minFreq,
+		maxFreq:       maxFreq,
+		numberFilters: numberFilters,
+		roundFilters:  true,
+		unitArea:      true,
+	}
+}
+
+/** @param roundFilters whether the filter edges are rounded to the nearest DFT point */
+func (fb *MelFrequencyFilterBank2) SetRoundFilters(roundFilters bool) {
+	fb.roundFilters = roundFilters
+	fb.filterWeights = nil
+}
+
+/** @param unitArea whether each filter is normalized to unit area */
+func (fb *MelFrequencyFilterBank2) SetUnitArea(unitArea bool) {
+	fb.unitArea = unitArea
+	fb.filterWeights = nil
+}
+
+/**
+ * Warps the frequency axis of the filterbank, which is rebuilt for the next spectrum.
+ *
+ * @param warp the frequency warp, or nil for none
+ */
+func (fb *MelFrequencyFilterBank2) SetFrequencyWarp(warp FrequencyWarp) {
+	fb.warp = warp
+	fb.filterWeights = nil
+}
+
+/** @return the frequency warp of the filterbank, or nil if there is none */
+func (fb *MelFrequencyFilterBank2) FrequencyWarp() FrequencyWarp {
+	return fb.warp
+}
+
+func (fb *MelFrequencyFilterBank2) Initialize() {
+	fb.filterWeights = nil
+}
+
+/**
+ * Returns the next DoubleData object, which is the filtered power spectrum of the input. Signals are returned
+ * unmodified.
+ *
+ * @return the next available Data object, returns null if no Data is available
+ * @throws error if there is a data processing error
+ */
+func (fb *MelFrequencyFilterBank2) GetData() (frontend.Data, error) {
+	input, err := fb.Predecessor().GetData()
+	if err != nil {
+		return nil, err
+	}
+
+	if data, ok := input.(*frontend.DoubleData); ok {
+		return fb.process(data)
+	}
+
+	return input, nil
+}
+
+/**
+ * Process data, creating the mel spectrum from an input spectrum.
+ *
+ * @param input a power spectrum
+ * @return the mel spectrum
+ * @throws error if the spectrum does not come from a power of two FFT
+ */
+func (fb *MelFrequencyFilterBank2) process(input *frontend.DoubleData) (*frontend.DoubleData, error) {
+	in := input.Values()
+	numberFftPoints := (len(in) - 1) << 1
+
+	if fb.filterWeights == nil || input.SampleRate() != fb.sampleRate || numberFftPoints != fb.numberFftPoints {
+		if len(in) < 2 || numberFftPoints&(numberFftPoints-1) != 0 {
+			return nil, fmt.Errorf("number of FFT points must be a power of 2, spectrum of %d points received", len(in))
+		}
+		if err := fb.buildFilterbank(numberFftPoints, input.SampleRate()); err != nil {
+			return nil, err
+		}
+	}
+
+	output := make([]float64, fb.numberFilters)
+	for i, weights := range fb.filterWeights {
+		start := fb.specStart[i]
+		for j, weight := range weights {
+			output[i] += weight * in[start+j]
+		}
+	}
+
+	return frontend.NewDoubleDataWithCollectTime(output, input.SampleRate(),
+		input.CollectTime(), input.FirstSampleNumber()), nil
+}
+
+/**
+ * Build a mel filterbank with the parameters given. Each filter will be shaped as a triangle. The triangles overlap
+ * so that they cover the whole frequency range requested. The edges of a given triangle will be by default at the
+ * center of the neighboring triangles.
+ *
+ * @param numberFftPoints number of points in the power spectrum
+ * @param sampleRate      the sample rate of the audio
+ * @throws error if the frequency range does not fit the sample rate
+ */
+func (fb *MelFrequencyFilterBank2) buildFilterbank(numberFftPoints, sampleRate int) error {
+	if fb.numberFilters <= 0 {
+		return fmt.Errorf("number of filters must be positive, got %d", fb.numberFilters)
+	}
+	if sampleRate <= 0 {
+		return fmt.Errorf("sample rate of the spectrum is unknown")
+	}
+	if fb.maxFreq > float64(sampleRate)/2 || fb.minFreq >= fb.maxFreq {
+		return fmt.Errorf("filterbank range %v-%vHz does not fit %dHz audio", fb.minFreq, fb.maxFreq, sampleRate)
+	}
+
+	fb.sampleRate = sampleRate
+	fb.numberFftPoints = numberFftPoints
+
+	melMin := fb.linToMelFreq(fb.minFreq)
+	melMax := fb.linToMelFreq(fb.maxFreq)
+	// Filter edges are spaced equally on the mel scale, the right edge of a filter is the center of the next one.
+	deltaMel := (melMax - melMin) / float64(fb.numberFilters+1)
+	fftFreq := float64(sampleRate) / float64(numberFftPoints)
+
+	fb.specStart = make([]int, fb.numberFilters)
+	fb.filterWeights = make([][]float64, fb.numberFilters)
+	for i := 0; i < fb.numberFilters; i++ {
+		var freqs [3]float64
+		for j := range freqs {
+			freqs[j] = fb.melToLinFreq(float64(i+j)*deltaMel + melMin)
+			// Round them to DFT points if requested
+			if fb.roundFilters {
+				freqs[j] = float64(int(freqs[j]/fftFreq+0.5)) * fftFreq
+			}
+		}
+
+		fb.specStart[i] = -1
+		weights := make([]float64, 0)
+		for j := 0; j <= numberFftPoints/2; j++ {
+			hz := float64(j) * fftFreq
+			if hz < freqs[0] {
+				continue
+			}
+			if hz > freqs[2] || j == numberFftPoints/2 {
+				break
+			}
+			if fb.specStart[i] == -1 {
+				fb.specStart[i] = j
+			}
+
+			loslope := (hz - freqs[0]) / (freqs[1] - freqs[0])
+			hislope := (freqs[2] - hz) / (freqs[2] - freqs[1])
+			if fb.unitArea {
+				loslope *= 2 / (freqs[2] - freqs[0])
+				hislope *= 2 / (freqs[2] - freqs[0])
+			}
+			weights = append(weights, math.Min(loslope, hislope))
+		}
+		if fb.specStart[i] == -1 {
+			fb.specStart[i] = 0
+		}
+		fb.filterWeights[i] = weights
+	}
+	return nil
+}
+
+/**
+ * Compute mel frequency from linear frequency, warping it first if the filterbank has a frequency warp.
+ *
+ * @param inputFreq the input frequency in linear scale
+ * @return the frequency in a mel scale
+ */
+func (fb *MelFrequencyFilterBank2) linToMelFreq(inputFreq float64) float64 {
+	if fb.warp != nil {
+		inputFreq = fb.warp.Warp(inputFreq, float64(fb.sampleRate)/2)
+	}
+	return 2595.0 * math.Log10(1.0+inputFreq/700.0)
+}
+
+/**
+ * Compute linear frequency from mel frequency, unwarping it if the filterbank has a frequency warp.
+ *
+ * @param inputFreq the input frequency in mel scale
+ * @return the frequency in a linear scale
+ */
+func (fb *MelFrequencyFilterBank2) melToLinFreq(inputFreq float64) float64 {
+	linFreq := 700.0 * (math.Pow(10.0, inputFreq/2595.0) - 1.0)
+	if fb.warp != nil {
+		linFreq = fb.warp.Unwarp(linFreq, float64(fb.sampleRate)/2)
+	}
+	return linFreq
+}
@@ -0,0 +1,146 @@
+package frequencywarp
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/jtejido/go-sphinx/frontend"
+)
+
+const (
+	// The default number of PLP cepstra.
+	DEFAULT_PLP_CEPSTRUM_LENGTH = 13
+
+	// The default order of the linear prediction model.
+	DEFAULT_LPC_ORDER = 14
+
+	// The exponent of the intensity-loudness power law.
+	POWER_LAW_EXPONENT = 1.0 / 3.0
+
+	// The floor of the autocorrelation of silent frames.
+	AUTOCORRELATION_FLOOR = 1e-8
+)
+
+/**
+ * Computes the PLP cepstrum from the auditory spectrum of a PLPFrequencyFilterBank, the last stages of perceptual
+ * linear prediction:
+ *
+ * 1. intensity-loudness compression: each band is raised to the power 1/3, approximating the relation between the
+ * intensity of a sound and its perceived loudness,
+ * 2. the autocorrelation of the compressed spectrum is obtained by its inverse cosine transform,
+ * 3. an all-pole model of the requested order is fitted to the autocorrelation by a LinearPredictor, with the
+ * Levinson-Durbin recursion,
+ * 4. the cepstrum of the all-pole model is computed by the LPC to cepstrum recursion.
+ *
+ * The first cepstrum is the logarithm of the prediction error, the gain of the model, which plays the role of the
+ * energy term of MFCC.
+ */
+type PLPCepstrumProducer struct {
+	frontend.BaseDataProcessor
+	cepstrumSize int
+	lpcOrder     int
+	predictor    *LinearPredictor
+	cosine       [][]float64
+}
+
+/** Constructs a PLPCepstrumProducer with the default cepstrum size and LPC order. */
+func NewDefaultPLPCepstrumProducer() *PLPCepstrumProducer {
+	return NewPLPCepstrumProducer(DEFAULT_PLP_CEPSTRUM_LENGTH, DEFAULT_LPC_ORDER)
+}
+
+/**
+ * Constructs a PLPCepstrumProducer.
+ *
+ * @param cepstrumSize the number of cepstra
+ * @param lpcOrder     the order of the linear prediction model
+ */
+func NewPLPCepstrumProducer(cepstrumSize, lpcOrder int) *PLPCepstrumProducer {
+	return &PLPCepstrumProducer{
+		cepstrumSize: cepstrumSize,
+		lpcOrder:     lpcOrder,
+		predictor:    NewLinearPredictor(lpcOrder),
+	}
+}
+
+func (p *PLPCepstrumProducer) Initialize() {
+	p.cosine = nil
+}
+
+/**
+ * Returns the next DoubleData object, which is the PLP cepstrum of the input auditory spectrum. Signals are returned
+ * unmodified.
+ *
+ * @return the next available Data object, returns null if no Data is available
+ * @throws error if there is a data processing error
+ */
+func (p *PLPCepstrumProducer) GetData() (frontend.Data, error) {
+	input, err := p.Predecessor().GetData()
+	if err != nil {
+		return nil, err
+	}
+
+	if data, ok := input.(*frontend.DoubleData); ok {
+		return p.process(data)
+	}
+
+	return input, nil
+}
+
+/**
+ * Process data, creating the PLP cepstrum from an input auditory spectrum.
+ *
+ * @param input an auditory spectrum
+ * @return the PLP cepstrum
+ * @throws error if the auditory spectrum changes length
+ */
+func (p *PLPCepstrumProducer) process(input *frontend.DoubleData) (*frontend.DoubleData, error) {
+	spectrum := input.Values()
+	if p.cosine == nil {
+		if len(spectrum) == 0 {
+			return nil, fmt.Errorf("empty auditory spectrum")
+		}
+		p.computeCosine(len(spectrum))
+	} else if len(spectrum) != len(p.cosine[0]) {
+		return nil, fmt.Errorf("auditory spectrum of %d bands, expected %d", len(spectrum), len(p.cosine[0]))
+	}
+
+	// intensity-loudness power law
+	loudness := make([]float64, len(spectrum))
+	for i, energy := range spectrum {
+		loudness[i] = math.Pow(math.Max(energy, 0), POWER_LAW_EXPONENT)
+	}
+
+	// the autocorrelation is the inverse transform of the (even) power spectrum
+	autocor := make([]float64, p.lpcOrder+1)
+	for k := range autocor {
+		for j, value := range loudness {
+			autocor[k] += value * p.cosine[k][j]
+		}
+		autocor[k] /= float64(len(loudness))
+	}
+	if autocor[0] < AUTOCORRELATION_FLOOR {
+		autocor[0] = AUTOCORRELATION_FLOOR
+	}
+
+	if _, err := p.predictor.ARFilter(autocor); err != nil {
+		return nil, err
+	}
+	return frontend.NewDoubleDataWithCollectTime(p.predictor.Cepstrum(p.cepstrumSize), input.SampleRate(),
+		input.CollectTime(), input.FirstSampleNumber()), nil
+}
+
+/**
+ * Computes the cosine basis of the inverse transform. The bands of the auditory spectrum are taken as equally
+ * spaced samples of the spectrum between 0 and the Nyquist frequency, at the middle of their interval.
+ *
+ * @param numberBands the number of bands of the auditory spectrum
+ */
+func (p *PLPCepstrumProducer) computeCosine(numberBands int) {
+	p.cosine = make([][]float64, p.lpcOrder+1)
+	for k := range p.cosine {
+		p.cosine[k] = make([]float64, numberBands)
+		for j := range p.cosine[k] {
+			p.cosine[k][j] = math.Cos(math.Pi * float64(k) * (float64(j) + 0.5) / float64(numberBands))
+		}
+	}
+}
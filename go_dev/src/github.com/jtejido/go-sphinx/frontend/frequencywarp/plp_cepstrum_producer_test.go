@@ -0,0 +1,52 @@
+package frequencywarp
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+
+	"github.com/jtejido/go-sphinx/frontend"
+)
+
+func TestPLPCepstrumProducerLoudness(t *testing.T) {
+	// a flat auditory spectrum of 8 is a flat loudness of 2, white noise of power 2
+	p := NewPLPCepstrumProducer(4, 4)
+	output, err := p.process(frontend.NewDoubleData([]float64{8, 8, 8, 8, 8, 8, 8, 8}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []float64{math.Ln2, 0, 0, 0} {
+		if math.Abs(output.Values()[i]-want) > 1e-12 {
+			t.Fatalf("cepstrum %v, want [ln 2 0 0 0]", output.Values())
+		}
+	}
+}
+
+func TestPLPCepstrumProducerAR(t *testing.T) {
+	// the loudness of the bands is the power spectrum 1/|A|^2 of x[n] = 1.3 x[n-1] - 0.6 x[n-2] + e[n], of unit
+	// innovation, whose cepstrum is the sum of the powers of the poles p of 1/A: c[n] = (p1^n + p2^n) / n
+	const bands = 64
+	spectrum := make([]float64, bands)
+	for j := range spectrum {
+		w := math.Pi * (float64(j) + 0.5) / bands
+		response := 1 - 1.3*cmplx.Exp(complex(0, -w)) + 0.6*cmplx.Exp(complex(0, -2*w))
+		spectrum[j] = math.Pow(cmplx.Abs(response), -6)
+	}
+
+	p := NewPLPCepstrumProducer(4, 2)
+	output, err := p.process(frontend.NewDoubleData(spectrum))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// p1 + p2 = 1.3 and p1 p2 = 0.6
+	expected := []float64{0, 1.3, (1.3*1.3 - 2*0.6) / 2, (1.3*1.3*1.3 - 3*0.6*1.3) / 3}
+	for i, want := range expected {
+		if math.Abs(output.Values()[i]-want) > 1e-9 {
+			t.Errorf("c[%d] = %v, want %v", i, output.Values()[i], want)
+		}
+	}
+
+	if _, err := p.process(frontend.NewDoubleData(spectrum[1:])); err == nil {
+		t.Error("no error for an auditory spectrum changing length")
+	}
+}
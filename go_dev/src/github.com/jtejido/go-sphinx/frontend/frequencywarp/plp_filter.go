@@ -0,0 +1,80 @@
+package frequencywarp
+
+import (
+	"fmt"
+	"math"
+)
+
+/**
+ * Defines a critical band filter of the PLP front end. The filter is defined on the Bark scale around its center
+ * frequency: it rises by 10^(bark + 0.5) from -2.5 Bark, is flat between -0.5 and 0.5 Bark and falls by
+ * 10^(-2.5 * (bark - 0.5)) up to 1.3 Bark. The filter is evaluated at the frequencies of the DFT points, and its
+ * output is the weighted sum of the power spectrum.
+ */
+type PLPFilter struct {
+	centerFreqInHz     float64
+	centerFreqInBark   float64
+	filterCoefficients []float64
+	numberDFTPoints    int
+}
+
+/**
+ * Constructs a PLPFilter.
+ *
+ * @param dftFrequenciesInHz the frequencies of the DFT points, in Hertz
+ * @param centerFreqInHz     the center frequency of the filter, in Hertz
+ * @throws error if the center frequency is outside the range of the DFT points
+ */
+func NewPLPFilter(dftFrequenciesInHz []float64, centerFreqInHz float64) (*PLPFilter, error) {
+	numberDFTPoints := len(dftFrequenciesInHz)
+	if numberDFTPoints == 0 || centerFreqInHz < dftFrequenciesInHz[0] ||
+		centerFreqInHz > dftFrequenciesInHz[numberDFTPoints-1] {
+		return nil, fmt.Errorf("center frequency %vHz of PLP filter out of the range of the DFT", centerFreqInHz)
+	}
+
+	f := &PLPFilter{
+		centerFreqInHz:     centerFreqInHz,
+		centerFreqInBark:   HertzToBark(centerFreqInHz),
+		filterCoefficients: make([]float64, numberDFTPoints),
+		numberDFTPoints:    numberDFTPoints,
+	}
+	for i, hz := range dftFrequenciesInHz {
+		bark := HertzToBark(hz) - f.centerFreqInBark
+		switch {
+		case bark < -2.5:
+			f.filterCoefficients[i] = 0.0
+		case bark <= -0.5:
+			f.filterCoefficients[i] = math.Pow(10.0, bark+0.5)
+		case bark <= 0.5:
+			f.filterCoefficients[i] = 1.0
+		case bark <= 1.3:
+			f.filterCoefficients[i] = math.Pow(10.0, -2.5*(bark-0.5))
+		default:
+			f.filterCoefficients[i] = 0.0
+		}
+	}
+	return f, nil
+}
+
+/** @return the center frequency of the filter, in Hertz */
+func (f *PLPFilter) CenterFrequency() float64 {
+	return f.centerFreqInHz
+}
+
+/**
+ * Computes the output of the filter for the given power spectrum.
+ *
+ * @param spectrum the power spectrum, one value per DFT point
+ * @return the energy of the spectrum in the band of the filter
+ * @throws error if the spectrum does not have as many points as the filter
+ */
+func (f *PLPFilter) FilterOutput(spectrum []float64) (float64, error) {
+	if len(spectrum) != f.numberDFTPoints {
+		return 0, fmt.Errorf("mismatch in no. of DFT points %d in spectrum and in filter %d", len(spectrum), f.numberDFTPoints)
+	}
+	var output float64
+	for i, coefficient := range f.filterCoefficients {
+		output += spectrum[i] * coefficient
+	}
+	return output, nil
+}
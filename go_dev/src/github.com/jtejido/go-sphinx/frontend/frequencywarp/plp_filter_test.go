@@ -0,0 +1,40 @@
+package frequencywarp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPLPFilter(t *testing.T) {
+	// DFT points at known distances in Bark from a center of 10 Bark, the weights being those of Hermansky (1990)
+	const center = 10.0
+	offsets := []float64{-3, -2.4, -1.5, -0.5, 0, 0.5, 0.9, 1.2, 2}
+	expected := []float64{0, math.Pow(10, -1.9), 0.1, 1, 1, 1, 0.1, math.Pow(10, -1.75), 0}
+	frequencies := make([]float64, len(offsets))
+	for i, offset := range offsets {
+		frequencies[i] = 600 * math.Sinh((center+offset)/6)
+	}
+
+	f, err := NewPLPFilter(frequencies, 600*math.Sinh(center/6))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, offset := range offsets {
+		spectrum := make([]float64, len(frequencies))
+		spectrum[i] = 2
+		output, err := f.FilterOutput(spectrum)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(output-2*expected[i]) > 1e-9 {
+			t.Errorf("weight %v at %v Bark from the center, want %v", output/2, offset, expected[i])
+		}
+	}
+
+	if _, err := NewPLPFilter(frequencies, frequencies[len(frequencies)-1]+1); err == nil {
+		t.Error("no error for a center out of the range of the DFT")
+	}
+	if _, err := f.FilterOutput(make([]float64, 3)); err == nil {
+		t.Error("no error for a spectrum of the wrong size")
+	}
+}
@@ -0,0 +1,183 @@
+package frequencywarp
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/jtejido/go-sphinx/frontend"
+)
+
+const (
+	// The default number of critical band filters of the PLP front end.
+	DEFAULT_PLP_NUMBER_FILTERS = 32
+
+	// The default center frequency of the lowest critical band filter, in Hertz.
+	DEFAULT_PLP_MIN_FREQ = 130.0
+
+	// The default center frequency of the highest critical band filter, in Hertz.
+	DEFAULT_PLP_MAX_FREQ = 3600.0
+)
+
+/**
+ * Filters an input power spectrum through a bank of critical band filters, the first stages of perceptual linear
+ * prediction (PLP). The centers of the PLPFilters are equally spaced on the Bark scale between the minimum and maximum
+ * frequency, both included. The output of each filter is then weighted by the equal-loudness curve at its center
+ * frequency, which approximates the unequal sensitivity of human hearing at different frequencies:
+ *
+ * E(w) = ((w^2 + 56.8e6) * w^4) / ((w^2 + 6.3e6)^2 * (w^2 + 0.38e9) * (1 + w^6 / 9.58e26)), with w = 2 * Pi * f.
+ *
+ * The output of this processor is the auditory spectrum, a vector of as many values as there are filters, which is
+ * turned into cepstra by a PLPCepstrumProducer. The filters are built from the first spectrum received, since the
+ * number of FFT points and the sample rate are only known then.
+ */
+type PLPFrequencyFilterBank struct {
+	frontend.BaseDataProcessor
+	minFreq, maxFreq float64
+	numberFilters    int
+
+	sampleRate           int
+	numberFftPoints      int
+	criticalBandFilters  []*PLPFilter
+	equalLoudnessScaling []float64
+}
+
+/** Constructs a PLPFrequencyFilterBank with the default filters. */
+func NewDefaultPLPFrequencyFilterBank() *PLPFrequencyFilterBank {
+	return NewPLPFrequencyFilterBank(DEFAULT_PLP_MIN_FREQ, DEFAULT_PLP_MAX_FREQ, DEFAULT_PLP_NUMBER_FILTERS)
+}
+
+/**
+ * Constructs a PLPFrequencyFilterBank.
+ *
+ * @param minFreq       the center frequency of the lowest filter, in Hertz
+ * @param maxFreq       the center frequency of the highest filter, in Hertz
+ * @param numberFilters the number of filters
+ */
+func NewPLPFrequencyFilterBank(minFreq, maxFreq float64, numberFilters int) *PLPFrequencyFilterBank {
+	return &PLPFrequencyFilterBank{
+		minFreq:       minFreq,
+		maxFreq:       maxFreq,
+		numberFilters: numberFilters,
+	}
+}
+
+func (fb *PLPFrequencyFilterBank) Initialize() {
+	fb.criticalBandFilters = nil
+}
+
+/** @return the number of filters, the length of the auditory spectrum */
+func (fb *PLPFrequencyFilterBank) NumberFilters() int {
+	return fb.numberFilters
+}
+
+/**
+ * Returns the next DoubleData object, which is the auditory spectrum of the input power spectrum. Signals are
+ * returned unmodified.
+ *
+ * @return the next available Data object, returns null if no Data is available
+ * @throws error if there is a data processing error
+ */
+func (fb *PLPFrequencyFilterBank) GetData() (frontend.Data, error) {
+	input, err := fb.Predecessor().GetData()
+	if err != nil {
+		return nil, err
+	}
+
+	if data, ok := input.(*frontend.DoubleData); ok {
+		return fb.process(data)
+	}
+
+	return input, nil
+}
+
+/**
+ * Process data, creating the auditory spectrum from an input power spectrum.
+ *
+ * @param input a power spectrum
+ * @return the auditory spectrum
+ * @throws error if the spectrum does not come from a power of two FFT
+ */
+func (fb *PLPFrequencyFilterBank) process(input *frontend.DoubleData) (*frontend.DoubleData, error) {
+	in := input.Values()
+	numberFftPoints := (len(in) - 1) << 1
+
+	if fb.criticalBandFilters == nil || input.SampleRate() != fb.sampleRate || numberFftPoints != fb.numberFftPoints {
+		if len(in) < 2 || numberFftPoints&(numberFftPoints-1) != 0 {
+			return nil, fmt.Errorf("number of FFT points must be a power of 2, spectrum of %d points received", len(in))
+		}
+		if err := fb.buildCriticalBandFilterbank(numberFftPoints, input.SampleRate()); err != nil {
+			return nil, err
+		}
+	}
+
+	output := make([]float64, fb.numberFilters)
+	for i, f := range fb.criticalBandFilters {
+		energy, err := f.FilterOutput(in)
+		if err != nil {
+			return nil, err
+		}
+		output[i] = energy * fb.equalLoudnessScaling[i]
+	}
+
+	return frontend.NewDoubleDataWithCollectTime(output, input.SampleRate(),
+		input.CollectTime(), input.FirstSampleNumber()), nil
+}
+
+/**
+ * Builds the critical band filters and their equal-loudness weights.
+ *
+ * @param numberFftPoints number of points in the power spectrum
+ * @param sampleRate      the sample rate of the audio
+ * @throws error if the frequency range does not fit the sample rate
+ */
+func (fb *PLPFrequencyFilterBank) buildCriticalBandFilterbank(numberFftPoints, sampleRate int) error {
+	if fb.numberFilters < 2 {
+		return fmt.Errorf("number of PLP filters must be at least 2, got %d", fb.numberFilters)
+	}
+	if sampleRate <= 0 {
+		return fmt.Errorf("sample rate of the spectrum is unknown")
+	}
+	if fb.maxFreq > float64(sampleRate)/2 || fb.minFreq < 0 || fb.minFreq >= fb.maxFreq {
+		return fmt.Errorf("filterbank range %v-%vHz does not fit %dHz audio", fb.minFreq, fb.maxFreq, sampleRate)
+	}
+
+	fb.sampleRate = sampleRate
+	fb.numberFftPoints = numberFftPoints
+
+	dftFrequencies := make([]float64, numberFftPoints/2+1)
+	for i := range dftFrequencies {
+		dftFrequencies[i] = float64(i) * float64(sampleRate) / float64(numberFftPoints)
+	}
+
+	minBark := HertzToBark(fb.minFreq)
+	deltaBark := (HertzToBark(fb.maxFreq) - minBark) / float64(fb.numberFilters-1)
+
+	fb.criticalBandFilters = make([]*PLPFilter, fb.numberFilters)
+	fb.equalLoudnessScaling = make([]float64, fb.numberFilters)
+	for i := range fb.criticalBandFilters {
+		centerFreq := BarkToHertz(minBark + float64(i)*deltaBark)
+		f, err := NewPLPFilter(dftFrequencies, centerFreq)
+		if err != nil {
+			fb.criticalBandFilters = nil
+			return err
+		}
+		fb.criticalBandFilters[i] = f
+		fb.equalLoudnessScaling[i] = EqualLoudness(centerFreq)
+	}
+	return nil
+}
+
+/**
+ * Computes the equal-loudness weight of a frequency, the sensitivity of human hearing at about 40dB relative to its
+ * maximum, following Hermansky (1990), with the attenuation above 5kHz of the extended curve.
+ *
+ * @param freq the frequency in Hertz
+ * @return the equal-loudness weight
+ */
+func EqualLoudness(freq float64) float64 {
+	w := 2 * math.Pi * freq
+	w2 := w * w
+	w4 := w2 * w2
+	w6 := w4 * w2
+	return (w2 + 56.8e6) * w4 / ((w2 + 6.3e6) * (w2 + 6.3e6) * (w2 + 0.38e9) * (1 + w6/9.58e26))
+}
@@ -0,0 +1,40 @@
+package frequencywarp
+
+import (
+	"math"
+	"testing"
+
+	"github.com/jtejido/go-sphinx/frontend"
+)
+
+func TestPLPFrequencyFilterBank(t *testing.T) {
+	// filters centered on 1kHz and 2kHz, 3.8 Bark apart, over a 16 point FFT of 8kHz audio: each sees one of the
+	// two spectral lines alone, with a weight of 1
+	fb := NewPLPFrequencyFilterBank(1000, 2000, 2)
+	spectrum := make([]float64, 9)
+	spectrum[2] = 2 // 1kHz
+	spectrum[4] = 3 // 2kHz
+	output, err := fb.process(frontend.NewDoubleDataWithSampleRate(spectrum, 8000, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the equal-loudness curve of Hermansky (1990) at 1kHz and 2kHz
+	expected := []float64{2 * 0.17068263963317973, 3 * 0.3676092657782672}
+	values := output.Values()
+	if len(values) != 2 {
+		t.Fatalf("auditory spectrum of %d bands", len(values))
+	}
+	for i := range expected {
+		if math.Abs(values[i]-expected[i]) > 1e-9*expected[i] {
+			t.Errorf("band %d = %v, want %v", i, values[i], expected[i])
+		}
+	}
+
+	if _, err := fb.process(frontend.NewDoubleDataWithSampleRate(make([]float64, 10), 8000, 0)); err == nil {
+		t.Error("no error for a spectrum of 18 FFT points")
+	}
+	if _, err := NewPLPFrequencyFilterBank(1000, 5000, 2).process(frontend.NewDoubleDataWithSampleRate(spectrum, 8000, 0)); err == nil {
+		t.Error("no error for filters above the Nyquist frequency")
+	}
+}
@@ -0,0 +1,191 @@
+package frontend
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+/**
+ * FrontEnd is a wrapper class for the chain of front end processors. It provides methods for manipulating and
+ * navigating the processors.
+ *
+ * The front end is modeled as a series of data processors, each of which performs a specific signal processing
+ * function. For example, a processor performs Fast-Fourier Transform (FFT) on input data, another processor performs
+ * high-pass filtering. Each DataProcessor obtains its input from its predecessor; the first processor obtains its
+ * input from the data source, which is attached with SetDataSource. Calling GetData on the FrontEnd returns the
+ * output of the last processor in the chain.
+ *
+ * Data objects are either actual data or signals (DataStartSignal, DataEndSignal, SpeechStartSignal,
+ * SpeechEndSignal). Signals flow through the pipeline together with the data they delimit, and every signal coming
+ * out of the front end is also reported to the registered SignalListeners.
+ */
+type FrontEnd struct {
+	BaseDataProcessor
+	frontEndList    []DataProcessor
+	first, last     DataProcessor
+	signalListeners []SignalListener
+}
+
+/**
+ * Constructs a FrontEnd with no processors. Such a front end simply passes through whatever its data source
+ * produces.
+ */
+func NewDefaultFrontEnd() *FrontEnd {
+	return NewFrontEnd(nil)
+}
+
+/**
+ * Constructs a FrontEnd with the given chain of processors, in pipeline order.
+ *
+ * @param frontEndList the processors, the first one reads from the data source
+ */
+func NewFrontEnd(frontEndList []DataProcessor) *FrontEnd {
+	fe := new(FrontEnd)
+	fe.frontEndList = frontEndList
+	fe.signalListeners = make([]SignalListener, 0)
+	fe.Initialize()
+	return fe
+}
+
+/**
+ * Initializes this Front End.
+ *
+ * This method chains all DataProcessors together and initializes them.
+ */
+func (fe *FrontEnd) Initialize() {
+	fe.first, fe.last = nil, nil
+	var last DataProcessor
+
+	for _, dp := range fe.frontEndList {
+		if dp == nil {
+			panic("front end contains a nil data processor")
+		}
+
+		if last != nil {
+			dp.SetPredecessor(last)
+		}
+
+		if fe.first == nil {
+			fe.first = dp
+		}
+		last = dp
+	}
+
+	if fe.first != nil && fe.predecessor != nil {
+		fe.first.SetPredecessor(fe.predecessor)
+	}
+	fe.last = last
+
+	for _, dp := range fe.frontEndList {
+		dp.Initialize()
+	}
+}
+
+/**
+ * Sets the source of data for this front end. It basically sets the predecessor of the first DataProcessor of this
+ * front end.
+ *
+ * @param dataSource the source of data
+ */
+func (fe *FrontEnd) SetDataSource(dataSource DataProcessor) {
+	fe.SetPredecessor(dataSource)
+}
+
+/**
+ * Sets the predecessor of this front end, which is the processor the first element of the chain reads from.
+ *
+ * @param predecessor the new predecessor of this DataProcessor
+ */
+func (fe *FrontEnd) SetPredecessor(predecessor DataProcessor) {
+	fe.predecessor = predecessor
+	if fe.first != nil {
+		fe.first.SetPredecessor(predecessor)
+	}
+}
+
+/** @return the collection of processors of this FrontEnd. */
+func (fe *FrontEnd) Elements() []DataProcessor {
+	return fe.frontEndList
+}
+
+/**
+ * Returns the processed Data output, basically calls GetData on the last processor.
+ *
+ * @return Data object that has been processed by this front end
+ * @throws error if a data processor error occurs
+ */
+func (fe *FrontEnd) GetData() (Data, error) {
+	source := fe.last
+	if source == nil {
+		source = fe.predecessor
+	}
+	if source == nil {
+		return nil, errors.New("front end has no data source")
+	}
+
+	data, err := source.GetData()
+	if err != nil {
+		return nil, err
+	}
+
+	// fire the signal listeners if its a signal
+	if signal, ok := data.(Signal); ok {
+		fe.fireSignalListeners(signal)
+	}
+
+	return data, nil
+}
+
+/**
+ * Add a listener to be called when a signal is detected.
+ *
+ * @param listener the listener to be added
+ */
+func (fe *FrontEnd) AddSignalListener(listener SignalListener) {
+	fe.signalListeners = append(fe.signalListeners, listener)
+}
+
+/**
+ * Removes a listener for signals.
+ *
+ * @param listener the listener to be removed
+ */
+func (fe *FrontEnd) RemoveSignalListener(listener SignalListener) {
+	for i, l := range fe.signalListeners {
+		if l == listener {
+			fe.signalListeners = append(fe.signalListeners[:i], fe.signalListeners[i+1:]...)
+			return
+		}
+	}
+}
+
+/**
+ * Fire all listeners for signals.
+ *
+ * @param signal the signal that occurred
+ */
+func (fe *FrontEnd) fireSignalListeners(signal Signal) {
+	for _, listener := range fe.signalListeners {
+		listener.SignalOccurred(signal)
+	}
+}
+
+/** @return the last data processor within the DataProcessor chain of this FrontEnd. */
+func (fe *FrontEnd) LastDataProcessor() DataProcessor {
+	return fe.last
+}
+
+/**
+ * Returns a description of this FrontEnd in the format: &lt;front end name&gt; {&lt;DataProcessor1&gt;, &lt;DataProcessor2&gt;
+ * ... &lt;DataProcessorN&gt;}
+ *
+ * @return a description of this FrontEnd
+ */
+func (fe *FrontEnd) String() string {
+	names := make([]string, len(fe.frontEndList))
+	for i, dp := range fe.frontEndList {
+		names[i] = fmt.Sprintf("%T", dp)
+	}
+	return "FrontEnd {" + strings.Join(names, ", ") + "}"
+}
@@ -0,0 +1,29 @@
+package frontend
+
+/**
+ * Indicates events like beginning or end of data, data dropped, quality changed, etc.. It implements the Data
+ * interface, and it will pass between DataProcessors to inform them about the Data that is passed between
+ * DataProcessors.
+ *
+ * @see Data
+ * @see DataProcessor
+ */
+type Signal interface {
+	Data
+
+	/**
+	 * Returns the time this Signal was created.
+	 *
+	 * @return the time this Signal was created
+	 */
+	Time() int64
+}
+
+/** Holds the state shared by all signal types. */
+type signal struct {
+	time int64
+}
+
+func (s *signal) Time() int64 {
+	return s.time
+}
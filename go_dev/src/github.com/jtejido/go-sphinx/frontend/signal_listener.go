@@ -0,0 +1,12 @@
+package frontend
+
+/** The listener interface for being informed when a Signal is generated. */
+type SignalListener interface {
+
+	/**
+	 * Method called when a signal is detected
+	 *
+	 * @param signal the signal
+	 */
+	SignalOccurred(signal Signal)
+}
@@ -0,0 +1,25 @@
+package frontend
+
+import "fmt"
+
+/**
+ * A signal that indicates the end of speech.
+ *
+ * @see SpeechStartSignal
+ */
+type SpeechEndSignal struct {
+	signal
+}
+
+/**
+ * Constructs a SpeechEndSignal at the given time.
+ *
+ * @param time the time this SpeechEndSignal is created
+ */
+func NewSpeechEndSignal(time int64) *SpeechEndSignal {
+	return &SpeechEndSignal{signal{time: time}}
+}
+
+func (s *SpeechEndSignal) String() string {
+	return fmt.Sprintf("SpeechEndSignal: creation time: %d", s.time)
+}
@@ -0,0 +1,25 @@
+package frontend
+
+import "fmt"
+
+/**
+ * A signal that indicates the start of speech.
+ *
+ * @see SpeechEndSignal
+ */
+type SpeechStartSignal struct {
+	signal
+}
+
+/**
+ * Constructs a SpeechStartSignal at the given time.
+ *
+ * @param time the time this SpeechStartSignal is created
+ */
+func NewSpeechStartSignal(time int64) *SpeechStartSignal {
+	return &SpeechStartSignal{signal{time: time}}
+}
+
+func (s *SpeechStartSignal) String() string {
+	return fmt.Sprintf("SpeechStartSignal: creation time: %d", s.time)
+}
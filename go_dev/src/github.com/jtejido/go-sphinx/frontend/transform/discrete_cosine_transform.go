@@ -0,0 +1,131 @@
+package transform
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/jtejido/go-sphinx/frontend"
+)
+
+const (
+	// The default number of cepstra.
+	DEFAULT_CEPSTRUM_LENGTH = 13
+
+	// The floor added to the mel spectrum before taking its logarithm.
+	LOG_FLOOR = 1e-4
+)
+
+/**
+ * Applies a logarithm and then a Discrete Cosine Transform (DCT) to the input data. The input data is normally the
+ * mel spectrum. It has been proven that, for a sequence of real numbers, the discrete cosine transform is equivalent
+ * to the discrete Fourier transform. Therefore, this class corresponds to the last stage of converting a signal to
+ * cepstra, defined as the inverse Fourier transform of the logarithm of the Fourier transform of a signal. The
+ * property cepstrumLength refers to the dimensionality of the coefficients that are actually returned, defaulting to
+ * 13.
+ *
+ * This is the "legacy" transform of the Sphinx front end (-transform legacy in feat.params); see
+ * DiscreteCosineTransform2 for the orthonormal "dct" variant.
+ */
+type DiscreteCosineTransform struct {
+	frontend.BaseDataProcessor
+	cepstrumSize     int
+	numberMelFilters int
+	melcosine        [][]float64
+}
+
+/** Constructs a DiscreteCosineTransform that returns the default number of cepstra. */
+func NewDefaultDiscreteCosineTransform() *DiscreteCosineTransform {
+	return NewDiscreteCosineTransform(0, DEFAULT_CEPSTRUM_LENGTH)
+}
+
+/**
+ * Constructs a DiscreteCosineTransform.
+ *
+ * @param numberMelFilters the number of mel filters, or zero to take it from the first incoming spectrum
+ * @param cepstrumSize     the number of cepstra to return
+ */
+func NewDiscreteCosineTransform(numberMelFilters, cepstrumSize int) *DiscreteCosineTransform {
+	return &DiscreteCosineTransform{numberMelFilters: numberMelFilters, cepstrumSize: cepstrumSize}
+}
+
+func (t *DiscreteCosineTransform) Initialize() {
+	t.melcosine = nil
+}
+
+/**
+ * Returns the next DoubleData object, which is the mel cepstrum of the input frame. Signals are returned
+ * unmodified.
+ *
+ * @return the next available DoubleData melcepstrum, or null if no Data is available
+ * @throws error if there is a data processing error
+ */
+func (t *DiscreteCosineTransform) GetData() (frontend.Data, error) {
+	return t.getData(t.applyMelCosine)
+}
+
+func (t *DiscreteCosineTransform) getData(applyMelCosine func([]float64) []float64) (frontend.Data, error) {
+	input, err := t.Predecessor().GetData()
+	if err != nil {
+		return nil, err
+	}
+
+	if data, ok := input.(*frontend.DoubleData); ok {
+		melspectrum := data.Values()
+		if t.melcosine == nil {
+			t.numberMelFilters = len(melspectrum)
+			t.computeMelCosine()
+		} else if len(melspectrum) != t.numberMelFilters {
+			return nil, fmt.Errorf("mel spectrum size is incorrect: melspectrum.length == %d, numberMelFilters == %d",
+				len(melspectrum), t.numberMelFilters)
+		}
+
+		// first compute the log of the spectrum
+		logspectrum := make([]float64, len(melspectrum))
+		for i, v := range melspectrum {
+			logspectrum[i] = math.Log(v + LOG_FLOOR)
+		}
+
+		return frontend.NewDoubleDataWithCollectTime(applyMelCosine(logspectrum), data.SampleRate(),
+			data.CollectTime(), data.FirstSampleNumber()), nil
+	}
+
+	return input, nil
+}
+
+/** Compute the MelCosine filter bank. */
+func (t *DiscreteCosineTransform) computeMelCosine() {
+	t.melcosine = make([][]float64, t.cepstrumSize)
+	period := float64(2 * t.numberMelFilters)
+	for i := range t.melcosine {
+		t.melcosine[i] = make([]float64, t.numberMelFilters)
+		frequency := 2 * math.Pi * float64(i) / period
+		for j := range t.melcosine[i] {
+			t.melcosine[i][j] = math.Cos(frequency * (float64(j) + 0.5))
+		}
+	}
+}
+
+/**
+ * Apply the MelCosine filter to the given melspectrum.
+ *
+ * @param melspectrum the MelSpectrum data
+ * @return MelCepstrum data produced by apply the MelCosine filter to the MelSpectrum data
+ */
+func (t *DiscreteCosineTransform) applyMelCosine(melspectrum []float64) []float64 {
+	// create the cepstrum
+	cepstrum := make([]float64, t.cepstrumSize)
+	period := float64(t.numberMelFilters)
+	beta := 0.5
+	// apply the melcosine filter
+	for i := range cepstrum {
+		if t.numberMelFilters > 0 {
+			melcosine_i := t.melcosine[i]
+			cepstrum[i] += beta * melspectrum[0] * melcosine_i[0]
+			for j := 1; j < t.numberMelFilters; j++ {
+				cepstrum[i] += melspectrum[j] * melcosine_i[j]
+			}
+			cepstrum[i] /= period
+		}
+	}
+	return cepstrum
+}
@@ -0,0 +1,66 @@
+package transform
+
+import (
+	"math"
+
+	"github.com/jtejido/go-sphinx/frontend"
+)
+
+/**
+ * Applies the optimized MelCosine filter used in pocketsphinx to the given melspectrum. This is the orthonormal
+ * DCT-II selected by -transform dct in feat.params.
+ */
+type DiscreteCosineTransform2 struct {
+	DiscreteCosineTransform
+}
+
+/** Constructs a DiscreteCosineTransform2 that returns the default number of cepstra. */
+func NewDefaultDiscreteCosineTransform2() *DiscreteCosineTransform2 {
+	return NewDiscreteCosineTransform2(0, DEFAULT_CEPSTRUM_LENGTH)
+}
+
+/**
+ * Constructs a DiscreteCosineTransform2.
+ *
+ * @param numberMelFilters the number of mel filters, or zero to take it from the first incoming spectrum
+ * @param cepstrumSize     the number of cepstra to return
+ */
+func NewDiscreteCosineTransform2(numberMelFilters, cepstrumSize int) *DiscreteCosineTransform2 {
+	return &DiscreteCosineTransform2{*NewDiscreteCosineTransform(numberMelFilters, cepstrumSize)}
+}
+
+func (t *DiscreteCosineTransform2) GetData() (frontend.Data, error) {
+	return t.getData(t.applyMelCosine)
+}
+
+/**
+ * Apply the optimized MelCosine filter used in pocketsphinx to the given melspectrum.
+ *
+ * @param melspectrum the MelSpectrum data
+ * @return MelCepstrum data produced by apply the MelCosine filter to the MelSpectrum data
+ */
+func (t *DiscreteCosineTransform2) applyMelCosine(melspectrum []float64) []float64 {
+	// create the cepstrum
+	cepstrum := make([]float64, t.cepstrumSize)
+	sqrt_inv_n := math.Sqrt(1.0 / float64(t.numberMelFilters))
+	sqrt_inv_2n := math.Sqrt(2.0 / float64(t.numberMelFilters))
+
+	if t.numberMelFilters <= 0 {
+		return cepstrum
+	}
+
+	cepstrum[0] = melspectrum[0]
+	for j := 1; j < t.numberMelFilters; j++ {
+		cepstrum[0] += melspectrum[j]
+	}
+	cepstrum[0] *= sqrt_inv_n
+
+	for i := 1; i < len(cepstrum); i++ {
+		melcosine_i := t.melcosine[i]
+		for j := 0; j < t.numberMelFilters; j++ {
+			cepstrum[i] += melspectrum[j] * melcosine_i[j]
+		}
+		cepstrum[i] *= sqrt_inv_2n
+	}
+	return cepstrum
+}
@@ -0,0 +1,183 @@
+package transform
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+
+	"github.com/jtejido/go-sphinx/frontend"
+)
+
+/**
+ * Computes the Discrete Fourier Transform (FT) of an input sequence, using Fast Fourier Transform (FFT). Fourier
+ * Transform is the process of analyzing a signal into its frequency components. In speech, rather than analyzing the
+ * signal over its entire duration, we analyze one window of audio data. This window is the product of applying a
+ * sliding Hamming window to the signal. Moreover, since the amplitude is a lot more important than the phase for
+ * speech recognition, this class returns the power spectrum of that window of data instead of the complex spectrum.
+ * Each value in the returned spectrum represents the strength of that particular frequency for that window of data.
+ *
+ * By default, the number of FFT points is the closest power of 2 that is equal to or larger than the number of
+ * samples in the incoming window of data. The FFT points can also be set by the user with the numberFftPoints
+ * parameter, in which case the window is zero padded up to that length. The size of the returned power spectrum is
+ * half of the number of FFT points plus one, covering the frequencies from zero to the Nyquist frequency.
+ */
+type DiscreteFourierTransform struct {
+	frontend.BaseDataProcessor
+	isNumberFftPointsSet    bool
+	numberFftPoints         int
+	logBase2NumberFftPoints int
+	invert                  bool
+	weightFft               []complex128
+	bitReverse              []int
+}
+
+/** Constructs a DiscreteFourierTransform whose number of FFT points follows the window size. */
+func NewDefaultDiscreteFourierTransform() *DiscreteFourierTransform {
+	return NewDiscreteFourierTransform(-1, false)
+}
+
+/**
+ * Constructs a DiscreteFourierTransform.
+ *
+ * @param numberFftPoints the number of FFT points, a power of two, or -1 to derive it from the window size
+ * @param invert          whether to compute the inverse transform
+ */
+func NewDiscreteFourierTransform(numberFftPoints int, invert bool) *DiscreteFourierTransform {
+	dft := new(DiscreteFourierTransform)
+	dft.isNumberFftPointsSet = numberFftPoints != -1
+	dft.numberFftPoints = numberFftPoints
+	dft.invert = invert
+	return dft
+}
+
+func (dft *DiscreteFourierTransform) Initialize() {
+	if dft.isNumberFftPointsSet {
+		dft.initializeFFT()
+	}
+}
+
+/**
+ * Returns the next DoubleData object, which is the power spectrum of an input DoubleData object.
+ *
+ * @return the next available DoubleData object, or null if no Spectrum object is available
+ * @throws error if there is a processing error
+ */
+func (dft *DiscreteFourierTransform) GetData() (frontend.Data, error) {
+	input, err := dft.Predecessor().GetData()
+	if err != nil {
+		return nil, err
+	}
+
+	switch data := input.(type) {
+	case *frontend.DoubleData:
+		return dft.process(data)
+	case *frontend.DataStartSignal:
+		// Sample rate changes invalidate the FFT size, unless the user set it explicitly.
+		if !dft.isNumberFftPointsSet {
+			dft.numberFftPoints = 0
+		}
+	}
+
+	return input, nil
+}
+
+/**
+ * Process data creating the power spectrum from an input audio frame.
+ *
+ * @param input input audio frame
+ * @return power spectrum
+ * @throws error if there is a data processing error
+ */
+func (dft *DiscreteFourierTransform) process(input *frontend.DoubleData) (*frontend.DoubleData, error) {
+	in := input.Values()
+
+	if dft.numberFftPoints <= 0 {
+		dft.numberFftPoints = nextPowerOfTwo(len(in))
+		dft.initializeFFT()
+	} else if dft.weightFft == nil {
+		dft.initializeFFT()
+	}
+
+	if len(in) > dft.numberFftPoints {
+		return nil, fmt.Errorf("window of %d samples is longer than the %d FFT points", len(in), dft.numberFftPoints)
+	}
+
+	// zero pad the window up to the number of FFT points
+	frame := make([]complex128, dft.numberFftPoints)
+	for i := range in {
+		frame[dft.bitReverse[i]] = complex(in[i], 0)
+	}
+	dft.fft(frame)
+
+	// the power spectrum is symmetric, so only the lower half and the Nyquist frequency are kept
+	outputSpectrum := make([]float64, (dft.numberFftPoints>>1)+1)
+	for i := range outputSpectrum {
+		re, im := real(frame[i]), imag(frame[i])
+		outputSpectrum[i] = re*re + im*im
+	}
+
+	return frontend.NewDoubleDataWithCollectTime(outputSpectrum, input.SampleRate(),
+		input.CollectTime(), input.FirstSampleNumber()), nil
+}
+
+/**
+ * Initializes the weight table and the bit reversal table for the FFT.
+ */
+func (dft *DiscreteFourierTransform) initializeFFT() {
+	dft.logBase2NumberFftPoints = 0
+	for 1<<uint(dft.logBase2NumberFftPoints) < dft.numberFftPoints {
+		dft.logBase2NumberFftPoints++
+	}
+	dft.numberFftPoints = 1 << uint(dft.logBase2NumberFftPoints)
+
+	sign := -1.0
+	if dft.invert {
+		sign = 1.0
+	}
+	dft.weightFft = make([]complex128, dft.numberFftPoints>>1)
+	for k := range dft.weightFft {
+		dft.weightFft[k] = cmplx.Rect(1, sign*2*math.Pi*float64(k)/float64(dft.numberFftPoints))
+	}
+
+	dft.bitReverse = make([]int, dft.numberFftPoints)
+	for i := range dft.bitReverse {
+		r := 0
+		for b := 0; b < dft.logBase2NumberFftPoints; b++ {
+			r |= ((i >> uint(b)) & 1) << uint(dft.logBase2NumberFftPoints-1-b)
+		}
+		dft.bitReverse[i] = r
+	}
+}
+
+/**
+ * Iterative radix-2 butterfly over a frame already stored in bit reversed order.
+ *
+ * @param frame the frame, transformed in place
+ */
+func (dft *DiscreteFourierTransform) fft(frame []complex128) {
+	n := len(frame)
+	for size := 2; size <= n; size <<= 1 {
+		half := size >> 1
+		step := n / size
+		for start := 0; start < n; start += size {
+			for k := 0; k < half; k++ {
+				t := dft.weightFft[k*step] * frame[start+k+half]
+				frame[start+k+half] = frame[start+k] - t
+				frame[start+k] += t
+			}
+		}
+	}
+	if dft.invert {
+		for i := range frame {
+			frame[i] /= complex(float64(n), 0)
+		}
+	}
+}
+
+func nextPowerOfTwo(n int) int {
+	p := 1
+	for p < n {
+		p <<= 1
+	}
+	return p
+}
@@ -0,0 +1,79 @@
+package transform
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/jtejido/go-sphinx/frontend"
+)
+
+const (
+	// The default value of the lifter.
+	DEFAULT_LIFTER_VALUE = 22
+)
+
+/**
+ * Applies the Lifter to the input mel-cepstrum to smooth cepstrum values. Each cepstrum c[i] is multiplied by
+ * 1 + (lifterValue / 2) * sin(Pi * i / lifterValue), as selected by -lifter in feat.params.
+ */
+type Lifter struct {
+	frontend.BaseDataProcessor
+	lifterValue   int
+	cepstrumSize  int
+	lifterWeights []float64
+}
+
+/** Constructs a Lifter with the default lifter value. */
+func NewDefaultLifter() *Lifter {
+	return NewLifter(DEFAULT_LIFTER_VALUE)
+}
+
+/**
+ * Constructs a Lifter.
+ *
+ * @param lifterValue the lifter value
+ */
+func NewLifter(lifterValue int) *Lifter {
+	return &Lifter{lifterValue: lifterValue}
+}
+
+func (l *Lifter) Initialize() {
+	l.lifterWeights = nil
+}
+
+/**
+ * Returns the next DoubleData object, which is the lifted mel-cepstrum of the input mel-cepstrum. Signals are
+ * returned unmodified.
+ *
+ * @return the next available DoubleData lifted mel-cepstrum, or null if no Data is available
+ * @throws error if there is a data processing error
+ */
+func (l *Lifter) GetData() (frontend.Data, error) {
+	input, err := l.Predecessor().GetData()
+	if err != nil {
+		return nil, err
+	}
+
+	if data, ok := input.(*frontend.DoubleData); ok {
+		melCepstrum := data.Values()
+		if l.lifterWeights == nil {
+			l.cepstrumSize = len(melCepstrum)
+			l.computeLifterWeights()
+		} else if len(melCepstrum) != l.cepstrumSize {
+			return nil, fmt.Errorf("mel cepstrum size is incorrect: melcepstrum.length == %d, cepstrumSize == %d",
+				len(melCepstrum), l.cepstrumSize)
+		}
+		for i := range melCepstrum {
+			melCepstrum[i] *= l.lifterWeights[i]
+		}
+	}
+
+	return input, nil
+}
+
+func (l *Lifter) computeLifterWeights() {
+	l.lifterWeights = make([]float64, l.cepstrumSize)
+	for i := range l.lifterWeights {
+		l.lifterWeights[i] = 1 + float64(l.lifterValue)/2*math.Sin(float64(i)*math.Pi/float64(l.lifterValue))
+	}
+}
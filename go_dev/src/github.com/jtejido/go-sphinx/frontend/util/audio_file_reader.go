@@ -0,0 +1,291 @@
+package util
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+const (
+	WAVE_FORMAT_PCM        = 0x0001
+	WAVE_FORMAT_IEEE_FLOAT = 0x0003
+	WAVE_FORMAT_ALAW       = 0x0006
+	WAVE_FORMAT_MULAW      = 0x0007
+	WAVE_FORMAT_EXTENSIBLE = 0xFFFE
+)
+
+/**
+ * Checks whether the stream starts with a RIFF/WAVE or AIFF/AIFC header without consuming it.
+ *
+ * @param reader the buffered stream to check
+ * @return true if the stream looks like an audio file with a header
+ */
+func HasAudioHeader(reader *bufio.Reader) bool {
+	magic, err := reader.Peek(12)
+	if err != nil {
+		return false
+	}
+
+	switch string(magic[0:4]) {
+	case "RIFF", "RIFX":
+		return string(magic[8:12]) == "WAVE"
+	case "FORM":
+		return string(magic[8:12]) == "AIFF" || string(magic[8:12]) == "AIFC"
+	}
+	return false
+}
+
+/**
+ * Reads the header of a RIFF/WAVE or AIFF/AIFC audio file. Supported WAVE formats are integer PCM, IEEE float,
+ * mu-law, A-law and WAVE_FORMAT_EXTENSIBLE wrapping any of those; supported AIFC compression types are NONE, sowt,
+ * fl32, fl64, ulaw and alaw.
+ *
+ * @param stream the audio file stream, positioned at its very beginning
+ * @return the format of the samples, and a reader positioned at the first sample that ends with the sample data
+ * @throws UnsupportedAudioFormatError if the container or the sample format is not supported
+ */
+func ReadAudioHeader(stream io.Reader) (*AudioFormat, io.Reader, error) {
+	var header [12]byte
+	if _, err := io.ReadFull(stream, header[:]); err != nil {
+		return nil, nil, fmt.Errorf("error reading audio header: %w", err)
+	}
+
+	switch string(header[0:4]) {
+	case "RIFF", "RIFX":
+		if string(header[8:12]) != "WAVE" {
+			return nil, nil, &UnsupportedAudioFormatError{Format: string(header[8:12]), Reason: "not a WAVE file"}
+		}
+		var order binary.ByteOrder = binary.LittleEndian
+		if string(header[0:4]) == "RIFX" {
+			order = binary.BigEndian
+		}
+		return readWaveChunks(stream, order)
+	case "FORM":
+		formType := string(header[8:12])
+		if formType != "AIFF" && formType != "AIFC" {
+			return nil, nil, &UnsupportedAudioFormatError{Format: formType, Reason: "not an AIFF file"}
+		}
+		return readAiffChunks(stream, formType == "AIFC")
+	}
+
+	return nil, nil, &UnsupportedAudioFormatError{Format: fmt.Sprintf("%q", header[0:4]), Reason: "unknown container"}
+}
+
+// chunk is the header of a RIFF or IFF chunk.
+type chunk struct {
+	id   string
+	size uint32
+}
+
+func readChunk(stream io.Reader, order binary.ByteOrder) (chunk, error) {
+	var header [8]byte
+	if _, err := io.ReadFull(stream, header[:]); err != nil {
+		return chunk{}, err
+	}
+	return chunk{string(header[0:4]), order.Uint32(header[4:8])}, nil
+}
+
+// skipChunk skips the body of a chunk including the pad byte of odd-sized chunks.
+func skipChunk(stream io.Reader, c chunk) error {
+	size := int64(c.size) + int64(c.size&1)
+	_, err := io.CopyN(io.Discard, stream, size)
+	return err
+}
+
+// dataReader returns a reader for a sample data chunk. Streaming writers leave the size at zero or at its maximum,
+// in which case the data runs to the end of the stream.
+func dataReader(stream io.Reader, size uint32) io.Reader {
+	if size == 0 || size == math.MaxUint32 {
+		return stream
+	}
+	return io.LimitReader(stream, int64(size))
+}
+
+func readWaveChunks(stream io.Reader, order binary.ByteOrder) (*AudioFormat, io.Reader, error) {
+	var format *AudioFormat
+
+	for {
+		c, err := readChunk(stream, order)
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil, nil, &UnsupportedAudioFormatError{Format: "WAVE", Reason: "no data chunk"}
+			}
+			return nil, nil, err
+		}
+
+		switch c.id {
+		case "fmt ":
+			body := make([]byte, int(c.size)+int(c.size&1))
+			if _, err := io.ReadFull(stream, body); err != nil {
+				return nil, nil, fmt.Errorf("error reading fmt chunk: %w", err)
+			}
+			if format, err = parseWaveFormat(body[:c.size], order); err != nil {
+				return nil, nil, err
+			}
+		case "data":
+			if format == nil {
+				return nil, nil, &UnsupportedAudioFormatError{Format: "WAVE", Reason: "data chunk before fmt chunk"}
+			}
+			return format, dataReader(stream, c.size), nil
+		default:
+			if err := skipChunk(stream, c); err != nil {
+				return nil, nil, fmt.Errorf("error skipping %q chunk: %w", c.id, err)
+			}
+		}
+	}
+}
+
+func parseWaveFormat(body []byte, order binary.ByteOrder) (*AudioFormat, error) {
+	if len(body) < 16 {
+		return nil, &UnsupportedAudioFormatError{Format: "WAVE", Reason: "fmt chunk too short"}
+	}
+
+	formatTag := order.Uint16(body[0:2])
+	format := &AudioFormat{
+		Channels:      int(order.Uint16(body[2:4])),
+		SampleRate:    int(order.Uint32(body[4:8])),
+		BitsPerSample: int(order.Uint16(body[14:16])),
+		BigEndian:     order == binary.BigEndian,
+	}
+
+	if formatTag == WAVE_FORMAT_EXTENSIBLE {
+		if len(body) < 40 {
+			return nil, &UnsupportedAudioFormatError{Format: "WAVE_FORMAT_EXTENSIBLE", Reason: "fmt chunk too short"}
+		}
+		// the sub format GUID starts with the format tag it stands for
+		formatTag = order.Uint16(body[24:26])
+		if containerBits := format.BitsPerSample; containerBits%8 != 0 {
+			format.BitsPerSample = (containerBits + 7) / 8 * 8
+		}
+	}
+
+	switch formatTag {
+	case WAVE_FORMAT_PCM:
+		// 8-bit WAVE data is unsigned, anything wider is signed
+		if format.BitsPerSample == 8 {
+			format.Encoding = PCM_UNSIGNED
+		} else {
+			format.Encoding = PCM_SIGNED
+		}
+	case WAVE_FORMAT_IEEE_FLOAT:
+		format.Encoding = PCM_FLOAT
+	case WAVE_FORMAT_ALAW:
+		format.Encoding = ALAW
+	case WAVE_FORMAT_MULAW:
+		format.Encoding = ULAW
+	default:
+		return nil, &UnsupportedAudioFormatError{Format: fmt.Sprintf("WAVE format tag 0x%04x", formatTag), Reason: "unsupported encoding"}
+	}
+
+	return format, format.Validate()
+}
+
+func readAiffChunks(stream io.Reader, compressed bool) (*AudioFormat, io.Reader, error) {
+	var format *AudioFormat
+
+	for {
+		c, err := readChunk(stream, binary.BigEndian)
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return nil, nil, &UnsupportedAudioFormatError{Format: "AIFF", Reason: "no SSND chunk"}
+			}
+			return nil, nil, err
+		}
+
+		switch c.id {
+		case "COMM":
+			body := make([]byte, int(c.size)+int(c.size&1))
+			if _, err := io.ReadFull(stream, body); err != nil {
+				return nil, nil, fmt.Errorf("error reading COMM chunk: %w", err)
+			}
+			if format, err = parseAiffCommon(body[:c.size], compressed); err != nil {
+				return nil, nil, err
+			}
+		case "SSND":
+			if format == nil {
+				return nil, nil, &UnsupportedAudioFormatError{Format: "AIFF", Reason: "SSND chunk before COMM chunk"}
+			}
+			var ssnd [8]byte
+			if _, err := io.ReadFull(stream, ssnd[:]); err != nil {
+				return nil, nil, fmt.Errorf("error reading SSND chunk: %w", err)
+			}
+			offset := binary.BigEndian.Uint32(ssnd[0:4])
+			if _, err := io.CopyN(io.Discard, stream, int64(offset)); err != nil {
+				return nil, nil, fmt.Errorf("error reading SSND chunk: %w", err)
+			}
+			size := c.size
+			if size >= 8+offset {
+				size -= 8 + offset
+			}
+			return format, dataReader(stream, size), nil
+		default:
+			if err := skipChunk(stream, c); err != nil {
+				return nil, nil, fmt.Errorf("error skipping %q chunk: %w", c.id, err)
+			}
+		}
+	}
+}
+
+func parseAiffCommon(body []byte, compressed bool) (*AudioFormat, error) {
+	if len(body) < 18 {
+		return nil, &UnsupportedAudioFormatError{Format: "AIFF", Reason: "COMM chunk too short"}
+	}
+
+	format := &AudioFormat{
+		Encoding:      PCM_SIGNED,
+		Channels:      int(binary.BigEndian.Uint16(body[0:2])),
+		BitsPerSample: int(binary.BigEndian.Uint16(body[6:8])),
+		SampleRate:    int(math.Round(extendedToFloat64(body[8:18]))),
+		BigEndian:     true,
+	}
+	// samples are stored in whole bytes, left-justified
+	format.BitsPerSample = (format.BitsPerSample + 7) / 8 * 8
+
+	if compressed {
+		if len(body) < 22 {
+			return nil, &UnsupportedAudioFormatError{Format: "AIFC", Reason: "COMM chunk too short"}
+		}
+		compressionType := string(body[18:22])
+		switch compressionType {
+		case "NONE", "twos":
+		case "sowt":
+			format.BigEndian = false
+		case "fl32", "FL32":
+			format.Encoding = PCM_FLOAT
+			format.BitsPerSample = 32
+		case "fl64", "FL64":
+			format.Encoding = PCM_FLOAT
+			format.BitsPerSample = 64
+		case "ulaw", "ULAW":
+			format.Encoding = ULAW
+			format.BitsPerSample = 8
+		case "alaw", "ALAW":
+			format.Encoding = ALAW
+			format.BitsPerSample = 8
+		default:
+			return nil, &UnsupportedAudioFormatError{Format: fmt.Sprintf("AIFC compression %q", bytes.TrimSpace(body[18:22])), Reason: "unsupported encoding"}
+		}
+	}
+
+	return format, format.Validate()
+}
+
+// extendedToFloat64 converts an 80-bit IEEE 754 extended precision number, as used for the AIFF sample rate.
+func extendedToFloat64(b []byte) float64 {
+	exponent := int(binary.BigEndian.Uint16(b[0:2]))
+	mantissa := binary.BigEndian.Uint64(b[2:10])
+
+	sign := 1.0
+	if exponent&0x8000 != 0 {
+		sign = -1.0
+		exponent &= 0x7FFF
+	}
+	if exponent == 0 && mantissa == 0 {
+		return 0
+	}
+	return sign * math.Ldexp(float64(mantissa), exponent-16383-63)
+}
@@ -0,0 +1,89 @@
+package util
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/jtejido/go-sphinx/frontend"
+	"github.com/jtejido/go-sphinx/util"
+)
+
+func waveFile(formatTag uint16, channels uint16, sampleRate uint32, bits uint16, data []byte) []byte {
+	var b bytes.Buffer
+	b.WriteString("RIFF")
+	binary.Write(&b, binary.LittleEndian, uint32(36+len(data)))
+	b.WriteString("WAVEfmt ")
+	binary.Write(&b, binary.LittleEndian, uint32(16))
+	binary.Write(&b, binary.LittleEndian, formatTag)
+	binary.Write(&b, binary.LittleEndian, channels)
+	binary.Write(&b, binary.LittleEndian, sampleRate)
+	binary.Write(&b, binary.LittleEndian, sampleRate*uint32(channels*bits/8))
+	binary.Write(&b, binary.LittleEndian, channels*bits/8)
+	binary.Write(&b, binary.LittleEndian, bits)
+	b.WriteString("data")
+	binary.Write(&b, binary.LittleEndian, uint32(len(data)))
+	b.Write(data)
+	return b.Bytes()
+}
+
+func readAll(t *testing.T, sds *StreamDataSource) []float64 {
+	var values []float64
+	for {
+		data, err := sds.GetData()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data == nil {
+			return values
+		}
+		if dd, ok := data.(*frontend.DoubleData); ok {
+			values = append(values, dd.Values()...)
+		}
+	}
+}
+
+func TestWaveStereoDownmix(t *testing.T) {
+	samples := []int16{1000, 3000, -2000, -4000}
+	var data bytes.Buffer
+	binary.Write(&data, binary.LittleEndian, samples)
+
+	sds := NewDefaultStreamDataSource()
+	if err := sds.SetAudioStream(bytes.NewReader(waveFile(WAVE_FORMAT_PCM, 2, 8000, 16, data.Bytes())), util.INFINITE); err != nil {
+		t.Fatal(err)
+	}
+	if sds.SampleRate() != 8000 {
+		t.Errorf("sample rate = %d, want 8000", sds.SampleRate())
+	}
+
+	values := readAll(t, sds)
+	if len(values) != 2 || values[0] != 2000 || values[1] != -3000 {
+		t.Errorf("values = %v, want [2000 -3000]", values)
+	}
+}
+
+func TestWaveMuLaw(t *testing.T) {
+	sds := NewDefaultStreamDataSource()
+	if err := sds.SetAudioStream(bytes.NewReader(waveFile(WAVE_FORMAT_MULAW, 1, 8000, 8, []byte{0xFF, 0x00, 0x80})), util.INFINITE); err != nil {
+		t.Fatal(err)
+	}
+
+	values := readAll(t, sds)
+	want := []float64{0, -32124, 32124}
+	for i := range want {
+		if values[i] != want[i] {
+			t.Errorf("values = %v, want %v", values, want)
+			break
+		}
+	}
+}
+
+func TestWaveUnsupported(t *testing.T) {
+	// ADPCM
+	_, _, err := ReadAudioHeader(bytes.NewReader(waveFile(0x0002, 1, 8000, 4, []byte{0})))
+	var formatErr *UnsupportedAudioFormatError
+	if !errors.As(err, &formatErr) {
+		t.Errorf("err = %v, want UnsupportedAudioFormatError", err)
+	}
+}
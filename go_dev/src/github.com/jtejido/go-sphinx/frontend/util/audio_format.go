@@ -0,0 +1,102 @@
+package util
+
+import "fmt"
+
+// Encoding is the way samples are represented in an audio stream.
+type Encoding int
+
+const (
+	// Linear PCM with signed integer samples.
+	PCM_SIGNED Encoding = iota
+	// Linear PCM with unsigned integer samples.
+	PCM_UNSIGNED
+	// Linear PCM with IEEE floating point samples.
+	PCM_FLOAT
+	// 8-bit G.711 mu-law companded samples.
+	ULAW
+	// 8-bit G.711 A-law companded samples.
+	ALAW
+)
+
+func (e Encoding) String() string {
+	switch e {
+	case PCM_SIGNED:
+		return "PCM_SIGNED"
+	case PCM_UNSIGNED:
+		return "PCM_UNSIGNED"
+	case PCM_FLOAT:
+		return "PCM_FLOAT"
+	case ULAW:
+		return "ULAW"
+	case ALAW:
+		return "ALAW"
+	}
+	return fmt.Sprintf("Encoding(%d)", int(e))
+}
+
+/**
+ * Describes the layout of the samples in an audio stream, as found in the header of an audio file or given by the
+ * user for headerless streams. Multi-channel data is interleaved frame by frame.
+ */
+type AudioFormat struct {
+	Encoding      Encoding
+	SampleRate    int
+	BitsPerSample int
+	Channels      int
+	BigEndian     bool
+}
+
+/**
+ * Checks that the format can be decoded by a StreamDataSource.
+ *
+ * @return an UnsupportedAudioFormatError describing the first problem found, or nil
+ */
+func (f *AudioFormat) Validate() error {
+	if f.SampleRate <= 0 {
+		return &UnsupportedAudioFormatError{Format: f.String(), Reason: "invalid sample rate"}
+	}
+	if f.Channels <= 0 {
+		return &UnsupportedAudioFormatError{Format: f.String(), Reason: "invalid channel count"}
+	}
+
+	switch f.Encoding {
+	case PCM_SIGNED, PCM_UNSIGNED:
+		switch f.BitsPerSample {
+		case 8, 16, 24, 32:
+			return nil
+		}
+	case PCM_FLOAT:
+		switch f.BitsPerSample {
+		case 32, 64:
+			return nil
+		}
+	case ULAW, ALAW:
+		if f.BitsPerSample == 8 {
+			return nil
+		}
+	default:
+		return &UnsupportedAudioFormatError{Format: f.String(), Reason: "unknown encoding"}
+	}
+
+	return &UnsupportedAudioFormatError{Format: f.String(), Reason: fmt.Sprintf("%d bits per sample", f.BitsPerSample)}
+}
+
+func (f *AudioFormat) String() string {
+	endian := "little-endian"
+	if f.BigEndian {
+		endian = "big-endian"
+	}
+	return fmt.Sprintf("%s %d Hz, %d bit, %d channels, %s", f.Encoding, f.SampleRate, f.BitsPerSample, f.Channels, endian)
+}
+
+/** Returned when an audio stream uses a container or sample format that cannot be decoded. */
+type UnsupportedAudioFormatError struct {
+	// Description of the offending format, e.g. the container type or format tag.
+	Format string
+	// Why the format cannot be decoded.
+	Reason string
+}
+
+func (e *UnsupportedAudioFormatError) Error() string {
+	return fmt.Sprintf("unsupported audio format %s: %s", e.Format, e.Reason)
+}
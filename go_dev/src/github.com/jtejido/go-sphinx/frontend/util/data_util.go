@@ -0,0 +1,147 @@
+package util
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/jtejido/go-sphinx/frontend"
+)
+
+/**
+ * Converts a DoubleData object to a FloatData object.
+ *
+ * @param data the DoubleData object to convert
+ * @return the resulting FloatData object
+ */
+func DoubleData2FloatData(data *frontend.DoubleData) *frontend.FloatData {
+	values := data.Values()
+	floatValues := make([]float32, len(values))
+	for i, v := range values {
+		floatValues[i] = float32(v)
+	}
+
+	return frontend.NewFloatDataWithCollectTime(floatValues, data.SampleRate(), data.CollectTime(), data.FirstSampleNumber())
+}
+
+/**
+ * Converts a FloatData object to a DoubleData object.
+ *
+ * @param data the FloatData object to convert
+ * @return the resulting DoubleData object
+ */
+func FloatData2DoubleData(data *frontend.FloatData) *frontend.DoubleData {
+	values := data.Values()
+	doubleValues := make([]float64, len(values))
+	for i, v := range values {
+		doubleValues[i] = float64(v)
+	}
+
+	return frontend.NewDoubleDataWithCollectTime(doubleValues, data.SampleRate(), data.CollectTime(), data.FirstSampleNumber())
+}
+
+/**
+ * Converts a byte array into an array of doubles. Each consecutive bytesPerValue bytes make up one value, which is
+ * scaled to the range of a signed 16-bit sample: unsigned data is centered around zero, 8-bit data is scaled up and
+ * 24- and 32-bit data is scaled down.
+ *
+ * @param byteArray     the byte array to convert
+ * @param bytesPerValue the number of bytes needed to make a value
+ * @param bigEndian     whether the most significant byte of each value comes first
+ * @param signedData    whether the data is signed
+ * @return a new array of doubles
+ */
+func BytesToValues(byteArray []byte, bytesPerValue int, bigEndian, signedData bool) []float64 {
+	values := make([]float64, len(byteArray)/bytesPerValue)
+	bits := uint(8 * bytesPerValue)
+	scale := math.Ldexp(1, 16-int(bits))
+
+	for j := range values {
+		sample := byteArray[j*bytesPerValue : (j+1)*bytesPerValue]
+		var val uint32
+		for c := 0; c < bytesPerValue; c++ {
+			b := sample[c]
+			if !bigEndian {
+				b = sample[bytesPerValue-1-c]
+			}
+			val = val<<8 | uint32(b)
+		}
+
+		var signed int64
+		if signedData {
+			// sign extend
+			signed = int64(int32(val<<(32-bits)) >> (32 - bits))
+		} else {
+			signed = int64(val) - int64(1)<<(bits-1)
+		}
+		values[j] = float64(signed) * scale
+	}
+	return values
+}
+
+/**
+ * Converts a byte array of IEEE floating point samples into an array of doubles scaled to the range of a signed
+ * 16-bit sample.
+ *
+ * @param byteArray     the byte array to convert
+ * @param bytesPerValue 4 for single precision, 8 for double precision samples
+ * @param bigEndian     whether the most significant byte of each value comes first
+ * @return a new array of doubles
+ */
+func FloatBytesToValues(byteArray []byte, bytesPerValue int, bigEndian bool) []float64 {
+	var order binary.ByteOrder = binary.LittleEndian
+	if bigEndian {
+		order = binary.BigEndian
+	}
+
+	values := make([]float64, len(byteArray)/bytesPerValue)
+	for j := range values {
+		sample := byteArray[j*bytesPerValue : (j+1)*bytesPerValue]
+		if bytesPerValue == 8 {
+			values[j] = math.Float64frombits(order.Uint64(sample)) * 32768.0
+		} else {
+			values[j] = float64(math.Float32frombits(order.Uint32(sample))) * 32768.0
+		}
+	}
+	return values
+}
+
+/**
+ * Expands a G.711 mu-law companded sample to a 16-bit linear sample.
+ *
+ * @param ulaw the companded sample
+ * @return the linear sample
+ */
+func ULawToLinear(ulaw byte) int16 {
+	u := ^ulaw
+	t := (int16(u&0x0F) << 3) + 0x84
+	t <<= (u & 0x70) >> 4
+	if u&0x80 != 0 {
+		return 0x84 - t
+	}
+	return t - 0x84
+}
+
+/**
+ * Expands a G.711 A-law companded sample to a 16-bit linear sample.
+ *
+ * @param alaw the companded sample
+ * @return the linear sample
+ */
+func ALawToLinear(alaw byte) int16 {
+	a := alaw ^ 0x55
+	t := int16(a&0x0F) << 4
+	seg := (a & 0x70) >> 4
+	switch seg {
+	case 0:
+		t += 8
+	case 1:
+		t += 0x108
+	default:
+		t += 0x108
+		t <<= seg - 1
+	}
+	if a&0x80 != 0 {
+		return t
+	}
+	return -t
+}
@@ -0,0 +1,166 @@
+package util
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/jtejido/go-sphinx/frontend"
+)
+
+const (
+	// The default number of cepstra per frame of a feature file.
+	DEFAULT_CEPSTRUM_LENGTH = 13
+
+	// The default frame rate of a feature file, in frames per second.
+	DEFAULT_FRAME_RATE = 100
+)
+
+/**
+ * Produces cepstra from a Sphinx feature file (.mfc), as written by sphinx_fe or SphinxTrain, so that precomputed
+ * features can be decoded without any signal processing.
+ *
+ * A feature file starts with a 4-byte integer holding the number of 32-bit floats that follow, the cepstra of all the
+ * frames one after the other. There is no byte order mark: the file is big-endian if the header read as big-endian
+ * matches the size of the file, and little-endian if the byte swapped header does.
+ *
+ * Each frame is returned as a DoubleData, between a DataStartSignal and a DataEndSignal. The sample numbers and
+ * collect times of the frames are derived from the sample rate and the frame rate, as if the cepstra came from
+ * audio.
+ */
+type FeatureFileDataSource struct {
+	frontend.BaseDataProcessor
+	cepstrumLength int
+	sampleRate     int
+	frameRate      int
+	cepstra        []float32
+	frame          int
+	started, ended bool
+}
+
+/** Constructs a FeatureFileDataSource for 13 cepstra per frame, 100 frames per second of 16kHz audio. */
+func NewDefaultFeatureFileDataSource() *FeatureFileDataSource {
+	return NewFeatureFileDataSource(DEFAULT_CEPSTRUM_LENGTH, DEFAULT_SAMPLE_RATE, DEFAULT_FRAME_RATE)
+}
+
+/**
+ * Constructs a FeatureFileDataSource.
+ *
+ * @param cepstrumLength the number of cepstra per frame
+ * @param sampleRate     the sample rate of the audio the features were computed from, in Hz
+ * @param frameRate      the number of frames per second
+ */
+func NewFeatureFileDataSource(cepstrumLength, sampleRate, frameRate int) *FeatureFileDataSource {
+	return &FeatureFileDataSource{
+		cepstrumLength: cepstrumLength,
+		sampleRate:     sampleRate,
+		frameRate:      frameRate,
+		ended:          true,
+	}
+}
+
+/**
+ * Reads the feature file from the given stream. The whole file is read at once, since its byte order can only be
+ * told from its size.
+ *
+ * @param stream the feature file
+ * @throws error if the stream is not a feature file with whole frames
+ */
+func (ffds *FeatureFileDataSource) SetInputStream(stream io.Reader) error {
+	data, err := io.ReadAll(stream)
+	if closer, ok := stream.(io.Closer); ok {
+		closer.Close()
+	}
+	if err != nil {
+		return err
+	}
+
+	cepstra, err := ReadFeatureFile(data)
+	if err != nil {
+		return err
+	}
+	if len(cepstra)%ffds.cepstrumLength != 0 {
+		return fmt.Errorf("feature file of %d values does not hold frames of %d cepstra", len(cepstra), ffds.cepstrumLength)
+	}
+
+	ffds.cepstra = cepstra
+	ffds.frame = 0
+	ffds.started = false
+	ffds.ended = false
+	return nil
+}
+
+/** @return the number of cepstra per frame */
+func (ffds *FeatureFileDataSource) CepstrumLength() int {
+	return ffds.cepstrumLength
+}
+
+/** @return the number of frames of the current file */
+func (ffds *FeatureFileDataSource) FrameCount() int {
+	return len(ffds.cepstra) / ffds.cepstrumLength
+}
+
+/**
+ * Returns the next Data object, which is the cepstrum of a frame, or a Signal.
+ *
+ * @return the next available Data object, returns null if no Data object is available
+ */
+func (ffds *FeatureFileDataSource) GetData() (frontend.Data, error) {
+	if ffds.ended {
+		return nil, nil
+	}
+
+	if !ffds.started {
+		ffds.started = true
+		return frontend.NewDataStartSignal(ffds.sampleRate, 0), nil
+	}
+
+	samplesPerFrame := int64(ffds.sampleRate / ffds.frameRate)
+	if ffds.frame >= ffds.FrameCount() {
+		ffds.ended = true
+		duration := int64(ffds.frame) * 1000 / int64(ffds.frameRate)
+		return frontend.NewDataEndSignal(duration, duration), nil
+	}
+
+	values := make([]float64, ffds.cepstrumLength)
+	offset := ffds.frame * ffds.cepstrumLength
+	for i := range values {
+		values[i] = float64(ffds.cepstra[offset+i])
+	}
+	firstSampleNumber := int64(ffds.frame) * samplesPerFrame
+	ffds.frame++
+
+	return frontend.NewDoubleDataWithSampleRate(values, ffds.sampleRate, firstSampleNumber), nil
+}
+
+/**
+ * Decodes the content of a Sphinx feature file, detecting its byte order from the value count of its header.
+ *
+ * @param data the content of the feature file
+ * @return the values of the file
+ * @throws error if the header matches the file size in neither byte order
+ */
+func ReadFeatureFile(data []byte) ([]float32, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("feature file is too short: %d bytes", len(data))
+	}
+
+	count := uint32((len(data) - 4) / 4)
+	var order binary.ByteOrder
+	switch {
+	case (len(data)-4)%4 == 0 && binary.BigEndian.Uint32(data) == count:
+		order = binary.BigEndian
+	case (len(data)-4)%4 == 0 && binary.LittleEndian.Uint32(data) == count:
+		order = binary.LittleEndian
+	default:
+		return nil, fmt.Errorf("feature file header (%d values) does not match its size (%d bytes)",
+			binary.BigEndian.Uint32(data), len(data))
+	}
+
+	values := make([]float32, count)
+	for i := range values {
+		values[i] = math.Float32frombits(order.Uint32(data[4+4*i:]))
+	}
+	return values, nil
+}
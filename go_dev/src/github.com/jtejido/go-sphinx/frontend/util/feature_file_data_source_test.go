@@ -0,0 +1,110 @@
+package util
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/jtejido/go-sphinx/frontend"
+)
+
+// cepstrumSource returns an utterance of the given cepstra.
+type cepstrumSource struct {
+	frontend.BaseDataProcessor
+	data []frontend.Data
+}
+
+func newCepstrumSource(cepstra [][]float64) *cepstrumSource {
+	data := []frontend.Data{frontend.NewDataStartSignal(16000, 0)}
+	for _, cepstrum := range cepstra {
+		data = append(data, frontend.NewDoubleData(cepstrum))
+	}
+	return &cepstrumSource{data: append(data, frontend.NewDataEndSignal(0, 0))}
+}
+
+func (s *cepstrumSource) GetData() (frontend.Data, error) {
+	if len(s.data) == 0 {
+		return nil, nil
+	}
+	data := s.data[0]
+	s.data = s.data[1:]
+	return data, nil
+}
+
+func TestFeatureFileRoundTrip(t *testing.T) {
+	cepstra := [][]float64{{1, -2, 3.5}, {4, 5.25, -6}}
+	for _, bigEndian := range []bool{true, false} {
+		var mfc bytes.Buffer
+		writer := NewFeatureFileWriter(&mfc, bigEndian)
+		writer.SetPredecessor(newCepstrumSource(cepstra))
+		writer.Initialize()
+		for {
+			data, err := writer.GetData()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if data == nil {
+				break
+			}
+		}
+
+		order := binary.ByteOrder(binary.LittleEndian)
+		if bigEndian {
+			order = binary.BigEndian
+		}
+		if mfc.Len() != 28 || order.Uint32(mfc.Bytes()) != 6 {
+			t.Fatalf("feature file of %d bytes with a header of %d values", mfc.Len(), order.Uint32(mfc.Bytes()))
+		}
+
+		ffds := NewFeatureFileDataSource(3, 16000, 100)
+		if err := ffds.SetInputStream(&mfc); err != nil {
+			t.Fatal(err)
+		}
+		if ffds.FrameCount() != 2 {
+			t.Fatalf("got %d frames, want 2", ffds.FrameCount())
+		}
+		var values []float64
+		var signals int
+		for {
+			data, err := ffds.GetData()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if data == nil {
+				break
+			}
+			switch d := data.(type) {
+			case *frontend.DoubleData:
+				if d.FirstSampleNumber() != int64(160*len(values)/3) {
+					t.Fatalf("frame starts at sample %d", d.FirstSampleNumber())
+				}
+				values = append(values, d.Values()...)
+			case *frontend.DataStartSignal, *frontend.DataEndSignal:
+				signals++
+			}
+		}
+		if signals != 2 || len(values) != 6 {
+			t.Fatalf("got %d signals and %d values, want 2 and 6", signals, len(values))
+		}
+		for i, v := range values {
+			if v != cepstra[i/3][i%3] {
+				t.Fatalf("value %d is %v, want %v", i, v, cepstra[i/3][i%3])
+			}
+		}
+	}
+}
+
+func TestFeatureFileErrors(t *testing.T) {
+	if _, err := ReadFeatureFile([]byte{0, 0, 0, 2, 0, 0, 0, 0}); err == nil {
+		t.Fatal("expected an error for a header larger than the file")
+	}
+	var mfc bytes.Buffer
+	binary.Write(&mfc, binary.LittleEndian, []float32{4, 1, 2, 3, 4})
+	binary.LittleEndian.PutUint32(mfc.Bytes(), 4)
+	if err := NewFeatureFileDataSource(3, 16000, 100).SetInputStream(bytes.NewReader(mfc.Bytes())); err == nil {
+		t.Fatal("expected an error for 4 values in frames of 3 cepstra")
+	}
+	if values, err := ReadFeatureFile(mfc.Bytes()); err != nil || len(values) != 4 || values[3] != 4 {
+		t.Fatalf("got %v, %v", values, err)
+	}
+}
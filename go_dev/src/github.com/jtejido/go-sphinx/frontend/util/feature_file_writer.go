@@ -0,0 +1,100 @@
+package util
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"math"
+
+	"github.com/jtejido/go-sphinx/frontend"
+)
+
+/**
+ * Dumps the features that pass through it to a Sphinx feature file (.mfc), leaving them unchanged. It can be placed
+ * anywhere in the front end: after the cepstrum it writes the files SphinxTrain reads, after the feature extraction
+ * it dumps the features the decoder scores.
+ *
+ * The values of all the DoubleData and FloatData between a DataStartSignal and a DataEndSignal are written as one
+ * file when the DataEndSignal passes, since the header of the file holds the number of values.
+ */
+type FeatureFileWriter struct {
+	frontend.BaseDataProcessor
+	output    io.Writer
+	byteOrder binary.ByteOrder
+	values    []float32
+}
+
+/**
+ * Constructs a FeatureFileWriter.
+ *
+ * @param output    the stream the feature file is written to
+ * @param bigEndian whether the file is written big-endian, otherwise little-endian
+ */
+func NewFeatureFileWriter(output io.Writer, bigEndian bool) *FeatureFileWriter {
+	ffw := &FeatureFileWriter{output: output, byteOrder: binary.LittleEndian}
+	if bigEndian {
+		ffw.byteOrder = binary.BigEndian
+	}
+	return ffw
+}
+
+/**
+ * Replaces the stream the next feature file is written to.
+ *
+ * @param output the stream the feature file is written to
+ */
+func (ffw *FeatureFileWriter) SetOutputStream(output io.Writer) {
+	ffw.output = output
+}
+
+func (ffw *FeatureFileWriter) Initialize() {
+	ffw.values = nil
+}
+
+/**
+ * Returns the next Data object of the predecessor, after recording its values.
+ *
+ * @return the next available Data object, returns null if no Data object is available
+ * @throws error if the feature file cannot be written
+ */
+func (ffw *FeatureFileWriter) GetData() (frontend.Data, error) {
+	data, err := ffw.Predecessor().GetData()
+	if err != nil {
+		return nil, err
+	}
+
+	switch d := data.(type) {
+	case *frontend.DataStartSignal:
+		ffw.values = ffw.values[:0]
+	case *frontend.DoubleData:
+		for _, v := range d.Values() {
+			ffw.values = append(ffw.values, float32(v))
+		}
+	case *frontend.FloatData:
+		ffw.values = append(ffw.values, d.Values()...)
+	case *frontend.DataEndSignal:
+		if err := ffw.write(); err != nil {
+			return nil, err
+		}
+	}
+
+	return data, nil
+}
+
+func (ffw *FeatureFileWriter) write() error {
+	w := bufio.NewWriter(ffw.output)
+	buf := make([]byte, 4)
+
+	ffw.byteOrder.PutUint32(buf, uint32(len(ffw.values)))
+	if _, err := w.Write(buf); err != nil {
+		return err
+	}
+	for _, v := range ffw.values {
+		ffw.byteOrder.PutUint32(buf, math.Float32bits(v))
+		if _, err := w.Write(buf); err != nil {
+			return err
+		}
+	}
+	ffw.values = ffw.values[:0]
+	return w.Flush()
+}
@@ -0,0 +1,370 @@
+package util
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jtejido/go-sphinx/frontend"
+	"github.com/jtejido/go-sphinx/util"
+)
+
+const (
+	// The default sample rate of the input stream, in Hz.
+	DEFAULT_SAMPLE_RATE = 16000
+
+	// The default number of bytes to read from the input stream for each Data block.
+	DEFAULT_BYTES_PER_READ = 3200
+
+	// The default number of bits per value.
+	DEFAULT_BITS_PER_SAMPLE = 16
+
+	// The default endianness of the input data.
+	DEFAULT_BIG_ENDIAN_DATA = false
+
+	// The default signedness of the input data.
+	DEFAULT_SIGNED_DATA = true
+
+	// Selects the average of all the channels of multi-channel input.
+	ALL_CHANNELS = -1
+)
+
+/**
+ * A StreamDataSource converts data from an io.Reader into Data objects. One would call SetInputStream to set the
+ * input stream from which Data objects are read, or SetAudioStream for audio files whose header describes the sample
+ * format. The size of each Data object is determined by the bytes per read setting, rounded down to a whole number
+ * of sample frames.
+ *
+ * The produced DoubleData values are always scaled to the range of signed 16-bit samples, whatever the encoding and
+ * sample width of the input, so that downstream processors see the same dynamic range regardless of the stream
+ * format. Multi-channel input is mixed down to mono by averaging the channels, unless a single channel is selected
+ * with SetChannel.
+ *
+ * The stream is bracketed by a DataStartSignal and a DataEndSignal. If a TimeFrame is given, samples before its start
+ * are skipped and the stream is ended once its end is passed.
+ */
+type StreamDataSource struct {
+	frontend.BaseDataProcessor
+	dataStream       io.Reader
+	format           AudioFormat
+	bytesPerRead     int
+	bytesPerValue    int
+	bytesPerFrame    int
+	totalValuesRead  int64
+	streamEndReached bool
+	utteranceEndSent bool
+	utteranceStarted bool
+	timeFrame        *util.TimeFrame
+	channel          int
+}
+
+/** Constructs a StreamDataSource for 16 kHz, 16-bit, signed, little-endian audio. */
+func NewDefaultStreamDataSource() *StreamDataSource {
+	sds, err := NewStreamDataSource(DEFAULT_SAMPLE_RATE, DEFAULT_BYTES_PER_READ, DEFAULT_BITS_PER_SAMPLE,
+		DEFAULT_BIG_ENDIAN_DATA, DEFAULT_SIGNED_DATA)
+	if err != nil {
+		panic(err)
+	}
+	return sds
+}
+
+/**
+ * Constructs a StreamDataSource.
+ *
+ * @param sampleRate    the sample rate of the input stream, in Hz
+ * @param bytesPerRead  the number of bytes to read for each Data block
+ * @param bitsPerSample the number of bits per value, one of 8, 16, 24 or 32
+ * @param bigEndian     whether the input data is big-endian
+ * @param signedData    whether the input data is signed
+ */
+func NewStreamDataSource(sampleRate, bytesPerRead, bitsPerSample int, bigEndian, signedData bool) (*StreamDataSource, error) {
+	sds := new(StreamDataSource)
+	if err := sds.SetFormat(sampleRate, bitsPerSample, bigEndian, signedData); err != nil {
+		return nil, err
+	}
+	sds.bytesPerRead = bytesPerRead
+	sds.timeFrame = util.INFINITE
+	sds.channel = ALL_CHANNELS
+	sds.Initialize()
+	return sds, nil
+}
+
+/**
+ * Sets the format of the linear PCM samples read from a single channel input stream.
+ *
+ * @param sampleRate    the sample rate of the input stream, in Hz
+ * @param bitsPerSample the number of bits per value, one of 8, 16, 24 or 32
+ * @param bigEndian     whether the input data is big-endian
+ * @param signedData    whether the input data is signed
+ */
+func (sds *StreamDataSource) SetFormat(sampleRate, bitsPerSample int, bigEndian, signedData bool) error {
+	encoding := PCM_SIGNED
+	if !signedData {
+		encoding = PCM_UNSIGNED
+	}
+
+	return sds.SetAudioFormat(&AudioFormat{
+		Encoding:      encoding,
+		SampleRate:    sampleRate,
+		BitsPerSample: bitsPerSample,
+		Channels:      1,
+		BigEndian:     bigEndian,
+	})
+}
+
+/**
+ * Sets the format of the samples read from the input stream.
+ *
+ * @param format the sample format
+ * @throws UnsupportedAudioFormatError if the format cannot be decoded
+ */
+func (sds *StreamDataSource) SetAudioFormat(format *AudioFormat) error {
+	if err := format.Validate(); err != nil {
+		return err
+	}
+
+	sds.format = *format
+	sds.bytesPerValue = format.BitsPerSample / 8
+	sds.bytesPerFrame = sds.bytesPerValue * format.Channels
+	return nil
+}
+
+/**
+ * Selects the channel of multi-channel input that is used, instead of the average of all the channels. Mono input is
+ * not affected.
+ *
+ * @param channel the zero based channel index, or ALL_CHANNELS to mix all of them down
+ */
+func (sds *StreamDataSource) SetChannel(channel int) {
+	sds.channel = channel
+}
+
+/** @return the selected channel, or ALL_CHANNELS if the channels are mixed down. */
+func (sds *StreamDataSource) Channel() int {
+	return sds.channel
+}
+
+/** @return the format of the samples read from the input stream. */
+func (sds *StreamDataSource) AudioFormat() AudioFormat {
+	return sds.format
+}
+
+func (sds *StreamDataSource) Initialize() {
+	// reset all stream tags
+	sds.streamEndReached = false
+	sds.utteranceEndSent = false
+	sds.utteranceStarted = false
+
+	if sds.bytesPerRead < sds.bytesPerFrame {
+		sds.bytesPerRead = sds.bytesPerFrame
+	}
+	// only read whole sample frames
+	sds.bytesPerRead -= sds.bytesPerRead % sds.bytesPerFrame
+}
+
+/**
+ * Sets the io.Reader from which to read the audio data.
+ *
+ * @param stream    the input stream
+ * @param timeFrame the part of the stream to process, util.INFINITE for all of it
+ */
+func (sds *StreamDataSource) SetInputStream(stream io.Reader, timeFrame *util.TimeFrame) {
+	sds.dataStream = stream
+	if timeFrame == nil {
+		timeFrame = util.INFINITE
+	}
+	sds.timeFrame = timeFrame
+	sds.streamEndReached = false
+	sds.utteranceEndSent = false
+	sds.utteranceStarted = false
+	sds.totalValuesRead = 0
+}
+
+/**
+ * Sets an audio file stream from which to read the audio data. If the stream starts with a RIFF/WAVE or AIFF/AIFC
+ * header, the sample rate and sample format are taken from it; otherwise the stream is read as headerless audio in
+ * the currently configured format.
+ *
+ * @param stream    the input stream
+ * @param timeFrame the part of the stream to process, util.INFINITE for all of it
+ * @throws UnsupportedAudioFormatError if the header describes a format that cannot be decoded
+ */
+func (sds *StreamDataSource) SetAudioStream(stream io.Reader, timeFrame *util.TimeFrame) error {
+	reader := bufio.NewReader(stream)
+	if !HasAudioHeader(reader) {
+		sds.SetInputStream(reader, timeFrame)
+		return nil
+	}
+
+	format, data, err := ReadAudioHeader(reader)
+	if err != nil {
+		return err
+	}
+	if err := sds.SetAudioFormat(format); err != nil {
+		return err
+	}
+	sds.Initialize()
+	sds.SetInputStream(data, timeFrame)
+	return nil
+}
+
+/** @return the sample rate of the input stream, in Hz. */
+func (sds *StreamDataSource) SampleRate() int {
+	return sds.format.SampleRate
+}
+
+/** @return the number of bits per value of the input stream. */
+func (sds *StreamDataSource) BitsPerSample() int {
+	return sds.format.BitsPerSample
+}
+
+/**
+ * Reads and returns the next Data from the input stream. Return null if there is no more data.
+ *
+ * @return the next Data or <code>nil</code> if none is available
+ * @throws error if there is a data processing error
+ */
+func (sds *StreamDataSource) GetData() (frontend.Data, error) {
+	if sds.streamEndReached {
+		if !sds.utteranceEndSent {
+			// since 'firstSampleNumber' starts at 0, the last
+			// sample number should be 'totalValuesRead - 1'
+			sds.utteranceEndSent = true
+			return sds.createDataEndSignal(), nil
+		}
+		return nil, nil
+	}
+
+	if !sds.utteranceStarted {
+		sds.utteranceStarted = true
+		return frontend.NewDataStartSignal(sds.format.SampleRate, time.Now().UnixMilli()), nil
+	}
+
+	if sds.dataStream == nil {
+		sds.streamEndReached = true
+		sds.utteranceEndSent = true
+		return sds.createDataEndSignal(), nil
+	}
+
+	var output *frontend.DoubleData
+	var err error
+	for {
+		output, err = sds.readNextFrame()
+		if err != nil {
+			return nil, err
+		}
+		if output == nil || sds.duration() >= sds.timeFrame.GetStart() {
+			break
+		}
+	}
+
+	if output == nil || sds.duration() > sds.timeFrame.GetEnd() {
+		sds.utteranceEndSent = true
+		sds.streamEndReached = true
+		return sds.createDataEndSignal(), nil
+	}
+
+	return output, nil
+}
+
+func (sds *StreamDataSource) createDataEndSignal() *frontend.DataEndSignal {
+	return frontend.NewDataEndSignal(sds.duration(), time.Now().UnixMilli())
+}
+
+/**
+ * Returns the next Data from the input stream, or nil if there is none available
+ *
+ * @return a Data or nil
+ * @throws error if there is a data processing error
+ */
+func (sds *StreamDataSource) readNextFrame() (*frontend.DoubleData, error) {
+	// read one frame's worth of bytes
+	samplesBuffer := make([]byte, sds.bytesPerRead)
+	firstSample := sds.totalValuesRead
+
+	totalRead, err := io.ReadFull(sds.dataStream, samplesBuffer)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, fmt.Errorf("error reading data: %w", err)
+	}
+
+	// drop a trailing partial sample frame
+	totalRead -= totalRead % sds.bytesPerFrame
+	if totalRead <= 0 {
+		sds.closeDataStream()
+		return nil, nil
+	}
+
+	sds.totalValuesRead += int64(totalRead / sds.bytesPerFrame)
+	if totalRead < len(samplesBuffer) {
+		sds.closeDataStream()
+	}
+
+	values := sds.decode(samplesBuffer[:totalRead])
+	return frontend.NewDoubleDataWithSampleRate(values, sds.format.SampleRate, firstSample), nil
+}
+
+// decode converts raw sample bytes to 16-bit scaled values and mixes interleaved channels down to mono, or picks
+// the selected channel.
+func (sds *StreamDataSource) decode(samples []byte) []float64 {
+	var values []float64
+	switch sds.format.Encoding {
+	case PCM_SIGNED:
+		values = BytesToValues(samples, sds.bytesPerValue, sds.format.BigEndian, true)
+	case PCM_UNSIGNED:
+		values = BytesToValues(samples, sds.bytesPerValue, sds.format.BigEndian, false)
+	case PCM_FLOAT:
+		values = FloatBytesToValues(samples, sds.bytesPerValue, sds.format.BigEndian)
+	case ULAW:
+		values = make([]float64, len(samples))
+		for i, b := range samples {
+			values[i] = float64(ULawToLinear(b))
+		}
+	case ALAW:
+		values = make([]float64, len(samples))
+		for i, b := range samples {
+			values[i] = float64(ALawToLinear(b))
+		}
+	}
+
+	channels := sds.format.Channels
+	if channels == 1 {
+		return values
+	}
+
+	if sds.channel >= 0 {
+		// an out of range channel falls back to the last one
+		channel := sds.channel
+		if channel >= channels {
+			channel = channels - 1
+		}
+		mono := make([]float64, len(values)/channels)
+		for i := range mono {
+			mono[i] = values[i*channels+channel]
+		}
+		return mono
+	}
+
+	mono := make([]float64, len(values)/channels)
+	for i := range mono {
+		var sum float64
+		for c := 0; c < channels; c++ {
+			sum += values[i*channels+c]
+		}
+		mono[i] = sum / float64(channels)
+	}
+	return mono
+}
+
+func (sds *StreamDataSource) closeDataStream() {
+	sds.streamEndReached = true
+	if closer, ok := sds.dataStream.(io.Closer); ok {
+		closer.Close()
+	}
+}
+
+/** @return the duration of the audio read so far, in milliseconds. */
+func (sds *StreamDataSource) duration() int64 {
+	return int64(float64(sds.totalValuesRead) / float64(sds.format.SampleRate) * 1000.0)
+}
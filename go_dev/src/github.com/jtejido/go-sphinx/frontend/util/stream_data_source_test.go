@@ -0,0 +1,179 @@
+package util
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/jtejido/go-sphinx/frontend"
+	"github.com/jtejido/go-sphinx/util"
+)
+
+func TestStreamDataSourceFormats(t *testing.T) {
+	// the extreme negative value, the smallest negative step and the value 1 in 16-bit units, wherever the width
+	// allows it
+	for _, test := range []struct {
+		bits              int
+		bigEndian, signed bool
+		data              []byte
+		expected          []float64
+	}{
+		{8, false, true, []byte{0x80, 0xFF, 0x00, 0x7F}, []float64{-32768, -256, 0, 32512}},
+		{8, true, true, []byte{0x80, 0xFF, 0x00, 0x7F}, []float64{-32768, -256, 0, 32512}},
+		{8, false, false, []byte{0x00, 0x7F, 0x80, 0xFF}, []float64{-32768, -256, 0, 32512}},
+		{8, true, false, []byte{0x00, 0x7F, 0x80, 0xFF}, []float64{-32768, -256, 0, 32512}},
+		{16, false, true, []byte{0x00, 0x80, 0xFF, 0xFF, 0x01, 0x00, 0xFF, 0x7F}, []float64{-32768, -1, 1, 32767}},
+		{16, true, true, []byte{0x80, 0x00, 0xFF, 0xFF, 0x00, 0x01, 0x7F, 0xFF}, []float64{-32768, -1, 1, 32767}},
+		{16, false, false, []byte{0x00, 0x00, 0xFF, 0x7F, 0x01, 0x80, 0xFF, 0xFF}, []float64{-32768, -1, 1, 32767}},
+		{16, true, false, []byte{0x00, 0x00, 0x7F, 0xFF, 0x80, 0x01, 0xFF, 0xFF}, []float64{-32768, -1, 1, 32767}},
+		{24, false, true, []byte{0x00, 0x00, 0x80, 0xFF, 0xFF, 0xFF, 0x00, 0x01, 0x00}, []float64{-32768, -1.0 / 256, 1}},
+		{24, true, true, []byte{0x80, 0x00, 0x00, 0xFF, 0xFF, 0xFF, 0x00, 0x01, 0x00}, []float64{-32768, -1.0 / 256, 1}},
+		{24, false, false, []byte{0x00, 0x00, 0x00, 0xFF, 0xFF, 0x7F, 0x00, 0x01, 0x80}, []float64{-32768, -1.0 / 256, 1}},
+		{24, true, false, []byte{0x00, 0x00, 0x00, 0x7F, 0xFF, 0xFF, 0x80, 0x01, 0x00}, []float64{-32768, -1.0 / 256, 1}},
+		{32, false, true, []byte{0, 0, 0, 0x80, 0, 0, 0xFF, 0xFF, 0, 0, 0x01, 0}, []float64{-32768, -1, 1}},
+		{32, true, true, []byte{0x80, 0, 0, 0, 0xFF, 0xFF, 0, 0, 0, 0x01, 0, 0}, []float64{-32768, -1, 1}},
+		{32, false, false, []byte{0, 0, 0, 0, 0, 0, 0xFF, 0x7F, 0, 0, 0x01, 0x80}, []float64{-32768, -1, 1}},
+		{32, true, false, []byte{0, 0, 0, 0, 0x7F, 0xFF, 0, 0, 0x80, 0x01, 0, 0}, []float64{-32768, -1, 1}},
+	} {
+		sds, err := NewStreamDataSource(16000, DEFAULT_BYTES_PER_READ, test.bits, test.bigEndian, test.signed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sds.SetInputStream(bytes.NewReader(test.data), util.INFINITE)
+
+		values := readAll(t, sds)
+		if len(values) != len(test.expected) {
+			t.Fatalf("%d bits, big-endian %v, signed %v: values = %v, want %v", test.bits, test.bigEndian,
+				test.signed, values, test.expected)
+		}
+		for i := range values {
+			if values[i] != test.expected[i] {
+				t.Errorf("%d bits, big-endian %v, signed %v: values = %v, want %v", test.bits, test.bigEndian,
+					test.signed, values, test.expected)
+				break
+			}
+		}
+	}
+}
+
+func TestStreamDataSourceTimeFrame(t *testing.T) {
+	// 100 samples at 1 kHz read 10 at a time, each holding its sample number
+	samples := make([]int16, 100)
+	for i := range samples {
+		samples[i] = int16(i)
+	}
+	var data bytes.Buffer
+	binary.Write(&data, binary.LittleEndian, samples)
+
+	for _, test := range []struct {
+		timeFrame      *util.TimeFrame
+		first, samples int
+	}{
+		{util.INFINITE, 0, 100},
+		// a block is skipped until the time read reaches the start, and the stream ends once it passes the end
+		{util.NewTimeFrame(20, 50), 10, 40},
+		{util.NewTimeFrame(0, 25), 0, 20},
+	} {
+		sds, err := NewStreamDataSource(1000, 20, 16, false, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sds.SetInputStream(bytes.NewReader(data.Bytes()), test.timeFrame)
+
+		values := readAll(t, sds)
+		if len(values) != test.samples {
+			t.Fatalf("time frame %v: got %d samples, want %d", test.timeFrame, len(values), test.samples)
+		}
+		for i, value := range values {
+			if value != float64(test.first+i) {
+				t.Fatalf("time frame %v: sample %d = %v, want %d", test.timeFrame, i, value, test.first+i)
+			}
+		}
+	}
+}
+
+func TestStreamDataSourceSignals(t *testing.T) {
+	sds, err := NewStreamDataSource(1000, 20, 16, false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sds.SetInputStream(bytes.NewReader(make([]byte, 50)), util.INFINITE)
+
+	// a data start, the blocks of 10, 10 and 5 samples, a data end, then nothing
+	var firstSamples []int64
+	var ended bool
+	for i := 0; ; i++ {
+		data, err := sds.GetData()
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch d := data.(type) {
+		case *frontend.DataStartSignal:
+			if i != 0 {
+				t.Fatalf("data start at %d", i)
+			}
+			if d.SampleRate() != 1000 {
+				t.Errorf("data start at %d Hz", d.SampleRate())
+			}
+		case *frontend.DoubleData:
+			if i == 0 || ended {
+				t.Fatalf("data at %d, ended %v", i, ended)
+			}
+			firstSamples = append(firstSamples, d.FirstSampleNumber())
+		case *frontend.DataEndSignal:
+			if ended || len(firstSamples) == 0 {
+				t.Fatalf("data end at %d", i)
+			}
+			if d.Duration() != 25 {
+				t.Errorf("data end after %d ms, want 25", d.Duration())
+			}
+			ended = true
+		case nil:
+			if !ended {
+				t.Fatalf("no data end before the end of the stream")
+			}
+			if len(firstSamples) != 3 || firstSamples[0] != 0 || firstSamples[1] != 10 || firstSamples[2] != 20 {
+				t.Errorf("blocks start at samples %v, want [0 10 20]", firstSamples)
+			}
+			return
+		default:
+			t.Fatalf("unexpected %v", data)
+		}
+	}
+}
+
+func TestStreamDataSourceChannel(t *testing.T) {
+	// interleaved left and right samples of headerless stereo PCM
+	samples := []int16{1000, 3000, -2000, -4000, 500, 700}
+	var data bytes.Buffer
+	binary.Write(&data, binary.LittleEndian, samples)
+
+	for _, test := range []struct {
+		channel  int
+		expected []float64
+	}{
+		{0, []float64{1000, -2000, 500}},
+		{1, []float64{3000, -4000, 700}},
+		{ALL_CHANNELS, []float64{2000, -3000, 600}},
+	} {
+		sds := NewDefaultStreamDataSource()
+		format := AudioFormat{Encoding: PCM_SIGNED, SampleRate: 16000, BitsPerSample: 16, Channels: 2}
+		if err := sds.SetAudioFormat(&format); err != nil {
+			t.Fatal(err)
+		}
+		sds.SetChannel(test.channel)
+		sds.Initialize()
+		sds.SetInputStream(bytes.NewReader(data.Bytes()), util.INFINITE)
+
+		values := readAll(t, sds)
+		if len(values) != len(test.expected) {
+			t.Fatalf("channel %d: values = %v, want %v", test.channel, values, test.expected)
+		}
+		for i := range values {
+			if values[i] != test.expected[i] {
+				t.Errorf("channel %d: values = %v, want %v", test.channel, values, test.expected)
+				break
+			}
+		}
+	}
+}
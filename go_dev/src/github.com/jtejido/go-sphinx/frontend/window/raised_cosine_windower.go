@@ -0,0 +1,311 @@
+package window
+
+import (
+	"math"
+
+	"github.com/jtejido/go-sphinx/frontend"
+)
+
+const (
+	// The default size of the window, in milliseconds.
+	DEFAULT_WINDOW_SIZE_MS = 25.625
+
+	// The default time between shifts, in milliseconds.
+	DEFAULT_WINDOW_SHIFT_MS = 10.0
+
+	// The default alpha value. 0.46 gives a Hamming window, 0.5 a Hanning window.
+	DEFAULT_ALPHA = 0.46
+)
+
+/**
+ * Slices up a Data object into a number of overlapping windows (usually referred to as "frames" in the speech world).
+ * In order to minimize the signal discontinuities at the boundaries of each frame, we multiply each frame with a
+ * raised cosine windowing function. Moreover, the system uses overlapping windows to capture information that may
+ * occur at the window boundaries. These events would not be well represented if the windows were juxtaposed.
+ *
+ * The number of resulting windows depends on the window size and the window shift (commonly known as frame shift in
+ * speech world). The windows overlap by (windowSize - windowShift) samples. Samples that do not fill a whole window
+ * are kept until the next DoubleData arrives, and dropped at the end of an utterance, unless the whole utterance is
+ * shorter than one window, in which case it is zero padded to a single window.
+ *
+ * The raised cosine function is defined as: W(x) = (1 - alpha) - (alpha * cos((2 * Math.PI * x) / (windowSize - 1)))
+ */
+type RaisedCosineWindower struct {
+	frontend.BaseDataProcessor
+	windowSizeInMs, windowShiftInMs float64
+	alpha                           float64
+	sampleRate                      int
+	cosineWindow                    []float64
+	windowShift                     int
+	overflowBuffer                  []float64
+	outputQueue                     []frontend.Data
+	currentFirstSampleNumber        int64
+	windowsInUtterance              int
+}
+
+/** Constructs a Hamming RaisedCosineWindower with the default window size and shift. */
+func NewDefaultRaisedCosineWindower() *RaisedCosineWindower {
+	return NewRaisedCosineWindower(DEFAULT_ALPHA, DEFAULT_WINDOW_SIZE_MS, DEFAULT_WINDOW_SHIFT_MS)
+}
+
+/**
+ * Constructs a RaisedCosineWindower.
+ *
+ * @param alpha           the alpha of the raised cosine function
+ * @param windowSizeInMs  the size of each window, in milliseconds
+ * @param windowShiftInMs the shift between two windows, in milliseconds
+ */
+func NewRaisedCosineWindower(alpha, windowSizeInMs, windowShiftInMs float64) *RaisedCosineWindower {
+	return &RaisedCosineWindower{
+		alpha:                    alpha,
+		windowSizeInMs:           windowSizeInMs,
+		windowShiftInMs:          windowShiftInMs,
+		outputQueue:              make([]frontend.Data, 0),
+		currentFirstSampleNumber: -1,
+	}
+}
+
+func (w *RaisedCosineWindower) Initialize() {
+	w.outputQueue = w.outputQueue[:0]
+	w.overflowBuffer = nil
+	w.currentFirstSampleNumber = -1
+}
+
+/** @return the shift between two windows, in samples, or zero before the sample rate is known. */
+func (w *RaisedCosineWindower) WindowShift() int {
+	return w.windowShift
+}
+
+/** @return the size of a window, in samples, or zero before the sample rate is known. */
+func (w *RaisedCosineWindower) WindowSize() int {
+	return len(w.cosineWindow)
+}
+
+/**
+ * Returns the next Data object, which is usually a window of the input Data, with the windowing function applied to
+ * it.
+ *
+ * @return the next available Data object, returns null if no Data object is available
+ * @throws error if a data processing error occurred
+ */
+func (w *RaisedCosineWindower) GetData() (frontend.Data, error) {
+	if len(w.outputQueue) == 0 {
+		input, err := w.Predecessor().GetData()
+		if err != nil {
+			return nil, err
+		}
+
+		switch data := input.(type) {
+		case nil:
+		case *frontend.DoubleData:
+			if w.currentFirstSampleNumber == -1 {
+				w.currentFirstSampleNumber = data.FirstSampleNumber()
+			}
+			// should not be necessary if all DataProcessor would forward Signals. Unfortunately this
+			// is currently not the case.
+			w.createWindow(data.SampleRate())
+			// process the Data, and output the windows
+			if err := w.process(data); err != nil {
+				return nil, err
+			}
+		case *frontend.DataStartSignal:
+			w.createWindow(data.SampleRate())
+			// reset the current first sample number
+			w.currentFirstSampleNumber = -1
+			w.windowsInUtterance = 0
+			w.outputQueue = append(w.outputQueue, input)
+		case *frontend.SpeechStartSignal:
+			// reset the current first sample number
+			w.currentFirstSampleNumber = -1
+			w.windowsInUtterance = 0
+			w.outputQueue = append(w.outputQueue, input)
+		case *frontend.DataEndSignal, *frontend.SpeechEndSignal:
+			// end of utterance handling
+			w.processUtteranceEnd()
+			w.outputQueue = append(w.outputQueue, input)
+		default:
+			w.outputQueue = append(w.outputQueue, input)
+		}
+	}
+
+	if len(w.outputQueue) == 0 {
+		return nil, nil
+	}
+
+	output := w.outputQueue[0]
+	w.outputQueue = w.outputQueue[1:]
+	return output, nil
+}
+
+/**
+ * Applies the Windowing to the given Data. The resulting windows are cached in the outputQueue.
+ *
+ * @param input the input Data object
+ * @throws error if a data processing error occurs
+ */
+func (w *RaisedCosineWindower) process(input *frontend.DoubleData) error {
+	windowSize := len(w.cosineWindow)
+	allSamples := append(w.overflowBuffer, input.Values()...)
+	var utteranceEnd frontend.Data
+	pending := make([]frontend.Data, 0)
+
+	// read in more Data if we have under one window's length of data
+	for len(allSamples) < windowSize {
+		next, err := w.Predecessor().GetData()
+		if err != nil {
+			return err
+		}
+		if next == nil {
+			break
+		}
+		if data, ok := next.(*frontend.DoubleData); ok {
+			allSamples = append(allSamples, data.Values()...)
+			continue
+		}
+		if isUtteranceEnd(next) {
+			utteranceEnd = next
+			break
+		}
+		pending = append(pending, next)
+	}
+
+	// apply the window
+	residue := w.applyRaisedCosineWindow(allSamples)
+
+	// save elements that also belong to the next window
+	w.overflowBuffer = append([]float64(nil), allSamples[residue:]...)
+	w.outputQueue = append(w.outputQueue, pending...)
+
+	if utteranceEnd != nil {
+		// end of utterance handling
+		w.processUtteranceEnd()
+		w.outputQueue = append(w.outputQueue, utteranceEnd)
+	}
+	return nil
+}
+
+func isUtteranceEnd(data frontend.Data) bool {
+	switch data.(type) {
+	case *frontend.DataEndSignal, *frontend.SpeechEndSignal:
+		return true
+	}
+	return false
+}
+
+/**
+ * What happens when an DataEndSignal is received. If there are still samples left over, we don't output them, as
+ * there is not a sufficient number of samples for the window.
+ */
+func (w *RaisedCosineWindower) processUtteranceEnd() {
+	w.overflowBuffer = nil
+	w.currentFirstSampleNumber = -1
+	w.windowsInUtterance = 0
+}
+
+/**
+ * Applies the Hamming window function to the given double array. The windows are added to the output queue. Returns
+ * the index of the first array element of next window that is not produced because of insufficient data.
+ *
+ * @param in     the audio data to apply window and the Hamming window function
+ * @return the index of the first array element of the next window
+ */
+func (w *RaisedCosineWindower) applyRaisedCosineWindow(in []float64) int {
+	windowSize := len(w.cosineWindow)
+	var windowCount int
+
+	// if no windows can be created but there is some data,
+	// pad it with zeros, unless the utterance already has windows
+	if len(in) < windowSize {
+		if len(in) == 0 || w.windowsInUtterance > 0 {
+			return 0
+		}
+		padded := make([]float64, windowSize)
+		copy(padded, in)
+		in = padded
+		windowCount = 1
+	} else {
+		windowCount = windowCountOf(len(in), windowSize, w.windowShift)
+	}
+
+	windowStart := 0
+	for i := 0; i < windowCount; i++ {
+		myWindow := make([]float64, windowSize)
+		// apply the Hamming Window function to the window of data
+		for k := range myWindow {
+			myWindow[k] = in[windowStart+k] * w.cosineWindow[k]
+		}
+
+		// add the frame to the output queue
+		w.outputQueue = append(w.outputQueue, frontend.NewDoubleDataWithSampleRate(myWindow, w.sampleRate, w.currentFirstSampleNumber))
+		w.currentFirstSampleNumber += int64(w.windowShift)
+		windowStart += w.windowShift
+		w.windowsInUtterance++
+	}
+
+	if windowStart > len(in) {
+		return len(in)
+	}
+	return windowStart
+}
+
+/**
+ * Returns the number of windows in the given array, given the windowSize and windowShift.
+ *
+ * @param sampleCount the number of samples
+ * @param windowSize  the window size
+ * @param windowShift the window shift
+ * @return the number of windows
+ */
+func windowCountOf(sampleCount, windowSize, windowShift int) int {
+	if sampleCount < windowSize {
+		return 0
+	}
+	return (sampleCount-windowSize)/windowShift + 1
+}
+
+/**
+ * Creates the cosine window for the given sample rate, if it has not been created yet.
+ *
+ * @param sampleRate the sample rate of the audio
+ */
+func (w *RaisedCosineWindower) createWindow(sampleRate int) {
+	if w.cosineWindow != nil && sampleRate == w.sampleRate {
+		return
+	}
+
+	w.sampleRate = sampleRate
+	windowSize := SamplesPerWindow(sampleRate, w.windowSizeInMs)
+	w.cosineWindow = make([]float64, windowSize)
+	w.windowShift = SamplesPerShift(sampleRate, w.windowShiftInMs)
+
+	if windowSize > 1 {
+		oneMinusAlpha := 1 - w.alpha
+		for i := range w.cosineWindow {
+			w.cosineWindow[i] = oneMinusAlpha - w.alpha*math.Cos(2*math.Pi*float64(i)/(float64(windowSize)-1.0))
+		}
+	}
+	w.overflowBuffer = nil
+}
+
+/**
+ * Returns the number of samples per window given the sample rate (in Hertz) and window size (in milliseconds).
+ *
+ * @param sampleRate     the sample rate in Hertz (i.e., frequency per seconds)
+ * @param windowSizeInMs the window size in milliseconds
+ * @return the number of samples per window
+ */
+func SamplesPerWindow(sampleRate int, windowSizeInMs float64) int {
+	return int(float64(sampleRate)*windowSizeInMs/1000.0 + 0.5)
+}
+
+/**
+ * Returns the number of samples in a window shift given the sample rate (in Hertz) and the window shift (in
+ * milliseconds).
+ *
+ * @param sampleRate      the sample rate in Hertz (i.e., frequency per seconds)
+ * @param windowShiftInMs the window shift in milliseconds
+ * @return the number of samples in a window shift
+ */
+func SamplesPerShift(sampleRate int, windowShiftInMs float64) int {
+	return int(float64(sampleRate)*windowShiftInMs/1000.0 + 0.5)
+}
@@ -2,12 +2,15 @@ module github.com/jtejido/go-sphinx
 
 go 1.21.0
 
+require (
+	github.com/gen2brain/malgo v0.11.10
+	github.com/jtejido/linear v0.0.0-20231130193738-4f8e5ba49219
+	github.com/sirupsen/logrus v1.9.3
+)
+
 require (
 	github.com/BurntSushi/toml v1.3.2 // indirect
-	github.com/gen2brain/malgo v0.11.10 // indirect
 	github.com/gordonklaus/portaudio v0.0.0-20230709114228-aafa478834f5 // indirect
-	github.com/jtejido/linear v0.0.0-20231130193738-4f8e5ba49219 // indirect
-	github.com/sirupsen/logrus v1.9.3 // indirect
 	go.lsp.dev/uri v0.3.0 // indirect
 	golang.org/x/sys v0.0.0-20220715151400-c0bba94af5f8 // indirect
 )
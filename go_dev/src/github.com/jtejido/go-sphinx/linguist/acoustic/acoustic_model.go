@@ -0,0 +1,51 @@
+package acoustic
+
+/**
+ * Represents the generic interface to the acoustic model of the recognizer: the HMMs of its units, and the units it
+ * knows about.
+ */
+type AcousticModel interface {
+
+	/**
+	 * Loads the acoustic model.
+	 *
+	 * @throws error if the model can't be loaded
+	 */
+	Allocate() error
+
+	/** Deallocates the acoustic model. */
+	Deallocate()
+
+	/**
+	 * Given a unit, returns the HMM that best matches the given unit. If exactMatch is false and an exact match is not
+	 * found, then different word positions are used. If any of the contexts are non-silence filler units, a silence
+	 * filler unit is tried instead.
+	 *
+	 * @param unit       the unit of interest
+	 * @param position   the position of the unit of interest
+	 * @param exactMatch if true, only an exact match is acceptable.
+	 * @return the HMM that best matches, or nil if no match could be found.
+	 */
+	LookupNearestHMM(unit *Unit, position HMMPosition, exactMatch bool) HMM
+
+	/**
+	 * Returns the context independent units of the model.
+	 *
+	 * @return the context independent units
+	 */
+	ContextIndependentUnits() []*Unit
+
+	/**
+	 * Returns the size of the left context for context dependent units.
+	 *
+	 * @return the left context size
+	 */
+	LeftContextSize() int
+
+	/**
+	 * Returns the size of the right context for context dependent units.
+	 *
+	 * @return the right context size
+	 */
+	RightContextSize() int
+}
@@ -0,0 +1,119 @@
+/**
+ * Package acoustictest provides an acoustic model and a dictionary for the tests of the linguists, which build their
+ * search graphs without real models.
+ */
+package acoustictest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jtejido/go-sphinx/frontend"
+	"github.com/jtejido/go-sphinx/linguist/acoustic"
+	"github.com/jtejido/go-sphinx/linguist/acoustic/tiedstate/model"
+	"github.com/jtejido/go-sphinx/linguist/dictionary"
+)
+
+/** The filler dictionary of NewDictionary: the sentence delimiters and the silence. */
+const NOISE_DICTIONARY = "<s> SIL\n</s> SIL\n<sil> SIL\n"
+
+/**
+ * A three state left to right HMM, followed by its non-emitting exit state. Its states score the frames with the
+ * scorer of the acoustic model, or 0 without one.
+ */
+type HMM struct {
+	unit     *acoustic.Unit
+	position acoustic.HMMPosition
+	states   []*HMMState
+	scorer   func(unit *acoustic.Unit, data frontend.Data) float32
+}
+
+/** A state of an HMM: it loops on itself with probability -1 and goes to the next state with probability -2. */
+type HMMState struct {
+	hmm        *HMM
+	state      int
+	successors []*acoustic.HMMStateArc
+}
+
+/** Creates the HMM of a unit at a position. */
+func NewHMM(unit *acoustic.Unit, position acoustic.HMMPosition) *HMM {
+	hmm := &HMM{unit: unit, position: position}
+	for i := 0; i < 4; i++ {
+		hmm.states = append(hmm.states, &HMMState{hmm: hmm, state: i})
+	}
+	for i := 0; i < 3; i++ {
+		hmm.states[i].successors = []*acoustic.HMMStateArc{
+			acoustic.NewHMMStateArc(hmm.states[i], -1),
+			acoustic.NewHMMStateArc(hmm.states[i+1], -2),
+		}
+	}
+	return hmm
+}
+
+func (h *HMM) Unit() *acoustic.Unit                                 { return h.unit }
+func (h *HMM) BaseUnit() *acoustic.Unit                             { return h.unit.BaseUnit() }
+func (h *HMM) State(which int) acoustic.HMMState                    { return h.states[which] }
+func (h *HMM) Order() int                                           { return 3 }
+func (h *HMM) Position() acoustic.HMMPosition                       { return h.position }
+func (h *HMM) InitialState() acoustic.HMMState                      { return h.states[0] }
+func (s *HMMState) HMM() acoustic.HMM                               { return s.hmm }
+func (s *HMMState) MixtureComponents() model.MixtureComponent       { return nil }
+func (s *HMMState) MixtureId() int64                                { return 0 }
+func (s *HMMState) LogMixtureWeights() []float32                    { return nil }
+func (s *HMMState) State() int                                      { return s.state }
+func (s *HMMState) CalculateComponentScore(frontend.Data) []float32 { return nil }
+func (s *HMMState) IsEmitting() bool                                { return s.state < 3 }
+func (s *HMMState) Successors() []*acoustic.HMMStateArc             { return s.successors }
+func (s *HMMState) IsExitState() bool                               { return s.state == 3 }
+
+func (s *HMMState) Score(data frontend.Data) float32 {
+	if s.hmm.scorer == nil {
+		return 0
+	}
+	return s.hmm.scorer(s.hmm.unit, data)
+}
+
+/**
+ * An acoustic model with the given context size and context independent units, which has an HMM for any unit at any
+ * position. The optional scorer gives the log score of a frame for the states of the HMM of a unit.
+ */
+type AcousticModel struct {
+	ContextSize int
+	Units       []*acoustic.Unit
+	Scorer      func(unit *acoustic.Unit, data frontend.Data) float32
+}
+
+func (m *AcousticModel) Allocate() error                           { return nil }
+func (m *AcousticModel) Deallocate()                               {}
+func (m *AcousticModel) ContextIndependentUnits() []*acoustic.Unit { return m.Units }
+func (m *AcousticModel) LeftContextSize() int                      { return m.ContextSize }
+func (m *AcousticModel) RightContextSize() int                     { return m.ContextSize }
+
+func (m *AcousticModel) LookupNearestHMM(unit *acoustic.Unit, position acoustic.HMMPosition, exactMatch bool) acoustic.HMM {
+	hmm := NewHMM(unit, position)
+	hmm.scorer = m.Scorer
+	return hmm
+}
+
+/** Writes the files, by name, to a temporary directory removed at the end of the test, and returns the directory. */
+func WriteFiles(t testing.TB, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+/** Creates a dictionary of the given cmudict entries, with the fillers of NOISE_DICTIONARY. */
+func NewDictionary(t testing.TB, words string) *dictionary.TextDictionary {
+	t.Helper()
+	dir := WriteFiles(t, map[string]string{"cmudict": words, "noisedict": NOISE_DICTIONARY})
+	d := dictionary.NewDefaultTextDictionary()
+	d.SetDictionaryPath(filepath.Join(dir, "cmudict"))
+	d.SetFillerPath(filepath.Join(dir, "noisedict"))
+	return d
+}
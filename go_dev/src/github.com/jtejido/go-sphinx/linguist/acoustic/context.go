@@ -4,8 +4,14 @@ var (
 	EMPTY_CONTEXT = new(Context)
 )
 
-/** Represents  the context for a unit */
+/**
+ * Represents  the context for a unit. The empty context is the one of context independent units, the left and right
+ * contexts of context dependent units are created with NewLeftRightContext.
+ */
 type Context struct {
+	leftContext, rightContext []*Unit
+	leftRight                 bool
+	name                      string
 }
 
 /**
@@ -16,12 +22,19 @@ type Context struct {
  * @return true if there is a partial match
  */
 func (c *Context) IsPartialMatch(context *Context) bool {
-	return true
+	if !c.leftRight {
+		return true
+	}
+	if !context.leftRight {
+		return context == EMPTY_CONTEXT && c.leftContext == nil && c.rightContext == nil
+	}
+	return (c.leftContext == nil || context.leftContext == nil || isContextMatch(c.leftContext, context.leftContext)) &&
+		(c.rightContext == nil || context.rightContext == nil || isContextMatch(c.rightContext, context.rightContext))
 }
 
 /** Provides a string representation of a context */
 func (c *Context) String() string {
-	return ""
+	return c.name
 }
 
 /**
@@ -0,0 +1,131 @@
+package acoustic
+
+import (
+	"fmt"
+
+	"github.com/jtejido/go-sphinx/util"
+)
+
+type hmmPoolKey struct {
+	unit, left, right string
+	position          HMMPosition
+}
+
+/**
+ * The HMMPool provides the HMMs of the triphones of an acoustic model, given the names of their base, left and right
+ * units, without building the context dependent units anew for every lookup. HMMs are looked up in the acoustic
+ * model on first use, the nearest one being taken when the model lacks the exact triphone.
+ *
+ * Filler units are context independent, and stand as silence in the contexts of other units. Contexts are ignored if
+ * the model has none.
+ */
+type HMMPool struct {
+	model       AcousticModel
+	unitManager *UnitManager
+	logger      util.Logger
+
+	ciUnits map[string]*Unit
+	hmms    map[hmmPoolKey]HMM
+}
+
+/**
+ * Constructs an HMMPool.
+ *
+ * @param model       the acoustic model
+ * @param unitManager the unit manager creating the context dependent units
+ * @param logger      an optional logger
+ * @throws error if the model has contexts of more than one unit
+ */
+func NewHMMPool(model AcousticModel, unitManager *UnitManager, logger util.Logger) (*HMMPool, error) {
+	if model.LeftContextSize() > 1 || model.RightContextSize() > 1 {
+		return nil, fmt.Errorf("contexts of %d left and %d right units are not supported",
+			model.LeftContextSize(), model.RightContextSize())
+	}
+	pool := &HMMPool{
+		model:       model,
+		unitManager: unitManager,
+		logger:      logger,
+		ciUnits:     make(map[string]*Unit),
+		hmms:        make(map[hmmPoolKey]HMM),
+	}
+	for _, unit := range model.ContextIndependentUnits() {
+		pool.ciUnits[unit.Name()] = unit
+	}
+	return pool, nil
+}
+
+/**
+ * Returns the context independent units of the model, by name.
+ *
+ * @return the context independent units
+ */
+func (p *HMMPool) ContextIndependentUnits() map[string]*Unit {
+	return p.ciUnits
+}
+
+/**
+ * Returns the unit standing for the given one in the context of another unit: silence for fillers, nil if the model
+ * has no context on that side.
+ *
+ * @param unit the unit of the context
+ * @param left whether the context is the left one
+ * @return the unit of the context
+ */
+func (p *HMMPool) ContextUnit(unit *Unit, left bool) *Unit {
+	if left && p.model.LeftContextSize() == 0 || !left && p.model.RightContextSize() == 0 || unit == nil {
+		return nil
+	}
+	if unit.IsFiller() {
+		return SILENCE
+	}
+	return unit.BaseUnit()
+}
+
+/**
+ * Returns the HMM of a unit at the given position in a word, in the given contexts.
+ *
+ * @param unit     the context independent unit
+ * @param left     the unit before it, or nil if unknown
+ * @param right    the unit after it, or nil if unknown
+ * @param position the position of the unit in its word
+ * @return the HMM, or nil if the model has none for the unit
+ */
+func (p *HMMPool) GetHMM(unit, left, right *Unit, position HMMPosition) HMM {
+	left, right = p.ContextUnit(left, true), p.ContextUnit(right, false)
+	if unit.IsFiller() {
+		left, right = nil, nil
+	}
+
+	key := hmmPoolKey{unit: unit.Name(), position: position}
+	if left != nil {
+		key.left = left.Name()
+	}
+	if right != nil {
+		key.right = right.Name()
+	}
+	if hmm, ok := p.hmms[key]; ok {
+		return hmm
+	}
+
+	lookup := unit
+	if left != nil || right != nil {
+		var leftContext, rightContext []*Unit
+		if left != nil {
+			leftContext = []*Unit{left}
+		}
+		if right != nil {
+			rightContext = []*Unit{right}
+		}
+		lookup = p.unitManager.UnitFromContext(unit.Name(), unit.IsFiller(), NewLeftRightContext(leftContext, rightContext))
+	}
+	hmm := p.model.LookupNearestHMM(lookup, position, false)
+	p.hmms[key] = hmm
+	return hmm
+}
+
+/** Logs the number of HMMs looked up so far. */
+func (p *HMMPool) DumpInfo() {
+	if p.logger != nil {
+		p.logger.Infof("HMM pool: %d context independent units, %d HMMs", len(p.ciUnits), len(p.hmms))
+	}
+}
@@ -64,7 +64,7 @@ type HMMState interface {
 	 *
 	 * @return the set of successor state arcs
 	 */
-	Successors() *HMMStateArc
+	Successors() []*HMMStateArc
 
 	/**
 	 * Determines if this state is an exit state of the HMM
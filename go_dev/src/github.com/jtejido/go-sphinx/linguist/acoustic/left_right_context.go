@@ -0,0 +1,89 @@
+package acoustic
+
+import (
+	"strings"
+	"sync"
+)
+
+var (
+	leftRightContexts   = make(map[string]*Context)
+	leftRightContextsMu sync.Mutex
+)
+
+/**
+ * Creates the context of a context dependent unit, made of the units before and after it. Contexts are shared: the
+ * same left and right units always give the same Context.
+ *
+ * @param leftContext  the units on the left, nearest last, or nil if any left context matches
+ * @param rightContext the units on the right, nearest first, or nil if any right context matches
+ * @return the context
+ */
+func NewLeftRightContext(leftContext, rightContext []*Unit) *Context {
+	name := ContextName(leftContext) + "," + ContextName(rightContext)
+
+	leftRightContextsMu.Lock()
+	defer leftRightContextsMu.Unlock()
+	context, ok := leftRightContexts[name]
+	if !ok {
+		context = &Context{
+			leftContext:  leftContext,
+			rightContext: rightContext,
+			leftRight:    true,
+			name:         name,
+		}
+		leftRightContexts[name] = context
+	}
+	return context
+}
+
+/**
+ * Returns the units on the left of a context dependent unit.
+ *
+ * @return the left context, nil for the empty context or if any left context matches
+ */
+func (c *Context) LeftContext() []*Unit {
+	return c.leftContext
+}
+
+/**
+ * Returns the units on the right of a context dependent unit.
+ *
+ * @return the right context, nil for the empty context or if any right context matches
+ */
+func (c *Context) RightContext() []*Unit {
+	return c.rightContext
+}
+
+/**
+ * Returns the string form of a left or right context: the names of its units separated by dots, "*" for a nil
+ * context and "(empty)" for an empty one.
+ *
+ * @param context the units of the context
+ * @return the name of the context
+ */
+func ContextName(context []*Unit) string {
+	if context == nil {
+		return "*"
+	}
+	if len(context) == 0 {
+		return "(empty)"
+	}
+	names := make([]string, len(context))
+	for i, unit := range context {
+		names[i] = unit.Name()
+	}
+	return strings.Join(names, ".")
+}
+
+// isContextMatch checks that two contexts hold units of the same names.
+func isContextMatch(a, b []*Unit) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Name() != b[i].Name() {
+			return false
+		}
+	}
+	return true
+}
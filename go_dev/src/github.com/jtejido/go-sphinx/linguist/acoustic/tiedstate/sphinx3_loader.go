@@ -3,6 +3,7 @@ package tiedstate
 import (
 	"bufio"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 	"math"
@@ -116,6 +117,20 @@ func (l *Sphinx3Loader) Location() string {
 	return l.location
 }
 
+// TransformMatrix returns the feature transform of the model, or nil if it doesn't ship one.
+func (l *Sphinx3Loader) TransformMatrix() [][]float32 {
+	return l.transformMatrix
+}
+
+// Properties returns the front end parameters of the model, as read from feat.params.
+func (l *Sphinx3Loader) Properties() *util.Properties {
+	props := util.NewProperties()
+	for key, value := range l.modelProps {
+		props.SetProperty(key, value)
+	}
+	return props
+}
+
 func (l *Sphinx3Loader) HasTiedMixtures() bool {
 	modelType := l.modelProps["-model"]
 	if modelType == "" {
@@ -911,6 +926,11 @@ func (l *Sphinx3Loader) loadTransformMatrix(path string) ([][]float32, error) {
 
 	props := make(map[string]string)
 
+	// the transform is optional, most models don't ship one
+	if _, err := os.Stat(filepath.Join(l.location, path)); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
 	dis, err := l.ReadS3BinaryHeader(path, props)
 	if err != nil {
 		return nil, err
@@ -947,6 +967,9 @@ func (l *Sphinx3Loader) loadTransformMatrix(path string) ([][]float32, error) {
 	result := make([][]float32, numRows)
 	for i := 0; i < numRows; i++ {
 		result[i], err = l.ReadFloatArray(dis, numValues)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	if err := l.validateChecksum(dis, doCheckSum); err != nil {
@@ -17,6 +17,11 @@ type UnitManager struct {
 	logger util.Logger
 }
 
+/** Constructs a UnitManager that does not log the units it creates. */
+func NewDefaultUnitManager() *UnitManager {
+	return NewUnitManager(nil)
+}
+
 func NewUnitManager(logger util.Logger) *UnitManager {
 	return &UnitManager{
 		ciMap: map[string]*Unit{
@@ -0,0 +1,237 @@
+package allphone
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/jtejido/go-sphinx/linguist"
+	"github.com/jtejido/go-sphinx/linguist/acoustic"
+	"github.com/jtejido/go-sphinx/linguist/dictionary"
+	"github.com/jtejido/go-sphinx/linguist/language/ngram"
+	"github.com/jtejido/go-sphinx/util"
+)
+
+const (
+	// The default linear probability of inserting a phone.
+	DEFAULT_PHONE_INSERTION_PROBABILITY = 1.0
+
+	// The default of whether the phones are triphones when the acoustic model has contexts.
+	DEFAULT_USE_CONTEXT_DEPENDENT_PHONES = false
+)
+
+// A linguist recognizing phones rather than words: its search graph is a loop over the context independent units of
+// the acoustic model, any phone following any other. It serves as the fast match of the lookahead search, which
+// scores the phones a few frames ahead, and on its own recognizes the phone strings of an utterance.
+//
+// The phones are ended by word states whose words are the phones themselves, so the word path of a result is the
+// phone string. Every phone ends a valid sentence.
+//
+// With context dependent phones, each phone is scored by the HMM of its triphone: its left context is the phone
+// before it, its right context one of the phones after it, and the phone is only followed by its right context.
+// Fillers are context independent, and stand as silence in the contexts of their neighbours.
+//
+// Entering a phone costs the phone insertion probability. An optional phone n-gram language model, whose words are
+// the names of the phones, adds the probability of each phone after the one before it, scaled by the language
+// weight; the first phone follows the sentence start word.
+type AllPhoneLinguist struct {
+	acousticModel acoustic.AcousticModel
+	unitManager   *acoustic.UnitManager
+	languageModel ngram.LanguageModel
+	logger        util.Logger
+
+	logPhoneInsertionProbability float64
+	languageWeight               float64
+	useContextDependentPhones    bool
+
+	hmmPool           *acoustic.HMMPool
+	phones            []*acoustic.Unit
+	phoneWords        map[string]*dictionary.Word
+	rightContexts     []*acoustic.Unit
+	sentenceStartWord *dictionary.Word
+	emptyHistory      *linguist.WordSequence
+	searchGraph       *PhoneLoopSearchGraph
+}
+
+// Creates an AllPhoneLinguist of context independent phones, without language model.
+func NewDefaultAllPhoneLinguist(acousticModel acoustic.AcousticModel, unitManager *acoustic.UnitManager) *AllPhoneLinguist {
+	return NewAllPhoneLinguist(acousticModel, unitManager, nil, DEFAULT_PHONE_INSERTION_PROBABILITY,
+		linguist.DEFAULT_LANGUAGE_WEIGHT, DEFAULT_USE_CONTEXT_DEPENDENT_PHONES, nil)
+}
+
+// Creates an AllPhoneLinguist.
+//
+// Accepts the acoustic model providing the phones and their HMMs, the unit manager creating the triphones, an
+// optional phone language model, the linear phone insertion probability, the language weight, whether the phones are
+// triphones, and an optional logger.
+func NewAllPhoneLinguist(acousticModel acoustic.AcousticModel, unitManager *acoustic.UnitManager,
+	languageModel ngram.LanguageModel, phoneInsertionProbability, languageWeight float64,
+	useContextDependentPhones bool, logger util.Logger) *AllPhoneLinguist {
+	return &AllPhoneLinguist{
+		acousticModel:                acousticModel,
+		unitManager:                  unitManager,
+		languageModel:                languageModel,
+		logger:                       logger,
+		logPhoneInsertionProbability: float64(util.GetLogMath().LinearToLog(phoneInsertionProbability)),
+		languageWeight:               languageWeight,
+		useContextDependentPhones:    useContextDependentPhones,
+		emptyHistory:                 linguist.NewEmptyWordSequence(),
+	}
+}
+
+// Loads the acoustic model and the language model, and creates the phone loop.
+func (l *AllPhoneLinguist) Allocate() error {
+	if err := l.acousticModel.Allocate(); err != nil {
+		return err
+	}
+	if l.languageModel != nil {
+		if err := l.languageModel.Allocate(); err != nil {
+			return err
+		}
+	}
+
+	pool, err := acoustic.NewHMMPool(l.acousticModel, l.unitManager, l.logger)
+	if err != nil {
+		return err
+	}
+	l.hmmPool = pool
+
+	l.phones = nil
+	l.phoneWords = make(map[string]*dictionary.Word)
+	for _, unit := range pool.ContextIndependentUnits() {
+		l.phones = append(l.phones, unit)
+		pronunciation := dictionary.NewPronunciation([]*acoustic.Unit{unit}, "", 1)
+		word := dictionary.NewWord(unit.Name(), []*dictionary.Pronunciation{pronunciation}, unit.IsFiller())
+		pronunciation.SetWord(word)
+		l.phoneWords[unit.Name()] = word
+	}
+	if len(l.phones) == 0 {
+		return errors.New("the acoustic model has no context independent units")
+	}
+	sort.Slice(l.phones, func(i, j int) bool { return l.phones[i].Name() < l.phones[j].Name() })
+	l.sentenceStartWord = dictionary.NewWord(dictionary.SENTENCE_START_SPELLING, nil, true)
+
+	// every phone can follow a phone, silence standing for the fillers
+	l.rightContexts = []*acoustic.Unit{nil}
+	if l.useContextDependentPhones && l.acousticModel.RightContextSize() > 0 {
+		l.rightContexts = nil
+		seen := make(map[*acoustic.Unit]bool)
+		for _, phone := range l.phones {
+			if context := pool.ContextUnit(phone, false); !seen[context] {
+				seen[context] = true
+				l.rightContexts = append(l.rightContexts, context)
+			}
+		}
+	}
+
+	l.searchGraph = &PhoneLoopSearchGraph{initialState: PhoneNonEmittingSearchState{linguist: l}}
+	if l.logger != nil {
+		l.logger.Infof("Phone loop of %d phones, %d right contexts", len(l.phones), len(l.rightContexts))
+	}
+	return nil
+}
+
+// Releases the models and the phone loop.
+func (l *AllPhoneLinguist) Deallocate() {
+	l.acousticModel.Deallocate()
+	if l.languageModel != nil {
+		l.languageModel.Deallocate()
+	}
+	l.hmmPool = nil
+	l.phones = nil
+	l.phoneWords = nil
+	l.searchGraph = nil
+}
+
+// Retrieves the search graph, or nil before allocation.
+func (l *AllPhoneLinguist) GetSearchGraph() linguist.SearchGraph {
+	if l.searchGraph == nil {
+		return nil
+	}
+	return l.searchGraph
+}
+
+// The phone loop is static: nothing is done before a recognition.
+func (l *AllPhoneLinguist) StartRecognition() {}
+
+// Tells the language model the utterance ended.
+func (l *AllPhoneLinguist) StopRecognition() {
+	if l.languageModel != nil {
+		l.languageModel.OnUtteranceEnd()
+	}
+}
+
+// Returns the context independent phones of the loop, sorted by name.
+func (l *AllPhoneLinguist) GetPhones() []*acoustic.Unit {
+	return l.phones
+}
+
+// Returns the word standing for a phone, or nil if the phone is not in the loop.
+func (l *AllPhoneLinguist) GetPhoneWord(phone *acoustic.Unit) *dictionary.Word {
+	return l.phoneWords[phone.Name()]
+}
+
+// Returns the arcs entering the phones that can follow the given one, nil at the start, ended in the given right
+// context, nil if any phone can follow.
+func (l *AllPhoneLinguist) enterPhones(previous, rightContext *acoustic.Unit) []linguist.SearchStateArc {
+	var arcs []linguist.SearchStateArc
+	for _, phone := range l.phones {
+		if rightContext != nil && l.hmmPool.ContextUnit(phone, false) != rightContext {
+			continue
+		}
+		logLanguageProbability := l.getLanguageProbability(previous, phone)
+		left := previous
+		if left == nil {
+			left = acoustic.SILENCE
+		}
+		for _, next := range l.rightContexts {
+			hmm := l.getHMM(phone, left, next)
+			if hmm == nil {
+				continue
+			}
+			if phone.IsFiller() {
+				next = nil
+			}
+			arcs = append(arcs, l.newArc(PhoneHmmSearchState{l, hmm.InitialState(), next},
+				logLanguageProbability, l.logPhoneInsertionProbability, 0))
+			if phone.IsFiller() {
+				// fillers are context independent, entered once
+				break
+			}
+		}
+	}
+	return arcs
+}
+
+func (l *AllPhoneLinguist) getHMM(phone, left, right *acoustic.Unit) acoustic.HMM {
+	if !l.useContextDependentPhones {
+		return l.hmmPool.GetHMM(phone, nil, nil, acoustic.UNDEFINED)
+	}
+	return l.hmmPool.GetHMM(phone, left, right, acoustic.INTERNAL)
+}
+
+// Returns the language probability of a phone after the given one, nil at the start.
+func (l *AllPhoneLinguist) getLanguageProbability(previous, phone *acoustic.Unit) float64 {
+	if l.languageModel == nil {
+		return float64(util.LOG_ONE)
+	}
+	history := l.sentenceStartWord
+	if previous != nil {
+		history = l.phoneWords[previous.Name()]
+	}
+	words := []*dictionary.Word{history, l.phoneWords[phone.Name()]}
+	if l.languageModel.GetMaxDepth() < 2 {
+		words = words[1:]
+	}
+	return float64(l.languageModel.GetProbability(linguist.NewWordSequenceByWordSlice(words)))
+}
+
+func (l *AllPhoneLinguist) newArc(state linguist.SearchState, logLanguageProbability, logInsertionProbability,
+	logAcousticProbability float64) linguist.SearchStateArc {
+	return &phoneSearchStateArc{
+		state:                   state,
+		logLanguageProbability:  logLanguageProbability,
+		logInsertionProbability: logInsertionProbability,
+		logAcousticProbability:  logAcousticProbability,
+		languageWeight:          l.languageWeight,
+	}
+}
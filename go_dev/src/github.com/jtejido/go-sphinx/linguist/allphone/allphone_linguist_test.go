@@ -0,0 +1,148 @@
+package allphone
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/jtejido/go-sphinx/linguist"
+	"github.com/jtejido/go-sphinx/linguist/acoustic"
+	"github.com/jtejido/go-sphinx/linguist/acoustic/acoustictest"
+	"github.com/jtejido/go-sphinx/linguist/language/ngram"
+	"github.com/jtejido/go-sphinx/util"
+)
+
+const arpa = `\data\
+ngram 1=4
+ngram 2=3
+
+\1-grams:
+-1.0 <s> -0.5
+-0.4 AA -0.2
+-0.6 B -0.3
+-0.5 SIL -0.1
+
+\2-grams:
+-0.1 <s> SIL
+-0.3 AA B
+-0.2 B AA
+
+\end\
+`
+
+func newTestLinguist(t *testing.T, contextSize int, languageModel ngram.LanguageModel,
+	useContextDependentPhones bool) *AllPhoneLinguist {
+	unitManager := acoustic.NewDefaultUnitManager()
+	am := &acoustictest.AcousticModel{ContextSize: contextSize, Units: []*acoustic.Unit{
+		unitManager.Unit("B", false), unitManager.Unit("AA", false), unitManager.Unit(acoustic.SILENCE_NAME, true),
+	}}
+	l := NewAllPhoneLinguist(am, unitManager, languageModel, 0.5, 5, useContextDependentPhones, nil)
+	if err := l.Allocate(); err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+// phoneWords returns the phone word states following a state, by phone, with the language probability of the path
+// to them.
+func phoneWords(state linguist.SearchState) map[string][]linguist.SearchStateArc {
+	words := make(map[string][]linguist.SearchStateArc)
+	visited := make(map[linguist.SearchState]bool)
+	var visit func(state linguist.SearchState, logLanguageProbability float64)
+	visit = func(state linguist.SearchState, logLanguageProbability float64) {
+		for _, arc := range state.GetSuccessors() {
+			next := arc.GetState()
+			if word, ok := next.(PhoneWordSearchState); ok {
+				words[word.GetPhone().Name()] = append(words[word.GetPhone().Name()],
+					&phoneSearchStateArc{state: word, logLanguageProbability: logLanguageProbability})
+			} else if !visited[next] {
+				visited[next] = true
+				visit(next, logLanguageProbability+arc.GetLanguageProbability())
+			}
+		}
+	}
+	visit(state, 0)
+	return words
+}
+
+func TestAllPhoneLinguist(t *testing.T) {
+	l := newTestLinguist(t, 1, nil, false)
+	graph := l.GetSearchGraph()
+	if graph.GetNumStateOrder() != 3 {
+		t.Fatalf("%d state orders", graph.GetNumStateOrder())
+	}
+
+	initialState := graph.GetInitialState()
+	arcs := initialState.GetSuccessors()
+	if len(arcs) != 3 {
+		t.Fatalf("%d phones follow the start, expected 3", len(arcs))
+	}
+	logInsertion := float64(util.GetLogMath().LinearToLog(0.5))
+	for i, name := range []string{"AA", "B", acoustic.SILENCE_NAME} {
+		state, ok := arcs[i].GetState().(PhoneHmmSearchState)
+		if !ok || !state.IsEmitting() || state.GetPhone().Name() != name || state.GetBaseId() != state.GetPhone().BaseID() {
+			t.Fatalf("unexpected state %v entering %s", arcs[i].GetState(), name)
+		}
+		if arcs[i].GetInsertionProbability() != logInsertion {
+			t.Fatalf("insertion probability %v, expected %v", arcs[i].GetInsertionProbability(), logInsertion)
+		}
+	}
+
+	words := phoneWords(initialState)
+	if len(words) != 3 || len(words["AA"]) != 1 {
+		t.Fatalf("unexpected phones %v", words)
+	}
+	aa := words["AA"][0].GetState().(PhoneWordSearchState)
+	if !aa.IsFinal() || aa.GetPronunciation().GetWord().GetSpelling() != "AA" {
+		t.Fatalf("unexpected phone word %v", aa)
+	}
+	if len(aa.GetSuccessors()) != 3 {
+		t.Fatal("every phone must follow AA")
+	}
+}
+
+func TestAllPhoneLinguistContextDependent(t *testing.T) {
+	l := newTestLinguist(t, 1, nil, true)
+	initialState := l.GetSearchGraph().GetInitialState()
+	// AA and B in the 3 right contexts, SIL once
+	if arcs := initialState.GetSuccessors(); len(arcs) != 7 {
+		t.Fatalf("%d triphones follow the start, expected 7", len(arcs))
+	}
+
+	words := phoneWords(initialState)
+	if len(words["AA"]) != 3 || len(words[acoustic.SILENCE_NAME]) != 1 {
+		t.Fatalf("unexpected phones %v", words)
+	}
+	for _, arc := range words["AA"] {
+		word := arc.GetState().(PhoneWordSearchState)
+		next := word.GetSuccessors()
+		for _, arc := range next {
+			state := arc.GetState().(PhoneHmmSearchState)
+			if state.GetPhone().Name() != word.rightContext.Name() {
+				t.Fatalf("%v follows %v", state, word)
+			}
+			if hmm := state.GetHMMState().HMM(); !hmm.Unit().IsFiller() && !hmm.Unit().IsContextDependent() {
+				t.Fatalf("%v is context independent", hmm.Unit())
+			}
+		}
+		if expected := map[string]int{"AA": 3, "B": 3, "SIL": 1}[word.rightContext.Name()]; len(next) != expected {
+			t.Fatalf("%d triphones follow %v, expected %d", len(next), word, expected)
+		}
+	}
+}
+
+func TestAllPhoneLinguistLanguageModel(t *testing.T) {
+	lm := ngram.NewDefaultSimpleNGramModel()
+	lm.SetLocation(filepath.Join(acoustictest.WriteFiles(t, map[string]string{"phone.lm": arpa}), "phone.lm"))
+	l := newTestLinguist(t, 0, lm, false)
+
+	logMath := util.GetLogMath()
+	words := phoneWords(l.GetSearchGraph().GetInitialState())
+	if p, expected := words[acoustic.SILENCE_NAME][0].GetLanguageProbability(), logMath.Log10ToLog(-0.1); math.Abs(p-float64(expected)) > 1 {
+		t.Fatalf("probability of SIL after <s> is %v, expected %v", p, expected)
+	}
+	next := phoneWords(words["AA"][0].GetState())
+	if p, expected := next["B"][0].GetLanguageProbability(), logMath.Log10ToLog(-0.3); math.Abs(p-float64(expected)) > 1 {
+		t.Fatalf("probability of B after AA is %v, expected %v", p, expected)
+	}
+}
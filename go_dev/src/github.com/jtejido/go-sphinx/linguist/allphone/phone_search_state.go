@@ -0,0 +1,266 @@
+package allphone
+
+import (
+	"fmt"
+
+	"github.com/jtejido/go-sphinx/linguist"
+	"github.com/jtejido/go-sphinx/linguist/acoustic"
+	"github.com/jtejido/go-sphinx/linguist/dictionary"
+)
+
+const (
+	// The orders of the states of the phone loop. Within a frame, the non-emitting states are always entered in
+	// increasing order, emitting HMM states last.
+	nonEmittingStateOrder = iota
+	phoneWordStateOrder
+	hmmStateOrder
+	numStateOrder
+)
+
+// An arc of the phone loop, with its probabilities in the LogMath log domain.
+type phoneSearchStateArc struct {
+	state                   linguist.SearchState
+	logLanguageProbability  float64
+	logInsertionProbability float64
+	logAcousticProbability  float64
+	languageWeight          float64
+}
+
+// Gets a successor to this search state
+func (arc *phoneSearchStateArc) GetState() linguist.SearchState {
+	return arc.state
+}
+
+// Gets the composite probability of entering this state: the language probability scaled by the language weight,
+// the insertion probability and the HMM transition probability.
+func (arc *phoneSearchStateArc) GetProbability() float64 {
+	return arc.logLanguageProbability*arc.languageWeight + arc.logInsertionProbability + arc.logAcousticProbability
+}
+
+// Gets the language probability of entering this state
+func (arc *phoneSearchStateArc) GetLanguageProbability() float64 {
+	return arc.logLanguageProbability
+}
+
+// Gets the insertion probability of entering this state
+func (arc *phoneSearchStateArc) GetInsertionProbability() float64 {
+	return arc.logInsertionProbability
+}
+
+// The start of the phone loop, followed by every phone.
+type PhoneNonEmittingSearchState struct {
+	linguist *AllPhoneLinguist
+}
+
+// Gets a successor to this search state
+func (s PhoneNonEmittingSearchState) GetSuccessors() []linguist.SearchStateArc {
+	return s.linguist.enterPhones(nil, nil)
+}
+
+// Determines if this is an emitting state
+func (s PhoneNonEmittingSearchState) IsEmitting() bool {
+	return false
+}
+
+// Determines if this is a final state
+func (s PhoneNonEmittingSearchState) IsFinal() bool {
+	return false
+}
+
+// Returns a pretty version of the string representation for this object
+func (s PhoneNonEmittingSearchState) ToPrettyString() string {
+	return "phoneLoopStart"
+}
+
+// Returns a unique signature for this state
+func (s PhoneNonEmittingSearchState) GetSignature() string {
+	return "phoneLoopStart"
+}
+
+// The phone loop keeps no word history
+func (s PhoneNonEmittingSearchState) GetWordHistory() *linguist.WordSequence {
+	return s.linguist.emptyHistory
+}
+
+// Returns the lex tree state, the state itself
+func (s PhoneNonEmittingSearchState) GetLexState() interface{} {
+	return s
+}
+
+// Returns the order of this particular state
+func (s PhoneNonEmittingSearchState) GetOrder() int {
+	return nonEmittingStateOrder
+}
+
+// A state of the HMM of a phone. The right context is the phone the HMM of a triphone was chosen for, nil if any
+// phone can follow.
+type PhoneHmmSearchState struct {
+	linguist     *AllPhoneLinguist
+	hmmState     acoustic.HMMState
+	rightContext *acoustic.Unit
+}
+
+// Gets a successor to this search state: the next states of the HMM, or the end of the phone for its exit state.
+func (s PhoneHmmSearchState) GetSuccessors() []linguist.SearchStateArc {
+	if s.hmmState.IsExitState() {
+		word := PhoneWordSearchState{s.linguist, s.GetPhone(), s.rightContext}
+		return []linguist.SearchStateArc{s.linguist.newArc(word, 0, 0, 0)}
+	}
+	var arcs []linguist.SearchStateArc
+	for _, arc := range s.hmmState.Successors() {
+		next := PhoneHmmSearchState{s.linguist, arc.HMMState(), s.rightContext}
+		arcs = append(arcs, s.linguist.newArc(next, 0, 0, float64(arc.LogProbability())))
+	}
+	return arcs
+}
+
+// Determines if this is an emitting state
+func (s PhoneHmmSearchState) IsEmitting() bool {
+	return s.hmmState.IsEmitting()
+}
+
+// Determines if this is a final state
+func (s PhoneHmmSearchState) IsFinal() bool {
+	return false
+}
+
+// Gets the HMM state
+func (s PhoneHmmSearchState) GetHMMState() acoustic.HMMState {
+	return s.hmmState
+}
+
+// Returns the context independent phone of the HMM.
+func (s PhoneHmmSearchState) GetPhone() *acoustic.Unit {
+	return s.hmmState.HMM().BaseUnit()
+}
+
+// Returns the id of the context independent phone of the HMM.
+func (s PhoneHmmSearchState) GetBaseId() int {
+	return s.GetPhone().BaseID()
+}
+
+// Returns a pretty version of the string representation for this object
+func (s PhoneHmmSearchState) ToPrettyString() string {
+	return s.String()
+}
+
+// Returns a unique signature for this state
+func (s PhoneHmmSearchState) GetSignature() string {
+	return fmt.Sprintf("phoneHmmState-%p-%s", s.hmmState, contextName(s.rightContext))
+}
+
+// The phone loop keeps no word history
+func (s PhoneHmmSearchState) GetWordHistory() *linguist.WordSequence {
+	return s.linguist.emptyHistory
+}
+
+// Returns the lex tree state, the state itself
+func (s PhoneHmmSearchState) GetLexState() interface{} {
+	return s
+}
+
+// Returns the order of this particular state
+func (s PhoneHmmSearchState) GetOrder() int {
+	if s.hmmState.IsEmitting() {
+		return hmmStateOrder
+	}
+	return nonEmittingStateOrder
+}
+
+func (s PhoneHmmSearchState) String() string {
+	return fmt.Sprintf("%s:%d", s.hmmState.HMM().Unit(), s.hmmState.State())
+}
+
+// The end of a phone, whose word is the phone itself. It ends a sentence, and is followed by the phones starting with
+// its right context.
+type PhoneWordSearchState struct {
+	linguist     *AllPhoneLinguist
+	phone        *acoustic.Unit
+	rightContext *acoustic.Unit
+}
+
+// Gets a successor to this search state
+func (s PhoneWordSearchState) GetSuccessors() []linguist.SearchStateArc {
+	return s.linguist.enterPhones(s.phone, s.rightContext)
+}
+
+// Determines if this is an emitting state
+func (s PhoneWordSearchState) IsEmitting() bool {
+	return false
+}
+
+// Determines if this is a final state: any phone ends the phone string.
+func (s PhoneWordSearchState) IsFinal() bool {
+	return true
+}
+
+// Returns the phone ended by the state.
+func (s PhoneWordSearchState) GetPhone() *acoustic.Unit {
+	return s.phone
+}
+
+// Gets the word (as a pronunciation)
+func (s PhoneWordSearchState) GetPronunciation() *dictionary.Pronunciation {
+	return s.linguist.GetPhoneWord(s.phone).GetPronunciations()[0]
+}
+
+// Returns false, the state being the end of the phone.
+func (s PhoneWordSearchState) IsWordStart() bool {
+	return false
+}
+
+// Returns a pretty version of the string representation for this object
+func (s PhoneWordSearchState) ToPrettyString() string {
+	return s.String()
+}
+
+// Returns a unique signature for this state
+func (s PhoneWordSearchState) GetSignature() string {
+	return fmt.Sprintf("phoneWordState-%s-%s", s.phone, contextName(s.rightContext))
+}
+
+// The phone loop keeps no word history
+func (s PhoneWordSearchState) GetWordHistory() *linguist.WordSequence {
+	return s.linguist.emptyHistory
+}
+
+// Returns the lex tree state, the state itself
+func (s PhoneWordSearchState) GetLexState() interface{} {
+	return s
+}
+
+// Returns the order of this particular state
+func (s PhoneWordSearchState) GetOrder() int {
+	return phoneWordStateOrder
+}
+
+func (s PhoneWordSearchState) String() string {
+	return fmt.Sprintf("%s[%s]", s.phone, contextName(s.rightContext))
+}
+
+// The search graph of the AllPhoneLinguist.
+type PhoneLoopSearchGraph struct {
+	initialState linguist.SearchState
+}
+
+// Retrieves initial search state
+func (g *PhoneLoopSearchGraph) GetInitialState() linguist.SearchState {
+	return g.initialState
+}
+
+// Returns the number of different state types maintained in the search graph
+func (g *PhoneLoopSearchGraph) GetNumStateOrder() int {
+	return numStateOrder
+}
+
+// Order of words and data tokens: phones are scored before their words.
+func (g *PhoneLoopSearchGraph) GetWordTokenFirst() bool {
+	return false
+}
+
+func contextName(unit *acoustic.Unit) string {
+	if unit == nil {
+		return "*"
+	}
+	return unit.Name()
+}
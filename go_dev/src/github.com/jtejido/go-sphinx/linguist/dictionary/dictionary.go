@@ -1,8 +1,11 @@
 package dictionary
 
 const (
-	DEFAULT_G2P_MODEL_PATH         string = ""
-	DEFAULT_G2P_MAX_PRONUNCIATIONS        = 1
+	// The default path of the grapheme to phoneme model, none.
+	DEFAULT_G2P_MODEL_PATH string = ""
+
+	// The default number of pronunciations generated for a word missing from the dictionary.
+	DEFAULT_G2P_MAX_PRONUNCIATIONS = 1
 
 	// Spelling of the sentence start word.
 	SENTENCE_START_SPELLING string = "<s>"
@@ -34,7 +37,7 @@ type Dictionary interface {
 	GetFillerWords() []*Word
 
 	// Allocates the dictionary
-	Allocate()
+	Allocate() error
 
 	// Deallocates the dictionary
 	Deallocate()
@@ -2,8 +2,8 @@ package dictionary
 
 import (
 	"fmt"
+
 	"github.com/jtejido/go-sphinx/linguist/acoustic"
-	"math"
 )
 
 // Provides pronunciation information for a word.
@@ -41,7 +41,7 @@ func (pro *Pronunciation) SetWord(word *Word) {
 }
 
 // Retrieves the word that this Pronunciation object represents.
-func (pro Pronunciation) GetWord() {
+func (pro Pronunciation) GetWord() *Word {
 	return pro.word
 }
 
@@ -59,8 +59,8 @@ func (pro Pronunciation) GetTag() string {
 
 // Retrieves the probability for the pronunciation. A word may have multiple pronunciations that are not all equally
 // probable. All probabilities for particular word sum to 1.0.
-func (pro Pronunciation) GetProbability() {
-	return probability
+func (pro Pronunciation) GetProbability() float64 {
+	return pro.probability
 }
 
 func (pro Pronunciation) Dump() {
@@ -68,10 +68,14 @@ func (pro Pronunciation) Dump() {
 }
 
 func (pro Pronunciation) String() string {
-	result = word + "("
+	result := ""
+	if pro.word != nil {
+		result = pro.word.GetSpelling()
+	}
+	result += "("
 
-	for _, unit := range units {
-		result += unit + " "
+	for _, unit := range pro.units {
+		result += unit.String() + " "
 	}
 
 	result += ")"
@@ -0,0 +1,98 @@
+#!/usr/bin/env python3
+"""Generates g2p.fst, a tiny grapheme to phoneme model.
+
+The model is a unigram joint-sequence model of the aligned words below, in the OpenFst binary layout of the models
+phonetisaurus-arpa2wfst writes: a start state with the <s> arc to the <s> history, which backs off to the unigram
+history, where every grapheme}phoneme token loops with its cost, and the </s> arc to the final state. Costs are the
+negative natural logs of the maximum likelihood estimates of the tokens.
+
+Phonetisaurus was not at hand when the file was made, so the alignments are written by hand and the model is not
+smoothed. To check the model against a real Phonetisaurus, train one from the same words with
+
+    phonetisaurus-align --input=words.dict --ofile=words.corpus
+    estimate-ngram -o 1 -t words.corpus -wl words.arpa
+    phonetisaurus-arpa2wfst --lm=words.arpa --ofile=g2p.fst
+"""
+
+import math
+import struct
+from collections import Counter
+
+# aligned words: a cluster of graphemes or phonemes is joined by '|', and '_' maps a grapheme to no phoneme
+WORDS = [
+    'c}K a}AE b}B',             # cab
+    'c}K a}EY k}K e}_',         # cake
+    'b}B a}EY k}K e}_',         # bake
+    'b}B a}AE c|k}K',           # back
+    'b}B a}AE t}T',             # bat
+    'b}B e}IY',                 # be
+    'a}AA h}_',                 # ah
+]
+
+FST_MAGIC = 2125659606
+SYMBOL_TABLE_MAGIC = 2125658996
+HAS_INPUT_SYMBOLS, HAS_OUTPUT_SYMBOLS = 0x1, 0x2
+
+
+def symbols(names):
+    table = {}
+    for name in names:
+        table.setdefault(name, len(table))
+    return table
+
+
+def main():
+    counts = Counter()
+    for word in WORDS:
+        counts.update(word.split())
+        counts['</s>'] += 1
+    total = sum(counts.values())
+
+    tokens = sorted(t for t in counts if t != '</s>')
+    isyms = symbols(['<eps>', '<s>', '</s>'] + sorted({t.split('}')[0] for t in tokens}))
+    osyms = symbols(['<eps>', '<s>', '</s>'] + sorted({t.split('}')[1] for t in tokens}))
+
+    def cost(token):
+        return -math.log(counts[token] / total)
+
+    start, history, unigram, final = range(4)
+    states = [
+        (math.inf, [(isyms['<s>'], osyms['<s>'], 0.0, history)]),
+        (math.inf, [(0, 0, 0.0, unigram)]),
+        (math.inf, [(isyms[t.split('}')[0]], osyms[t.split('}')[1]], cost(t), unigram) for t in tokens] +
+         [(isyms['</s>'], osyms['</s>'], cost('</s>'), final)]),
+        (0.0, []),
+    ]
+
+    out = bytearray()
+
+    def string(s):
+        data = s.encode('utf-8')
+        out.extend(struct.pack('<i', len(data)) + data)
+
+    def table(name, syms):
+        out.extend(struct.pack('<i', SYMBOL_TABLE_MAGIC))
+        string(name)
+        out.extend(struct.pack('<qq', len(syms), len(syms)))
+        for symbol, label in syms.items():
+            string(symbol)
+            out.extend(struct.pack('<q', label))
+
+    out.extend(struct.pack('<i', FST_MAGIC))
+    string('vector')
+    string('standard')
+    out.extend(struct.pack('<iiqqqq', 2, HAS_INPUT_SYMBOLS | HAS_OUTPUT_SYMBOLS, 0, start, len(states),
+                           sum(len(arcs) for _, arcs in states)))
+    table('isyms', isyms)
+    table('osyms', osyms)
+    for weight, arcs in states:
+        out.extend(struct.pack('<fq', weight, len(arcs)))
+        for ilabel, olabel, w, nextstate in arcs:
+            out.extend(struct.pack('<iifi', ilabel, olabel, w, nextstate))
+
+    with open('g2p.fst', 'wb') as f:
+        f.write(out)
+
+
+if __name__ == '__main__':
+    main()
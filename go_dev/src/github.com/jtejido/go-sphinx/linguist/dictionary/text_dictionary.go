@@ -0,0 +1,367 @@
+package dictionary
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jtejido/go-sphinx/linguist/acoustic"
+	"github.com/jtejido/go-sphinx/linguist/g2p"
+	"github.com/jtejido/go-sphinx/util"
+)
+
+const (
+	// The tag prepended to the entries of the filler dictionary.
+	FILLER_TAG = "-F-"
+)
+
+// Creates a dictionary by reading in an ASCII-based Sphinx-3 format dictionary, such as CMUdict. Each line of the
+// dictionary specifies the word, followed by spaces or tab, followed by the pronunciation (by way of the list of
+// phones) of the word:
+//
+//	ONE      HH W AH N
+//	ONE(2)   W AH N
+//
+// Alternate pronunciations are given either with an explicit (2), (3), ... suffix, as above, or by repeating the
+// word, in which case the next free index is assigned. Lines starting with ";;;" or "#" are comments.
+//
+// Words that are mostly silence, noise or garbage are read from a separate filler dictionary, usually the noisedict
+// of the acoustic model, which must contain the sentence start, sentence end and silence words. Addenda are read
+// after the main dictionary and may add words or alternate pronunciations.
+//
+// With case folding, the default, the words of the main dictionary and the addenda are looked up case
+// insensitively and spelled in lower case. Filler words are always matched exactly.
+//
+// Words missing from the dictionary can be given the pronunciations of a grapheme to phoneme model, set with
+// SetG2PModel: its N best pronunciations are added to the word, each with its probability among them.
+//
+// The files are read at allocation, but an entry is only parsed into a Word, with the units of its
+// pronunciations from the UnitManager, the first time the word is looked up.
+type TextDictionary struct {
+	wordDictionaryFile        string
+	fillerWordDictionaryFile  string
+	addenda                   []string
+	addSilEndingPronunciation bool
+	wordReplacement           string
+	caseFolding               bool
+	unitManager               *acoustic.UnitManager
+	logger                    util.Logger
+	g2pModelPath              string
+	g2pMaxPronunciations      int
+
+	g2pDecoder     *g2p.G2PConverter
+	allocated      bool
+	dictionary     map[string]string
+	wordDictionary map[string]*Word
+	fillerWords    map[string]bool
+}
+
+// Creates a TextDictionary without files, to be set with SetDictionaryPath and SetFillerPath before allocation.
+func NewDefaultTextDictionary() *TextDictionary {
+	return NewTextDictionary("", "", nil, false, "", acoustic.NewDefaultUnitManager(), nil)
+}
+
+// Creates a TextDictionary.
+//
+// Accepts the path of the main dictionary, the path of the filler dictionary, the paths of the addenda, whether a
+// pronunciation ending with silence is added to every word, the word whose pronunciations replace the ones of
+// missing words (empty for none), the unit manager the units are taken from and an optional logger.
+func NewTextDictionary(wordDictionaryFile, fillerWordDictionaryFile string, addenda []string,
+	addSilEndingPronunciation bool, wordReplacement string, unitManager *acoustic.UnitManager, logger util.Logger) *TextDictionary {
+	return &TextDictionary{
+		wordDictionaryFile:        wordDictionaryFile,
+		fillerWordDictionaryFile:  fillerWordDictionaryFile,
+		addenda:                   addenda,
+		addSilEndingPronunciation: addSilEndingPronunciation,
+		wordReplacement:           wordReplacement,
+		caseFolding:               true,
+		unitManager:               unitManager,
+		logger:                    logger,
+		g2pModelPath:              DEFAULT_G2P_MODEL_PATH,
+		g2pMaxPronunciations:      DEFAULT_G2P_MAX_PRONUNCIATIONS,
+	}
+}
+
+// Sets the path of the main dictionary, read at the next allocation.
+func (d *TextDictionary) SetDictionaryPath(path string) {
+	d.wordDictionaryFile = path
+}
+
+// Sets the path of the filler dictionary, read at the next allocation.
+func (d *TextDictionary) SetFillerPath(path string) {
+	d.fillerWordDictionaryFile = path
+}
+
+// Adds an addendum, read at the next allocation after the main dictionary.
+func (d *TextDictionary) AddAddendum(path string) {
+	d.addenda = append(d.addenda, path)
+}
+
+// Sets whether the words of the main dictionary are looked up case insensitively. Takes effect at the next
+// allocation.
+func (d *TextDictionary) SetCaseFolding(caseFolding bool) {
+	d.caseFolding = caseFolding
+}
+
+// Sets the path of the grapheme to phoneme model generating the pronunciations of missing words, loaded at the
+// next allocation, and the number of pronunciations generated per word. An empty path disables the generation.
+func (d *TextDictionary) SetG2PModel(path string, maxPronunciations int) {
+	d.g2pModelPath = path
+	d.g2pMaxPronunciations = maxPronunciations
+}
+
+// Returns the unit manager the units of the pronunciations are taken from.
+func (d *TextDictionary) UnitManager() *acoustic.UnitManager {
+	return d.unitManager
+}
+
+// Reads the dictionary files. Does nothing if the dictionary is already allocated.
+func (d *TextDictionary) Allocate() error {
+	if d.allocated {
+		return nil
+	}
+	if d.wordDictionaryFile == "" {
+		return errors.New("no dictionary path set")
+	}
+
+	d.dictionary = make(map[string]string)
+	d.wordDictionary = make(map[string]*Word)
+	d.fillerWords = make(map[string]bool)
+
+	if err := d.loadDictionary(d.wordDictionaryFile, false); err != nil {
+		return err
+	}
+	for _, addendum := range d.addenda {
+		if err := d.loadDictionary(addendum, false); err != nil {
+			return err
+		}
+	}
+	if d.fillerWordDictionaryFile != "" {
+		if err := d.loadDictionary(d.fillerWordDictionaryFile, true); err != nil {
+			return err
+		}
+	}
+
+	if d.g2pModelPath != "" {
+		g2pDecoder, err := g2p.NewG2PConverter(d.g2pModelPath)
+		if err != nil {
+			return err
+		}
+		d.g2pDecoder = g2pDecoder
+	}
+
+	d.allocated = true
+	return nil
+}
+
+// Releases the entries of the dictionary.
+func (d *TextDictionary) Deallocate() {
+	if d.allocated {
+		d.dictionary = nil
+		d.wordDictionary = nil
+		d.fillerWords = nil
+		d.g2pDecoder = nil
+		d.allocated = false
+	}
+}
+
+// Loads the given dictionary file into the map of unparsed entries.
+func (d *TextDictionary) loadDictionary(path string, isFillerDict bool) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, ";;;") || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return fmt.Errorf("%s:%d: no pronunciation for %q", path, lineNumber, fields[0])
+		}
+		word := fields[0]
+		if !isFillerDict && d.caseFolding {
+			word = strings.ToLower(word)
+		}
+
+		// Add numeric index if the word is repeated in the dictionary
+		if _, ok := d.dictionary[word]; ok {
+			base := baseSpelling(word)
+			for index := 2; ; index++ {
+				word = fmt.Sprintf("%s(%d)", base, index)
+				if _, ok := d.dictionary[word]; !ok {
+					break
+				}
+			}
+		}
+
+		entry := strings.Join(fields[1:], " ")
+		if isFillerDict {
+			d.dictionary[word] = FILLER_TAG + " " + entry
+			d.fillerWords[baseSpelling(word)] = true
+		} else {
+			d.dictionary[word] = entry
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// Returns the spelling without the alternate pronunciation index, "word" for "word(2)".
+func baseSpelling(word string) string {
+	if i := strings.LastIndexByte(word, '('); i > 0 && strings.HasSuffix(word, ")") {
+		return word[:i]
+	}
+	return word
+}
+
+// Returns the Word of the given spelling, or nil if the dictionary has no entry for it and there is neither a word
+// replacement nor a grapheme to phoneme model. The entry is parsed the first time the word is looked up.
+func (d *TextDictionary) GetWord(text string) *Word {
+	if !d.allocated {
+		return nil
+	}
+	if !d.fillerWords[text] && d.caseFolding {
+		text = strings.ToLower(text)
+	}
+
+	if word, ok := d.wordDictionary[text]; ok {
+		return word
+	}
+
+	if _, ok := d.dictionary[text]; !ok {
+		if d.logger != nil {
+			d.logger.Infof("The dictionary is missing a phonetic transcription for the word '%s'", text)
+		}
+		if d.wordReplacement != "" && d.wordReplacement != text {
+			return d.GetWord(d.wordReplacement)
+		}
+		if d.g2pDecoder != nil {
+			return d.extractPronunciation(text)
+		}
+		return nil
+	}
+	return d.processEntry(text)
+}
+
+// Parses all the pronunciations of the given word into a Word.
+func (d *TextDictionary) processEntry(word string) *Word {
+	pronunciations := make([]*Pronunciation, 0)
+	isFiller := false
+
+	for count := 1; ; count++ {
+		lookupWord := word
+		if count > 1 {
+			lookupWord = fmt.Sprintf("%s(%d)", word, count)
+		}
+		entry, ok := d.dictionary[lookupWord]
+		if !ok {
+			break
+		}
+
+		phones := strings.Fields(entry)
+		if phones[0] == FILLER_TAG {
+			isFiller = true
+			phones = phones[1:]
+		}
+		units := make([]*acoustic.Unit, 0, len(phones)+1)
+		for _, phone := range phones {
+			units = append(units, d.unitManager.Unit(phone, isFiller))
+		}
+		pronunciations = append(pronunciations, NewPronunciation(units, "", 1.0))
+
+		if d.addSilEndingPronunciation && !isFiller {
+			silenceEnding := append(append([]*acoustic.Unit(nil), units...), acoustic.SILENCE)
+			pronunciations = append(pronunciations, NewPronunciation(silenceEnding, "", 1.0))
+		}
+	}
+
+	createdWord := NewWord(word, pronunciations, isFiller)
+	for _, pronunciation := range pronunciations {
+		pronunciation.SetWord(createdWord)
+	}
+	d.wordDictionary[word] = createdWord
+	return createdWord
+}
+
+// Generates the pronunciations of a missing word with the grapheme to phoneme model. The probabilities of the
+// pronunciations are normalized over the ones generated. Paths without any phone, which only skip graphemes, are no
+// pronunciation. Returns nil if the model has no pronunciation for the word.
+func (d *TextDictionary) extractPronunciation(word string) *Word {
+	var paths []*g2p.Path
+	for _, path := range d.g2pDecoder.Phoneticize(word, d.g2pMaxPronunciations) {
+		if len(path.Path()) > 0 {
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+
+	total := 0.0
+	for _, path := range paths {
+		total += math.Exp(paths[0].Cost() - path.Cost())
+	}
+
+	pronunciations := make([]*Pronunciation, 0, len(paths))
+	for _, path := range paths {
+		units := make([]*acoustic.Unit, 0, len(path.Path()))
+		for _, phone := range path.Path() {
+			units = append(units, d.unitManager.Unit(phone, false))
+		}
+		probability := math.Exp(paths[0].Cost()-path.Cost()) / total
+		pronunciations = append(pronunciations, NewPronunciation(units, "", probability))
+	}
+
+	createdWord := NewWord(word, pronunciations, false)
+	for _, pronunciation := range pronunciations {
+		pronunciation.SetWord(createdWord)
+	}
+	d.wordDictionary[word] = createdWord
+	return createdWord
+}
+
+// Returns the sentence start word.
+func (d *TextDictionary) GetSentenceStartWord() *Word {
+	return d.GetWord(SENTENCE_START_SPELLING)
+}
+
+// Returns the sentence end word.
+func (d *TextDictionary) GetSentenceEndWord() *Word {
+	return d.GetWord(SENTENCE_END_SPELLING)
+}
+
+// Returns the silence word.
+func (d *TextDictionary) GetSilenceWord() *Word {
+	return d.GetWord(SILENCE_SPELLING)
+}
+
+// Gets the set of all filler words in the dictionary, ordered by spelling.
+func (d *TextDictionary) GetFillerWords() []*Word {
+	spellings := make([]string, 0, len(d.fillerWords))
+	for spelling := range d.fillerWords {
+		spellings = append(spellings, spelling)
+	}
+	sort.Strings(spellings)
+
+	fillerWords := make([]*Word, 0, len(spellings))
+	for _, spelling := range spellings {
+		fillerWords = append(fillerWords, d.GetWord(spelling))
+	}
+	return fillerWords
+}
+
+func (d *TextDictionary) String() string {
+	return fmt.Sprintf("TextDictionary: %s, filler: %s", d.wordDictionaryFile, d.fillerWordDictionaryFile)
+}
@@ -0,0 +1,117 @@
+package dictionary
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jtejido/go-sphinx/linguist/g2p"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestTextDictionary(t *testing.T) {
+	dir := t.TempDir()
+	d := NewDefaultTextDictionary()
+	d.SetDictionaryPath(writeFile(t, dir, "cmudict", ";;; comment\nONE HH W AH N\nONE(2) W AH N\nREAD R IY D\nREAD R EH D\n"))
+	d.SetFillerPath(writeFile(t, dir, "noisedict", "<s> SIL\n</s> SIL\n<sil> SIL\n++NOISE++ +NSN+\n"))
+	d.AddAddendum(writeFile(t, dir, "addenda", "one HH W AA N\nsphinx S F IH NG K S\n"))
+	if err := d.Allocate(); err != nil {
+		t.Fatal(err)
+	}
+
+	one := d.GetWord("One")
+	if one == nil || one.GetSpelling() != "one" || len(one.GetPronunciations()) != 3 {
+		t.Fatalf("got %v for One, want 3 pronunciations of one", one)
+	}
+	if units := one.GetPronunciations()[1].GetUnits(); len(units) != 3 || units[0].Name() != "W" {
+		t.Fatalf("second pronunciation of one is %v", one.GetPronunciations()[1])
+	}
+	if d.GetWord("one") != one {
+		t.Fatal("words are not cached")
+	}
+	if read := d.GetWord("READ"); read == nil || len(read.GetPronunciations()) != 2 {
+		t.Fatalf("got %v for READ, want 2 pronunciations", read)
+	}
+	if d.GetWord("sphinx") == nil {
+		t.Fatal("addendum word missing")
+	}
+	if d.GetWord("two") != nil {
+		t.Fatal("unexpected word two")
+	}
+
+	// units are shared through the unit manager
+	if d.GetWord("read").GetPronunciations()[0].GetUnits()[0] != d.UnitManager().Unit("R", false) {
+		t.Fatal("units do not come from the unit manager")
+	}
+
+	if start := d.GetSentenceStartWord(); start == nil || !start.IsFiller() || !start.IsSentenceStartWord() {
+		t.Fatalf("sentence start word is %v", start)
+	}
+	if fillers := d.GetFillerWords(); len(fillers) != 4 {
+		t.Fatalf("got %d filler words, want 4", len(fillers))
+	}
+}
+
+func TestTextDictionaryG2P(t *testing.T) {
+	dir := t.TempDir()
+	d := NewDefaultTextDictionary()
+	d.SetDictionaryPath(writeFile(t, dir, "cmudict", "CAB K AE B\n"))
+	d.SetFillerPath(writeFile(t, dir, "noisedict", "<s> SIL\n</s> SIL\n<sil> SIL\n"))
+	d.SetG2PModel(filepath.Join("testdata", "g2p.fst"), 4)
+	if err := d.Allocate(); err != nil {
+		t.Fatal(err)
+	}
+
+	converter, err := g2p.NewG2PConverter(filepath.Join("testdata", "g2p.fst"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := converter.Phoneticize("bake", 4)
+	bake := d.GetWord("bake")
+	if bake == nil || len(bake.GetPronunciations()) != len(paths) {
+		t.Fatalf("got %v for bake, want the pronunciations %v", bake, paths)
+	}
+	total := 0.0
+	for i, pronunciation := range bake.GetPronunciations() {
+		var phones []string
+		for _, unit := range pronunciation.GetUnits() {
+			phones = append(phones, unit.Name())
+		}
+		if !reflect.DeepEqual(phones, paths[i].Path()) {
+			t.Errorf("pronunciation %d of bake is %v, want %v", i, phones, paths[i].Path())
+		}
+		// the probabilities keep the ratios of the model
+		ratio := math.Exp(paths[0].Cost() - paths[i].Cost())
+		if p := pronunciation.GetProbability(); math.Abs(p-ratio*bake.GetPronunciations()[0].GetProbability()) > 1e-9 {
+			t.Errorf("pronunciation %d of bake has probability %v", i, p)
+		}
+		total += pronunciation.GetProbability()
+	}
+	if math.Abs(total-1) > 1e-9 {
+		t.Errorf("probabilities of bake sum to %v", total)
+	}
+	if d.GetWord("bake") != bake || d.wordDictionary["bake"] != bake {
+		t.Fatal("generated words are not cached")
+	}
+
+	// the most likely path of e skips it, which is no pronunciation
+	if e := d.GetWord("e"); e == nil || len(e.GetPronunciations()) != 1 ||
+		e.GetPronunciations()[0].GetProbability() != 1 || e.GetPronunciations()[0].GetUnits()[0].Name() != "IY" {
+		t.Fatalf("got %v for e, want the single pronunciation IY", e)
+	}
+	if h := d.GetWord("h"); h != nil {
+		t.Fatalf("got %v for h, which has no phone", h)
+	}
+	if cab := d.GetWord("cab"); cab == nil || len(cab.GetPronunciations()) != 1 {
+		t.Fatalf("got %v for cab, want the dictionary pronunciation", cab)
+	}
+}
@@ -18,7 +18,7 @@ type Word struct {
 func NewUnknownWord() *Word {
 	w := new(Word)
 	w.spelling = "<unk>"
-	w.pronunciations = NewUnknownPronunciation()
+	w.pronunciations = []*Pronunciation{NewUnknownPronunciation()}
 
 	return w
 }
@@ -72,8 +72,13 @@ func (w Word) GetMostLikelyPronunciation() *Pronunciation {
 	return best
 }
 
+// Returns the hash code of the spelling, computed as the Java String does.
 func (w Word) HashCode() int {
-	return w.spelling.HashCode()
+	var hashCode int32
+	for _, c := range w.spelling {
+		hashCode = 31*hashCode + int32(c)
+	}
+	return int(hashCode)
 }
 
 func (w Word) Equals(obj *Word) bool {
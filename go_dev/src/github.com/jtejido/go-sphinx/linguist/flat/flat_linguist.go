@@ -0,0 +1,574 @@
+package flat
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/jtejido/go-sphinx/linguist"
+	"github.com/jtejido/go-sphinx/linguist/acoustic"
+	"github.com/jtejido/go-sphinx/linguist/dictionary"
+	"github.com/jtejido/go-sphinx/linguist/language/grammar"
+	"github.com/jtejido/go-sphinx/util"
+)
+
+// The default path the search graph is dumped to, none.
+const DEFAULT_DUMP_GRAPHVIZ = ""
+
+// A linguist that expands a whole word grammar into a static search graph, before the recognition. Each word of the
+// grammar becomes a word state per pronunciation, followed by its pronunciation state, then the unit states of the
+// pronunciation, each leading to the states of the HMM of the unit.
+//
+// Units are triphones when the acoustic model has left and right contexts: the first unit of a word takes its left
+// context from the last unit of the word before it, and the last unit its right context from the first unit of the
+// word after it. A word is thus expanded once for every context it is entered in, and leaves to the words starting
+// with the unit its last unit was built for. Filler words are context independent, and stand as silence in the
+// contexts of their neighbours.
+//
+// Entering a word costs the silence insertion probability for the silence word, the filler insertion probability
+// for the other fillers and the word insertion probability for the rest; entering a unit costs the unit insertion
+// probability. The probabilities of the grammar arcs are scaled by the language weight.
+type FlatLinguist struct {
+	acousticModel acoustic.AcousticModel
+	unitManager   *acoustic.UnitManager
+	grammar       grammar.Grammar
+	logger        util.Logger
+	logMath       *util.LogMath
+
+	logWordInsertionProbability    float64
+	logSilenceInsertionProbability float64
+	logFillerInsertionProbability  float64
+	logUnitInsertionProbability    float64
+	languageWeight                 float64
+	dumpGraphViz                   string
+
+	searchGraph *flatSearchGraph
+}
+
+// Creates a FlatLinguist with the default probabilities and language weight.
+func NewDefaultFlatLinguist(acousticModel acoustic.AcousticModel, unitManager *acoustic.UnitManager,
+	grammar grammar.Grammar) *FlatLinguist {
+	return NewFlatLinguist(acousticModel, unitManager, grammar, linguist.DEFAULT_WORD_INSERTION_PROBABILITY,
+		linguist.DEFAULT_SILENCE_INSERTION_PROBABILITY, linguist.DEFAULT_FILLER_INSERTION_PROBABILITY,
+		linguist.DEFAULT_UNIT_INSERTION_PROBABILITY, linguist.DEFAULT_LANGUAGE_WEIGHT, nil)
+}
+
+// Creates a FlatLinguist.
+//
+// Accepts the acoustic model providing the HMMs, the unit manager of the dictionary of the grammar, the grammar, the
+// linear insertion probabilities of words, silences, fillers and units, the language weight and an optional logger.
+func NewFlatLinguist(acousticModel acoustic.AcousticModel, unitManager *acoustic.UnitManager, grammar grammar.Grammar,
+	wordInsertionProbability, silenceInsertionProbability, fillerInsertionProbability, unitInsertionProbability,
+	languageWeight float64, logger util.Logger) *FlatLinguist {
+	logMath := util.GetLogMath()
+	return &FlatLinguist{
+		acousticModel:                  acousticModel,
+		unitManager:                    unitManager,
+		grammar:                        grammar,
+		logger:                         logger,
+		logMath:                        logMath,
+		logWordInsertionProbability:    float64(logMath.LinearToLog(wordInsertionProbability)),
+		logSilenceInsertionProbability: float64(logMath.LinearToLog(silenceInsertionProbability)),
+		logFillerInsertionProbability:  float64(logMath.LinearToLog(fillerInsertionProbability)),
+		logUnitInsertionProbability:    float64(logMath.LinearToLog(unitInsertionProbability)),
+		languageWeight:                 languageWeight,
+		dumpGraphViz:                   DEFAULT_DUMP_GRAPHVIZ,
+	}
+}
+
+// Sets the path of the file the search graph is dumped to in the GraphViz format once compiled, or none if empty.
+func (l *FlatLinguist) SetDumpGraphViz(path string) {
+	l.dumpGraphViz = path
+}
+
+// Returns the grammar of the linguist.
+func (l *FlatLinguist) GetGrammar() grammar.Grammar {
+	return l.grammar
+}
+
+// Loads the acoustic model and the grammar, and compiles the search graph.
+func (l *FlatLinguist) Allocate() error {
+	if err := l.acousticModel.Allocate(); err != nil {
+		return err
+	}
+	if err := l.grammar.Allocate(); err != nil {
+		return err
+	}
+	if err := l.compileGrammar(); err != nil {
+		return err
+	}
+
+	if l.dumpGraphViz != "" {
+		file, err := os.Create(l.dumpGraphViz)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		return l.DumpGraphViz(file)
+	}
+	return nil
+}
+
+// Releases the acoustic model, the grammar and the search graph.
+func (l *FlatLinguist) Deallocate() {
+	l.acousticModel.Deallocate()
+	l.grammar.Deallocate()
+	l.searchGraph = nil
+}
+
+// Retrieves the search graph, or nil before allocation.
+func (l *FlatLinguist) GetSearchGraph() linguist.SearchGraph {
+	if l.searchGraph == nil {
+		return nil
+	}
+	return l.searchGraph
+}
+
+// The search graph is static: nothing is done before a recognition.
+func (l *FlatLinguist) StartRecognition() {}
+
+// The search graph is static: nothing is done after a recognition.
+func (l *FlatLinguist) StopRecognition() {}
+
+// Compiles the grammar into the search graph.
+func (l *FlatLinguist) compileGrammar() error {
+	if l.acousticModel.LeftContextSize() > 1 || l.acousticModel.RightContextSize() > 1 {
+		return fmt.Errorf("contexts of %d left and %d right units are not supported",
+			l.acousticModel.LeftContextSize(), l.acousticModel.RightContextSize())
+	}
+
+	c := &compiler{
+		FlatLinguist: l,
+		heads:        make(map[headKey]*wordState),
+		bodies:       make(map[bodyKey]*body),
+		tails:        make(map[tailKey]*unitState),
+	}
+	initialState, err := c.compile(l.grammar.GetInitialNode())
+	if err != nil {
+		return err
+	}
+	l.searchGraph = &flatSearchGraph{initialState: initialState}
+	if l.logger != nil {
+		l.logger.Infof("Flat search graph: %d states", c.stateCount)
+	}
+	return nil
+}
+
+// A word of the grammar, with the words that can follow it.
+type wordNode struct {
+	word *dictionary.Word
+	next []wordArc
+	// the log probability of ending the sentence after the word, LOG_ZERO if it can't
+	logFinalProbability float32
+}
+
+type wordArc struct {
+	node           *wordNode
+	logProbability float32
+}
+
+type headKey struct {
+	node          *wordNode
+	pronunciation int
+	left          *acoustic.Unit
+}
+
+type bodyKey struct {
+	node          *wordNode
+	pronunciation int
+}
+
+type tailKey struct {
+	node          *wordNode
+	pronunciation int
+	right         *acoustic.Unit
+}
+
+// The internal units of a pronunciation, shared by all the contexts of the word.
+type body struct {
+	entry *unitState
+	exit  *hmmStateState
+}
+
+// Holds the state of the compilation of a grammar.
+type compiler struct {
+	*FlatLinguist
+	firstWords map[*grammar.GrammarNode][]wordArc
+	lastWords  map[*grammar.GrammarNode][]*wordNode
+
+	heads      map[headKey]*wordState
+	bodies     map[bodyKey]*body
+	tails      map[tailKey]*unitState
+	pending    []headKey
+	finalState *grammarState
+	stateCount int
+}
+
+func (c *compiler) compile(initialNode *grammar.GrammarNode) (linguist.SearchState, error) {
+	c.buildWordGraph()
+
+	initialState := &grammarState{c.newState("<initial>")}
+	c.finalState = &grammarState{c.newState("<final>")}
+	c.finalState.final = true
+
+	start, logFinalProbability := c.entries([]*grammar.GrammarArc{grammar.NewGrammarArc(initialNode, 0)})
+	if logFinalProbability != util.LOG_ZERO {
+		initialState.connect(c.newArc(c.finalState, logFinalProbability, 0, 0))
+	}
+	left := c.leftContext(acoustic.SILENCE)
+	for _, arc := range start {
+		c.connectWord(&initialState.sentenceHMMState, arc, nil, left)
+	}
+
+	for len(c.pending) > 0 {
+		key := c.pending[len(c.pending)-1]
+		c.pending = c.pending[:len(c.pending)-1]
+		if err := c.expandHead(key); err != nil {
+			return nil, err
+		}
+	}
+	return initialState, nil
+}
+
+// Builds the graph of the words of the grammar, linked through its empty nodes.
+func (c *compiler) buildWordGraph() {
+	c.firstWords = make(map[*grammar.GrammarNode][]wordArc)
+	c.lastWords = make(map[*grammar.GrammarNode][]*wordNode)
+
+	nodes := c.grammar.GetGrammarNodes()
+	for _, node := range nodes {
+		alternatives := node.GetAlternatives()
+		if node.IsEmpty() {
+			continue
+		}
+		logAlternativeProbability := c.logMath.LinearToLog(1 / float64(len(alternatives)))
+		for _, alternative := range alternatives {
+			var previous *wordNode
+			for _, word := range alternative {
+				current := &wordNode{word: word, logFinalProbability: util.LOG_ZERO}
+				if previous == nil {
+					c.firstWords[node] = append(c.firstWords[node], wordArc{current, logAlternativeProbability})
+				} else {
+					previous.next = []wordArc{{current, 0}}
+				}
+				previous = current
+			}
+			c.lastWords[node] = append(c.lastWords[node], previous)
+		}
+	}
+
+	for _, node := range nodes {
+		if node.IsEmpty() {
+			continue
+		}
+		next, logFinalProbability := c.entries(node.GetSuccessors())
+		if node.IsFinalNode() {
+			logFinalProbability = 0
+		}
+		for _, last := range c.lastWords[node] {
+			last.next = next
+			last.logFinalProbability = logFinalProbability
+		}
+	}
+}
+
+// Returns the words entered through the given grammar arcs, empty nodes being skipped, and the log probability of
+// ending the sentence without entering a word. A word entered through several paths keeps the best one.
+func (c *compiler) entries(arcs []*grammar.GrammarArc) ([]wordArc, float32) {
+	var entries []wordArc
+	index := make(map[*wordNode]int)
+	logFinalProbability := float32(util.LOG_ZERO)
+	visiting := make(map[*grammar.GrammarNode]bool)
+
+	var follow func(node *grammar.GrammarNode, logProbability float32)
+	follow = func(node *grammar.GrammarNode, logProbability float32) {
+		if !node.IsEmpty() {
+			for _, first := range c.firstWords[node] {
+				arc := wordArc{first.node, logProbability + first.logProbability}
+				if i, ok := index[arc.node]; !ok {
+					index[arc.node] = len(entries)
+					entries = append(entries, arc)
+				} else if arc.logProbability > entries[i].logProbability {
+					entries[i] = arc
+				}
+			}
+			return
+		}
+		if node.IsFinalNode() && logProbability > logFinalProbability {
+			logFinalProbability = logProbability
+		}
+		if visiting[node] {
+			return
+		}
+		visiting[node] = true
+		for _, arc := range node.GetSuccessors() {
+			follow(arc.GetGrammarNode(), logProbability+arc.GetProbability())
+		}
+		delete(visiting, node)
+	}
+
+	for _, arc := range arcs {
+		follow(arc.GetGrammarNode(), arc.GetProbability())
+	}
+	return entries, logFinalProbability
+}
+
+// Returns the unit standing for the given one in the left context of another, nil if the model has no left context.
+func (c *compiler) leftContext(unit *acoustic.Unit) *acoustic.Unit {
+	if c.acousticModel.LeftContextSize() == 0 {
+		return nil
+	}
+	if unit.IsFiller() {
+		return acoustic.SILENCE
+	}
+	return unit
+}
+
+// Returns the unit standing for the given one in the right context of another, nil if the model has no right
+// context.
+func (c *compiler) rightContext(unit *acoustic.Unit) *acoustic.Unit {
+	if c.acousticModel.RightContextSize() == 0 {
+		return nil
+	}
+	if unit.IsFiller() {
+		return acoustic.SILENCE
+	}
+	return unit
+}
+
+// Returns the right contexts the last unit of a word is built for: the first units of the words that follow it, and
+// silence if the sentence can end after it. Filler words, being context independent, have a single nil context.
+func (c *compiler) rightContexts(node *wordNode) []*acoustic.Unit {
+	if node.word.IsFiller() || c.acousticModel.RightContextSize() == 0 {
+		return []*acoustic.Unit{nil}
+	}
+	contexts := make(map[string]*acoustic.Unit)
+	for _, arc := range node.next {
+		for _, pronunciation := range arc.node.word.GetPronunciations() {
+			if units := pronunciation.GetUnits(); len(units) > 0 {
+				unit := c.rightContext(units[0])
+				contexts[unit.Name()] = unit
+			}
+		}
+	}
+	if node.logFinalProbability != util.LOG_ZERO {
+		contexts[acoustic.SILENCE_NAME] = acoustic.SILENCE
+	}
+
+	names := make([]string, 0, len(contexts))
+	for name := range contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	units := make([]*acoustic.Unit, len(names))
+	for i, name := range names {
+		units[i] = contexts[name]
+	}
+	return units
+}
+
+func (c *compiler) newState(name string) sentenceHMMState {
+	c.stateCount++
+	return sentenceHMMState{id: c.stateCount, name: name}
+}
+
+func (c *compiler) newArc(state linguist.SearchState, logLanguageProbability float32, logInsertionProbability,
+	logAcousticProbability float64) *sentenceHMMStateArc {
+	return &sentenceHMMStateArc{
+		state:                   state,
+		logLanguageProbability:  float64(logLanguageProbability),
+		logInsertionProbability: logInsertionProbability,
+		logAcousticProbability:  logAcousticProbability,
+		languageWeight:          c.languageWeight,
+	}
+}
+
+// Returns the log insertion probability of a word.
+func (c *compiler) wordInsertionProbability(word *dictionary.Word) float64 {
+	switch {
+	case word.GetSpelling() == dictionary.SILENCE_SPELLING:
+		return c.logSilenceInsertionProbability
+	case word.IsFiller():
+		return c.logFillerInsertionProbability
+	default:
+		return c.logWordInsertionProbability
+	}
+}
+
+// Connects a state to the pronunciations of the word of the arc which start with the given right context, entered
+// with the given left context. A nil right context matches all the pronunciations.
+func (c *compiler) connectWord(from *sentenceHMMState, arc wordArc, right, left *acoustic.Unit) {
+	if arc.node.word.IsFiller() {
+		left = nil
+	}
+	for i, pronunciation := range arc.node.word.GetPronunciations() {
+		units := pronunciation.GetUnits()
+		if right != nil && (len(units) == 0 || c.rightContext(units[0]).Name() != right.Name()) {
+			continue
+		}
+		key := headKey{arc.node, i, left}
+		head, ok := c.heads[key]
+		if !ok {
+			head = &wordState{c.newState(arc.node.word.GetSpelling()), pronunciation}
+			c.heads[key] = head
+			c.pending = append(c.pending, key)
+		}
+		from.connect(c.newArc(head, arc.logProbability, c.wordInsertionProbability(arc.node.word), 0))
+	}
+}
+
+// Connects the exit of a word, whose last unit was built for the given right context, to the words that follow it.
+func (c *compiler) connectSuccessors(exit *hmmStateState, node *wordNode, pronunciation *dictionary.Pronunciation,
+	right *acoustic.Unit) {
+	units := pronunciation.GetUnits()
+	left := c.leftContext(units[len(units)-1])
+	for _, arc := range node.next {
+		c.connectWord(&exit.sentenceHMMState, arc, right, left)
+	}
+	if node.logFinalProbability != util.LOG_ZERO && (right == nil || right.Name() == acoustic.SILENCE_NAME) {
+		exit.connect(c.newArc(c.finalState, node.logFinalProbability, 0, 0))
+	}
+}
+
+// Expands a word entered in a left context into its pronunciation state and units.
+func (c *compiler) expandHead(key headKey) error {
+	head := c.heads[key]
+	pronunciation := head.pronunciation
+	units := pronunciation.GetUnits()
+	if len(units) == 0 {
+		return fmt.Errorf("word %s has an empty pronunciation", key.node.word.GetSpelling())
+	}
+
+	pronunciationState := &pronunciationState{c.newState(pronunciation.String()), pronunciation}
+	head.connect(c.newArc(pronunciationState, 0, 0, 0))
+
+	if len(units) == 1 {
+		for _, right := range c.rightContexts(key.node) {
+			exit, err := c.connectUnit(&pronunciationState.sentenceHMMState, units[0], key.left, right, acoustic.SINGLE)
+			if err != nil {
+				return err
+			}
+			c.connectSuccessors(exit, key.node, pronunciation, right)
+		}
+		return nil
+	}
+
+	exit, err := c.connectUnit(&pronunciationState.sentenceHMMState, units[0], key.left, c.rightContext(units[1]),
+		acoustic.BEGIN)
+	if err != nil {
+		return err
+	}
+	if len(units) > 2 {
+		body, err := c.getBody(key.node, key.pronunciation, pronunciation)
+		if err != nil {
+			return err
+		}
+		exit.connect(c.newArc(body.entry, 0, c.logUnitInsertionProbability, 0))
+		return nil
+	}
+	return c.connectTails(exit, key.node, key.pronunciation, pronunciation)
+}
+
+// Returns the internal units of a pronunciation of more than two units, creating them if needed.
+func (c *compiler) getBody(node *wordNode, index int, pronunciation *dictionary.Pronunciation) (*body, error) {
+	key := bodyKey{node, index}
+	if b, ok := c.bodies[key]; ok {
+		return b, nil
+	}
+
+	units := pronunciation.GetUnits()
+	b := new(body)
+	var exit *hmmStateState
+	for i := 1; i < len(units)-1; i++ {
+		unitState := c.newUnitState(units[i], c.leftContext(units[i-1]), c.rightContext(units[i+1]), acoustic.INTERNAL)
+		if exit == nil {
+			b.entry = unitState
+		} else {
+			exit.connect(c.newArc(unitState, 0, c.logUnitInsertionProbability, 0))
+		}
+		var err error
+		if exit, err = c.expandHMM(unitState); err != nil {
+			return nil, err
+		}
+	}
+	b.exit = exit
+	c.bodies[key] = b
+	return b, c.connectTails(exit, node, index, pronunciation)
+}
+
+// Connects the state before the last unit of a pronunciation to the last unit, in each of its right contexts.
+func (c *compiler) connectTails(from *hmmStateState, node *wordNode, index int,
+	pronunciation *dictionary.Pronunciation) error {
+	units := pronunciation.GetUnits()
+	last := units[len(units)-1]
+	for _, right := range c.rightContexts(node) {
+		key := tailKey{node, index, right}
+		tail, ok := c.tails[key]
+		if !ok {
+			tail = c.newUnitState(last, c.leftContext(units[len(units)-2]), right, acoustic.END)
+			exit, err := c.expandHMM(tail)
+			if err != nil {
+				return err
+			}
+			c.tails[key] = tail
+			c.connectSuccessors(exit, node, pronunciation, right)
+		}
+		from.connect(c.newArc(tail, 0, c.logUnitInsertionProbability, 0))
+	}
+	return nil
+}
+
+// Connects a state to a new unit state and its HMM, and returns the exit state of the HMM.
+func (c *compiler) connectUnit(from *sentenceHMMState, unit, left, right *acoustic.Unit,
+	position acoustic.HMMPosition) (*hmmStateState, error) {
+	unitState := c.newUnitState(unit, left, right, position)
+	from.connect(c.newArc(unitState, 0, c.logUnitInsertionProbability, 0))
+	return c.expandHMM(unitState)
+}
+
+// Creates the state of a unit in the given contexts. Fillers are context independent.
+func (c *compiler) newUnitState(unit, left, right *acoustic.Unit, position acoustic.HMMPosition) *unitState {
+	if !unit.IsFiller() && (left != nil || right != nil) {
+		var leftContext, rightContext []*acoustic.Unit
+		if left != nil {
+			leftContext = []*acoustic.Unit{left}
+		}
+		if right != nil {
+			rightContext = []*acoustic.Unit{right}
+		}
+		unit = c.unitManager.UnitFromContext(unit.Name(), false, acoustic.NewLeftRightContext(leftContext, rightContext))
+	}
+	return &unitState{c.newState(unit.String()), unit, position}
+}
+
+// Creates the states of the HMM of a unit, entered from the unit state, and returns the exit state of the HMM.
+func (c *compiler) expandHMM(unitState *unitState) (*hmmStateState, error) {
+	hmm := c.acousticModel.LookupNearestHMM(unitState.unit, unitState.position, false)
+	if hmm == nil {
+		return nil, fmt.Errorf("no HMM for unit %s", unitState.unit)
+	}
+
+	states := make(map[acoustic.HMMState]*hmmStateState)
+	var exit *hmmStateState
+	var visit func(hmmState acoustic.HMMState) *hmmStateState
+	visit = func(hmmState acoustic.HMMState) *hmmStateState {
+		if state, ok := states[hmmState]; ok {
+			return state
+		}
+		state := &hmmStateState{c.newState(fmt.Sprintf("%s:%d", unitState.unit, hmmState.State())), hmmState}
+		states[hmmState] = state
+		if hmmState.IsExitState() {
+			exit = state
+		}
+		for _, arc := range hmmState.Successors() {
+			state.connect(c.newArc(visit(arc.HMMState()), 0, 0, float64(arc.LogProbability())))
+		}
+		return state
+	}
+
+	unitState.connect(c.newArc(visit(hmm.InitialState()), 0, 0, 0))
+	if exit == nil {
+		return nil, fmt.Errorf("HMM of unit %s has no exit state", unitState.unit)
+	}
+	return exit, nil
+}
@@ -0,0 +1,136 @@
+package flat
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jtejido/go-sphinx/linguist"
+	"github.com/jtejido/go-sphinx/linguist/acoustic/acoustictest"
+	"github.com/jtejido/go-sphinx/linguist/dictionary"
+	"github.com/jtejido/go-sphinx/linguist/language/jsgf"
+)
+
+func newTestLinguist(t *testing.T, contextSize int) *FlatLinguist {
+	d := acoustictest.NewDictionary(t, "call K AO L\nhome HH OW M\nwork W ER K\n")
+	dir := acoustictest.WriteFiles(t, map[string]string{
+		"phone.gram": "#JSGF V1.0;\ngrammar phone;\npublic <command> = call (home | work);\n",
+	})
+
+	g := jsgf.NewJSGFGrammar(dir, "phone", d, true, false, false, nil)
+	l := NewFlatLinguist(&acoustictest.AcousticModel{ContextSize: contextSize}, d.UnitManager(), g, 0.1, 0.5, 0.01, 1,
+		8, nil)
+	if err := l.Allocate(); err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+// collect returns the states reachable from the initial state.
+func collect(graph linguist.SearchGraph) []linguist.SearchState {
+	states := []linguist.SearchState{graph.GetInitialState()}
+	seen := map[linguist.SearchState]bool{states[0]: true}
+	for i := 0; i < len(states); i++ {
+		for _, arc := range states[i].GetSuccessors() {
+			if !seen[arc.GetState()] {
+				seen[arc.GetState()] = true
+				states = append(states, arc.GetState())
+			}
+		}
+	}
+	return states
+}
+
+// sentences returns the word sequences of the paths from the initial to the final state.
+func sentences(state linguist.SearchState, words []string, visited map[linguist.SearchState]bool, found map[string]bool) {
+	if visited[state] {
+		return
+	}
+	if ws, ok := state.(linguist.WordSearchState); ok {
+		words = append(words, ws.GetPronunciation().GetWord().GetSpelling())
+	}
+	if state.IsFinal() {
+		found[strings.Join(words, " ")] = true
+		return
+	}
+	visited[state] = true
+	for _, arc := range state.GetSuccessors() {
+		sentences(arc.GetState(), words, visited, found)
+	}
+	delete(visited, state)
+}
+
+func TestFlatLinguistTriphones(t *testing.T) {
+	l := newTestLinguist(t, 1)
+	graph := l.GetSearchGraph()
+
+	found := make(map[string]bool)
+	sentences(graph.GetInitialState(), nil, make(map[linguist.SearchState]bool), found)
+	if len(found) != 2 || !found["<sil> call home <sil>"] || !found["<sil> call work <sil>"] {
+		t.Fatalf("got sentences %v", found)
+	}
+
+	units := make(map[string]bool)
+	for _, state := range collect(graph) {
+		if us, ok := state.(linguist.UnitSearchState); ok {
+			units[us.GetUnit().String()] = true
+		}
+		for _, arc := range state.GetSuccessors() {
+			if !state.IsEmitting() && arc.GetState().GetOrder() < state.GetOrder() {
+				t.Errorf("arc from %s to %s decreases the order", state.ToPrettyString(), arc.GetState().ToPrettyString())
+			}
+			// the right context of the last unit of call selects the next word
+			if us, ok := state.(*hmmStateState); ok && arc.GetState().GetOrder() == wordStateOrder {
+				word := arc.GetState().(linguist.WordSearchState).GetPronunciation().GetWord().GetSpelling()
+				if next := map[string]string{"L[AO,HH]": "home", "L[AO,W]": "work"}[us.hmmState.HMM().Unit().String()]; next != "" && next != word {
+					t.Errorf("%s leads to %s", us.hmmState.HMM().Unit(), word)
+				}
+			}
+		}
+	}
+	for _, unit := range []string{"K[SIL,AO]", "AO[K,L]", "L[AO,HH]", "L[AO,W]", "HH[L,OW]", "M[OW,SIL]", "W[L,ER]", "K[ER,SIL]", "*SIL"} {
+		if !units[unit] {
+			t.Errorf("missing unit %s in %v", unit, units)
+		}
+	}
+	if units["L[AO,SIL]"] {
+		t.Error("call can't end the sentence")
+	}
+
+	var dot strings.Builder
+	if err := l.DumpGraphViz(&dot); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(dot.String(), "digraph") || !strings.Contains(dot.String(), "shape=doublecircle") {
+		t.Errorf("unexpected dump %s", dot.String())
+	}
+}
+
+func TestFlatLinguistContextIndependent(t *testing.T) {
+	l := newTestLinguist(t, 0)
+	logMath := l.logMath
+
+	words := make(map[linguist.SearchState]bool)
+	for _, state := range collect(l.GetSearchGraph()) {
+		if us, ok := state.(linguist.UnitSearchState); ok && us.GetUnit().IsContextDependent() {
+			t.Errorf("context dependent unit %s", us.GetUnit())
+		}
+		for _, arc := range state.GetSuccessors() {
+			ws, ok := arc.GetState().(linguist.WordSearchState)
+			if !ok {
+				continue
+			}
+			words[ws] = true
+			want := logMath.LinearToLog(0.1)
+			if ws.GetPronunciation().GetWord().GetSpelling() == dictionary.SILENCE_SPELLING {
+				want = logMath.LinearToLog(0.5)
+			}
+			if arc.GetInsertionProbability() != float64(want) {
+				t.Errorf("insertion probability of %s is %v", ws.GetPronunciation().GetWord(), arc.GetInsertionProbability())
+			}
+		}
+	}
+	// every word is expanded once without contexts
+	if len(words) != 5 {
+		t.Errorf("got %d word states, want 5", len(words))
+	}
+}
@@ -0,0 +1,61 @@
+package flat
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"github.com/jtejido/go-sphinx/linguist"
+)
+
+// Writes the search graph in the GraphViz dot format. Word states are drawn as boxes, emitting states as circles and
+// the final state as a double circle; arcs are labelled with their log probability when it isn't zero.
+func (l *FlatLinguist) DumpGraphViz(w io.Writer) error {
+	if l.searchGraph == nil {
+		return fmt.Errorf("the search graph is not compiled")
+	}
+
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, "digraph \"FlatLinguist\" {")
+	fmt.Fprintln(out, "\trankdir=LR;")
+
+	ids := make(map[linguist.SearchState]int)
+	queue := []linguist.SearchState{l.searchGraph.GetInitialState()}
+	ids[queue[0]] = 0
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+		id := ids[state]
+
+		shape := "ellipse"
+		switch {
+		case state.IsFinal():
+			shape = "doublecircle"
+		case state.IsEmitting():
+			shape = "circle"
+		default:
+			if _, ok := state.(linguist.WordSearchState); ok {
+				shape = "box"
+			}
+		}
+		fmt.Fprintf(out, "\tn%d [label=%q, shape=%s];\n", id, state.ToPrettyString(), shape)
+
+		for _, arc := range state.GetSuccessors() {
+			next := arc.GetState()
+			nextID, ok := ids[next]
+			if !ok {
+				nextID = len(ids)
+				ids[next] = nextID
+				queue = append(queue, next)
+			}
+			if probability := arc.GetProbability(); probability != 0 {
+				fmt.Fprintf(out, "\tn%d -> n%d [label=\"%.4g\"];\n", id, nextID, probability)
+			} else {
+				fmt.Fprintf(out, "\tn%d -> n%d;\n", id, nextID)
+			}
+		}
+	}
+
+	fmt.Fprintln(out, "}")
+	return out.Flush()
+}
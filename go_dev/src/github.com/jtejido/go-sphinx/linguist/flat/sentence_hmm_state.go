@@ -0,0 +1,207 @@
+package flat
+
+import (
+	"fmt"
+
+	"github.com/jtejido/go-sphinx/linguist"
+	"github.com/jtejido/go-sphinx/linguist/acoustic"
+	"github.com/jtejido/go-sphinx/linguist/dictionary"
+)
+
+const (
+	// The orders of the states of the search graph. Within a frame, the non-emitting states are always entered in
+	// increasing order, emitting HMM states last.
+	exitStateOrder = iota
+	grammarStateOrder
+	wordStateOrder
+	pronunciationStateOrder
+	unitStateOrder
+	hmmStateOrder
+	numStateOrder
+)
+
+// An arc of the search graph, with its probabilities in the LogMath log domain.
+type sentenceHMMStateArc struct {
+	state                   linguist.SearchState
+	logLanguageProbability  float64
+	logInsertionProbability float64
+	logAcousticProbability  float64
+	languageWeight          float64
+}
+
+// Gets a successor to this search state
+func (arc *sentenceHMMStateArc) GetState() linguist.SearchState {
+	return arc.state
+}
+
+// Gets the composite probability of entering this state: the language probability scaled by the language weight,
+// the insertion probability and the HMM transition probability.
+func (arc *sentenceHMMStateArc) GetProbability() float64 {
+	return arc.logLanguageProbability*arc.languageWeight + arc.logInsertionProbability + arc.logAcousticProbability
+}
+
+// Gets the language probability of entering this state
+func (arc *sentenceHMMStateArc) GetLanguageProbability() float64 {
+	return arc.logLanguageProbability
+}
+
+// Gets the insertion probability of entering this state
+func (arc *sentenceHMMStateArc) GetInsertionProbability() float64 {
+	return arc.logInsertionProbability
+}
+
+// What the states of the search graph share: their identity, their successors and whether they end the sentence.
+type sentenceHMMState struct {
+	id    int
+	name  string
+	final bool
+	arcs  []linguist.SearchStateArc
+}
+
+func (s *sentenceHMMState) connect(arc *sentenceHMMStateArc) {
+	s.arcs = append(s.arcs, arc)
+}
+
+// Gets the successors of this state
+func (s *sentenceHMMState) GetSuccessors() []linguist.SearchStateArc {
+	return s.arcs
+}
+
+// Determines if this is a final state
+func (s *sentenceHMMState) IsFinal() bool {
+	return s.final
+}
+
+// Returns the name of this state
+func (s *sentenceHMMState) ToPrettyString() string {
+	return s.name
+}
+
+// Returns a unique signature for this state
+func (s *sentenceHMMState) GetSignature() string {
+	return fmt.Sprintf("%s-%d", s.name, s.id)
+}
+
+// The search graph is static: there is no word history
+func (s *sentenceHMMState) GetWordHistory() *linguist.WordSequence {
+	return nil
+}
+
+func (s *sentenceHMMState) String() string {
+	return s.GetSignature()
+}
+
+// A non-emitting state standing for a point of the grammar: the start or the end of the sentences.
+type grammarState struct {
+	sentenceHMMState
+}
+
+func (s *grammarState) IsEmitting() bool         { return false }
+func (s *grammarState) GetOrder() int            { return grammarStateOrder }
+func (s *grammarState) GetLexState() interface{} { return s }
+
+// The entry of a word in the search graph, for one of its pronunciations.
+type wordState struct {
+	sentenceHMMState
+	pronunciation *dictionary.Pronunciation
+}
+
+func (s *wordState) IsEmitting() bool         { return false }
+func (s *wordState) GetOrder() int            { return wordStateOrder }
+func (s *wordState) GetLexState() interface{} { return s }
+
+// Gets the word (as a pronunciation)
+func (s *wordState) GetPronunciation() *dictionary.Pronunciation {
+	return s.pronunciation
+}
+
+// The words of the flat linguist precede their units
+func (s *wordState) IsWordStart() bool {
+	return true
+}
+
+// The state of a pronunciation of a word, leading to its units.
+type pronunciationState struct {
+	sentenceHMMState
+	pronunciation *dictionary.Pronunciation
+}
+
+func (s *pronunciationState) IsEmitting() bool         { return false }
+func (s *pronunciationState) GetOrder() int            { return pronunciationStateOrder }
+func (s *pronunciationState) GetLexState() interface{} { return s }
+
+// Gets the pronunciation
+func (s *pronunciationState) GetPronunciation() *dictionary.Pronunciation {
+	return s.pronunciation
+}
+
+// The entry of a unit, in its context, leading to the states of its HMM.
+type unitState struct {
+	sentenceHMMState
+	unit     *acoustic.Unit
+	position acoustic.HMMPosition
+}
+
+func (s *unitState) IsEmitting() bool         { return false }
+func (s *unitState) GetOrder() int            { return unitStateOrder }
+func (s *unitState) GetLexState() interface{} { return s }
+
+// Gets the unit
+func (s *unitState) GetUnit() *acoustic.Unit {
+	return s.unit
+}
+
+// Gets the position of the unit in its word
+func (s *unitState) GetPosition() acoustic.HMMPosition {
+	return s.position
+}
+
+// A state of the HMM of a unit. The emitting states are scored against the features; the exit state of the HMM leads
+// to what follows the unit.
+type hmmStateState struct {
+	sentenceHMMState
+	hmmState acoustic.HMMState
+}
+
+func (s *hmmStateState) IsEmitting() bool         { return s.hmmState.IsEmitting() }
+func (s *hmmStateState) GetLexState() interface{} { return s }
+
+func (s *hmmStateState) GetOrder() int {
+	if s.hmmState.IsEmitting() {
+		return hmmStateOrder
+	}
+	return exitStateOrder
+}
+
+// Gets the HMM state
+func (s *hmmStateState) GetHMMState() acoustic.HMMState {
+	return s.hmmState
+}
+
+// The static search graph of a FlatLinguist.
+type flatSearchGraph struct {
+	initialState linguist.SearchState
+}
+
+// Retrieves initial search state
+func (g *flatSearchGraph) GetInitialState() linguist.SearchState {
+	return g.initialState
+}
+
+// Returns the number of different state types maintained in the search graph
+func (g *flatSearchGraph) GetNumStateOrder() int {
+	return numStateOrder
+}
+
+// Words precede their units
+func (g *flatSearchGraph) GetWordTokenFirst() bool {
+	return true
+}
+
+var (
+	_ linguist.WordSearchState = (*wordState)(nil)
+	_ linguist.UnitSearchState = (*unitState)(nil)
+	_ linguist.HMMSearchState  = (*hmmStateState)(nil)
+	_ linguist.SearchState     = (*grammarState)(nil)
+	_ linguist.SearchState     = (*pronunciationState)(nil)
+)
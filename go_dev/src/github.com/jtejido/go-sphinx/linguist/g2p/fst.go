@@ -0,0 +1,272 @@
+package g2p
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+const (
+	fstMagicNumber         = 2125659606
+	symbolTableMagicNumber = 2125658996
+
+	hasInputSymbols  = 0x1
+	hasOutputSymbols = 0x2
+	isAligned        = 0x4
+
+	// The label of epsilon transitions.
+	EPSILON = 0
+)
+
+// An arc of a weighted finite state transducer, in the tropical semiring.
+type Arc struct {
+	ILabel, OLabel int
+	Weight         float32
+	NextState      int
+}
+
+type fstState struct {
+	final float32
+	arcs  []Arc
+}
+
+// A weighted finite state transducer in the tropical semiring, as read from an OpenFst binary VectorFst of the
+// standard arc type, such as the models trained by Phonetisaurus. Weights are costs: negative log probabilities,
+// infinity for no final weight.
+type Fst struct {
+	start  int
+	states []fstState
+	isyms  *SymbolTable
+	osyms  *SymbolTable
+}
+
+// Loads an OpenFst binary VectorFst file.
+func LoadFst(path string) (*Fst, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	fst, err := ReadFst(bufio.NewReader(file))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return fst, nil
+}
+
+// Reads an OpenFst binary VectorFst.
+func ReadFst(r io.Reader) (*Fst, error) {
+	in := &fstReader{r: r}
+
+	if magic := in.int32(); magic != fstMagicNumber {
+		if in.err != nil {
+			return nil, in.err
+		}
+		return nil, fmt.Errorf("not an OpenFst binary file, magic number %d", magic)
+	}
+	fstType := in.string()
+	arcType := in.string()
+	in.int32() // version
+	flags := in.int32()
+	in.int64() // properties
+	start := in.int64()
+	numStates := in.int64()
+	in.int64() // number of arcs
+	if in.err != nil {
+		return nil, in.err
+	}
+	if fstType != "vector" || arcType != "standard" {
+		return nil, fmt.Errorf("unsupported %s fst of %s arcs", fstType, arcType)
+	}
+
+	fst := &Fst{start: int(start)}
+	if flags&hasInputSymbols != 0 {
+		fst.isyms = in.symbolTable()
+	}
+	if flags&hasOutputSymbols != 0 {
+		fst.osyms = in.symbolTable()
+	}
+	if flags&isAligned != 0 {
+		in.align(16)
+	}
+
+	for s := int64(0); numStates < 0 || s < numStates; s++ {
+		final := in.float32()
+		if numStates < 0 && in.err == io.EOF {
+			in.err = nil
+			break
+		}
+		numArcs := in.int64()
+		if in.err != nil {
+			return nil, in.err
+		}
+		state := fstState{final: final, arcs: make([]Arc, numArcs)}
+		for i := range state.arcs {
+			state.arcs[i] = Arc{
+				ILabel:    int(in.int32()),
+				OLabel:    int(in.int32()),
+				Weight:    in.float32(),
+				NextState: int(in.int32()),
+			}
+		}
+		fst.states = append(fst.states, state)
+	}
+	if in.err != nil {
+		return nil, in.err
+	}
+
+	if fst.start >= len(fst.states) {
+		return nil, fmt.Errorf("start state %d of an fst of %d states", fst.start, len(fst.states))
+	}
+	for _, state := range fst.states {
+		for _, arc := range state.arcs {
+			if arc.NextState < 0 || arc.NextState >= len(fst.states) {
+				return nil, fmt.Errorf("arc to state %d of an fst of %d states", arc.NextState, len(fst.states))
+			}
+		}
+	}
+	return fst, nil
+}
+
+// Returns the start state, -1 if the fst is empty.
+func (f *Fst) Start() int {
+	return f.start
+}
+
+// Returns the number of states.
+func (f *Fst) NumStates() int {
+	return len(f.states)
+}
+
+// Returns the final weight of the given state, infinity if it is not final.
+func (f *Fst) Final(state int) float32 {
+	return f.states[state].final
+}
+
+// Returns the arcs leaving the given state.
+func (f *Fst) Arcs(state int) []Arc {
+	return f.states[state].arcs
+}
+
+// Returns the input symbol table, nil if there is none.
+func (f *Fst) InputSymbols() *SymbolTable {
+	return f.isyms
+}
+
+// Returns the output symbol table, nil if there is none.
+func (f *Fst) OutputSymbols() *SymbolTable {
+	return f.osyms
+}
+
+// Maps the symbols of an fst to their labels and back.
+type SymbolTable struct {
+	name    string
+	symbols map[string]int
+	labels  map[int]string
+}
+
+// Creates an empty symbol table.
+func NewSymbolTable(name string) *SymbolTable {
+	return &SymbolTable{name: name, symbols: make(map[string]int), labels: make(map[int]string)}
+}
+
+// Adds a symbol with the given label.
+func (st *SymbolTable) Add(symbol string, label int) {
+	st.symbols[symbol] = label
+	st.labels[label] = symbol
+}
+
+// Returns the label of the given symbol, and whether the symbol is in the table.
+func (st *SymbolTable) Label(symbol string) (int, bool) {
+	label, ok := st.symbols[symbol]
+	return label, ok
+}
+
+// Returns the symbol of the given label, and whether the label is in the table.
+func (st *SymbolTable) Symbol(label int) (string, bool) {
+	symbol, ok := st.labels[label]
+	return symbol, ok
+}
+
+// Returns all the symbols of the table, in no particular order.
+func (st *SymbolTable) Symbols() []string {
+	symbols := make([]string, 0, len(st.symbols))
+	for symbol := range st.symbols {
+		symbols = append(symbols, symbol)
+	}
+	return symbols
+}
+
+// fstReader reads the little-endian fields of OpenFst files, keeping the first error.
+type fstReader struct {
+	r      io.Reader
+	offset int64
+	err    error
+}
+
+func (in *fstReader) read(buf []byte) {
+	if in.err != nil {
+		return
+	}
+	n, err := io.ReadFull(in.r, buf)
+	in.offset += int64(n)
+	if err == io.ErrUnexpectedEOF {
+		err = fmt.Errorf("truncated fst: %w", err)
+	}
+	in.err = err
+}
+
+func (in *fstReader) int32() int32 {
+	var buf [4]byte
+	in.read(buf[:])
+	return int32(binary.LittleEndian.Uint32(buf[:]))
+}
+
+func (in *fstReader) int64() int64 {
+	var buf [8]byte
+	in.read(buf[:])
+	return int64(binary.LittleEndian.Uint64(buf[:]))
+}
+
+func (in *fstReader) float32() float32 {
+	var buf [4]byte
+	in.read(buf[:])
+	return math.Float32frombits(binary.LittleEndian.Uint32(buf[:]))
+}
+
+func (in *fstReader) string() string {
+	length := in.int32()
+	if in.err != nil || length < 0 {
+		if in.err == nil {
+			in.err = fmt.Errorf("negative string length %d", length)
+		}
+		return ""
+	}
+	buf := make([]byte, length)
+	in.read(buf)
+	return string(buf)
+}
+
+func (in *fstReader) align(alignment int64) {
+	if padding := (alignment - in.offset%alignment) % alignment; padding > 0 {
+		in.read(make([]byte, padding))
+	}
+}
+
+func (in *fstReader) symbolTable() *SymbolTable {
+	if magic := in.int32(); in.err == nil && magic != symbolTableMagicNumber {
+		in.err = fmt.Errorf("bad symbol table magic number %d", magic)
+	}
+	st := NewSymbolTable(in.string())
+	in.int64() // available key
+	size := in.int64()
+	for i := int64(0); i < size && in.err == nil; i++ {
+		symbol := in.string()
+		st.Add(symbol, int(in.int64()))
+	}
+	return st
+}
@@ -0,0 +1,343 @@
+package g2p
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// The separator of the graphemes and phonemes of a cluster, as in "c|k" or "K|S".
+	SEPARATOR = "|"
+
+	// The phoneme of graphemes that are not pronounced.
+	SKIP = "_"
+
+	// The symbols of epsilon, sentence start and sentence end in the model.
+	EPSILON_SYMBOL        = "<eps>"
+	SENTENCE_START_SYMBOL = "<s>"
+	SENTENCE_END_SYMBOL   = "</s>"
+
+	// The maximum number of partial paths expanded by the search for the pronunciations of one word.
+	MAX_SEARCH_EXPANSIONS = 200000
+
+	// The label of the graphemes only found in clusters of the model, crossed alone without any cost or phoneme.
+	unknownGraphemeLabel = -1
+)
+
+// A pronunciation found by the G2PConverter: its phonemes and its cost, the negative natural logarithm of its
+// probability under the model.
+type Path struct {
+	path []string
+	cost float64
+}
+
+// Returns the phonemes of the pronunciation.
+func (p *Path) Path() []string {
+	return p.path
+}
+
+// Returns the cost of the pronunciation.
+func (p *Path) Cost() float64 {
+	return p.cost
+}
+
+func (p *Path) String() string {
+	return fmt.Sprintf("%.4f %s", p.cost, strings.Join(p.path, " "))
+}
+
+// Generates pronunciations of words with a joint-sequence grapheme to phoneme model, as trained by Phonetisaurus and
+// stored as an OpenFst binary WFST with its symbol tables. The input symbols of the model are graphemes or clusters of
+// graphemes ("c|k"), the output symbols are phonemes, clusters of phonemes ("K|S") or SKIP.
+//
+// The word is turned into an acceptor of its graphemes, including the clusters known to the model, which is composed
+// with the model on the fly. The N best distinct pronunciations are then found by an A* search of the composition,
+// guided by the exact cost to a final state. Graphemes unknown to the model are ignored.
+type G2PConverter struct {
+	model         *Fst
+	graphemes     map[string]int
+	clusters      [][]string
+	clusterLabels []int
+	startLabel    int
+	endLabel      int
+	phonemes      map[int][]string
+}
+
+// Loads a G2PConverter from a Phonetisaurus model file.
+func NewG2PConverter(path string) (*G2PConverter, error) {
+	model, err := LoadFst(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewG2PConverterFromFst(model)
+}
+
+// Creates a G2PConverter from a model with input and output symbol tables.
+func NewG2PConverterFromFst(model *Fst) (*G2PConverter, error) {
+	if model.InputSymbols() == nil || model.OutputSymbols() == nil {
+		return nil, fmt.Errorf("g2p model has no symbol tables")
+	}
+	if model.Start() < 0 {
+		return nil, fmt.Errorf("g2p model is empty")
+	}
+
+	c := &G2PConverter{
+		model:      model,
+		graphemes:  make(map[string]int),
+		phonemes:   make(map[int][]string),
+		startLabel: unknownGraphemeLabel,
+		endLabel:   unknownGraphemeLabel,
+	}
+
+	for _, symbol := range model.InputSymbols().Symbols() {
+		label, _ := model.InputSymbols().Label(symbol)
+		switch {
+		case label == EPSILON || symbol == EPSILON_SYMBOL:
+		case symbol == SENTENCE_START_SYMBOL:
+			c.startLabel = label
+		case symbol == SENTENCE_END_SYMBOL:
+			c.endLabel = label
+		case strings.Contains(symbol, SEPARATOR) && symbol != SEPARATOR:
+			c.clusters = append(c.clusters, strings.Split(symbol, SEPARATOR))
+			c.clusterLabels = append(c.clusterLabels, label)
+		default:
+			c.graphemes[symbol] = label
+		}
+	}
+
+	for _, symbol := range model.OutputSymbols().Symbols() {
+		label, _ := model.OutputSymbols().Label(symbol)
+		var phones []string
+		for _, phone := range strings.Split(symbol, SEPARATOR) {
+			switch phone {
+			case "", SKIP, EPSILON_SYMBOL, SENTENCE_START_SYMBOL, SENTENCE_END_SYMBOL:
+			default:
+				phones = append(phones, phone)
+			}
+		}
+		c.phonemes[label] = phones
+	}
+
+	// sort the arcs of the model by input label, for the composition
+	for s := 0; s < model.NumStates(); s++ {
+		arcs := model.Arcs(s)
+		sort.SliceStable(arcs, func(i, j int) bool { return arcs[i].ILabel < arcs[j].ILabel })
+	}
+	return c, nil
+}
+
+// Returns whether the grapheme is a symbol of the model or part of one of its clusters.
+func (c *G2PConverter) isKnown(grapheme string) bool {
+	if _, ok := c.graphemes[grapheme]; ok {
+		return true
+	}
+	for _, cluster := range c.clusters {
+		for _, g := range cluster {
+			if g == grapheme {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// An arc of the acceptor of the word.
+type entryArc struct {
+	label, next int
+}
+
+// Builds the acceptor of the graphemes of the word, with the arcs of the clusters and the sentence delimiters of
+// the model.
+func (c *G2PConverter) entryToFSA(word string) ([][]entryArc, int) {
+	graphemes := make([]string, 0, utf8.RuneCountInString(word))
+	for _, r := range word {
+		// drop the graphemes the model knows nothing of, which would break the clusters around them
+		if grapheme := string(r); c.isKnown(grapheme) {
+			graphemes = append(graphemes, grapheme)
+		}
+	}
+
+	offset := 0
+	var fsa [][]entryArc
+	if c.startLabel != unknownGraphemeLabel {
+		fsa = append(fsa, []entryArc{{c.startLabel, 1}})
+		offset = 1
+	}
+	for i, grapheme := range graphemes {
+		label, ok := c.graphemes[grapheme]
+		if !ok {
+			label = unknownGraphemeLabel
+		}
+		fsa = append(fsa, []entryArc{{label, offset + i + 1}})
+	}
+
+	// Add any cluster arcs
+	for k, cluster := range c.clusters {
+		for i := 0; i+len(cluster) <= len(graphemes); i++ {
+			matches := true
+			for j, grapheme := range cluster {
+				if graphemes[i+j] != grapheme {
+					matches = false
+					break
+				}
+			}
+			if matches {
+				fsa[offset+i] = append(fsa[offset+i], entryArc{c.clusterLabels[k], offset + i + len(cluster)})
+			}
+		}
+	}
+
+	final := offset + len(graphemes)
+	if c.endLabel != unknownGraphemeLabel {
+		fsa = append(fsa, []entryArc{{c.endLabel, final + 1}})
+		final++
+	}
+	return append(fsa, nil), final
+}
+
+// A state of the composition of the word acceptor with the model.
+type composedState struct {
+	entry, model int
+}
+
+type composedArc struct {
+	next   composedState
+	weight float64
+	olabel int
+}
+
+// Returns the arcs of a state of the composition.
+func (c *G2PConverter) arcs(fsa [][]entryArc, s composedState) []composedArc {
+	var arcs []composedArc
+	modelArcs := c.model.Arcs(s.model)
+	for _, arc := range modelArcs {
+		if arc.ILabel != EPSILON {
+			break
+		}
+		arcs = append(arcs, composedArc{composedState{s.entry, arc.NextState}, float64(arc.Weight), arc.OLabel})
+	}
+	for _, entry := range fsa[s.entry] {
+		if entry.label == unknownGraphemeLabel {
+			arcs = append(arcs, composedArc{composedState{entry.next, s.model}, 0, EPSILON})
+			continue
+		}
+		first := sort.Search(len(modelArcs), func(i int) bool { return modelArcs[i].ILabel >= entry.label })
+		for _, arc := range modelArcs[first:] {
+			if arc.ILabel != entry.label {
+				break
+			}
+			arcs = append(arcs, composedArc{composedState{entry.next, arc.NextState}, float64(arc.Weight), arc.OLabel})
+		}
+	}
+	return arcs
+}
+
+// Computes the cost from every reachable state of the composition to a final state.
+func (c *G2PConverter) distances(fsa [][]entryArc, final int, start composedState) map[composedState]float64 {
+	distance := make(map[composedState]float64)
+	onStack := make(map[composedState]bool)
+	var visit func(s composedState) float64
+	visit = func(s composedState) float64 {
+		if d, ok := distance[s]; ok {
+			return d
+		}
+		if onStack[s] {
+			return math.Inf(1)
+		}
+		onStack[s] = true
+		d := math.Inf(1)
+		if s.entry == final {
+			d = float64(c.model.Final(s.model))
+		}
+		for _, arc := range c.arcs(fsa, s) {
+			d = math.Min(d, arc.weight+visit(arc.next))
+		}
+		delete(onStack, s)
+		distance[s] = d
+		return d
+	}
+	visit(start)
+	return distance
+}
+
+// A partial path of the A* search, its phoneme labels linked backwards.
+type searchPath struct {
+	state    composedState
+	cost     float64
+	estimate float64
+	olabel   int
+	previous *searchPath
+	complete bool
+}
+
+type pathQueue []*searchPath
+
+func (q pathQueue) Len() int            { return len(q) }
+func (q pathQueue) Less(i, j int) bool  { return q[i].estimate < q[j].estimate }
+func (q pathQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *pathQueue) Push(x interface{}) { *q = append(*q, x.(*searchPath)) }
+func (q *pathQueue) Pop() interface{} {
+	old := *q
+	p := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return p
+}
+
+// Returns up to nbest distinct pronunciations of the word, the most likely first.
+func (c *G2PConverter) Phoneticize(word string, nbest int) []*Path {
+	fsa, final := c.entryToFSA(word)
+	start := composedState{0, c.model.Start()}
+	distance := c.distances(fsa, final, start)
+	if math.IsInf(distance[start], 1) {
+		return nil
+	}
+
+	var paths []*Path
+	seen := make(map[string]bool)
+	queue := &pathQueue{{state: start, estimate: distance[start], olabel: EPSILON}}
+	for expansions := 0; queue.Len() > 0 && len(paths) < nbest && expansions < MAX_SEARCH_EXPANSIONS; expansions++ {
+		p := heap.Pop(queue).(*searchPath)
+		if p.complete {
+			phones := c.phones(p)
+			if key := strings.Join(phones, " "); !seen[key] {
+				seen[key] = true
+				paths = append(paths, &Path{path: phones, cost: p.cost})
+			}
+			continue
+		}
+
+		if p.state.entry == final {
+			if weight := float64(c.model.Final(p.state.model)); !math.IsInf(weight, 1) {
+				heap.Push(queue, &searchPath{state: p.state, cost: p.cost + weight, estimate: p.cost + weight,
+					olabel: EPSILON, previous: p, complete: true})
+			}
+		}
+		for _, arc := range c.arcs(fsa, p.state) {
+			h, ok := distance[arc.next]
+			if !ok || math.IsInf(h, 1) {
+				continue
+			}
+			cost := p.cost + arc.weight
+			heap.Push(queue, &searchPath{state: arc.next, cost: cost, estimate: cost + h, olabel: arc.olabel, previous: p})
+		}
+	}
+	return paths
+}
+
+// Returns the phonemes along a path of the search.
+func (c *G2PConverter) phones(p *searchPath) []string {
+	var reversed [][]string
+	for ; p != nil; p = p.previous {
+		if p.olabel != EPSILON {
+			reversed = append(reversed, c.phonemes[p.olabel])
+		}
+	}
+	phones := make([]string, 0, len(reversed))
+	for i := len(reversed) - 1; i >= 0; i-- {
+		phones = append(phones, reversed[i]...)
+	}
+	return phones
+}
@@ -0,0 +1,103 @@
+package g2p
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// writeFst writes a single state model in the OpenFst binary format, with its symbol tables.
+func writeFst(t *testing.T, isyms, osyms []string, arcs []Arc) []byte {
+	var buf bytes.Buffer
+	write := func(v interface{}) {
+		if err := binary.Write(&buf, binary.LittleEndian, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeString := func(s string) {
+		write(int32(len(s)))
+		buf.WriteString(s)
+	}
+	writeSymbols := func(symbols []string) {
+		write(int32(symbolTableMagicNumber))
+		writeString("symbols")
+		write(int64(len(symbols)))
+		write(int64(len(symbols)))
+		for label, symbol := range symbols {
+			writeString(symbol)
+			write(int64(label))
+		}
+	}
+
+	write(int32(fstMagicNumber))
+	writeString("vector")
+	writeString("standard")
+	write(int32(2))
+	write(int32(hasInputSymbols | hasOutputSymbols))
+	write(int64(0))
+	write(int64(0)) // start
+	write(int64(1)) // states
+	write(int64(len(arcs)))
+	writeSymbols(isyms)
+	writeSymbols(osyms)
+
+	write(float32(0))
+	write(int64(len(arcs)))
+	for _, arc := range arcs {
+		write(int32(arc.ILabel))
+		write(int32(arc.OLabel))
+		write(arc.Weight)
+		write(int32(arc.NextState))
+	}
+	return buf.Bytes()
+}
+
+func TestPhoneticize(t *testing.T) {
+	isyms := []string{"<eps>", "c", "a", "b", "a|b"}
+	osyms := []string{"<eps>", "K", "S", "AE", "EY", "B", "AE|B", "_"}
+	model, err := ReadFst(bytes.NewReader(writeFst(t, isyms, osyms, []Arc{
+		{ILabel: 1, OLabel: 1, Weight: 1},
+		{ILabel: 1, OLabel: 2, Weight: 2},
+		{ILabel: 2, OLabel: 3, Weight: 0.5},
+		{ILabel: 2, OLabel: 4, Weight: 1.5},
+		{ILabel: 3, OLabel: 5, Weight: 0.1},
+		{ILabel: 3, OLabel: 7, Weight: 3},
+		{ILabel: 4, OLabel: 6, Weight: 0.3},
+	})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	converter, err := NewG2PConverterFromFst(model)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []struct {
+		phones []string
+		cost   float64
+	}{
+		{[]string{"K", "AE", "B"}, 1.3},
+		{[]string{"S", "AE", "B"}, 2.3},
+		{[]string{"K", "EY", "B"}, 2.6},
+		{[]string{"S", "EY", "B"}, 3.6},
+		{[]string{"K", "AE"}, 4.5},
+	}
+	// the unknown grapheme is ignored
+	for _, word := range []string{"cab", "caxb"} {
+		paths := converter.Phoneticize(word, len(expected))
+		if len(paths) != len(expected) {
+			t.Fatalf("%s: %d pronunciations, expected %d: %v", word, len(paths), len(expected), paths)
+		}
+		for i, path := range paths {
+			if !reflect.DeepEqual(path.Path(), expected[i].phones) || math.Abs(path.Cost()-expected[i].cost) > 1e-5 {
+				t.Errorf("%s: pronunciation %d is %v, expected %v %v", word, i, path, expected[i].cost, expected[i].phones)
+			}
+		}
+	}
+
+	if paths := converter.Phoneticize("d", 1); len(paths) != 1 || len(paths[0].Path()) != 0 {
+		t.Errorf("unexpected pronunciations of an unknown word: %v", paths)
+	}
+}
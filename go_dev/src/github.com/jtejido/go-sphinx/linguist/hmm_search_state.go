@@ -0,0 +1,13 @@
+package linguist
+
+import (
+	"github.com/jtejido/go-sphinx/linguist/acoustic"
+)
+
+// Represents a single HMM state in a language search space
+type HMMSearchState interface {
+	SearchState
+
+	// Gets the HMM state
+	GetHMMState() acoustic.HMMState
+}
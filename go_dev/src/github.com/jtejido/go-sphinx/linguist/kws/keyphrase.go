@@ -0,0 +1,51 @@
+package kws
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// The default detection threshold of a keyphrase, a linear probability ratio.
+const DEFAULT_KEYPHRASE_THRESHOLD = 1.0
+
+// A phrase to spot, with its detection threshold: the linear ratio the probability of the phrase must reach over the
+// probability of the phone loop for the same frames. Longer phrases take smaller thresholds, such as 1e-20, shorter
+// ones larger, such as 1e-5; the lower the threshold, the more detections and false alarms.
+type Keyphrase struct {
+	Phrase    string
+	Threshold float64
+}
+
+// Parses a list of keyphrases: one per line, optionally followed by its threshold between slashes, as in
+// "oh mighty computer /1e-40/". Phrases without threshold get the default one. Empty lines are skipped.
+func ParseKeyphrases(r io.Reader) ([]Keyphrase, error) {
+	var keyphrases []Keyphrase
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		keyphrase := Keyphrase{Phrase: text, Threshold: DEFAULT_KEYPHRASE_THRESHOLD}
+		if strings.HasSuffix(text, "/") {
+			start := strings.LastIndex(text[:len(text)-1], "/")
+			if start < 0 {
+				return nil, fmt.Errorf("line %d: missing \"/\" before the threshold", line)
+			}
+			threshold, err := strconv.ParseFloat(strings.TrimSpace(text[start+1:len(text)-1]), 64)
+			if err != nil || threshold <= 0 {
+				return nil, fmt.Errorf("line %d: invalid threshold %q", line, text[start:])
+			}
+			keyphrase.Phrase, keyphrase.Threshold = strings.TrimSpace(text[:start]), threshold
+		}
+		keyphrase.Phrase = strings.Join(strings.Fields(keyphrase.Phrase), " ")
+		if keyphrase.Phrase == "" {
+			return nil, fmt.Errorf("line %d: empty keyphrase", line)
+		}
+		keyphrases = append(keyphrases, keyphrase)
+	}
+	return keyphrases, scanner.Err()
+}
@@ -0,0 +1,246 @@
+package kws
+
+import (
+	"math"
+
+	"github.com/jtejido/go-sphinx/frontend"
+	"github.com/jtejido/go-sphinx/linguist"
+	"github.com/jtejido/go-sphinx/util"
+)
+
+// The default beam of the keyword spotter, relative to the best score of the frame.
+const DEFAULT_RELATIVE_BEAM_WIDTH = 1e-48
+
+// A keyphrase detected in the stream: the first and last frames it spans, its confidence, the log ratio of its score
+// over the best phone loop exit of its last frame, and the collect time of that frame.
+type Detection struct {
+	Keyphrase   Keyphrase
+	StartFrame  int
+	EndFrame    int
+	Confidence  float64
+	CollectTime int64
+}
+
+// The best path reaching a state of the keyword search: its score, and the frame its keyphrase was entered at.
+type keywordPath struct {
+	score      float64
+	startFrame int
+}
+
+// Spots the keyphrases of a KeywordSpottingLinguist in an unbounded stream of frames. The keyphrases and the phone
+// loop of the linguist are searched in parallel, the phrases being entered from the phone loop at every frame. A
+// keyphrase is detected when its score beats the best phone loop exit of the frame by its threshold; the search then
+// forgets the paths of the keyphrase, which has to be entered again to be detected again.
+//
+// Only the best score of every state is kept, with the frame its keyphrase was entered at, so the memory of the
+// search doesn't grow with the stream. Scores are normalized by the best one at every frame.
+type KeywordSpotter struct {
+	linguist             *KeywordSpottingLinguist
+	frontend             frontend.DataProcessor
+	logger               util.Logger
+	logRelativeBeamWidth float64
+
+	frameNumber int
+	collectTime int64
+	activeList  map[linguist.SearchState]keywordPath
+	detections  []*Detection
+	streamEnd   bool
+	err         error
+}
+
+// Creates a KeywordSpotter with the default beam.
+func NewDefaultKeywordSpotter(linguist *KeywordSpottingLinguist, frontend frontend.DataProcessor) *KeywordSpotter {
+	return NewKeywordSpotter(linguist, frontend, DEFAULT_RELATIVE_BEAM_WIDTH, nil)
+}
+
+// Creates a KeywordSpotter.
+//
+// Accepts the linguist of the keyphrases, which must be allocated before spotting starts, the front end providing the
+// features, the linear beam relative to the best score of a frame, and an optional logger.
+func NewKeywordSpotter(linguist *KeywordSpottingLinguist, frontend frontend.DataProcessor, relativeBeamWidth float64,
+	logger util.Logger) *KeywordSpotter {
+	return &KeywordSpotter{
+		linguist:             linguist,
+		frontend:             frontend,
+		logger:               logger,
+		logRelativeBeamWidth: float64(util.GetLogMath().LinearToLog(relativeBeamWidth)),
+	}
+}
+
+// Starts spotting: every keyphrase and every phone of the loop is entered at the first frame.
+func (ks *KeywordSpotter) Start() {
+	ks.frameNumber = 0
+	ks.collectTime = 0
+	ks.detections = nil
+	ks.streamEnd = false
+	ks.err = nil
+	ks.activeList = make(map[linguist.SearchState]keywordPath)
+	ks.grow(map[linguist.SearchState]keywordPath{ks.linguist.GetSearchGraph().GetInitialState(): {}})
+}
+
+// Stops spotting, dropping the paths and the detections not returned yet.
+func (ks *KeywordSpotter) Stop() {
+	ks.activeList = nil
+	ks.detections = nil
+}
+
+// Processes frames until a keyphrase is detected, at most nFrames of them. Returns the oldest detection not returned
+// yet, or nil if there is none. Once the front end failed, no frame is processed anymore and Err returns its error.
+func (ks *KeywordSpotter) Spot(nFrames int) *Detection {
+	for i := 0; i < nFrames && len(ks.detections) == 0 && !ks.streamEnd && ks.err == nil; i++ {
+		ks.spot()
+	}
+	if len(ks.detections) == 0 {
+		return nil
+	}
+	detection := ks.detections[0]
+	ks.detections = ks.detections[1:]
+	return detection
+}
+
+// Returns whether the stream ended, in which case Spot detects nothing more.
+func (ks *KeywordSpotter) IsStreamEnd() bool {
+	return ks.streamEnd && len(ks.detections) == 0
+}
+
+// Returns the error of the front end which stopped spotting, nil if none.
+func (ks *KeywordSpotter) Err() error {
+	return ks.err
+}
+
+// Returns the collect time of the last frame processed.
+func (ks *KeywordSpotter) GetCollectTime() int64 {
+	return ks.collectTime
+}
+
+// Scores the next frame, and grows the paths to the states of the next one.
+func (ks *KeywordSpotter) spot() {
+	data, err := ks.nextFrame()
+	if err != nil {
+		ks.err = err
+		if ks.logger != nil {
+			ks.logger.Errorf("keyword search: %v", err)
+		}
+		return
+	}
+	if data == nil {
+		ks.streamEnd = true
+		return
+	}
+
+	bestScore := -math.MaxFloat64
+	for state, path := range ks.activeList {
+		path.score += float64(state.(linguist.HMMSearchState).GetHMMState().Score(data))
+		ks.activeList[state] = path
+		if path.score > bestScore {
+			bestScore = path.score
+		}
+	}
+	// prune, and normalize the scores so that they don't drift over an unbounded stream
+	emitting := make(map[linguist.SearchState]keywordPath, len(ks.activeList))
+	for state, path := range ks.activeList {
+		if path.score >= bestScore+ks.logRelativeBeamWidth {
+			path.score -= bestScore
+			emitting[state] = path
+		}
+	}
+
+	ks.frameNumber++
+	ks.activeList = make(map[linguist.SearchState]keywordPath, len(emitting))
+	ks.grow(emitting)
+}
+
+// Returns the features of the next frame, skipping the signals, nil at the end of the stream, or the error of the
+// front end.
+func (ks *KeywordSpotter) nextFrame() (frontend.Data, error) {
+	for {
+		data, err := ks.frontend.GetData()
+		if err != nil {
+			return nil, err
+		}
+		switch d := data.(type) {
+		case nil, *frontend.DataEndSignal:
+			return nil, nil
+		case frontend.Signal:
+			continue
+		case *frontend.FloatData:
+			ks.collectTime = d.CollectTime()
+		}
+		return data, nil
+	}
+}
+
+// Follows the arcs of the given states until the emitting states of the next frame, which go to the active list.
+// The non-emitting states are entered in increasing order, so that the best phone loop exit of the frame is known
+// when the keyphrases end.
+func (ks *KeywordSpotter) grow(states map[linguist.SearchState]keywordPath) {
+	bestGarbageScore := -math.MaxFloat64
+	var ended []KeyphraseEndState
+	paths := make(map[linguist.SearchState]keywordPath)
+
+	for len(states) > 0 {
+		order := -1
+		for state := range states {
+			if !state.IsEmitting() && (order < 0 || state.GetOrder() < order) {
+				order = state.GetOrder()
+			}
+		}
+
+		next := make(map[linguist.SearchState]keywordPath)
+		for state, path := range states {
+			if !state.IsEmitting() && state.GetOrder() != order {
+				next[state] = path
+				continue
+			}
+			if garbage, ok := state.(GarbageHMMState); ok && garbage.GetHMMState().IsExitState() &&
+				path.score > bestGarbageScore {
+				bestGarbageScore = path.score
+			}
+			if end, ok := state.(KeyphraseEndState); ok {
+				paths[end] = path
+				ended = append(ended, end)
+				continue
+			}
+			for _, arc := range state.GetSuccessors() {
+				successor := arc.GetState()
+				successorPath := keywordPath{path.score + arc.GetProbability(), path.startFrame}
+				if keyphrase, ok := successor.(KeyphraseHMMState); ok && keyphrase.IsKeyphraseStart() {
+					if _, fromKeyphrase := state.(KeyphraseHMMState); !fromKeyphrase {
+						successorPath.startFrame = ks.frameNumber
+					}
+				}
+				target := next
+				if successor.IsEmitting() {
+					target = ks.activeList
+				}
+				if best, ok := target[successor]; !ok || successorPath.score > best.score {
+					target[successor] = successorPath
+				}
+			}
+		}
+		states = next
+	}
+
+	if bestGarbageScore == -math.MaxFloat64 {
+		return
+	}
+	for _, end := range ended {
+		confidence := paths[end].score - bestGarbageScore
+		if confidence < end.GetLogThreshold() {
+			continue
+		}
+		ks.detections = append(ks.detections, &Detection{end.GetKeyphrase(), paths[end].startFrame, ks.frameNumber - 1,
+			confidence, ks.collectTime})
+		if ks.logger != nil {
+			ks.logger.Infof("Keyphrase '%s' from frame %d to %d, confidence %v", end.GetKeyphrase().Phrase,
+				paths[end].startFrame, ks.frameNumber-1, confidence)
+		}
+		// the keyphrase has to be entered again to be detected again
+		for state := range ks.activeList {
+			if keyphrase, ok := state.(KeyphraseHMMState); ok &&
+				keyphrase.GetKeyphraseIndex() == end.GetKeyphraseIndex() {
+				delete(ks.activeList, state)
+			}
+		}
+	}
+}
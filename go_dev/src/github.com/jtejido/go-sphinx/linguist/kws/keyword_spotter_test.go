@@ -0,0 +1,130 @@
+package kws
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jtejido/go-sphinx/frontend"
+)
+
+// frameSource gives a 10ms frame per phone of its list, holding the index of the phone in phones, between the data
+// start and end signals. With an error, the error follows the frames instead.
+type frameSource struct {
+	frontend.BaseDataProcessor
+	frames         []string
+	err            error
+	position       int
+	started, ended bool
+}
+
+func (s *frameSource) GetData() (frontend.Data, error) {
+	if !s.started {
+		s.started = true
+		return frontend.NewDataStartSignal(16000, 0), nil
+	}
+	if s.position == len(s.frames) {
+		if s.err != nil {
+			return nil, s.err
+		}
+		if s.ended {
+			return nil, nil
+		}
+		s.ended = true
+		return frontend.NewDataEndSignal(int64(s.position*10), int64(s.position*10)), nil
+	}
+	i := s.position
+	s.position++
+	for index, phone := range phones {
+		if phone == s.frames[i] {
+			return frontend.NewFloatDataWithCollectTime([]float32{float32(index)}, 100, int64(i*10), int64(i)), nil
+		}
+	}
+	panic("unknown phone " + s.frames[i])
+}
+
+// speak lists the phones of the parts, each repeated its number of frames, as in speak("SIL", 5, "HH", 3).
+func speak(parts ...interface{}) []string {
+	var frames []string
+	for i := 0; i < len(parts); i += 2 {
+		for j := 0; j < parts[i+1].(int); j++ {
+			frames = append(frames, parts[i].(string))
+		}
+	}
+	return frames
+}
+
+// spot runs a KeywordSpotter over the frames, and describes its detections as "phrase start-end".
+func spot(t *testing.T, frames []string, keyphrases ...Keyphrase) string {
+	l := newTestLinguist(t, keyphrases...)
+	if err := l.Allocate(); err != nil {
+		t.Fatal(err)
+	}
+	spotter := NewDefaultKeywordSpotter(l, &frameSource{frames: frames})
+	spotter.Start()
+	defer spotter.Stop()
+
+	var detections []string
+	for !spotter.IsStreamEnd() {
+		if detection := spotter.Spot(4); detection != nil {
+			if detection.CollectTime != int64(detection.EndFrame*10) {
+				t.Errorf("collect time %d of a detection ending at frame %d", detection.CollectTime, detection.EndFrame)
+			}
+			detections = append(detections, fmt.Sprintf("%s %d-%d", detection.Keyphrase.Phrase,
+				detection.StartFrame, detection.EndFrame))
+		}
+	}
+	return strings.Join(detections, ", ")
+}
+
+func TestKeywordSpotter(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		frames     []string
+		keyphrases []Keyphrase
+		expected   string
+	}{
+		// the phrase is detected at the first frame it beats the loop by its threshold, before its last frame as the
+		// loop can't exit any better there
+		{"detection", speak("SIL", 5, "HH", 3, "AY", 3, "SIL", 5), []Keyphrase{{"hi", 1}}, "hi 4-9"},
+		{"other speech", speak("SIL", 5, "W", 3, "ER", 3, "L", 3, "D", 3, "SIL", 5), []Keyphrase{{"hi", 1}}, ""},
+		// the phrase beats the loop by its two phone insertions, a ratio of 4
+		{"threshold", speak("SIL", 5, "HH", 3, "AY", 3, "SIL", 5), []Keyphrase{{"hi", 10}}, ""},
+		{"continuous", speak("SIL", 5, "HH", 3, "AY", 3, "SIL", 3, "HH", 4, "AY", 3, "W", 3, "HH", 3, "AY", 3),
+			[]Keyphrase{{"hi", 1}}, "hi 4-9, hi 14-19, hi 24-29"},
+		// once detected, the phrase has to be entered again: the longer AY doesn't detect it again
+		{"re-entry", speak("SIL", 5, "HH", 3, "AY", 10, "SIL", 5), []Keyphrase{{"hi", 1}}, "hi 4-9"},
+		{"keyphrases", speak("SIL", 3, "HH", 3, "AH", 3, "L", 3, "OW", 3, "HH", 3, "AY", 3),
+			[]Keyphrase{{"hello", 1}, {"hi", 1}}, "hello 3-14, hi 15-20"},
+	} {
+		if actual := spot(t, test.frames, test.keyphrases...); actual != test.expected {
+			t.Errorf("%s: got %q, want %q", test.name, actual, test.expected)
+		}
+	}
+}
+
+func TestKeywordSpotterError(t *testing.T) {
+	l := newTestLinguist(t, Keyphrase{"hi", 1})
+	if err := l.Allocate(); err != nil {
+		t.Fatal(err)
+	}
+	err := errors.New("read error")
+	spotter := NewDefaultKeywordSpotter(l, &frameSource{frames: speak("SIL", 5, "HH", 3, "AY", 3), err: err})
+	spotter.Start()
+	defer spotter.Stop()
+
+	// the phrase is detected before the error, which stops spotting without ending the stream
+	if detection := spotter.Spot(20); detection == nil || detection.Keyphrase.Phrase != "hi" {
+		t.Fatalf("unexpected detection %v", detection)
+	}
+	if detection := spotter.Spot(20); detection != nil {
+		t.Fatalf("detection %v after the error", detection)
+	}
+	if spotter.Err() != err {
+		t.Fatalf("got error %v, want %v", spotter.Err(), err)
+	}
+	if spotter.IsStreamEnd() {
+		t.Fatal("the error ended the stream")
+	}
+}
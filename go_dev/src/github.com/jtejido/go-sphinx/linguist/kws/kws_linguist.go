@@ -0,0 +1,216 @@
+package kws
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/jtejido/go-sphinx/linguist"
+	"github.com/jtejido/go-sphinx/linguist/acoustic"
+	"github.com/jtejido/go-sphinx/linguist/dictionary"
+	"github.com/jtejido/go-sphinx/util"
+)
+
+// The default linear probability of entering a phone of the phone loop.
+const DEFAULT_PHONE_INSERTION_PROBABILITY = 0.1
+
+// A linguist spotting keyphrases in a stream rather than transcribing it. Its search graph holds a chain of HMMs per
+// keyphrase, in parallel with a garbage model: a loop over the context independent phones of the acoustic model,
+// which matches any speech or noise. The phrases are entered from the phone loop at every frame, and end in final
+// states which the search manager compares with the phone loop to decide on a detection.
+//
+// The units of a keyphrase are triphones when the acoustic model has contexts, the phrase being surrounded by silence.
+// Entering a phone of the loop costs the phone insertion probability, which sets how easily the garbage model wins
+// over the phrases.
+type KeywordSpottingLinguist struct {
+	acousticModel acoustic.AcousticModel
+	unitManager   *acoustic.UnitManager
+	dictionary    dictionary.Dictionary
+	keyphrases    []Keyphrase
+	logger        util.Logger
+
+	logPhoneInsertionProbability float64
+
+	hmmPool         *acoustic.HMMPool
+	garbageHMMs     []acoustic.HMM
+	keyphraseHMMs   [][]acoustic.HMM
+	keyphraseWords  []*linguist.WordSequence
+	logThresholds   []float64
+	loopEntries     []linguist.SearchStateArc
+	emptyHistory    *linguist.WordSequence
+	searchGraph     *KeywordSpottingSearchGraph
+	keyphraseStates int
+}
+
+// Creates a KeywordSpottingLinguist with the default phone insertion probability.
+func NewDefaultKeywordSpottingLinguist(acousticModel acoustic.AcousticModel, unitManager *acoustic.UnitManager,
+	dictionary dictionary.Dictionary, keyphrases []Keyphrase) *KeywordSpottingLinguist {
+	return NewKeywordSpottingLinguist(acousticModel, unitManager, dictionary, keyphrases,
+		DEFAULT_PHONE_INSERTION_PROBABILITY, nil)
+}
+
+// Creates a KeywordSpottingLinguist.
+//
+// Accepts the acoustic model providing the phones and their HMMs, the unit manager of the dictionary, the dictionary
+// of the words of the keyphrases, the keyphrases, the linear phone insertion probability of the phone loop and an
+// optional logger.
+func NewKeywordSpottingLinguist(acousticModel acoustic.AcousticModel, unitManager *acoustic.UnitManager,
+	dictionary dictionary.Dictionary, keyphrases []Keyphrase, phoneInsertionProbability float64,
+	logger util.Logger) *KeywordSpottingLinguist {
+	return &KeywordSpottingLinguist{
+		acousticModel:                acousticModel,
+		unitManager:                  unitManager,
+		dictionary:                   dictionary,
+		keyphrases:                   keyphrases,
+		logger:                       logger,
+		logPhoneInsertionProbability: float64(util.GetLogMath().LinearToLog(phoneInsertionProbability)),
+		emptyHistory:                 linguist.NewEmptyWordSequence(),
+	}
+}
+
+// Returns the keyphrases spotted.
+func (l *KeywordSpottingLinguist) GetKeyphrases() []Keyphrase {
+	return l.keyphrases
+}
+
+// Replaces the keyphrases spotted, taken into account at the next allocation.
+func (l *KeywordSpottingLinguist) SetKeyphrases(keyphrases []Keyphrase) {
+	l.keyphrases = keyphrases
+}
+
+// Loads the dictionary and the acoustic model, and creates the phone loop and the chains of the keyphrases.
+//
+// Returns an error if there is no keyphrase, or if a word of a keyphrase is not in the dictionary.
+func (l *KeywordSpottingLinguist) Allocate() error {
+	if len(l.keyphrases) == 0 {
+		return errors.New("no keyphrase to spot")
+	}
+	if err := l.dictionary.Allocate(); err != nil {
+		return err
+	}
+	if err := l.acousticModel.Allocate(); err != nil {
+		return err
+	}
+	pool, err := acoustic.NewHMMPool(l.acousticModel, l.unitManager, l.logger)
+	if err != nil {
+		return err
+	}
+	l.hmmPool = pool
+
+	var phones []*acoustic.Unit
+	for _, unit := range pool.ContextIndependentUnits() {
+		phones = append(phones, unit)
+	}
+	if len(phones) == 0 {
+		return errors.New("the acoustic model has no context independent units")
+	}
+	sort.Slice(phones, func(i, j int) bool { return phones[i].Name() < phones[j].Name() })
+	l.garbageHMMs = nil
+	for _, phone := range phones {
+		if hmm := pool.GetHMM(phone, nil, nil, acoustic.UNDEFINED); hmm != nil {
+			l.garbageHMMs = append(l.garbageHMMs, hmm)
+		}
+	}
+
+	l.keyphraseHMMs, l.keyphraseWords, l.logThresholds, l.keyphraseStates = nil, nil, nil, 0
+	logMath := util.GetLogMath()
+	for _, keyphrase := range l.keyphrases {
+		hmms, words, err := l.compileKeyphrase(keyphrase.Phrase)
+		if err != nil {
+			return err
+		}
+		l.keyphraseHMMs = append(l.keyphraseHMMs, hmms)
+		l.keyphraseWords = append(l.keyphraseWords, linguist.NewWordSequenceByWordSlice(words))
+		l.logThresholds = append(l.logThresholds, float64(logMath.LinearToLog(keyphrase.Threshold)))
+		l.keyphraseStates += len(hmms)
+	}
+
+	// the loop is entered again at the end of every phone, and so are the keyphrases
+	l.loopEntries = nil
+	for _, hmm := range l.garbageHMMs {
+		l.loopEntries = append(l.loopEntries, newArc(GarbageHMMState{l, hmm.InitialState()},
+			l.logPhoneInsertionProbability, 0))
+	}
+	for i, hmms := range l.keyphraseHMMs {
+		l.loopEntries = append(l.loopEntries, newArc(KeyphraseHMMState{l, i, 0, hmms[0].InitialState()}, 0, 0))
+	}
+
+	l.searchGraph = &KeywordSpottingSearchGraph{initialState: KeywordSpottingStartState{l}}
+	if l.logger != nil {
+		l.logger.Infof("Spotting %d keyphrases of %d HMMs over a loop of %d phones", len(l.keyphrases),
+			l.keyphraseStates, len(l.garbageHMMs))
+	}
+	return nil
+}
+
+// Returns the chain of HMMs of a keyphrase, and its words.
+func (l *KeywordSpottingLinguist) compileKeyphrase(phrase string) ([]acoustic.HMM, []*dictionary.Word, error) {
+	var words []*dictionary.Word
+	var units []*acoustic.Unit
+	var positions []acoustic.HMMPosition
+	for _, spelling := range strings.Fields(phrase) {
+		word := l.dictionary.GetWord(spelling)
+		if word == nil || len(word.GetPronunciations()) == 0 {
+			return nil, nil, fmt.Errorf("can't find pronunciation for '%s' in keyphrase '%s'", spelling, phrase)
+		}
+		words = append(words, word)
+		pronunciation := word.GetMostLikelyPronunciation().GetUnits()
+		for i, unit := range pronunciation {
+			position := acoustic.INTERNAL
+			switch {
+			case len(pronunciation) == 1:
+				position = acoustic.SINGLE
+			case i == 0:
+				position = acoustic.BEGIN
+			case i == len(pronunciation)-1:
+				position = acoustic.END
+			}
+			units = append(units, unit)
+			positions = append(positions, position)
+		}
+	}
+	if len(units) == 0 {
+		return nil, nil, fmt.Errorf("keyphrase '%s' has no units", phrase)
+	}
+
+	hmms := make([]acoustic.HMM, len(units))
+	for i, unit := range units {
+		left, right := acoustic.SILENCE, acoustic.SILENCE
+		if i > 0 {
+			left = units[i-1]
+		}
+		if i < len(units)-1 {
+			right = units[i+1]
+		}
+		if hmms[i] = l.hmmPool.GetHMM(unit, left, right, positions[i]); hmms[i] == nil {
+			return nil, nil, fmt.Errorf("no HMM for unit %s of keyphrase '%s'", unit, phrase)
+		}
+	}
+	return hmms, words, nil
+}
+
+// Releases the models and the search graph.
+func (l *KeywordSpottingLinguist) Deallocate() {
+	l.acousticModel.Deallocate()
+	l.dictionary.Deallocate()
+	l.hmmPool = nil
+	l.garbageHMMs = nil
+	l.keyphraseHMMs = nil
+	l.loopEntries = nil
+	l.searchGraph = nil
+}
+
+// Retrieves the search graph, or nil before allocation.
+func (l *KeywordSpottingLinguist) GetSearchGraph() linguist.SearchGraph {
+	if l.searchGraph == nil {
+		return nil
+	}
+	return l.searchGraph
+}
+
+// The search graph is static: nothing is done before a recognition.
+func (l *KeywordSpottingLinguist) StartRecognition() {}
+
+// The search graph is static: nothing is done after a recognition.
+func (l *KeywordSpottingLinguist) StopRecognition() {}
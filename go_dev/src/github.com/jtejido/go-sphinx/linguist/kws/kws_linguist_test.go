@@ -0,0 +1,148 @@
+package kws
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jtejido/go-sphinx/frontend"
+	"github.com/jtejido/go-sphinx/linguist"
+	"github.com/jtejido/go-sphinx/linguist/acoustic"
+	"github.com/jtejido/go-sphinx/linguist/acoustic/acoustictest"
+	"github.com/jtejido/go-sphinx/util"
+)
+
+// The phones of the acoustic model of newTestLinguist.
+var phones = []string{"AH", "AY", "D", "ER", "HH", "L", "OW", "W", acoustic.SILENCE_NAME}
+
+// The log score of a frame for a state of a phone other than the one spoken.
+const mismatchScore = -50000
+
+// scoreFrame scores a frame holding the index of the phone spoken: 0 for the states of that phone, mismatchScore for
+// the others.
+func scoreFrame(unit *acoustic.Unit, data frontend.Data) float32 {
+	if phones[int(data.(*frontend.FloatData).Values()[0])] == unit.BaseUnit().Name() {
+		return 0
+	}
+	return mismatchScore
+}
+
+func newTestLinguist(t *testing.T, keyphrases ...Keyphrase) *KeywordSpottingLinguist {
+	d := acoustictest.NewDictionary(t, "hello HH AH L OW\nworld W ER L D\nhi HH AY\n")
+	unitManager := d.UnitManager()
+	var units []*acoustic.Unit
+	for _, name := range phones {
+		units = append(units, unitManager.Unit(name, name == acoustic.SILENCE_NAME))
+	}
+	am := &acoustictest.AcousticModel{ContextSize: 1, Units: units, Scorer: scoreFrame}
+	return NewKeywordSpottingLinguist(am, unitManager, d, keyphrases, 0.5, nil)
+}
+
+// keyphraseChain follows a keyphrase from its first state to its end state, returning the units of its HMMs.
+func keyphraseChain(t *testing.T, state linguist.SearchState) ([]*acoustic.Unit, KeyphraseEndState) {
+	units := []*acoustic.Unit{state.(KeyphraseHMMState).GetHMMState().HMM().Unit()}
+	for {
+		var next linguist.SearchState
+		for _, arc := range state.GetSuccessors() {
+			switch successor := arc.GetState().(type) {
+			case KeyphraseEndState:
+				return units, successor
+			case KeyphraseHMMState:
+				if successor != state {
+					next = successor
+				}
+			}
+		}
+		if next == nil {
+			t.Fatalf("the keyphrase ends at %v", state)
+		}
+		if hmmState := next.(KeyphraseHMMState).GetHMMState(); hmmState.State() == 0 {
+			units = append(units, hmmState.HMM().Unit())
+		}
+		state = next
+	}
+}
+
+func TestParseKeyphrases(t *testing.T) {
+	keyphrases, err := ParseKeyphrases(strings.NewReader("oh  mighty computer /1e-40/\n\nhello world\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Keyphrase{{"oh mighty computer", 1e-40}, {"hello world", DEFAULT_KEYPHRASE_THRESHOLD}}
+	if len(keyphrases) != 2 || keyphrases[0] != expected[0] || keyphrases[1] != expected[1] {
+		t.Fatalf("keyphrases %v, expected %v", keyphrases, expected)
+	}
+	for _, invalid := range []string{"hello /abc/\n", "hello /-1/\n", "/1e-5/\n"} {
+		if _, err := ParseKeyphrases(strings.NewReader(invalid)); err == nil {
+			t.Errorf("no error parsing %q", invalid)
+		}
+	}
+}
+
+func TestKeywordSpottingLinguist(t *testing.T) {
+	l := newTestLinguist(t, Keyphrase{"hello world", 1e-20}, Keyphrase{"hi", 1e-5})
+	if err := l.Allocate(); err != nil {
+		t.Fatal(err)
+	}
+	graph := l.GetSearchGraph()
+	if graph.GetNumStateOrder() != 3 {
+		t.Fatalf("%d state orders", graph.GetNumStateOrder())
+	}
+
+	// the 9 phones of the loop, then the first HMM of every keyphrase
+	initialState := graph.GetInitialState()
+	arcs := initialState.GetSuccessors()
+	if len(arcs) != 11 {
+		t.Fatalf("%d states follow the start, expected 11", len(arcs))
+	}
+	logInsertion := float64(util.GetLogMath().LinearToLog(0.5))
+	for _, arc := range arcs[:9] {
+		if _, ok := arc.GetState().(GarbageHMMState); !ok || arc.GetInsertionProbability() != logInsertion {
+			t.Fatalf("unexpected phone loop entry %v", arc.GetState())
+		}
+	}
+	for i, arc := range arcs[9:] {
+		state, ok := arc.GetState().(KeyphraseHMMState)
+		if !ok || state.GetKeyphraseIndex() != i || !state.IsKeyphraseStart() {
+			t.Fatalf("unexpected keyphrase entry %v", arc.GetState())
+		}
+	}
+
+	// the exit of a phone enters the loop and the keyphrases again
+	phone := arcs[0].GetState().(GarbageHMMState)
+	exit := phone.GetHMMState().HMM().State(3)
+	if successors := (GarbageHMMState{l, exit}).GetSuccessors(); len(successors) != 11 {
+		t.Fatalf("%d states follow the end of %v, expected 11", len(successors), phone)
+	}
+
+	// hello world is a chain of triphones, from silence to silence
+	units, end := keyphraseChain(t, arcs[9].GetState())
+	names := make([]string, len(units))
+	for i, unit := range units {
+		names[i] = unit.String()
+	}
+	if len(units) != 8 || !units[0].IsContextDependent() {
+		t.Fatalf("unexpected units %v", names)
+	}
+	if names[0] != "HH[SIL,AH]" || names[7] != "D[L,SIL]" {
+		t.Fatalf("unexpected units %v", names)
+	}
+	if !end.IsFinal() || end.GetKeyphrase().Phrase != "hello world" || len(end.GetSuccessors()) != 0 {
+		t.Fatalf("unexpected end state %v", end)
+	}
+	if end.GetLogThreshold() != float64(util.GetLogMath().LinearToLog(1e-20)) {
+		t.Fatalf("threshold %v", end.GetLogThreshold())
+	}
+	if end.GetWordHistory().String() != "hello world" {
+		t.Fatalf("history %v", end.GetWordHistory())
+	}
+}
+
+func TestKeywordSpottingLinguistUnknownWord(t *testing.T) {
+	if err := newTestLinguist(t).Allocate(); err == nil {
+		t.Fatal("no error without keyphrases")
+	}
+	err := newTestLinguist(t, Keyphrase{"hello there", 1}).Allocate()
+	if err == nil || !strings.Contains(err.Error(), "'there'") {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
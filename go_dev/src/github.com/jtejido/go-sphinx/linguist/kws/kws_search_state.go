@@ -0,0 +1,326 @@
+package kws
+
+import (
+	"fmt"
+
+	"github.com/jtejido/go-sphinx/linguist"
+	"github.com/jtejido/go-sphinx/linguist/acoustic"
+)
+
+const (
+	// The orders of the states of the search graph. Within a frame, the non-emitting states are always entered in
+	// increasing order, emitting HMM states last.
+	nonEmittingStateOrder = iota
+	keyphraseStateOrder
+	hmmStateOrder
+	numStateOrder
+)
+
+// An arc of the search graph, with its probabilities in the LogMath log domain.
+type kwsSearchStateArc struct {
+	state                   linguist.SearchState
+	logInsertionProbability float64
+	logAcousticProbability  float64
+}
+
+func newArc(state linguist.SearchState, logInsertionProbability, logAcousticProbability float64) linguist.SearchStateArc {
+	return &kwsSearchStateArc{state, logInsertionProbability, logAcousticProbability}
+}
+
+// Gets a successor to this search state
+func (arc *kwsSearchStateArc) GetState() linguist.SearchState {
+	return arc.state
+}
+
+// Gets the composite probability of entering this state: the insertion probability and the HMM transition
+// probability.
+func (arc *kwsSearchStateArc) GetProbability() float64 {
+	return arc.logInsertionProbability + arc.logAcousticProbability
+}
+
+// Keyword spotting has no language model
+func (arc *kwsSearchStateArc) GetLanguageProbability() float64 {
+	return 0
+}
+
+// Gets the insertion probability of entering this state
+func (arc *kwsSearchStateArc) GetInsertionProbability() float64 {
+	return arc.logInsertionProbability
+}
+
+// The start of the search, followed by the phone loop and the keyphrases.
+type KeywordSpottingStartState struct {
+	linguist *KeywordSpottingLinguist
+}
+
+// Gets a successor to this search state
+func (s KeywordSpottingStartState) GetSuccessors() []linguist.SearchStateArc {
+	return s.linguist.loopEntries
+}
+
+// Determines if this is an emitting state
+func (s KeywordSpottingStartState) IsEmitting() bool {
+	return false
+}
+
+// Determines if this is a final state
+func (s KeywordSpottingStartState) IsFinal() bool {
+	return false
+}
+
+// Returns a pretty version of the string representation for this object
+func (s KeywordSpottingStartState) ToPrettyString() string {
+	return "kwsStart"
+}
+
+// Returns a unique signature for this state
+func (s KeywordSpottingStartState) GetSignature() string {
+	return "kwsStart"
+}
+
+// Keyword spotting keeps no word history
+func (s KeywordSpottingStartState) GetWordHistory() *linguist.WordSequence {
+	return s.linguist.emptyHistory
+}
+
+// Returns the lex tree state, the state itself
+func (s KeywordSpottingStartState) GetLexState() interface{} {
+	return s
+}
+
+// Returns the order of this particular state
+func (s KeywordSpottingStartState) GetOrder() int {
+	return nonEmittingStateOrder
+}
+
+// A state of the HMM of a phone of the phone loop. The exit state of the HMM leads back to the start of the loop and
+// of the keyphrases.
+type GarbageHMMState struct {
+	linguist *KeywordSpottingLinguist
+	hmmState acoustic.HMMState
+}
+
+// Gets a successor to this search state
+func (s GarbageHMMState) GetSuccessors() []linguist.SearchStateArc {
+	if s.hmmState.IsExitState() {
+		return s.linguist.loopEntries
+	}
+	var arcs []linguist.SearchStateArc
+	for _, arc := range s.hmmState.Successors() {
+		arcs = append(arcs, newArc(GarbageHMMState{s.linguist, arc.HMMState()}, 0, float64(arc.LogProbability())))
+	}
+	return arcs
+}
+
+// Determines if this is an emitting state
+func (s GarbageHMMState) IsEmitting() bool {
+	return s.hmmState.IsEmitting()
+}
+
+// Determines if this is a final state
+func (s GarbageHMMState) IsFinal() bool {
+	return false
+}
+
+// Gets the HMM state
+func (s GarbageHMMState) GetHMMState() acoustic.HMMState {
+	return s.hmmState
+}
+
+// Returns a pretty version of the string representation for this object
+func (s GarbageHMMState) ToPrettyString() string {
+	return s.String()
+}
+
+// Returns a unique signature for this state
+func (s GarbageHMMState) GetSignature() string {
+	return fmt.Sprintf("garbageHMMState-%p", s.hmmState)
+}
+
+// Keyword spotting keeps no word history
+func (s GarbageHMMState) GetWordHistory() *linguist.WordSequence {
+	return s.linguist.emptyHistory
+}
+
+// Returns the lex tree state, the state itself
+func (s GarbageHMMState) GetLexState() interface{} {
+	return s
+}
+
+// Returns the order of this particular state
+func (s GarbageHMMState) GetOrder() int {
+	if s.hmmState.IsEmitting() {
+		return hmmStateOrder
+	}
+	return nonEmittingStateOrder
+}
+
+func (s GarbageHMMState) String() string {
+	return fmt.Sprintf("garbage %s:%d", s.hmmState.HMM().Unit(), s.hmmState.State())
+}
+
+// A state of the HMM of a unit of a keyphrase, given by the index of the keyphrase and of the unit.
+type KeyphraseHMMState struct {
+	linguist  *KeywordSpottingLinguist
+	keyphrase int
+	unit      int
+	hmmState  acoustic.HMMState
+}
+
+// Gets a successor to this search state: the next states of the HMM, the next unit of the keyphrase for its exit
+// state, or the end of the keyphrase.
+func (s KeyphraseHMMState) GetSuccessors() []linguist.SearchStateArc {
+	if s.hmmState.IsExitState() {
+		hmms := s.linguist.keyphraseHMMs[s.keyphrase]
+		if s.unit == len(hmms)-1 {
+			return []linguist.SearchStateArc{newArc(KeyphraseEndState{s.linguist, s.keyphrase}, 0, 0)}
+		}
+		next := KeyphraseHMMState{s.linguist, s.keyphrase, s.unit + 1, hmms[s.unit+1].InitialState()}
+		return []linguist.SearchStateArc{newArc(next, 0, 0)}
+	}
+	var arcs []linguist.SearchStateArc
+	for _, arc := range s.hmmState.Successors() {
+		next := KeyphraseHMMState{s.linguist, s.keyphrase, s.unit, arc.HMMState()}
+		arcs = append(arcs, newArc(next, 0, float64(arc.LogProbability())))
+	}
+	return arcs
+}
+
+// Determines if this is an emitting state
+func (s KeyphraseHMMState) IsEmitting() bool {
+	return s.hmmState.IsEmitting()
+}
+
+// Determines if this is a final state
+func (s KeyphraseHMMState) IsFinal() bool {
+	return false
+}
+
+// Gets the HMM state
+func (s KeyphraseHMMState) GetHMMState() acoustic.HMMState {
+	return s.hmmState
+}
+
+// Returns the index of the keyphrase of the state.
+func (s KeyphraseHMMState) GetKeyphraseIndex() int {
+	return s.keyphrase
+}
+
+// Returns whether the state is the first state of its keyphrase, entered from the phone loop.
+func (s KeyphraseHMMState) IsKeyphraseStart() bool {
+	return s.unit == 0 && s.hmmState == s.linguist.keyphraseHMMs[s.keyphrase][0].InitialState()
+}
+
+// Returns a pretty version of the string representation for this object
+func (s KeyphraseHMMState) ToPrettyString() string {
+	return s.String()
+}
+
+// Returns a unique signature for this state
+func (s KeyphraseHMMState) GetSignature() string {
+	return fmt.Sprintf("keyphraseHMMState-%d-%d-%p", s.keyphrase, s.unit, s.hmmState)
+}
+
+// Returns the words of the keyphrase
+func (s KeyphraseHMMState) GetWordHistory() *linguist.WordSequence {
+	return s.linguist.keyphraseWords[s.keyphrase]
+}
+
+// Returns the lex tree state, the state itself
+func (s KeyphraseHMMState) GetLexState() interface{} {
+	return s
+}
+
+// Returns the order of this particular state
+func (s KeyphraseHMMState) GetOrder() int {
+	if s.hmmState.IsEmitting() {
+		return hmmStateOrder
+	}
+	return nonEmittingStateOrder
+}
+
+func (s KeyphraseHMMState) String() string {
+	return fmt.Sprintf("%s %s:%d", s.linguist.keyphrases[s.keyphrase].Phrase, s.hmmState.HMM().Unit(),
+		s.hmmState.State())
+}
+
+// The end of a keyphrase, where it is detected if its score beats the phone loop by its threshold.
+type KeyphraseEndState struct {
+	linguist  *KeywordSpottingLinguist
+	keyphrase int
+}
+
+// The keyphrase ends here: the search enters it again from the phone loop.
+func (s KeyphraseEndState) GetSuccessors() []linguist.SearchStateArc {
+	return nil
+}
+
+// Determines if this is an emitting state
+func (s KeyphraseEndState) IsEmitting() bool {
+	return false
+}
+
+// Determines if this is a final state
+func (s KeyphraseEndState) IsFinal() bool {
+	return true
+}
+
+// Returns the index of the keyphrase of the state.
+func (s KeyphraseEndState) GetKeyphraseIndex() int {
+	return s.keyphrase
+}
+
+// Returns the keyphrase ended.
+func (s KeyphraseEndState) GetKeyphrase() Keyphrase {
+	return s.linguist.keyphrases[s.keyphrase]
+}
+
+// Returns the detection threshold of the keyphrase, in the LogMath log domain.
+func (s KeyphraseEndState) GetLogThreshold() float64 {
+	return s.linguist.logThresholds[s.keyphrase]
+}
+
+// Returns a pretty version of the string representation for this object
+func (s KeyphraseEndState) ToPrettyString() string {
+	return s.GetKeyphrase().Phrase
+}
+
+// Returns a unique signature for this state
+func (s KeyphraseEndState) GetSignature() string {
+	return fmt.Sprintf("keyphraseEndState-%d", s.keyphrase)
+}
+
+// Returns the words of the keyphrase
+func (s KeyphraseEndState) GetWordHistory() *linguist.WordSequence {
+	return s.linguist.keyphraseWords[s.keyphrase]
+}
+
+// Returns the lex tree state, the state itself
+func (s KeyphraseEndState) GetLexState() interface{} {
+	return s
+}
+
+// Returns the order of this particular state
+func (s KeyphraseEndState) GetOrder() int {
+	return keyphraseStateOrder
+}
+
+// The search graph of the KeywordSpottingLinguist.
+type KeywordSpottingSearchGraph struct {
+	initialState linguist.SearchState
+}
+
+// Retrieves initial search state
+func (g *KeywordSpottingSearchGraph) GetInitialState() linguist.SearchState {
+	return g.initialState
+}
+
+// Returns the number of different state types maintained in the search graph
+func (g *KeywordSpottingSearchGraph) GetNumStateOrder() int {
+	return numStateOrder
+}
+
+// Order of words and data tokens: the keyphrases are ended after their units.
+func (g *KeywordSpottingSearchGraph) GetWordTokenFirst() bool {
+	return false
+}
@@ -0,0 +1,233 @@
+package grammar
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jtejido/go-sphinx/linguist/dictionary"
+	"github.com/jtejido/go-sphinx/util"
+)
+
+const (
+	// The default of whether the grammar is optimized by removing the empty nodes that can be skipped.
+	DEFAULT_OPTIMIZE_GRAMMAR = true
+
+	// The default of whether optional silence words are added after every word of the grammar.
+	DEFAULT_ADD_SIL_WORDS = false
+
+	// The default of whether optional filler words are added after every word of the grammar.
+	DEFAULT_ADD_FILLER_WORDS = false
+)
+
+// Represents a word grammar: a graph of GrammarNodes starting at the initial node, whose paths to final nodes are the
+// word sequences of the language.
+type Grammar interface {
+	// Creates the grammar.
+	Allocate() error
+
+	// Deallocates the grammar.
+	Deallocate()
+
+	// Returns the initial node for the grammar.
+	GetInitialNode() *GrammarNode
+
+	// Returns the nodes of the grammar reachable from the initial node.
+	GetGrammarNodes() []*GrammarNode
+
+	// Returns the dictionary the words of the grammar are taken from.
+	GetDictionary() dictionary.Dictionary
+}
+
+// Holds what the grammars share: the dictionary their words come from, the creation of their nodes, and the post
+// processing of their graph, which optimizes it and adds optional silence and filler words.
+//
+// A grammar embeds a BaseGrammar, creates its nodes with CreateGrammarNode and CreateWordNode, and ends its
+// allocation with SetInitialNode.
+type BaseGrammar struct {
+	dictionary      dictionary.Dictionary
+	optimizeGrammar bool
+	addSilenceWords bool
+	addFillerWords  bool
+	logger          util.Logger
+	logMath         *util.LogMath
+
+	identity     int
+	initialNode  *GrammarNode
+	grammarNodes []*GrammarNode
+}
+
+// Creates a BaseGrammar.
+//
+// Accepts the dictionary of the words, whether the grammar is optimized, whether optional silence and filler words
+// are added after every word, and an optional logger.
+func NewBaseGrammar(dictionary dictionary.Dictionary, optimizeGrammar, addSilenceWords, addFillerWords bool,
+	logger util.Logger) BaseGrammar {
+	return BaseGrammar{
+		dictionary:      dictionary,
+		optimizeGrammar: optimizeGrammar,
+		addSilenceWords: addSilenceWords,
+		addFillerWords:  addFillerWords,
+		logger:          logger,
+		logMath:         util.GetLogMath(),
+	}
+}
+
+// Returns the dictionary the words of the grammar are taken from.
+func (g *BaseGrammar) GetDictionary() dictionary.Dictionary {
+	return g.dictionary
+}
+
+// Returns the LogMath of the probabilities of the grammar.
+func (g *BaseGrammar) LogMath() *util.LogMath {
+	return g.logMath
+}
+
+// Returns the initial node for the grammar, or nil before allocation.
+func (g *BaseGrammar) GetInitialNode() *GrammarNode {
+	return g.initialNode
+}
+
+// Returns the nodes of the grammar reachable from the initial node.
+func (g *BaseGrammar) GetGrammarNodes() []*GrammarNode {
+	return g.grammarNodes
+}
+
+// Returns the number of nodes of the grammar.
+func (g *BaseGrammar) GetNumNodes() int {
+	return len(g.grammarNodes)
+}
+
+// Releases the nodes of the grammar.
+func (g *BaseGrammar) Deallocate() {
+	g.initialNode = nil
+	g.grammarNodes = nil
+}
+
+// Prepares the creation of a new graph: allocates the dictionary and forgets the previous nodes.
+func (g *BaseGrammar) NewGrammar() error {
+	if g.dictionary == nil {
+		return errors.New("grammar has no dictionary")
+	}
+	if err := g.dictionary.Allocate(); err != nil {
+		return err
+	}
+	g.identity = 0
+	g.initialNode = nil
+	g.grammarNodes = nil
+	return nil
+}
+
+// Creates an empty grammar node.
+func (g *BaseGrammar) CreateGrammarNode(isFinal bool) *GrammarNode {
+	node := NewGrammarNode(g.identity, nil)
+	node.SetFinalNode(isFinal)
+	g.identity++
+	return node
+}
+
+// Creates a grammar node accepting the given words, separated by spaces, in sequence.
+//
+// Returns an error if one of the words is not in the dictionary.
+func (g *BaseGrammar) CreateWordNode(words string) (*GrammarNode, error) {
+	spellings := strings.Fields(words)
+	if len(spellings) == 0 {
+		return g.CreateGrammarNode(false), nil
+	}
+	alternative := make([]*dictionary.Word, len(spellings))
+	for i, spelling := range spellings {
+		word := g.dictionary.GetWord(spelling)
+		if word == nil {
+			return nil, fmt.Errorf("can't find pronunciation for '%s'", spelling)
+		}
+		alternative[i] = word
+	}
+	node := NewGrammarNode(g.identity, [][]*dictionary.Word{alternative})
+	g.identity++
+	return node, nil
+}
+
+// Ends the creation of the graph: sets its initial node, then optimizes it and adds the optional silence and filler
+// words as configured.
+func (g *BaseGrammar) SetInitialNode(initialNode *GrammarNode) {
+	g.initialNode = initialNode
+	g.collectNodes()
+	if g.optimizeGrammar {
+		for _, node := range g.grammarNodes {
+			node.optimize()
+		}
+		g.collectNodes()
+	}
+	if g.addSilenceWords {
+		g.addSilenceWordNodes()
+	}
+	if g.addFillerWords {
+		g.addFillerWordNodes()
+	}
+	if g.logger != nil {
+		g.logger.Infof("Grammar of %d nodes", len(g.grammarNodes))
+	}
+}
+
+// Collects the nodes reachable from the initial node.
+func (g *BaseGrammar) collectNodes() {
+	g.grammarNodes = nil
+	visited := map[*GrammarNode]bool{g.initialNode: true}
+	queue := []*GrammarNode{g.initialNode}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		g.grammarNodes = append(g.grammarNodes, node)
+		for _, arc := range node.GetSuccessors() {
+			if !visited[arc.grammarNode] {
+				visited[arc.grammarNode] = true
+				queue = append(queue, arc.grammarNode)
+			}
+		}
+	}
+}
+
+// Adds an optional, repeatable silence after every word node.
+func (g *BaseGrammar) addSilenceWordNodes() {
+	silenceWord := g.dictionary.GetSilenceWord()
+	if silenceWord == nil {
+		return
+	}
+	for _, node := range g.grammarNodes {
+		if node.IsEmpty() || node.GetWord().IsFiller() {
+			continue
+		}
+		silenceNode := NewGrammarNode(g.identity, [][]*dictionary.Word{{silenceWord}})
+		g.identity++
+		branchNode := node.SplitNode(g.identity)
+		g.identity++
+		node.Add(silenceNode, 0)
+		silenceNode.Add(branchNode, 0)
+		silenceNode.Add(silenceNode, 0)
+	}
+	g.collectNodes()
+}
+
+// Adds optional, repeatable filler words after every word node.
+func (g *BaseGrammar) addFillerWordNodes() {
+	fillerWords := g.dictionary.GetFillerWords()
+	for _, node := range g.grammarNodes {
+		if node.IsEmpty() || node.GetWord().IsFiller() {
+			continue
+		}
+		wordNode := node.SplitNode(g.identity)
+		g.identity++
+		fillerStart := g.CreateGrammarNode(false)
+		fillerEnd := g.CreateGrammarNode(false)
+		fillerEnd.Add(fillerStart, 0)
+		fillerEnd.Add(wordNode, 0)
+		node.Add(fillerStart, 0)
+		for _, filler := range fillerWords {
+			fillerNode := NewGrammarNode(g.identity, [][]*dictionary.Word{{filler}})
+			g.identity++
+			fillerStart.Add(fillerNode, 0)
+			fillerNode.Add(fillerEnd, 0)
+		}
+	}
+	g.collectNodes()
+}
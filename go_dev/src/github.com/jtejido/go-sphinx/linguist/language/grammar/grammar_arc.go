@@ -0,0 +1,22 @@
+package grammar
+
+// Represents a single state transition in a grammar, to a node with a log probability.
+type GrammarArc struct {
+	grammarNode    *GrammarNode
+	logProbability float32
+}
+
+// Creates a GrammarArc to the given node, with the given log probability.
+func NewGrammarArc(grammarNode *GrammarNode, logProbability float32) *GrammarArc {
+	return &GrammarArc{grammarNode: grammarNode, logProbability: logProbability}
+}
+
+// Retrieves the destination node for this transition.
+func (arc *GrammarArc) GetGrammarNode() *GrammarNode {
+	return arc.grammarNode
+}
+
+// Retrieves the log probability for this transition.
+func (arc *GrammarArc) GetProbability() float32 {
+	return arc.logProbability
+}
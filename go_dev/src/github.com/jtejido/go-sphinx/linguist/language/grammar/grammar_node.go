@@ -0,0 +1,136 @@
+package grammar
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jtejido/go-sphinx/linguist/dictionary"
+)
+
+// Represents a grammar node in a grammar. A GrammarNode holds the alternative word sequences it accepts, and the arcs
+// to the nodes that may follow it. A node without words is empty: it is crossed without consuming any word, and is
+// used to branch and join the paths of the grammar.
+type GrammarNode struct {
+	identity     int
+	isFinal      bool
+	alternatives [][]*dictionary.Word
+	arcList      []*GrammarArc
+}
+
+// Creates a GrammarNode with the given alternative word sequences. A node without alternatives is empty.
+func NewGrammarNode(identity int, alternatives [][]*dictionary.Word) *GrammarNode {
+	return &GrammarNode{identity: identity, alternatives: alternatives}
+}
+
+// Returns the unique ID for this node.
+func (node *GrammarNode) GetID() int {
+	return node.identity
+}
+
+// Retrieves the words associated with this grammar node: the alternative word sequences it accepts.
+func (node *GrammarNode) GetAlternatives() [][]*dictionary.Word {
+	return node.alternatives
+}
+
+// Returns the first word of the first alternative of this node, or nil if the node is empty.
+func (node *GrammarNode) GetWord() *dictionary.Word {
+	if node.IsEmpty() {
+		return nil
+	}
+	return node.alternatives[0][0]
+}
+
+// Determines if this grammar node is empty, that is, it has no words associated with it.
+func (node *GrammarNode) IsEmpty() bool {
+	return len(node.alternatives) == 0 || len(node.alternatives[0]) == 0
+}
+
+// Determines if this is a final node in the grammar.
+func (node *GrammarNode) IsFinalNode() bool {
+	return node.isFinal
+}
+
+// Sets the 'final' state of this node.
+func (node *GrammarNode) SetFinalNode(isFinal bool) {
+	node.isFinal = isFinal
+}
+
+// Retrieves the set of transitions out of this node.
+func (node *GrammarNode) GetSuccessors() []*GrammarArc {
+	return node.arcList
+}
+
+// Adds an arc to the given node, with the given log probability.
+func (node *GrammarNode) Add(grammarNode *GrammarNode, logProbability float32) {
+	node.arcList = append(node.arcList, NewGrammarArc(grammarNode, logProbability))
+}
+
+// Splits this node into a pair of nodes: the new empty node of the given identity takes the arcs of this one, which
+// is then followed by it alone.
+func (node *GrammarNode) SplitNode(identity int) *GrammarNode {
+	branchNode := NewGrammarNode(identity, nil)
+	branchNode.arcList = node.arcList
+	branchNode.isFinal = node.isFinal
+	node.arcList = nil
+	node.isFinal = false
+	node.Add(branchNode, 0)
+	return branchNode
+}
+
+// Optimizes this node: arcs to empty nodes that merely lead to a single other node are replaced by an arc to that
+// node, and empty self loops are removed.
+func (node *GrammarNode) optimize() {
+	for i, arc := range node.arcList {
+		node.arcList[i] = optimizeArc(arc)
+	}
+	if node.IsEmpty() {
+		arcs := node.arcList[:0]
+		for _, arc := range node.arcList {
+			if arc.grammarNode != node {
+				arcs = append(arcs, arc)
+			}
+		}
+		node.arcList = arcs
+	}
+}
+
+// Skips the chain of empty, non final nodes with a single successor that follows the arc. A chain that loops back on
+// itself is followed up to the node closing the loop.
+func optimizeArc(arc *GrammarArc) *GrammarArc {
+	visited := make(map[*GrammarNode]bool)
+	nextNode := arc.grammarNode
+	for nextNode.IsEmpty() && !nextNode.isFinal && len(nextNode.arcList) == 1 {
+		visited[nextNode] = true
+		nextArc := nextNode.arcList[0]
+		if visited[nextArc.grammarNode] {
+			break
+		}
+		arc = NewGrammarArc(nextArc.grammarNode, arc.logProbability+nextArc.logProbability)
+		nextNode = arc.grammarNode
+	}
+	return arc
+}
+
+// Returns the word sequences of this node, separated by "|".
+func (node *GrammarNode) GetWordsString() string {
+	alternatives := make([]string, len(node.alternatives))
+	for i, alternative := range node.alternatives {
+		words := make([]string, len(alternative))
+		for j, word := range alternative {
+			words[j] = word.GetSpelling()
+		}
+		alternatives[i] = strings.Join(words, " ")
+	}
+	return strings.Join(alternatives, "|")
+}
+
+func (node *GrammarNode) String() string {
+	s := fmt.Sprintf("G%d", node.identity)
+	if !node.IsEmpty() {
+		s += " " + node.GetWordsString()
+	}
+	if node.isFinal {
+		s += " (final)"
+	}
+	return s
+}
@@ -0,0 +1,199 @@
+package grammar
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/jtejido/go-sphinx/linguist/acoustic/acoustictest"
+	"github.com/jtejido/go-sphinx/linguist/dictionary"
+)
+
+// testGrammar accepts "hello world": an empty initial node, the two words, and an empty final node.
+type testGrammar struct {
+	BaseGrammar
+}
+
+func (g *testGrammar) Allocate() error {
+	if err := g.NewGrammar(); err != nil {
+		return err
+	}
+	initialNode := g.CreateGrammarNode(false)
+	hello, err := g.CreateWordNode("hello")
+	if err != nil {
+		return err
+	}
+	world, err := g.CreateWordNode("world")
+	if err != nil {
+		return err
+	}
+	finalNode := g.CreateGrammarNode(true)
+	initialNode.Add(hello, 0)
+	hello.Add(world, 0)
+	world.Add(finalNode, 0)
+	g.SetInitialNode(initialNode)
+	return nil
+}
+
+func newTestGrammar(t *testing.T, addSilenceWords, addFillerWords bool) *testGrammar {
+	dir := acoustictest.WriteFiles(t, map[string]string{
+		"cmudict":   "hello HH AH L OW\nworld W ER L D\n",
+		"noisedict": "<s> SIL\n</s> SIL\n<sil> SIL\n++NOISE++ +NSN+\n",
+	})
+	d := dictionary.NewDefaultTextDictionary()
+	d.SetDictionaryPath(filepath.Join(dir, "cmudict"))
+	d.SetFillerPath(filepath.Join(dir, "noisedict"))
+	g := &testGrammar{NewBaseGrammar(d, true, addSilenceWords, addFillerWords, nil)}
+	if err := g.Allocate(); err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+// accepts tells whether a path of the graph spells the words, fillers included, empty nodes being skipped.
+func accepts(node *GrammarNode, words ...string) bool {
+	type state struct {
+		node     *GrammarNode
+		consumed int
+	}
+	visited := make(map[state]bool)
+	var walk func(s state) bool
+	walk = func(s state) bool {
+		if visited[s] {
+			return false
+		}
+		visited[s] = true
+		if !s.node.IsEmpty() {
+			if s.consumed == len(words) || words[s.consumed] != s.node.GetWord().GetSpelling() {
+				return false
+			}
+			s.consumed++
+		}
+		if s.node.IsFinalNode() && s.consumed == len(words) {
+			return true
+		}
+		for _, arc := range s.node.GetSuccessors() {
+			if walk(state{arc.GetGrammarNode(), s.consumed}) {
+				return true
+			}
+		}
+		return false
+	}
+	return walk(state{node, 0})
+}
+
+// transitions lists the word transitions of the graph, as "word next", the empty and filler nodes being crossed and
+// "<final>" standing for a final node.
+func transitions(g *testGrammar) []string {
+	var result []string
+	for _, node := range g.GetGrammarNodes() {
+		if node.IsEmpty() || node.GetWord().IsFiller() {
+			continue
+		}
+		next := make(map[string]bool)
+		visited := make(map[*GrammarNode]bool)
+		var walk func(node *GrammarNode)
+		walk = func(node *GrammarNode) {
+			for _, arc := range node.GetSuccessors() {
+				successor := arc.GetGrammarNode()
+				if visited[successor] {
+					continue
+				}
+				visited[successor] = true
+				if !successor.IsEmpty() && !successor.GetWord().IsFiller() {
+					next[successor.GetWord().GetSpelling()] = true
+					continue
+				}
+				if successor.IsFinalNode() {
+					next["<final>"] = true
+				}
+				walk(successor)
+			}
+		}
+		walk(node)
+		for spelling := range next {
+			result = append(result, node.GetWord().GetSpelling()+" "+spelling)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+func TestSplitNode(t *testing.T) {
+	node := NewGrammarNode(0, nil)
+	a, b := NewGrammarNode(1, nil), NewGrammarNode(2, nil)
+	node.Add(a, -1)
+	node.Add(b, -2)
+	node.SetFinalNode(true)
+
+	branchNode := node.SplitNode(3)
+	if successors := node.GetSuccessors(); len(successors) != 1 || successors[0].GetGrammarNode() != branchNode ||
+		node.IsFinalNode() {
+		t.Fatalf("the split node leads to %v, final %v", successors, node.IsFinalNode())
+	}
+	successors := branchNode.GetSuccessors()
+	if branchNode.GetID() != 3 || !branchNode.IsEmpty() || !branchNode.IsFinalNode() || len(successors) != 2 {
+		t.Fatalf("unexpected branch node %v with %d successors", branchNode, len(successors))
+	}
+	if successors[0].GetGrammarNode() != a || successors[0].GetProbability() != -1 ||
+		successors[1].GetGrammarNode() != b || successors[1].GetProbability() != -2 {
+		t.Fatalf("the branch node lost the arcs of the split node")
+	}
+}
+
+func TestAddSilenceAndFillerWords(t *testing.T) {
+	plain := newTestGrammar(t, false, false)
+	g := newTestGrammar(t, true, true)
+
+	// the silence after every word loops on itself
+	for _, spelling := range []string{"hello", "world"} {
+		var loops bool
+		for _, node := range g.GetGrammarNodes() {
+			if node.IsEmpty() || node.GetWord().GetSpelling() != spelling {
+				continue
+			}
+			for _, arc := range node.GetSuccessors() {
+				for _, silenceArc := range arc.GetGrammarNode().GetSuccessors() {
+					silence := silenceArc.GetGrammarNode()
+					if silence.IsEmpty() || silence.GetWord().GetSpelling() != dictionary.SILENCE_SPELLING {
+						continue
+					}
+					for _, loop := range silence.GetSuccessors() {
+						loops = loops || loop.GetGrammarNode() == silence
+					}
+				}
+			}
+		}
+		if !loops {
+			t.Errorf("no silence loop after %q", spelling)
+		}
+	}
+
+	// the silences and fillers are optional and repeatable after a word, but don't start the grammar
+	for _, words := range [][]string{
+		{"hello", "world"},
+		{"hello", "<sil>", "<sil>", "world"},
+		{"hello", "++NOISE++", "++NOISE++", "world"},
+		{"hello", "++NOISE++", "<sil>", "world", "<sil>"},
+		{"hello", "world", "++NOISE++"},
+	} {
+		if !accepts(g.GetInitialNode(), words...) {
+			t.Errorf("%q is rejected", strings.Join(words, " "))
+		}
+	}
+	for _, words := range [][]string{{"<sil>", "hello", "world"}, {"++NOISE++", "hello", "world"}, {"hello"}} {
+		if accepts(g.GetInitialNode(), words...) {
+			t.Errorf("%q is accepted", strings.Join(words, " "))
+		}
+	}
+	if accepts(plain.GetInitialNode(), "hello", "<sil>", "world") {
+		t.Error("a silence is accepted without silence words")
+	}
+
+	// splitting the word nodes keeps the transitions between the words
+	expected, actual := transitions(plain), transitions(g)
+	if strings.Join(actual, ", ") != strings.Join(expected, ", ") || len(expected) != 2 {
+		t.Errorf("transitions %v, want %v", actual, expected)
+	}
+}
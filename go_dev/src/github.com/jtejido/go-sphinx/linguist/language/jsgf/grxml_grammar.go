@@ -0,0 +1,62 @@
+package jsgf
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jtejido/go-sphinx/linguist/dictionary"
+	"github.com/jtejido/go-sphinx/linguist/language/grammar"
+	"github.com/jtejido/go-sphinx/util"
+)
+
+// The extension of the GrXML grammar files.
+const GRXML_EXTENSION = ".grxml"
+
+// Defines a grammar based upon a W3C SRGS grammar in its XML form, GrXML. The grammar of a given name is read from
+// the file of that name in the grammar location, with the .grxml extension if the name has none.
+//
+// The rules of the document are converted to JSGF rules, whose graph is built the same way as the one of a
+// JSGFGrammar: see ParseGrXML for the supported elements.
+type GrXMLGrammar struct {
+	JSGFGrammar
+}
+
+// Creates a GrXMLGrammar.
+//
+// Accepts the directory of the grammar file, the name of the grammar, the dictionary of its words, whether the
+// grammar is optimized, whether optional silence and filler words are added after every word, and an optional
+// logger.
+func NewGrXMLGrammar(baseURL, grammarName string, dictionary dictionary.Dictionary, optimizeGrammar, addSilenceWords,
+	addFillerWords bool, logger util.Logger) *GrXMLGrammar {
+	g := &GrXMLGrammar{
+		JSGFGrammar: JSGFGrammar{
+			BaseGrammar: grammar.NewBaseGrammar(dictionary, optimizeGrammar, addSilenceWords, addFillerWords, logger),
+			baseURL:     baseURL,
+			grammarName: grammarName,
+		},
+	}
+	g.loadGrammar = g.loadGrXMLFile
+	return g
+}
+
+// Reads the grammar of the given name from its file.
+func (g *GrXMLGrammar) loadGrXMLFile(name string) (*RuleGrammar, error) {
+	path := filepath.Join(g.baseURL, name)
+	if filepath.Ext(name) == "" {
+		path += GRXML_EXTENSION
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	ruleGrammar, err := ParseGrXML(file, strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	g.ruleGrammars[ruleGrammar.GetName()] = ruleGrammar
+	return ruleGrammar, nil
+}
@@ -0,0 +1,111 @@
+package jsgf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGrXMLGrammar(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "phone.grxml", `<?xml version="1.0" encoding="UTF-8"?>
+<grammar xmlns="http://www.w3.org/2001/06/grammar" version="1.0" xml:lang="en-US" root="command">
+  <!-- the call command -->
+  <rule id="command" scope="public">
+    <ruleref uri="#verb"/>
+    <ruleref uri="#place"/>
+    <item repeat="0-1">please</item>
+    <tag>out = "dial"</tag>
+  </rule>
+  <rule id="verb">
+    <one-of>
+      <item weight="3">call</item>
+      <item weight="1"><token>dial</token></item>
+    </one-of>
+  </rule>
+  <rule id="place">
+    <one-of>
+      <item>home</item>
+      <item>work</item>
+      <item><ruleref special="NULL"/></item>
+    </one-of>
+    <item repeat="2-3">now</item>
+  </rule>
+  <rule id="other" scope="public">very</rule>
+</grammar>
+`)
+
+	g := NewGrXMLGrammar(dir, "phone", newDictionary(t, dir), true, false, false, nil)
+	if err := g.Allocate(); err != nil {
+		t.Fatal(err)
+	}
+	if !g.GetRuleGrammar().IsPublic("command") || g.GetRuleGrammar().IsPublic("other") {
+		t.Fatal("the root rule is not the only public rule")
+	}
+
+	initial := g.GetInitialNode()
+	for _, sentence := range []string{
+		"call home now now",
+		"dial now now now please",
+		"call work now now please",
+	} {
+		if !accepts(initial, strings.Fields(sentence)) {
+			t.Errorf("%q is rejected", sentence)
+		}
+	}
+	for _, sentence := range []string{
+		"call home now",
+		"call home now now now now",
+		"very",
+		"home now now",
+	} {
+		if accepts(initial, strings.Fields(sentence)) {
+			t.Errorf("%q is accepted", sentence)
+		}
+	}
+
+	var weights []float64
+	for _, node := range g.GetGrammarNodes() {
+		for _, arc := range node.GetSuccessors() {
+			if arc.GetProbability() != 0 {
+				weights = append(weights, g.LogMath().LogToLinear(arc.GetProbability()))
+			}
+		}
+	}
+	if len(weights) != 2 || weights[0]+weights[1] < 0.999 || weights[0]+weights[1] > 1.001 {
+		t.Errorf("got weights %v, want 0.75 and 0.25", weights)
+	}
+}
+
+func TestParseGrXMLRepeat(t *testing.T) {
+	for repeat, want := range map[string]string{
+		"0-1": "[a]",
+		"3":   "a a a",
+		"1-":  "a+",
+		"0-":  "a*",
+		"2-":  "a a+",
+		"1-3": "a [a [a]]",
+	} {
+		rg, err := ParseGrXML(strings.NewReader(`<grammar root="r"><rule id="r"><item repeat="`+repeat+`">a</item></rule></grammar>`), "test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := rg.GetRule("r").String(); got != want {
+			t.Errorf("repeat %s: got %s, want %s", repeat, got, want)
+		}
+	}
+}
+
+func TestParseGrXMLErrors(t *testing.T) {
+	for _, source := range []string{
+		`<grammar root="r"><rule id="r">a</rule>`,
+		`<grammar root="missing"><rule id="r">a</rule></grammar>`,
+		`<grammar root="r"><rule id="r"><ruleref uri="other.grxml#r"/></rule></grammar>`,
+		`<grammar root="r"><rule id="r"><item repeat="x">a</item></rule></grammar>`,
+		`<grammar root="r"><rule id="r"><one-of><item weight="-1">a</item></one-of></rule></grammar>`,
+		`<grammar root="r"><one-of><item>a</item></one-of></grammar>`,
+	} {
+		if _, err := ParseGrXML(strings.NewReader(source), "test"); err == nil {
+			t.Errorf("no error for %s", source)
+		}
+	}
+}
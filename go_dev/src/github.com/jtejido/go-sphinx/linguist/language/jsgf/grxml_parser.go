@@ -0,0 +1,296 @@
+package jsgf
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/jtejido/go-sphinx/util"
+)
+
+// An element of the GrXML document being read, with the rules it holds so far.
+type grxmlElement struct {
+	name    string
+	rules   []Rule
+	weights []float64
+	// whether one of the items of a one-of has a weight
+	weighted bool
+	// the attributes of a rule or an item
+	id, scope, repeat, weight string
+	// the text of a tag or a token
+	text strings.Builder
+}
+
+// Builds a RuleGrammar from the SAX events of a GrXML document. The first error stops the building, and is kept to be
+// returned once the document is read.
+type grxmlHandler struct {
+	util.BaseHandler
+	ruleGrammar *RuleGrammar
+	root        string
+	publicRules []string
+	stack       []*grxmlElement
+	err         error
+}
+
+// Parses a W3C SRGS grammar in its XML form, GrXML, into a RuleGrammar of the given name.
+//
+// Rules hold words, <token>s, <ruleref>s to the rules of the same document (uri="#id") or the special NULL and VOID
+// rules, <one-of> alternatives of <item>s with optional weights, <item>s repeated as "n", "n-m" or "n-", and <tag>s,
+// which are attached to the expansion before them. The root rule of the grammar is its only public rule; without a
+// root, the rules of public scope are.
+func ParseGrXML(r io.Reader, name string) (*RuleGrammar, error) {
+	h := &grxmlHandler{ruleGrammar: NewRuleGrammar(name)}
+	if err := util.NewParser(r, h).Parse(); err != nil {
+		return nil, err
+	}
+	if h.err != nil {
+		return nil, h.err
+	}
+
+	if h.root != "" {
+		rule := h.ruleGrammar.GetRule(h.root)
+		if rule == nil {
+			return nil, fmt.Errorf("grammar %s: root rule %s is not defined", name, h.root)
+		}
+		h.ruleGrammar.SetRule(h.root, rule, true)
+	} else {
+		for _, ruleName := range h.publicRules {
+			h.ruleGrammar.SetRule(ruleName, h.ruleGrammar.GetRule(ruleName), true)
+		}
+	}
+	return h.ruleGrammar, nil
+}
+
+func (h *grxmlHandler) fail(format string, args ...interface{}) {
+	if h.err == nil {
+		h.err = fmt.Errorf("grammar %s: %s", h.ruleGrammar.GetName(), fmt.Sprintf(format, args...))
+	}
+}
+
+func (h *grxmlHandler) top() *grxmlElement {
+	if len(h.stack) == 0 {
+		return nil
+	}
+	return h.stack[len(h.stack)-1]
+}
+
+// Returns whether the element holds rule expansions.
+func (e *grxmlElement) isExpansion() bool {
+	return e != nil && (e.name == "rule" || e.name == "item")
+}
+
+func attribute(element xml.StartElement, name string) string {
+	for _, attr := range element.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+func (h *grxmlHandler) StartElement(element xml.StartElement) {
+	if h.err != nil {
+		return
+	}
+	parent := h.top()
+	e := &grxmlElement{name: element.Name.Local}
+
+	switch e.name {
+	case "grammar":
+		if parent != nil {
+			h.fail("nested grammar")
+		}
+		h.root = attribute(element, "root")
+		if mode := attribute(element, "mode"); mode != "" && mode != "voice" {
+			h.fail("unsupported mode %s", mode)
+		}
+	case "rule":
+		if parent == nil || parent.name != "grammar" {
+			h.fail("rule outside of the grammar")
+		}
+		e.id, e.scope = attribute(element, "id"), attribute(element, "scope")
+		if e.id == "" {
+			h.fail("rule without id")
+		}
+	case "one-of", "ruleref", "token", "tag":
+		if !parent.isExpansion() {
+			h.fail("%s outside of a rule", e.name)
+		}
+	case "item":
+		if !parent.isExpansion() && (parent == nil || parent.name != "one-of") {
+			h.fail("item outside of a rule")
+		}
+		e.repeat, e.weight = attribute(element, "repeat"), attribute(element, "weight")
+	}
+
+	if e.name == "ruleref" {
+		if rule, err := ruleref(element); err != nil {
+			h.fail("%v", err)
+		} else {
+			e.rules = append(e.rules, rule)
+		}
+	}
+	h.stack = append(h.stack, e)
+}
+
+// Returns the rule a ruleref element refers to.
+func ruleref(element xml.StartElement) (Rule, error) {
+	if special := attribute(element, "special"); special != "" {
+		switch special {
+		case "NULL":
+			return NULL, nil
+		case "VOID":
+			return VOID, nil
+		default:
+			return nil, fmt.Errorf("unsupported special rule %s", special)
+		}
+	}
+	uri := attribute(element, "uri")
+	if !strings.HasPrefix(uri, "#") || len(uri) == 1 {
+		return nil, fmt.Errorf("unsupported rule reference %q, only local references are", uri)
+	}
+	return &RuleName{Name: uri[1:]}, nil
+}
+
+func (h *grxmlHandler) CharData(data xml.CharData) {
+	e := h.top()
+	if h.err != nil || e == nil {
+		return
+	}
+	switch {
+	case e.name == "tag" || e.name == "token":
+		e.text.Write(data)
+	case e.isExpansion():
+		for _, word := range strings.Fields(string(data)) {
+			e.rules = append(e.rules, &RuleToken{Text: word})
+		}
+	case e.name == "one-of" && strings.TrimSpace(string(data)) != "":
+		h.fail("text in one-of outside of an item")
+	}
+}
+
+func (h *grxmlHandler) EndElement(xml.EndElement) {
+	if h.err != nil {
+		return
+	}
+	e := h.top()
+	h.stack = h.stack[:len(h.stack)-1]
+	parent := h.top()
+
+	switch e.name {
+	case "rule":
+		h.ruleGrammar.SetRule(e.id, sequence(e.rules), false)
+		if e.scope == "public" {
+			h.publicRules = append(h.publicRules, e.id)
+		}
+	case "one-of":
+		if len(e.rules) == 0 {
+			h.fail("one-of without items")
+			return
+		}
+		alternatives := &RuleAlternatives{Rules: e.rules}
+		if e.weighted {
+			alternatives.Weights = e.weights
+		}
+		parent.rules = append(parent.rules, alternatives)
+	case "item":
+		rule, err := repeat(sequence(e.rules), e.repeat)
+		if err != nil {
+			h.fail("%v", err)
+			return
+		}
+		parent.rules = append(parent.rules, rule)
+		if parent.name == "one-of" {
+			weight := 1.0
+			if e.weight != "" {
+				parent.weighted = true
+				if weight, err = strconv.ParseFloat(e.weight, 64); err != nil || weight < 0 {
+					h.fail("invalid weight %q", e.weight)
+					return
+				}
+			}
+			parent.weights = append(parent.weights, weight)
+		}
+	case "ruleref":
+		parent.rules = append(parent.rules, e.rules...)
+	case "token":
+		if text := strings.Join(strings.Fields(e.text.String()), " "); text != "" {
+			parent.rules = append(parent.rules, &RuleToken{Text: text})
+		}
+	case "tag":
+		tag := strings.TrimSpace(e.text.String())
+		if n := len(parent.rules); n > 0 {
+			parent.rules[n-1] = &RuleTag{Rule: parent.rules[n-1], Tag: tag}
+		} else {
+			parent.rules = append(parent.rules, &RuleTag{Rule: NULL, Tag: tag})
+		}
+	}
+}
+
+// Returns the rule matching the given rules in sequence.
+func sequence(rules []Rule) Rule {
+	switch len(rules) {
+	case 0:
+		return NULL
+	case 1:
+		return rules[0]
+	default:
+		return &RuleSequence{Rules: rules}
+	}
+}
+
+// Returns the rule matching the given one as many times as the repeat attribute of an item allows: "n" times, "n-m"
+// times, or at least "n-" times.
+func repeat(rule Rule, attr string) (Rule, error) {
+	if attr == "" {
+		return rule, nil
+	}
+
+	var min, max int
+	var err error
+	bounds := strings.SplitN(attr, "-", 2)
+	if min, err = strconv.Atoi(bounds[0]); err != nil || min < 0 {
+		return nil, fmt.Errorf("invalid repeat %q", attr)
+	}
+	switch {
+	case len(bounds) == 1:
+		max = min
+	case bounds[1] == "":
+		max = -1
+	default:
+		if max, err = strconv.Atoi(bounds[1]); err != nil || max < min {
+			return nil, fmt.Errorf("invalid repeat %q", attr)
+		}
+	}
+	if max == 0 {
+		return NULL, nil
+	}
+
+	var rules []Rule
+	for i := 0; i < min; i++ {
+		rules = append(rules, rule)
+	}
+	if max < 0 {
+		if min == 0 {
+			return &RuleCount{Rule: rule, Count: ZERO_OR_MORE}, nil
+		}
+		rules[min-1] = &RuleCount{Rule: rule, Count: ONCE_OR_MORE}
+		return sequence(rules), nil
+	}
+
+	// the optional repetitions are nested, [rule [rule]], so that the same count is never matched twice
+	var optional Rule
+	for i := min; i < max; i++ {
+		if optional == nil {
+			optional = &RuleCount{Rule: rule, Count: OPTIONAL}
+		} else {
+			optional = &RuleCount{Rule: &RuleSequence{Rules: []Rule{rule, optional}}, Count: OPTIONAL}
+		}
+	}
+	if optional != nil {
+		rules = append(rules, optional)
+	}
+	return sequence(rules), nil
+}
@@ -0,0 +1,349 @@
+package jsgf
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jtejido/go-sphinx/linguist/dictionary"
+	"github.com/jtejido/go-sphinx/linguist/language/grammar"
+	"github.com/jtejido/go-sphinx/util"
+)
+
+const (
+	// The default name of the grammar, read from default.gram.
+	DEFAULT_GRAMMAR_NAME = "default"
+
+	// The extension of the JSGF grammar files.
+	GRAMMAR_EXTENSION = ".gram"
+)
+
+// The first and last nodes of the graph of a rule.
+type graphPair struct {
+	start, end *grammar.GrammarNode
+}
+
+type ruleStackEntry struct {
+	name string
+	pair graphPair
+}
+
+// Defines a grammar based upon a JSGF grammar file. The grammar of a given name is read from the file of that name
+// with the .gram extension in the grammar location, "com.acme.menu" being read from com/acme/menu.gram. The grammars
+// it imports are read the same way.
+//
+// The public rules of the grammar are its alternative sentences, each starting and ending with silence. Every
+// reference to a rule is expanded into its own copy of the graph of the rule, but for the references of a rule to
+// itself, directly or not, which loop back to the graph being built: recursive rules become loops of the graph.
+//
+// The weights of alternatives become the probabilities of their arcs, normalized to sum to one; unweighted
+// alternatives all have a probability of one. Tags are kept in the rules of the RuleGrammar, but have no effect on
+// the graph.
+type JSGFGrammar struct {
+	grammar.BaseGrammar
+	baseURL     string
+	grammarName string
+
+	ruleGrammar  *RuleGrammar
+	ruleGrammars map[string]*RuleGrammar
+	ruleStack    []ruleStackEntry
+	// reads a grammar of the given name and the grammars it imports
+	loadGrammar func(name string) (*RuleGrammar, error)
+}
+
+// Creates a JSGFGrammar of the default name, with the grammar defaults, to be given its location with
+// SetGrammarLocation.
+func NewDefaultJSGFGrammar(dictionary dictionary.Dictionary) *JSGFGrammar {
+	return NewJSGFGrammar("", DEFAULT_GRAMMAR_NAME, dictionary, grammar.DEFAULT_OPTIMIZE_GRAMMAR,
+		grammar.DEFAULT_ADD_SIL_WORDS, grammar.DEFAULT_ADD_FILLER_WORDS, nil)
+}
+
+// Creates a JSGFGrammar.
+//
+// Accepts the directory of the grammar files, the name of the grammar, the dictionary of its words, whether the
+// grammar is optimized, whether optional silence and filler words are added after every word, and an optional
+// logger.
+func NewJSGFGrammar(baseURL, grammarName string, dictionary dictionary.Dictionary, optimizeGrammar, addSilenceWords,
+	addFillerWords bool, logger util.Logger) *JSGFGrammar {
+	g := &JSGFGrammar{
+		BaseGrammar: grammar.NewBaseGrammar(dictionary, optimizeGrammar, addSilenceWords, addFillerWords, logger),
+		baseURL:     baseURL,
+		grammarName: grammarName,
+	}
+	g.loadGrammar = g.loadJSGFFile
+	return g
+}
+
+// Sets the directory of the grammar files, read at the next allocation.
+func (g *JSGFGrammar) SetGrammarLocation(baseURL string) {
+	g.baseURL = baseURL
+}
+
+// Returns the name of the grammar.
+func (g *JSGFGrammar) GetGrammarName() string {
+	return g.grammarName
+}
+
+// Returns the rules of the grammar, or nil before allocation.
+func (g *JSGFGrammar) GetRuleGrammar() *RuleGrammar {
+	return g.ruleGrammar
+}
+
+// Reads the grammar and its imports, and builds its graph.
+func (g *JSGFGrammar) Allocate() error {
+	if err := g.NewGrammar(); err != nil {
+		return err
+	}
+	g.ruleGrammars = make(map[string]*RuleGrammar)
+	ruleGrammar, err := g.loadGrammar(g.grammarName)
+	if err != nil {
+		return err
+	}
+	g.ruleGrammar = ruleGrammar
+	return g.createGrammar()
+}
+
+// Loads the grammar of the given name in place of the current one, and builds its graph.
+func (g *JSGFGrammar) LoadJSGF(grammarName string) error {
+	g.grammarName = grammarName
+	return g.Allocate()
+}
+
+// Reads the grammar of the given name from its file, then the grammars it imports.
+func (g *JSGFGrammar) loadJSGFFile(name string) (*RuleGrammar, error) {
+	if ruleGrammar, ok := g.ruleGrammars[name]; ok {
+		return ruleGrammar, nil
+	}
+
+	path := filepath.Join(g.baseURL, filepath.FromSlash(strings.ReplaceAll(name, ".", "/"))+GRAMMAR_EXTENSION)
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	ruleGrammar, err := ParseRuleGrammar(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	g.ruleGrammars[name] = ruleGrammar
+	g.ruleGrammars[ruleGrammar.GetName()] = ruleGrammar
+	return ruleGrammar, g.loadImports(ruleGrammar)
+}
+
+// Loads the grammars imported by the given one.
+func (g *JSGFGrammar) loadImports(ruleGrammar *RuleGrammar) error {
+	for _, importName := range ruleGrammar.GetImports() {
+		i := strings.LastIndexByte(importName, '.')
+		if i < 0 {
+			return fmt.Errorf("grammar %s: invalid import <%s>", ruleGrammar.GetName(), importName)
+		}
+		if _, err := g.loadJSGFFile(importName[:i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Builds the graph of the public rules of the grammar.
+func (g *JSGFGrammar) createGrammar() error {
+	initialNode, err := g.CreateWordNode(dictionary.SILENCE_SPELLING)
+	if err != nil {
+		return err
+	}
+	finalNode, err := g.CreateWordNode(dictionary.SILENCE_SPELLING)
+	if err != nil {
+		return err
+	}
+	finalNode.SetFinalNode(true)
+
+	g.ruleStack = nil
+	publicRules := 0
+	for _, name := range g.ruleGrammar.ListRuleNames() {
+		if !g.ruleGrammar.IsPublic(name) {
+			continue
+		}
+		pair, err := g.processRuleName(g.ruleGrammar, name)
+		if err != nil {
+			return err
+		}
+		initialNode.Add(pair.start, 0)
+		pair.end.Add(finalNode, 0)
+		publicRules++
+	}
+	if publicRules == 0 {
+		return fmt.Errorf("grammar %s has no public rule", g.ruleGrammar.GetName())
+	}
+
+	g.SetInitialNode(initialNode)
+	return nil
+}
+
+// Finds the grammar and the name of a rule referenced from a grammar, by a simple or a qualified name.
+func (g *JSGFGrammar) resolve(ruleGrammar *RuleGrammar, name string) (*RuleGrammar, string, error) {
+	i := strings.LastIndexByte(name, '.')
+	if i < 0 {
+		if ruleGrammar.GetRule(name) != nil {
+			return ruleGrammar, name, nil
+		}
+		for _, importName := range ruleGrammar.GetImports() {
+			j := strings.LastIndexByte(importName, '.')
+			imported := g.ruleGrammars[importName[:j]]
+			if imported == nil || (importName[j+1:] != "*" && importName[j+1:] != name) {
+				continue
+			}
+			if imported.IsPublic(name) {
+				return imported, name, nil
+			}
+		}
+		return nil, "", fmt.Errorf("grammar %s: can't find rule <%s>", ruleGrammar.GetName(), name)
+	}
+
+	grammarName, ruleName := name[:i], name[i+1:]
+	if referenced, ok := g.ruleGrammars[grammarName]; ok && referenced.GetRule(ruleName) != nil {
+		return referenced, ruleName, nil
+	}
+	for _, referenced := range g.ruleGrammars {
+		if simpleName(referenced.GetName()) == grammarName && referenced.GetRule(ruleName) != nil {
+			return referenced, ruleName, nil
+		}
+	}
+	return nil, "", fmt.Errorf("grammar %s: can't find rule <%s>", ruleGrammar.GetName(), name)
+}
+
+// Builds the graph of the rule of the given name, or returns the graph being built if the rule is recursive.
+func (g *JSGFGrammar) processRuleName(ruleGrammar *RuleGrammar, name string) (graphPair, error) {
+	fullName := ruleGrammar.GetName() + "." + name
+	for _, entry := range g.ruleStack {
+		if entry.name == fullName {
+			return entry.pair, nil
+		}
+	}
+
+	pair := graphPair{g.CreateGrammarNode(false), g.CreateGrammarNode(false)}
+	g.ruleStack = append(g.ruleStack, ruleStackEntry{fullName, pair})
+	ruleGraph, err := g.ruleToGrammar(ruleGrammar, ruleGrammar.GetRule(name))
+	g.ruleStack = g.ruleStack[:len(g.ruleStack)-1]
+	if err != nil {
+		return graphPair{}, err
+	}
+	pair.start.Add(ruleGraph.start, 0)
+	ruleGraph.end.Add(pair.end, 0)
+	return pair, nil
+}
+
+// Builds the graph of a rule expansion of a grammar.
+func (g *JSGFGrammar) ruleToGrammar(ruleGrammar *RuleGrammar, rule Rule) (graphPair, error) {
+	switch rule := rule.(type) {
+	case *RuleToken:
+		node, err := g.CreateWordNode(rule.Text)
+		if err != nil {
+			return graphPair{}, err
+		}
+		return graphPair{node, node}, nil
+
+	case *RuleName:
+		switch rule {
+		case NULL:
+			node := g.CreateGrammarNode(false)
+			return graphPair{node, node}, nil
+		case VOID:
+			// the end can't be reached
+			return graphPair{g.CreateGrammarNode(false), g.CreateGrammarNode(false)}, nil
+		}
+		referenced, name, err := g.resolve(ruleGrammar, rule.Name)
+		if err != nil {
+			return graphPair{}, err
+		}
+		return g.processRuleName(referenced, name)
+
+	case *RuleAlternatives:
+		pair := graphPair{g.CreateGrammarNode(false), g.CreateGrammarNode(false)}
+		logWeights, err := g.normalizedWeights(rule.Weights)
+		if err != nil {
+			return graphPair{}, err
+		}
+		for i, alternative := range rule.Rules {
+			if logWeights != nil && logWeights[i] == util.LOG_ZERO {
+				continue
+			}
+			alternativeGraph, err := g.ruleToGrammar(ruleGrammar, alternative)
+			if err != nil {
+				return graphPair{}, err
+			}
+			logWeight := float32(0)
+			if logWeights != nil {
+				logWeight = logWeights[i]
+			}
+			pair.start.Add(alternativeGraph.start, logWeight)
+			alternativeGraph.end.Add(pair.end, 0)
+		}
+		return pair, nil
+
+	case *RuleSequence:
+		var pair graphPair
+		for i, item := range rule.Rules {
+			itemGraph, err := g.ruleToGrammar(ruleGrammar, item)
+			if err != nil {
+				return graphPair{}, err
+			}
+			if i == 0 {
+				pair.start = itemGraph.start
+			} else {
+				pair.end.Add(itemGraph.start, 0)
+			}
+			pair.end = itemGraph.end
+		}
+		if pair.start == nil {
+			node := g.CreateGrammarNode(false)
+			return graphPair{node, node}, nil
+		}
+		return pair, nil
+
+	case *RuleCount:
+		pair := graphPair{g.CreateGrammarNode(false), g.CreateGrammarNode(false)}
+		inner, err := g.ruleToGrammar(ruleGrammar, rule.Rule)
+		if err != nil {
+			return graphPair{}, err
+		}
+		pair.start.Add(inner.start, 0)
+		inner.end.Add(pair.end, 0)
+		if rule.Count == OPTIONAL || rule.Count == ZERO_OR_MORE {
+			pair.start.Add(pair.end, 0)
+		}
+		if rule.Count == ONCE_OR_MORE || rule.Count == ZERO_OR_MORE {
+			inner.end.Add(inner.start, 0)
+		}
+		return pair, nil
+
+	case *RuleTag:
+		return g.ruleToGrammar(ruleGrammar, rule.Rule)
+
+	default:
+		return graphPair{}, fmt.Errorf("unsupported rule %v", rule)
+	}
+}
+
+// Returns the log of the weights normalized to sum to one, or nil if there are no weights.
+func (g *JSGFGrammar) normalizedWeights(weights []float64) ([]float32, error) {
+	if weights == nil {
+		return nil, nil
+	}
+	sum := 0.0
+	for _, weight := range weights {
+		sum += weight
+	}
+	if sum <= 0 {
+		return nil, errors.New("the weights of alternatives sum to zero")
+	}
+	logWeights := make([]float32, len(weights))
+	for i, weight := range weights {
+		logWeights[i] = util.LOG_ZERO
+		if weight > 0 {
+			logWeights[i] = g.LogMath().LinearToLog(weight / sum)
+		}
+	}
+	return logWeights, nil
+}
@@ -0,0 +1,169 @@
+package jsgf
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jtejido/go-sphinx/linguist/dictionary"
+	"github.com/jtejido/go-sphinx/linguist/language/grammar"
+)
+
+func writeFile(t *testing.T, dir, name, content string) {
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newDictionary(t *testing.T, dir string) dictionary.Dictionary {
+	writeFile(t, dir, "cmudict", "call K AO L\ndial D AY AX L\nhome HH OW M\nwork W ER K\nplease P L IY Z\nnow N AW\nvery V EH R IY\n")
+	writeFile(t, dir, "noisedict", "<s> SIL\n</s> SIL\n<sil> SIL\n")
+	d := dictionary.NewDefaultTextDictionary()
+	d.SetDictionaryPath(filepath.Join(dir, "cmudict"))
+	d.SetFillerPath(filepath.Join(dir, "noisedict"))
+	return d
+}
+
+// accepts tells whether a path of the graph spells the words, silences and empty nodes being skipped.
+func accepts(node *grammar.GrammarNode, words []string) bool {
+	type state struct {
+		node     *grammar.GrammarNode
+		consumed int
+	}
+	visited := make(map[state]bool)
+	var walk func(s state) bool
+	walk = func(s state) bool {
+		if visited[s] {
+			return false
+		}
+		visited[s] = true
+		if !s.node.IsEmpty() {
+			if spelling := s.node.GetWord().GetSpelling(); spelling != dictionary.SILENCE_SPELLING {
+				if s.consumed == len(words) || words[s.consumed] != spelling {
+					return false
+				}
+				s.consumed++
+			}
+		}
+		if s.node.IsFinalNode() && s.consumed == len(words) {
+			return true
+		}
+		for _, arc := range s.node.GetSuccessors() {
+			if walk(state{arc.GetGrammarNode(), s.consumed}) {
+				return true
+			}
+		}
+		return false
+	}
+	return walk(state{node, 0})
+}
+
+func TestJSGFGrammar(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "demo/phone.gram", `#JSGF V1.0;
+/* a phone dialing grammar */
+grammar demo.phone;
+
+import <common.polite.*>;
+
+public <command> = <verb> <place> [<please>] {dial};
+<verb> = /3/ call | /1/ dial;
+<place> = home | work | <NULL> | <VOID> home;
+public <now> = (now)+ <common.polite.please>* ;
+`)
+	writeFile(t, dir, "common/polite.gram", `#JSGF V1.0;
+grammar common.polite;
+public <please> = please | very <please>; // recursive
+`)
+
+	g := NewJSGFGrammar(dir, "demo.phone", newDictionary(t, dir), true, false, false, nil)
+	if err := g.Allocate(); err != nil {
+		t.Fatal(err)
+	}
+	if names := g.GetRuleGrammar().ListRuleNames(); len(names) != 4 {
+		t.Fatalf("got rules %v", names)
+	}
+
+	initial := g.GetInitialNode()
+	for _, sentence := range []string{
+		"call home",
+		"dial work please",
+		"call",
+		"call home very very please",
+		"now",
+		"now now please please",
+		"now very please",
+	} {
+		if !accepts(initial, strings.Fields(sentence)) {
+			t.Errorf("%q is rejected", sentence)
+		}
+	}
+	for _, sentence := range []string{
+		"",
+		"home",
+		"call home home",
+		"call very",
+		"please",
+		"now call",
+	} {
+		if accepts(initial, strings.Fields(sentence)) {
+			t.Errorf("%q is accepted", sentence)
+		}
+	}
+
+	// the weights of call and dial are normalized
+	var weights []float64
+	for _, node := range g.GetGrammarNodes() {
+		for _, arc := range node.GetSuccessors() {
+			if successor := arc.GetGrammarNode(); !successor.IsEmpty() && arc.GetProbability() != 0 {
+				weights = append(weights, g.LogMath().LogToLinear(arc.GetProbability()))
+			}
+		}
+	}
+	if len(weights) != 2 || math.Abs(weights[0]+weights[1]-1) > 1e-3 ||
+		(math.Abs(weights[0]-0.75) > 1e-3 && math.Abs(weights[1]-0.75) > 1e-3) {
+		t.Errorf("got weights %v, want 0.75 and 0.25", weights)
+	}
+}
+
+func TestJSGFGrammarErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "private.gram", "#JSGF V1.0;\ngrammar private;\n<a> = call;\n")
+	writeFile(t, dir, "unknown.gram", "#JSGF V1.0;\ngrammar unknown;\npublic <a> = call <b>;\n")
+	writeFile(t, dir, "oov.gram", "#JSGF V1.0;\ngrammar oov;\npublic <a> = call mom;\n")
+
+	for _, name := range []string{"private", "unknown", "oov", "missing"} {
+		g := NewJSGFGrammar(dir, name, newDictionary(t, dir), true, false, false, nil)
+		if err := g.Allocate(); err == nil {
+			t.Errorf("grammar %s: no error", name)
+		}
+	}
+}
+
+// TestJSGFGrammarEmptyCycle checks that the optimization of the graph ends on rules that only refer to each other.
+func TestJSGFGrammarEmptyCycle(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "cycle.gram", `#JSGF V1.0;
+grammar cycle;
+public <a> = <b>;
+<b> = <a>;
+public <call> = call <c>;
+<c> = <d> | <NULL>;
+<d> = <e>;
+<e> = <c>;
+`)
+
+	g := NewJSGFGrammar(dir, "cycle", newDictionary(t, dir), true, false, false, nil)
+	if err := g.Allocate(); err != nil {
+		t.Fatal(err)
+	}
+	if !accepts(g.GetInitialNode(), []string{"call"}) {
+		t.Error(`"call" is rejected`)
+	}
+}
@@ -0,0 +1,335 @@
+package jsgf
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type tokenType int
+
+const (
+	tokenEOF tokenType = iota
+	tokenHeader
+	tokenWord
+	tokenQuoted
+	tokenRuleName
+	tokenTag
+	tokenWeight
+	tokenPunctuation
+)
+
+type token struct {
+	kind tokenType
+	text string
+	line int
+}
+
+// The characters that end a word.
+const specialCharacters = ";=|*+()[]<>{}/\""
+
+// Parses a JSGF 1.0 grammar: the optional "#JSGF V1.0;" header, the grammar name, its imports and its rules.
+//
+// Rule expansions may hold tokens, quoted tokens, rule references including <NULL> and <VOID>, alternatives with
+// optional /weights/, grouping, [optional] parts, the * and + operators and {tags}. Comments are C and C++ style.
+func ParseRuleGrammar(r io.Reader) (*RuleGrammar, error) {
+	source, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := tokenize(string(source))
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	return p.grammar()
+}
+
+// Splits the source of a grammar into tokens.
+func tokenize(source string) ([]token, error) {
+	var tokens []token
+	line := 1
+	runes := []rune(source)
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case unicode.IsSpace(c):
+			i++
+		case c == '/' && i+1 < len(runes) && runes[i+1] == '/':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			startLine := line
+			for i += 2; i+1 < len(runes) && !(runes[i] == '*' && runes[i+1] == '/'); i++ {
+				if runes[i] == '\n' {
+					line++
+				}
+			}
+			if i+1 >= len(runes) {
+				return nil, fmt.Errorf("line %d: unterminated comment", startLine)
+			}
+			i += 2
+		case c == '#' && len(tokens) == 0:
+			start := i
+			for i < len(runes) && runes[i] != ';' && runes[i] != '\n' {
+				i++
+			}
+			tokens = append(tokens, token{tokenHeader, string(runes[start:i]), line})
+			if i < len(runes) && runes[i] == ';' {
+				i++
+			}
+		case c == '<' || c == '{' || c == '/' || c == '"':
+			closing := map[rune]rune{'<': '>', '{': '}', '/': '/', '"': '"'}[c]
+			kind := map[rune]tokenType{'<': tokenRuleName, '{': tokenTag, '/': tokenWeight, '"': tokenQuoted}[c]
+			var text strings.Builder
+			startLine := line
+			for i++; i < len(runes) && runes[i] != closing; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) && (kind == tokenTag || kind == tokenQuoted) {
+					i++
+				}
+				if runes[i] == '\n' {
+					line++
+				}
+				text.WriteRune(runes[i])
+			}
+			if i == len(runes) {
+				return nil, fmt.Errorf("line %d: missing %q", startLine, closing)
+			}
+			i++
+			tokens = append(tokens, token{kind, strings.TrimSpace(text.String()), startLine})
+		case strings.ContainsRune(specialCharacters, c):
+			tokens = append(tokens, token{tokenPunctuation, string(c), line})
+			i++
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && !strings.ContainsRune(specialCharacters, runes[i]) {
+				i++
+			}
+			tokens = append(tokens, token{tokenWord, string(runes[start:i]), line})
+		}
+	}
+	return append(tokens, token{tokenEOF, "", line}), nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) is(kind tokenType, text string) bool {
+	t := p.peek()
+	return t.kind == kind && t.text == text
+}
+
+func (p *parser) expect(kind tokenType, text string) (token, error) {
+	t := p.next()
+	if t.kind != kind || (text != "" && t.text != text) {
+		expected := text
+		if expected == "" {
+			expected = [...]string{"end of grammar", "header", "word", "quoted token", "rule name", "tag", "weight",
+				"punctuation"}[kind]
+		}
+		return t, p.errorf(t, "expected %s", expected)
+	}
+	return t, nil
+}
+
+func (p *parser) errorf(t token, format string, args ...interface{}) error {
+	found := t.text
+	if t.kind == tokenEOF {
+		found = "end of grammar"
+	}
+	return fmt.Errorf("line %d: %s, found %q", t.line, fmt.Sprintf(format, args...), found)
+}
+
+func (p *parser) grammar() (*RuleGrammar, error) {
+	if header := p.peek(); header.kind == tokenHeader {
+		if !strings.HasPrefix(header.text, "#JSGF") {
+			return nil, p.errorf(header, "expected #JSGF header")
+		}
+		p.next()
+	}
+
+	if _, err := p.expect(tokenWord, "grammar"); err != nil {
+		return nil, err
+	}
+	name, err := p.expect(tokenWord, "")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(tokenPunctuation, ";"); err != nil {
+		return nil, err
+	}
+	g := NewRuleGrammar(name.text)
+
+	for p.is(tokenWord, "import") {
+		p.next()
+		importName, err := p.expect(tokenRuleName, "")
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokenPunctuation, ";"); err != nil {
+			return nil, err
+		}
+		g.AddImport(importName.text)
+	}
+
+	for p.peek().kind != tokenEOF {
+		isPublic := p.is(tokenWord, "public")
+		if isPublic {
+			p.next()
+		}
+		name, err := p.expect(tokenRuleName, "")
+		if err != nil {
+			return nil, err
+		}
+		if strings.Contains(name.text, ".") || name.text == NULL.Name || name.text == VOID.Name {
+			return nil, p.errorf(name, "invalid rule name")
+		}
+		if _, err := p.expect(tokenPunctuation, "="); err != nil {
+			return nil, err
+		}
+		rule, err := p.alternatives()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokenPunctuation, ";"); err != nil {
+			return nil, err
+		}
+		g.SetRule(name.text, rule, isPublic)
+	}
+	return g, nil
+}
+
+// Parses alternatives, each optionally preceded by its weight.
+func (p *parser) alternatives() (Rule, error) {
+	var rules []Rule
+	var weights []float64
+	for {
+		weighted := p.peek().kind == tokenWeight
+		if len(rules) > 0 && weighted != (weights != nil) {
+			return nil, p.errorf(p.peek(), "either all or none of the alternatives must be weighted")
+		}
+		if weighted {
+			t := p.next()
+			weight, err := strconv.ParseFloat(t.text, 64)
+			if err != nil || weight < 0 {
+				return nil, p.errorf(t, "invalid weight")
+			}
+			weights = append(weights, weight)
+		}
+
+		rule, err := p.sequence()
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+
+		if !p.is(tokenPunctuation, "|") {
+			break
+		}
+		p.next()
+	}
+
+	if len(rules) == 1 && weights == nil {
+		return rules[0], nil
+	}
+	return &RuleAlternatives{Rules: rules, Weights: weights}, nil
+}
+
+func (p *parser) sequence() (Rule, error) {
+	var rules []Rule
+	for {
+		t := p.peek()
+		if t.kind == tokenEOF || t.kind == tokenPunctuation && strings.Contains(";|)]", t.text) {
+			break
+		}
+		rule, err := p.item()
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	switch len(rules) {
+	case 0:
+		return nil, p.errorf(p.peek(), "expected a rule expansion")
+	case 1:
+		return rules[0], nil
+	default:
+		return &RuleSequence{Rules: rules}, nil
+	}
+}
+
+// Parses an expansion followed by its operators and tags.
+func (p *parser) item() (Rule, error) {
+	rule, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch t := p.peek(); {
+		case t.kind == tokenPunctuation && t.text == "*":
+			rule = &RuleCount{Rule: rule, Count: ZERO_OR_MORE}
+		case t.kind == tokenPunctuation && t.text == "+":
+			rule = &RuleCount{Rule: rule, Count: ONCE_OR_MORE}
+		case t.kind == tokenTag:
+			rule = &RuleTag{Rule: rule, Tag: t.text}
+		default:
+			return rule, nil
+		}
+		p.next()
+	}
+}
+
+func (p *parser) primary() (Rule, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenWord, tokenQuoted:
+		return &RuleToken{Text: t.text}, nil
+	case tokenRuleName:
+		switch t.text {
+		case NULL.Name:
+			return NULL, nil
+		case VOID.Name:
+			return VOID, nil
+		}
+		return &RuleName{Name: t.text}, nil
+	case tokenPunctuation:
+		switch t.text {
+		case "(":
+			rule, err := p.alternatives()
+			if err != nil {
+				return nil, err
+			}
+			_, err = p.expect(tokenPunctuation, ")")
+			return rule, err
+		case "[":
+			rule, err := p.alternatives()
+			if err != nil {
+				return nil, err
+			}
+			_, err = p.expect(tokenPunctuation, "]")
+			return &RuleCount{Rule: rule, Count: OPTIONAL}, err
+		}
+	}
+	return nil, p.errorf(t, "expected a rule expansion")
+}
@@ -0,0 +1,104 @@
+package jsgf
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// The counts of a RuleCount.
+	OPTIONAL     = 1
+	ZERO_OR_MORE = 2
+	ONCE_OR_MORE = 3
+)
+
+// A rule expansion of a JSGF grammar.
+type Rule interface {
+	String() string
+}
+
+// A choice between rules, with optional weights.
+type RuleAlternatives struct {
+	Rules []Rule
+	// the weights of the rules, or nil if the alternatives are not weighted
+	Weights []float64
+}
+
+func (r *RuleAlternatives) String() string {
+	alternatives := make([]string, len(r.Rules))
+	for i, rule := range r.Rules {
+		if r.Weights != nil {
+			alternatives[i] = fmt.Sprintf("/%v/ %v", r.Weights[i], rule)
+		} else {
+			alternatives[i] = rule.String()
+		}
+	}
+	return "(" + strings.Join(alternatives, " | ") + ")"
+}
+
+// A sequence of rules.
+type RuleSequence struct {
+	Rules []Rule
+}
+
+func (r *RuleSequence) String() string {
+	rules := make([]string, len(r.Rules))
+	for i, rule := range r.Rules {
+		rules[i] = rule.String()
+	}
+	return strings.Join(rules, " ")
+}
+
+// A rule that is optional ([rule]), repeated (rule+) or both (rule*).
+type RuleCount struct {
+	Rule  Rule
+	Count int
+}
+
+func (r *RuleCount) String() string {
+	switch r.Count {
+	case OPTIONAL:
+		return "[" + r.Rule.String() + "]"
+	case ZERO_OR_MORE:
+		return r.Rule.String() + "*"
+	default:
+		return r.Rule.String() + "+"
+	}
+}
+
+// A reference to a rule, by its name, simple or qualified by its grammar. The special rules <NULL> and <VOID> match
+// nothing and can never be spoken.
+type RuleName struct {
+	Name string
+}
+
+var (
+	NULL = &RuleName{Name: "NULL"}
+	VOID = &RuleName{Name: "VOID"}
+)
+
+func (r *RuleName) String() string {
+	return "<" + r.Name + ">"
+}
+
+// A rule with a tag, the semantic information attached to it.
+type RuleTag struct {
+	Rule Rule
+	Tag  string
+}
+
+func (r *RuleTag) String() string {
+	return r.Rule.String() + " {" + r.Tag + "}"
+}
+
+// A token, one or more words to be spoken.
+type RuleToken struct {
+	Text string
+}
+
+func (r *RuleToken) String() string {
+	if strings.ContainsAny(r.Text, " \t") {
+		return `"` + r.Text + `"`
+	}
+	return r.Text
+}
@@ -0,0 +1,86 @@
+package jsgf
+
+import (
+	"strings"
+)
+
+type ruleEntry struct {
+	rule     Rule
+	isPublic bool
+}
+
+// The rules of a JSGF grammar, with its name and its imports.
+type RuleGrammar struct {
+	name      string
+	imports   []string
+	rules     map[string]*ruleEntry
+	ruleNames []string
+}
+
+// Creates an empty RuleGrammar of the given name.
+func NewRuleGrammar(name string) *RuleGrammar {
+	return &RuleGrammar{name: name, rules: make(map[string]*ruleEntry)}
+}
+
+// Returns the fully qualified name of the grammar.
+func (g *RuleGrammar) GetName() string {
+	return g.name
+}
+
+// Returns the imported rule names, such as "com.acme.politeness.*" or "com.acme.politeness.startPolite".
+func (g *RuleGrammar) GetImports() []string {
+	return g.imports
+}
+
+// Adds an import.
+func (g *RuleGrammar) AddImport(importName string) {
+	g.imports = append(g.imports, importName)
+}
+
+// Sets a rule of the grammar, replacing the rule of that name if there is one.
+func (g *RuleGrammar) SetRule(name string, rule Rule, isPublic bool) {
+	if _, ok := g.rules[name]; !ok {
+		g.ruleNames = append(g.ruleNames, name)
+	}
+	g.rules[name] = &ruleEntry{rule: rule, isPublic: isPublic}
+}
+
+// Returns the rule of the given name, or nil if there is none.
+func (g *RuleGrammar) GetRule(name string) Rule {
+	if entry, ok := g.rules[name]; ok {
+		return entry.rule
+	}
+	return nil
+}
+
+// Returns whether the rule of the given name is public.
+func (g *RuleGrammar) IsPublic(name string) bool {
+	entry, ok := g.rules[name]
+	return ok && entry.isPublic
+}
+
+// Returns the names of the rules, in the order they were defined.
+func (g *RuleGrammar) ListRuleNames() []string {
+	return g.ruleNames
+}
+
+// Returns the last component of a dotted name, "name" for "com.acme.name".
+func simpleName(name string) string {
+	return name[strings.LastIndexByte(name, '.')+1:]
+}
+
+func (g *RuleGrammar) String() string {
+	var b strings.Builder
+	b.WriteString("#JSGF V1.0;\n\ngrammar " + g.name + ";\n\n")
+	for _, importName := range g.imports {
+		b.WriteString("import <" + importName + ">;\n")
+	}
+	for _, name := range g.ruleNames {
+		entry := g.rules[name]
+		if entry.isPublic {
+			b.WriteString("public ")
+		}
+		b.WriteString("<" + name + "> = " + entry.rule.String() + ";\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,172 @@
+package ngram
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jtejido/go-sphinx/linguist"
+	"github.com/jtejido/go-sphinx/linguist/dictionary"
+	"github.com/jtejido/go-sphinx/util"
+)
+
+// The class of a word and the log probability of the word within it.
+type classMember struct {
+	class          string
+	logProbability float32
+}
+
+// A class-based language model over a base model whose vocabulary holds class tokens, such as [CONTACT_NAME]. Each
+// class expands to a list of words, which can be replaced at any time, between two requests, without reloading the
+// base model.
+//
+// The probability of a word of a class is the probability of the class token given the history, in which the words
+// of classes are also replaced by their class token, times the probability of the word within the class. The words
+// of a class are equally likely. Words are folded to lower case, as the base models do.
+type DynamicClassLanguageModel struct {
+	languageModel LanguageModel
+	logMath       *util.LogMath
+
+	lock    sync.RWMutex
+	classes map[string][]string
+	members map[string]classMember
+}
+
+// Creates a DynamicClassLanguageModel over the given base model, without any class.
+func NewDynamicClassLanguageModel(languageModel LanguageModel) *DynamicClassLanguageModel {
+	return &DynamicClassLanguageModel{
+		languageModel: languageModel,
+		logMath:       util.GetLogMath(),
+		classes:       make(map[string][]string),
+		members:       make(map[string]classMember),
+	}
+}
+
+// Returns the base language model.
+func (m *DynamicClassLanguageModel) LanguageModel() LanguageModel {
+	return m.languageModel
+}
+
+// Sets the words of a class, replacing its previous words. An empty list removes the class.
+//
+// Returns an error if one of the words already belongs to another class.
+func (m *DynamicClassLanguageModel) SetClass(class string, words []string) error {
+	class = strings.ToLower(class)
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	members := make([]string, 0, len(words))
+	seen := make(map[string]bool)
+	for _, word := range words {
+		word = strings.ToLower(word)
+		if member, ok := m.members[word]; ok && member.class != class {
+			return fmt.Errorf("%s already belongs to the class %s", word, member.class)
+		}
+		if !seen[word] {
+			seen[word] = true
+			members = append(members, word)
+		}
+	}
+
+	for _, word := range m.classes[class] {
+		delete(m.members, word)
+	}
+	delete(m.classes, class)
+	if len(members) == 0 {
+		return nil
+	}
+
+	logProbability := m.logMath.LinearToLog(1.0 / float64(len(members)))
+	for _, word := range members {
+		m.members[word] = classMember{class: class, logProbability: logProbability}
+	}
+	m.classes[class] = members
+	return nil
+}
+
+// Returns the words of a class.
+func (m *DynamicClassLanguageModel) Class(class string) []string {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.classes[strings.ToLower(class)]
+}
+
+// Allocates the base model.
+func (m *DynamicClassLanguageModel) Allocate() error {
+	return m.languageModel.Allocate()
+}
+
+// Deallocates the base model.
+func (m *DynamicClassLanguageModel) Deallocate() {
+	m.languageModel.Deallocate()
+}
+
+// Gets the probability of the newest word of the sequence given the words before it, through its class if it has one.
+func (m *DynamicClassLanguageModel) GetProbability(wordSequence *linguist.WordSequence) float32 {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	classSequence := m.classSequence(wordSequence)
+	logProbability := m.languageModel.GetProbability(classSequence)
+	if wordSequence.Size() == 0 || logProbability == util.LOG_ZERO {
+		return logProbability
+	}
+	newest := wordSequence.GetWord(wordSequence.Size() - 1).GetSpelling()
+	if member, ok := m.members[strings.ToLower(newest)]; ok {
+		logProbability += member.logProbability
+	}
+	return logProbability
+}
+
+// Gets the smear term of the base model for the sequence of classes.
+func (m *DynamicClassLanguageModel) GetSmear(wordSequence *linguist.WordSequence) float32 {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+	return m.languageModel.GetSmear(m.classSequence(wordSequence))
+}
+
+// Returns the vocabulary of the base model, its class tokens replaced by the words of the classes, ordered by
+// spelling.
+func (m *DynamicClassLanguageModel) GetVocabulary() []string {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	vocabulary := make([]string, 0)
+	for _, word := range m.languageModel.GetVocabulary() {
+		if members, ok := m.classes[word]; ok {
+			vocabulary = append(vocabulary, members...)
+		} else {
+			vocabulary = append(vocabulary, word)
+		}
+	}
+	sort.Strings(vocabulary)
+	return vocabulary
+}
+
+// Returns the maximum depth of the base model.
+func (m *DynamicClassLanguageModel) GetMaxDepth() int {
+	return m.languageModel.GetMaxDepth()
+}
+
+// Lets the base model clear its caches.
+func (m *DynamicClassLanguageModel) OnUtteranceEnd() {
+	m.languageModel.OnUtteranceEnd()
+}
+
+// Returns the sequence with the words of classes replaced by their class token.
+func (m *DynamicClassLanguageModel) classSequence(wordSequence *linguist.WordSequence) *linguist.WordSequence {
+	var words []*dictionary.Word
+	for i, word := range wordSequence.GetWords() {
+		if member, ok := m.members[strings.ToLower(word.GetSpelling())]; ok {
+			if words == nil {
+				words = append([]*dictionary.Word(nil), wordSequence.GetWords()...)
+			}
+			words[i] = dictionary.NewWord(member.class, nil, false)
+		}
+	}
+	if words == nil {
+		return wordSequence
+	}
+	return linguist.NewWordSequenceByWordSlice(words)
+}
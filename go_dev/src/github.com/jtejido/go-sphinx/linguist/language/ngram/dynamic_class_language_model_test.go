@@ -0,0 +1,73 @@
+package ngram
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jtejido/go-sphinx/util"
+)
+
+const classArpa = `\data\
+ngram 1=4
+ngram 2=2
+
+\1-grams:
+-1.0 <s> -0.3
+-0.5 </s>
+-0.6 call -0.1
+-0.4 [CONTACT_NAME] -0.2
+
+\2-grams:
+-0.2 call [CONTACT_NAME]
+-0.1 [CONTACT_NAME] </s>
+
+\end\
+`
+
+func loadArpa(t *testing.T, name, content string) *SimpleNGramModel {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	model := NewDefaultSimpleNGramModel()
+	model.SetLocation(path)
+	if err := model.Allocate(); err != nil {
+		t.Fatal(err)
+	}
+	return model
+}
+
+func TestDynamicClassLanguageModel(t *testing.T) {
+	model := NewDynamicClassLanguageModel(loadArpa(t, "class.lm", classArpa))
+	if err := model.SetClass("[CONTACT_NAME]", []string{"Alice", "bob", "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := model.SetClass("[OTHER]", []string{"bob"}); err == nil {
+		t.Fatal("a word cannot belong to two classes")
+	}
+	if vocabulary := model.GetVocabulary(); len(vocabulary) != 5 || vocabulary[2] != "alice" || vocabulary[3] != "bob" {
+		t.Fatalf("unexpected vocabulary %v", vocabulary)
+	}
+
+	logMath := util.GetLogMath()
+	check := func(log10 float64, words ...string) {
+		t.Helper()
+		expected := logMath.Log10ToLog(float32(log10))
+		if p := model.GetProbability(sequence(words...)); math.Abs(float64(p-expected)) > 1 {
+			t.Errorf("probability of %v is %v, expected %v", words, p, expected)
+		}
+	}
+	check(-0.2+math.Log10(0.5), "call", "bob")
+	check(-0.1, "alice", "</s>")
+
+	// swap the class, bob is now alone in it
+	if err := model.SetClass("[CONTACT_NAME]", []string{"bob"}); err != nil {
+		t.Fatal(err)
+	}
+	check(-0.2, "call", "bob")
+	if p := model.GetProbability(sequence("call", "alice")); p != util.LOG_ZERO {
+		t.Errorf("alice is no longer in the class, probability %v", p)
+	}
+}
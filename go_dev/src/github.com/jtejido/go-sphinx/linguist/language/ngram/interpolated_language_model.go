@@ -0,0 +1,141 @@
+package ngram
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/jtejido/go-sphinx/linguist"
+	"github.com/jtejido/go-sphinx/util"
+)
+
+// Linearly interpolates a number of language models: the probability of a word sequence is the sum of its
+// probabilities under each model, weighted by the fixed weight of the model. The weights sum to one.
+//
+// The vocabulary is the union of the vocabularies of the models and the depth the largest of their depths, each model
+// only using the newest words it needs. The smear term is the weighted sum of the smear terms of the models.
+type InterpolatedLanguageModel struct {
+	languageModels []LanguageModel
+	weights        []float64
+	logWeights     []float32
+	logMath        *util.LogMath
+}
+
+// Creates an InterpolatedLanguageModel of the given models and weights.
+//
+// Returns an error if there is not one non-negative weight per model or if the weights do not sum to one.
+func NewInterpolatedLanguageModel(languageModels []LanguageModel, weights []float64) (*InterpolatedLanguageModel, error) {
+	if len(languageModels) == 0 {
+		return nil, errors.New("no language model to interpolate")
+	}
+	if len(weights) != len(languageModels) {
+		return nil, fmt.Errorf("%d weights for %d language models", len(weights), len(languageModels))
+	}
+
+	m := &InterpolatedLanguageModel{
+		languageModels: languageModels,
+		weights:        weights,
+		logWeights:     make([]float32, len(weights)),
+		logMath:        util.GetLogMath(),
+	}
+	sum := 0.0
+	for i, weight := range weights {
+		if weight < 0 {
+			return nil, fmt.Errorf("negative weight %v", weight)
+		}
+		sum += weight
+		m.logWeights[i] = util.LOG_ZERO
+		if weight > 0 {
+			m.logWeights[i] = m.logMath.LinearToLog(weight)
+		}
+	}
+	if math.Abs(sum-1.0) > 1e-3 {
+		return nil, fmt.Errorf("weights %v do not sum to 1.0", weights)
+	}
+	return m, nil
+}
+
+// Returns the interpolated language models.
+func (m *InterpolatedLanguageModel) LanguageModels() []LanguageModel {
+	return m.languageModels
+}
+
+// Returns the weights of the language models.
+func (m *InterpolatedLanguageModel) Weights() []float64 {
+	return m.weights
+}
+
+// Allocates all the language models.
+func (m *InterpolatedLanguageModel) Allocate() error {
+	for _, model := range m.languageModels {
+		if err := model.Allocate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Deallocates all the language models.
+func (m *InterpolatedLanguageModel) Deallocate() {
+	for _, model := range m.languageModels {
+		model.Deallocate()
+	}
+}
+
+// Gets the weighted sum of the probabilities of the word sequence under each model.
+func (m *InterpolatedLanguageModel) GetProbability(wordSequence *linguist.WordSequence) float32 {
+	logProbability := util.LOG_ZERO
+	for i, model := range m.languageModels {
+		if m.logWeights[i] == util.LOG_ZERO {
+			continue
+		}
+		if p := model.GetProbability(wordSequence); p != util.LOG_ZERO {
+			logProbability = m.logMath.AddAsLinear(logProbability, m.logWeights[i]+p)
+		}
+	}
+	return logProbability
+}
+
+// Gets the weighted sum of the smear terms of the models.
+func (m *InterpolatedLanguageModel) GetSmear(wordSequence *linguist.WordSequence) float32 {
+	smear := 0.0
+	for i, model := range m.languageModels {
+		smear += m.weights[i] * float64(model.GetSmear(wordSequence))
+	}
+	return float32(smear)
+}
+
+// Returns the union of the vocabularies of the models, ordered by spelling.
+func (m *InterpolatedLanguageModel) GetVocabulary() []string {
+	words := make(map[string]bool)
+	for _, model := range m.languageModels {
+		for _, word := range model.GetVocabulary() {
+			words[word] = true
+		}
+	}
+	vocabulary := make([]string, 0, len(words))
+	for word := range words {
+		vocabulary = append(vocabulary, word)
+	}
+	sort.Strings(vocabulary)
+	return vocabulary
+}
+
+// Returns the largest depth of the models.
+func (m *InterpolatedLanguageModel) GetMaxDepth() int {
+	maxDepth := 0
+	for _, model := range m.languageModels {
+		if depth := model.GetMaxDepth(); depth > maxDepth {
+			maxDepth = depth
+		}
+	}
+	return maxDepth
+}
+
+// Lets every model clear its caches.
+func (m *InterpolatedLanguageModel) OnUtteranceEnd() {
+	for _, model := range m.languageModels {
+		model.OnUtteranceEnd()
+	}
+}
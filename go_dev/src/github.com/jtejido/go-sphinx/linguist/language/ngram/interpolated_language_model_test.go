@@ -0,0 +1,37 @@
+package ngram
+
+import (
+	"math"
+	"testing"
+
+	"github.com/jtejido/go-sphinx/util"
+)
+
+func TestInterpolatedLanguageModel(t *testing.T) {
+	general := loadArpa(t, "general.lm", arpa)
+	domain := loadArpa(t, "domain.lm", classArpa)
+	if _, err := NewInterpolatedLanguageModel([]LanguageModel{general, domain}, []float64{0.5, 0.6}); err == nil {
+		t.Fatal("weights must sum to one")
+	}
+	model, err := NewInterpolatedLanguageModel([]LanguageModel{general, domain}, []float64{0.75, 0.25})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if model.GetMaxDepth() != 2 || len(model.GetVocabulary()) != 7 {
+		t.Fatalf("depth %d, vocabulary %v", model.GetMaxDepth(), model.GetVocabulary())
+	}
+
+	logMath := util.GetLogMath()
+	for _, test := range []struct {
+		words    []string
+		expected float64
+	}{
+		{[]string{"<s>", "hello"}, 0.75 * math.Pow(10, -0.3)},
+		{[]string{"call"}, 0.75*math.Pow(10, -1.5) + 0.25*math.Pow(10, -0.6)},
+	} {
+		expected := logMath.LinearToLog(test.expected)
+		if p := model.GetProbability(sequence(test.words...)); math.Abs(float64(p-expected)) > 2 {
+			t.Errorf("probability of %v is %v, expected %v", test.words, p, expected)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package ngram
+
+import (
+	"github.com/jtejido/go-sphinx/linguist"
+	"github.com/jtejido/go-sphinx/util"
+)
+
+const (
+	// The default weight of the unigrams, 1.0 leaves them untouched. Lower weights interpolate the unigram
+	// probabilities with a uniform distribution over the vocabulary.
+	DEFAULT_UNIGRAM_WEIGHT = 1.0
+
+	// The default maximum depth of the language model, -1 for the order of the model.
+	DEFAULT_MAX_DEPTH = -1
+
+	// Spelling of the word standing for the words out of the vocabulary of the model.
+	UNKNOWN_SPELLING = "<unk>"
+)
+
+// Represents the generic interface to an N-Gram language model.
+//
+// Probabilities are in the log domain of util.LogMath, and word sequences are ordered from the oldest to the newest
+// word: the probability of a sequence is the one of its newest word given the words before it.
+type LanguageModel interface {
+	// Creates the language model.
+	Allocate() error
+
+	// Deallocates the language model.
+	Deallocate()
+
+	// Gets the n-gram probability of the word sequence represented by the word list.
+	GetProbability(wordSequence *linguist.WordSequence) float32
+
+	// Gets the smear term for the given word sequence: the expected log probability of the words following it. It is
+	// used to anticipate the language score of the words of a lex tree before they are known.
+	GetSmear(wordSequence *linguist.WordSequence) float32
+
+	// Returns the set of words in the language model, ordered by spelling.
+	GetVocabulary() []string
+
+	// Returns the maximum depth of the language model.
+	GetMaxDepth() int
+
+	// Called by the linguist at the end of each utterance, to let the model clear its caches.
+	OnUtteranceEnd()
+}
+
+// Returns the function interpolating a unigram log probability with the uniform distribution over a vocabulary of the
+// given size, the unigram probability weighted by unigramWeight.
+func unigramWeighting(logMath *util.LogMath, unigramWeight float64, vocabularySize int) func(float32) float32 {
+	logUnigramWeight, logUniform := util.LOG_ZERO, util.LOG_ZERO
+	if unigramWeight > 0 {
+		logUnigramWeight = logMath.LinearToLog(unigramWeight)
+	}
+	if unigramWeight < 1 && vocabularySize > 0 {
+		logUniform = logMath.LinearToLog((1.0 - unigramWeight) / float64(vocabularySize))
+	}
+	return func(logProbability float32) float32 {
+		if logProbability == util.LOG_ZERO || logUnigramWeight == util.LOG_ZERO {
+			return logUniform
+		}
+		return logMath.AddAsLinear(logProbability+logUnigramWeight, logUniform)
+	}
+}
+
+// Computes a smear term: the average log probability of the words of the vocabulary following a history, weighted by
+// their probability, given the probability of each word after that history.
+func smearTerm(logMath *util.LogMath, vocabulary []string, probability func(word string) float32) float32 {
+	var numerator, denominator float64
+	for _, word := range vocabulary {
+		logProbability := probability(word)
+		if logProbability == util.LOG_ZERO {
+			continue
+		}
+		p := logMath.LogToLinear(logProbability)
+		numerator += p * float64(logProbability)
+		denominator += p
+	}
+	if denominator == 0 {
+		return 0
+	}
+	return float32(numerator / denominator)
+}
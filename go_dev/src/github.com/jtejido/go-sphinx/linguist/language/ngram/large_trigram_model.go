@@ -0,0 +1,562 @@
+package ngram
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jtejido/go-sphinx/linguist"
+	"github.com/jtejido/go-sphinx/linguist/dictionary"
+	"github.com/jtejido/go-sphinx/util"
+)
+
+const (
+	dmpHeader = "Darpa Trigram LM"
+
+	// The trigrams are indexed by segments of 2^9 bigrams, unless the file tells otherwise.
+	logBigramSegmentSize = 9
+
+	// The versions of the DMP format, in the field following the name of the original file. The oldest files have no
+	// version field, and are read as version 0, with 16 bit n-grams.
+	dmpVersion16Bit        = -1 // 16 bit n-grams
+	dmpVersion16BitSegment = -2 // 16 bit n-grams, and the size of the trigram segments
+	dmpVersion32Bit        = -3 // 32 bit n-grams, and the size of the trigram segments
+
+	dmpUnigramSize = 16
+)
+
+// A unigram of a DMP model, its probability and backoff in the log domain.
+type dmpUnigram struct {
+	logProbability float32
+	logBackoff     float32
+	firstBigram    int32
+}
+
+// A bigram or trigram of a DMP model: the id of its newest word, the indices of its probability and backoff in the
+// tables of the model and, for bigrams, the index of its first trigram in its segment.
+type dmpNgram struct {
+	wordID       uint32
+	probID       uint32
+	backoffID    uint32
+	firstTrigram uint32
+}
+
+// A trigram language model in the binary DMP format ("Darpa Trigram LM") of Sphinx-3, as produced by sphinx_lm_convert
+// or lm3g2dmp. Both the 16 bit and the 32 bit variants are read, the width of the entries being given by the version
+// field of the file.
+//
+// Only the unigrams, the probability tables and the words are read at allocation. The bigrams following a word and
+// the trigrams following a bigram are read from the file the first time they are needed, and kept until the model is
+// deallocated. The probabilities of the n-grams looked up are cached until the end of the utterance.
+//
+// As with the SimpleNGramModel, words are folded to lower case and the words out of the vocabulary are looked up as
+// <unk> if the model has it.
+type LargeTrigramModel struct {
+	location        string
+	unigramWeight   float64
+	desiredMaxDepth int
+	ngramCacheSize  int
+	logger          util.Logger
+
+	logMath     *util.LogMath
+	allocated   bool
+	file        *os.File
+	byteOrder   binary.ByteOrder
+	ngramSize   int64
+	logSegment  uint
+	counts      []int
+	maxNGram    int
+	unigrams    []dmpUnigram
+	bigramProbs []float32
+	bigramBows  []float32
+	trigramProb []float32
+	segmentBase []int32
+	bigramsAt   int64
+	trigramsAt  int64
+	words       []string
+	wordIDs     map[string]int
+	unknownID   int
+
+	// the words, ordered by spelling, as GetVocabulary and the smear terms need them
+	vocabulary []string
+
+	bigrams  map[int][]dmpNgram
+	trigrams map[int][]dmpNgram
+	cache    map[string]float32
+	smears   map[string]float32
+}
+
+// Creates a LargeTrigramModel without a location, to be set with SetLocation before allocation.
+func NewDefaultLargeTrigramModel() *LargeTrigramModel {
+	return NewLargeTrigramModel("", DEFAULT_UNIGRAM_WEIGHT, DEFAULT_MAX_DEPTH, DEFAULT_NGRAM_CACHE_SIZE, nil)
+}
+
+// Creates a LargeTrigramModel.
+//
+// Accepts the path of the DMP file, the weight of the unigrams, the maximum depth used, -1 for the order of the
+// model, the maximum number of cached probabilities and an optional logger.
+func NewLargeTrigramModel(location string, unigramWeight float64, desiredMaxDepth, ngramCacheSize int, logger util.Logger) *LargeTrigramModel {
+	return &LargeTrigramModel{
+		location:        location,
+		unigramWeight:   unigramWeight,
+		desiredMaxDepth: desiredMaxDepth,
+		ngramCacheSize:  ngramCacheSize,
+		logger:          logger,
+		logMath:         util.GetLogMath(),
+	}
+}
+
+// Sets the path of the DMP file, read at the next allocation.
+func (m *LargeTrigramModel) SetLocation(location string) {
+	m.location = location
+}
+
+// Reads the unigrams, the probability tables and the words of the model. The file stays open for the bigrams and
+// trigrams until deallocation. Does nothing if the model is already allocated.
+func (m *LargeTrigramModel) Allocate() error {
+	if m.allocated {
+		return nil
+	}
+	if m.location == "" {
+		return errors.New("no language model location set")
+	}
+
+	file, err := os.Open(m.location)
+	if err != nil {
+		return err
+	}
+	m.file = file
+	if err := m.load(); err != nil {
+		file.Close()
+		m.file = nil
+		return fmt.Errorf("%s: %w", m.location, err)
+	}
+
+	m.bigrams = make(map[int][]dmpNgram)
+	m.trigrams = make(map[int][]dmpNgram)
+	m.cache = make(map[string]float32)
+	m.smears = make(map[string]float32)
+	m.allocated = true
+	if m.logger != nil {
+		m.logger.Infof("Loaded %d-gram language model %s with %v n-grams", m.maxNGram, m.location, m.counts)
+	}
+	return nil
+}
+
+// Closes the file and releases the n-grams read.
+func (m *LargeTrigramModel) Deallocate() {
+	if !m.allocated {
+		return
+	}
+	m.file.Close()
+	m.file = nil
+	m.unigrams = nil
+	m.vocabulary = nil
+	m.bigrams = nil
+	m.trigrams = nil
+	m.cache = nil
+	m.smears = nil
+	m.allocated = false
+}
+
+// Returns the maximum depth of the language model.
+func (m *LargeTrigramModel) GetMaxDepth() int {
+	if m.desiredMaxDepth > 0 && m.desiredMaxDepth < m.maxNGram {
+		return m.desiredMaxDepth
+	}
+	return m.maxNGram
+}
+
+// Returns the number of unigrams, bigrams and trigrams of the model.
+func (m *LargeTrigramModel) Counts() []int {
+	return m.counts
+}
+
+// Returns the words of the model, ordered by spelling. The slice is shared and must not be modified.
+func (m *LargeTrigramModel) GetVocabulary() []string {
+	return m.vocabulary
+}
+
+// Gets the probability of the newest word of the sequence given the words before it, backing off to shorter
+// histories. Only the newest words are used if the sequence is longer than the maximum depth.
+func (m *LargeTrigramModel) GetProbability(wordSequence *linguist.WordSequence) float32 {
+	if depth := m.GetMaxDepth(); wordSequence.Size() > depth {
+		wordSequence = wordSequence.Trim(depth)
+	}
+	if wordSequence.Size() == 0 {
+		return util.LOG_ZERO
+	}
+	ids := make([]int, wordSequence.Size())
+	for i, word := range wordSequence.GetWords() {
+		id, ok := m.wordID(word.GetSpelling())
+		if !ok {
+			return util.LOG_ZERO
+		}
+		ids[i] = id
+	}
+
+	key := fmt.Sprint(ids)
+	if logProbability, ok := m.cache[key]; ok {
+		return logProbability
+	}
+	logProbability := m.probability(ids)
+	if len(m.cache) >= m.ngramCacheSize {
+		m.cache = make(map[string]float32)
+	}
+	m.cache[key] = logProbability
+	return logProbability
+}
+
+// Gets the smear term of the newest word of the sequence, the average log probability of the words following it,
+// weighted by their probability. The smear term of the empty sequence is the one of the unigrams.
+func (m *LargeTrigramModel) GetSmear(wordSequence *linguist.WordSequence) float32 {
+	history, historyID := "", 0
+	if wordSequence.Size() > 0 && m.GetMaxDepth() > 1 {
+		id, ok := m.wordID(wordSequence.GetWord(wordSequence.Size() - 1).GetSpelling())
+		if !ok {
+			return 0
+		}
+		history, historyID = m.words[id], id
+	}
+	if smear, ok := m.smears[history]; ok {
+		return smear
+	}
+
+	smear := smearTerm(m.logMath, m.vocabulary, func(word string) float32 {
+		if history == "" {
+			return m.probability([]int{m.wordIDs[word]})
+		}
+		return m.probability([]int{historyID, m.wordIDs[word]})
+	})
+	m.smears[history] = smear
+	return smear
+}
+
+// Clears the cache of n-gram probabilities.
+func (m *LargeTrigramModel) OnUtteranceEnd() {
+	if m.allocated {
+		m.cache = make(map[string]float32)
+	}
+}
+
+func (m *LargeTrigramModel) wordID(spelling string) (int, bool) {
+	if id, ok := m.wordIDs[strings.ToLower(spelling)]; ok {
+		return id, true
+	}
+	if m.unknownID >= 0 {
+		return m.unknownID, true
+	}
+	return 0, false
+}
+
+// Returns the probability of the n-gram of word ids, ordered from the oldest to the newest.
+func (m *LargeTrigramModel) probability(ids []int) float32 {
+	switch len(ids) {
+	case 1:
+		return m.unigrams[ids[0]].logProbability
+	case 2:
+		if bigram, _, ok := m.findBigram(ids[0], ids[1]); ok {
+			return m.bigramProbs[bigram.probID]
+		}
+		return m.unigrams[ids[0]].logBackoff + m.probability(ids[1:])
+	default:
+		bigram, index, ok := m.findBigram(ids[0], ids[1])
+		if !ok {
+			return m.probability(ids[1:])
+		}
+		if trigram, ok := find(m.loadTrigrams(index), uint32(ids[2])); ok {
+			return m.trigramProb[trigram.probID]
+		}
+		return m.bigramBows[bigram.backoffID] + m.probability(ids[1:])
+	}
+}
+
+// Finds the bigram of the two words, returning it with its index in the bigrams of the model.
+func (m *LargeTrigramModel) findBigram(first, second int) (dmpNgram, int, bool) {
+	bigrams := m.loadBigrams(first)
+	if i := sort.Search(len(bigrams), func(i int) bool { return bigrams[i].wordID >= uint32(second) }); i < len(bigrams) && bigrams[i].wordID == uint32(second) {
+		return bigrams[i], int(m.unigrams[first].firstBigram) + i, true
+	}
+	return dmpNgram{}, 0, false
+}
+
+// Finds the n-gram of the word among n-grams sorted by word id.
+func find(ngrams []dmpNgram, wordID uint32) (dmpNgram, bool) {
+	i := sort.Search(len(ngrams), func(i int) bool { return ngrams[i].wordID >= wordID })
+	if i < len(ngrams) && ngrams[i].wordID == wordID {
+		return ngrams[i], true
+	}
+	return dmpNgram{}, false
+}
+
+// Returns the bigrams following the word, reading them if needed.
+func (m *LargeTrigramModel) loadBigrams(wordID int) []dmpNgram {
+	if bigrams, ok := m.bigrams[wordID]; ok {
+		return bigrams
+	}
+	var bigrams []dmpNgram
+	if m.maxNGram > 1 {
+		first, last := m.unigrams[wordID].firstBigram, m.unigrams[wordID+1].firstBigram
+		bigrams = m.readNgrams(m.bigramsAt, int64(first), int(last-first), true)
+	}
+	m.bigrams[wordID] = bigrams
+	return bigrams
+}
+
+// Returns the trigrams following the bigram of the given index, reading them if needed.
+func (m *LargeTrigramModel) loadTrigrams(bigramIndex int) []dmpNgram {
+	if trigrams, ok := m.trigrams[bigramIndex]; ok {
+		return trigrams
+	}
+	var trigrams []dmpNgram
+	if m.maxNGram > 2 {
+		// the bigram and the next one give the range of the trigrams
+		pair := m.readNgrams(m.bigramsAt, int64(bigramIndex), 2, true)
+		if len(pair) == 2 {
+			first := int64(m.segmentBase[bigramIndex>>m.logSegment]) + int64(pair[0].firstTrigram)
+			last := int64(m.segmentBase[(bigramIndex+1)>>m.logSegment]) + int64(pair[1].firstTrigram)
+			trigrams = m.readNgrams(m.trigramsAt, first, int(last-first), false)
+		}
+	}
+	m.trigrams[bigramIndex] = trigrams
+	return trigrams
+}
+
+// Reads count n-grams from the index-th one of the section starting at the given offset. Returns nil on a read
+// error, which is logged: the n-grams are then backed off.
+func (m *LargeTrigramModel) readNgrams(section, index int64, count int, isBigram bool) []dmpNgram {
+	if count <= 0 {
+		return nil
+	}
+	size := m.ngramSize
+	if !isBigram {
+		size /= 2
+	}
+	buf := make([]byte, int64(count)*size)
+	if _, err := m.file.ReadAt(buf, section+index*size); err != nil {
+		if m.logger != nil {
+			m.logger.Errorf("Reading %s: %v", m.location, err)
+		}
+		return nil
+	}
+
+	field := func(b []byte, i int) uint32 {
+		if m.ngramSize == 16 {
+			return m.byteOrder.Uint32(b[i*4:])
+		}
+		return uint32(m.byteOrder.Uint16(b[i*2:]))
+	}
+	ngrams := make([]dmpNgram, count)
+	for i := range ngrams {
+		b := buf[int64(i)*size:]
+		ngrams[i].wordID = field(b, 0)
+		ngrams[i].probID = field(b, 1)
+		if isBigram {
+			ngrams[i].backoffID = field(b, 2)
+			ngrams[i].firstTrigram = field(b, 3)
+		}
+	}
+	return ngrams
+}
+
+// A reader of the fields of a DMP file, keeping the first error.
+type dmpReader struct {
+	r         io.Reader
+	byteOrder binary.ByteOrder
+	offset    int64
+	limit     int64
+	err       error
+}
+
+func (r *dmpReader) bytes(n int64) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || r.offset+n > r.limit {
+		r.err = fmt.Errorf("bad length %d", n)
+		return nil
+	}
+	buf := make([]byte, n)
+	_, r.err = io.ReadFull(r.r, buf)
+	r.offset += n
+	return buf
+}
+
+func (r *dmpReader) int32() int32 {
+	if b := r.bytes(4); b != nil {
+		return int32(r.byteOrder.Uint32(b))
+	}
+	return 0
+}
+
+// Reads the log10 floats of a table into the log domain.
+func (r *dmpReader) table(logMath *util.LogMath) []float32 {
+	b := r.bytes(4 * int64(r.int32()))
+	table := make([]float32, len(b)/4)
+	for i := range table {
+		table[i] = logMath.Log10ToLog(math.Float32frombits(r.byteOrder.Uint32(b[i*4:])))
+	}
+	return table
+}
+
+// Reads the parts of the model that stay in memory.
+func (m *LargeTrigramModel) load() error {
+	info, err := m.file.Stat()
+	if err != nil {
+		return err
+	}
+
+	// the length of the header tells the byte order of the file
+	var probe [4]byte
+	if _, err := m.file.ReadAt(probe[:], 0); err != nil {
+		return errors.New("not a DMP language model")
+	}
+	m.byteOrder = binary.LittleEndian
+	if binary.LittleEndian.Uint32(probe[:]) != uint32(len(dmpHeader)+1) {
+		m.byteOrder = binary.BigEndian
+	}
+
+	r := &dmpReader{r: io.NewSectionReader(m.file, 0, info.Size()), byteOrder: m.byteOrder, limit: info.Size()}
+	if header := r.bytes(int64(r.int32())); r.err != nil || strings.TrimRight(string(header), "\x00") != dmpHeader {
+		return errors.New("not a DMP language model")
+	}
+	r.bytes(int64(r.int32())) // the name of the original file
+
+	// version, time stamp, format description and segment size are only in newer files
+	version := r.int32()
+	unigramCount := version
+	m.logSegment = logBigramSegmentSize
+	if version <= 0 {
+		r.int32() // time stamp
+		for k := r.int32(); k != 0 && r.err == nil; k = r.int32() {
+			r.bytes(int64(k))
+		}
+		if version <= dmpVersion16BitSegment {
+			logSegment := r.int32()
+			if r.err == nil && (logSegment < 1 || logSegment > 15) {
+				return fmt.Errorf("bad trigram segment size 2^%d", logSegment)
+			}
+			m.logSegment = uint(logSegment)
+		}
+		unigramCount = r.int32()
+	} else {
+		version = 0
+	}
+	switch version {
+	case 0, dmpVersion16Bit, dmpVersion16BitSegment:
+		m.ngramSize = 8
+	case dmpVersion32Bit:
+		m.ngramSize = 16
+	default:
+		return fmt.Errorf("unsupported DMP version %d", version)
+	}
+	bigramCount := r.int32()
+	trigramCount := r.int32()
+	if r.err != nil {
+		return r.err
+	}
+	if unigramCount <= 0 || bigramCount < 0 || trigramCount < 0 {
+		return fmt.Errorf("bad n-gram counts %d %d %d", unigramCount, bigramCount, trigramCount)
+	}
+	m.counts = []int{int(unigramCount), int(bigramCount), int(trigramCount)}
+	m.maxNGram = 1
+	if bigramCount > 0 {
+		m.maxNGram = 2
+	}
+	if trigramCount > 0 {
+		m.maxNGram = 3
+	}
+
+	m.unigrams = make([]dmpUnigram, unigramCount+1)
+	b := r.bytes(int64(unigramCount+1) * dmpUnigramSize)
+	if r.err != nil {
+		return r.err
+	}
+	for i := range m.unigrams {
+		u := b[i*dmpUnigramSize:]
+		m.unigrams[i] = dmpUnigram{
+			logProbability: m.logMath.Log10ToLog(math.Float32frombits(m.byteOrder.Uint32(u[4:]))),
+			logBackoff:     m.logMath.Log10ToLog(math.Float32frombits(m.byteOrder.Uint32(u[8:]))),
+			firstBigram:    int32(m.byteOrder.Uint32(u[12:])),
+		}
+	}
+
+	if err := m.loadTail(r.offset, info.Size()); err != nil {
+		return fmt.Errorf("%d bit n-grams of DMP version %d: %w", m.ngramSize*2, version, err)
+	}
+
+	m.wordIDs = make(map[string]int, len(m.words))
+	m.unknownID = -1
+	for id, word := range m.words {
+		m.wordIDs[strings.ToLower(word)] = id
+	}
+	if id, ok := m.wordIDs[UNKNOWN_SPELLING]; ok {
+		m.unknownID = id
+	}
+	m.vocabulary = make([]string, 0, len(m.wordIDs))
+	for word := range m.wordIDs {
+		m.vocabulary = append(m.vocabulary, word)
+	}
+	sort.Strings(m.vocabulary)
+
+	if m.unigramWeight != 1.0 {
+		weight := unigramWeighting(m.logMath, m.unigramWeight, len(m.words))
+		for id, word := range m.words {
+			if word != dictionary.SENTENCE_START_SPELLING {
+				m.unigrams[id].logProbability = weight(m.unigrams[id].logProbability)
+			}
+		}
+	}
+	return nil
+}
+
+// Reads the tables and the words that follow the bigrams and trigrams, with the n-gram size of the version. Fails
+// unless they end the file, as they don't if the version lies about the size of the n-grams.
+func (m *LargeTrigramModel) loadTail(offset, size int64) error {
+	bigramCount, trigramCount := int64(m.counts[1]), int64(m.counts[2])
+	m.bigramsAt = offset
+	if bigramCount > 0 {
+		offset += (bigramCount + 1) * m.ngramSize
+	}
+	m.trigramsAt = offset
+	offset += trigramCount * m.ngramSize / 2
+	if offset > size {
+		return errors.New("truncated DMP language model")
+	}
+
+	r := &dmpReader{r: io.NewSectionReader(m.file, offset, size-offset), byteOrder: m.byteOrder, offset: offset, limit: size}
+	m.bigramProbs, m.bigramBows, m.trigramProb, m.segmentBase = nil, nil, nil, nil
+	if bigramCount > 0 {
+		m.bigramProbs = r.table(m.logMath)
+	}
+	if trigramCount > 0 {
+		m.bigramBows = r.table(m.logMath)
+		m.trigramProb = r.table(m.logMath)
+		b := r.bytes(4 * int64(r.int32()))
+		m.segmentBase = make([]int32, len(b)/4)
+		for i := range m.segmentBase {
+			m.segmentBase[i] = int32(m.byteOrder.Uint32(b[i*4:]))
+		}
+		if r.err == nil && int64(len(m.segmentBase)) <= (bigramCount+1)>>m.logSegment {
+			return errors.New("too few trigram segments")
+		}
+	}
+	words := r.bytes(int64(r.int32()))
+	if r.err != nil {
+		return r.err
+	}
+	if r.offset != size {
+		return fmt.Errorf("%d bytes after the words", size-r.offset)
+	}
+
+	m.words = strings.Split(strings.TrimRight(string(words), "\x00"), "\x00")
+	if len(m.words) != m.counts[0] {
+		return fmt.Errorf("%d words for %d unigrams", len(m.words), m.counts[0])
+	}
+	return nil
+}
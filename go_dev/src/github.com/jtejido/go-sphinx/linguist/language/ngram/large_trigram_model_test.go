@@ -0,0 +1,132 @@
+package ngram
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jtejido/go-sphinx/util"
+)
+
+// writeDMP writes a trigram model in the given DMP version, with 32 bit n-grams if wide.
+func writeDMP(t *testing.T, version int32, wide bool) string {
+	var buf bytes.Buffer
+	write := func(v interface{}) {
+		if err := binary.Write(&buf, binary.LittleEndian, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeString := func(s string) {
+		write(int32(len(s) + 1))
+		buf.WriteString(s)
+		buf.WriteByte(0)
+	}
+	ngram := func(fields ...int) {
+		for _, field := range fields {
+			if wide {
+				write(uint32(field))
+			} else {
+				write(uint16(field))
+			}
+		}
+	}
+
+	writeString(dmpHeader)
+	writeString("test.lm")
+	write(version)
+	write(int32(0))
+	writeString("format")
+	write(int32(0))
+	if version <= dmpVersion16BitSegment {
+		write(int32(logBigramSegmentSize))
+	}
+	write([]int32{5, 3, 1})
+	// mapid, prob, backoff, first bigram of <s> </s> <unk> hello world and the sentinel
+	for _, u := range []struct {
+		prob, bo float32
+		first    int32
+	}{{-1, -0.5, 0}, {-0.5, 0, 1}, {-1.5, 0, 1}, {-0.7, -0.2, 1}, {-0.8, -0.3, 2}, {0, 0, 3}} {
+		write(int32(0))
+		write(u.prob)
+		write(u.bo)
+		write(u.first)
+	}
+	// <s> hello, hello world, world </s> and the sentinel
+	ngram(3, 0, 0, 0)
+	ngram(4, 1, 1, 1)
+	ngram(1, 2, 2, 1)
+	ngram(0, 0, 0, 1)
+	// <s> hello world
+	ngram(4, 0)
+	write(int32(3))
+	write([]float32{-0.3, -0.1, -0.2})
+	write(int32(3))
+	write([]float32{-0.1, -0.4, 0})
+	write(int32(1))
+	write([]float32{-0.05})
+	write(int32(1))
+	write(int32(0))
+	words := "<s>\x00</s>\x00<unk>\x00hello\x00world\x00"
+	write(int32(len(words)))
+	buf.WriteString(words)
+
+	path := filepath.Join(t.TempDir(), "test.lm.dmp")
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLargeTrigramModel(t *testing.T) {
+	logMath := util.GetLogMath()
+	for _, version := range []int32{dmpVersion16Bit, dmpVersion16BitSegment, dmpVersion32Bit} {
+		model := NewDefaultLargeTrigramModel()
+		model.SetLocation(writeDMP(t, version, version == dmpVersion32Bit))
+		if err := model.Allocate(); err != nil {
+			t.Fatal(err)
+		}
+		if model.GetMaxDepth() != 3 {
+			t.Fatalf("max depth %d, expected 3", model.GetMaxDepth())
+		}
+
+		for _, test := range []struct {
+			words []string
+			log10 float64
+		}{
+			{[]string{"world"}, -0.8},
+			{[]string{"hello", "world"}, -0.1},
+			{[]string{"<s>", "hello", "world"}, -0.05},
+			{[]string{"<s>", "hello", "</s>"}, -0.1 - 0.2 - 0.5},
+			{[]string{"world", "hello"}, -0.3 - 0.7},
+			{[]string{"Hello", "sphinx"}, -0.2 - 1.5},
+			{[]string{"<unk>", "hello", "world"}, -0.1},
+		} {
+			expected := logMath.Log10ToLog(float32(test.log10))
+			if p := model.GetProbability(sequence(test.words...)); math.Abs(float64(p-expected)) > 1 {
+				t.Errorf("version %d: probability of %v is %v, expected %v", version, test.words, p, expected)
+			}
+		}
+		model.Deallocate()
+	}
+}
+
+func TestLargeTrigramModelVersion(t *testing.T) {
+	for _, test := range []struct {
+		version int32
+		wide    bool
+	}{
+		// the version doesn't match the width of the n-grams
+		{dmpVersion16Bit, true},
+		{dmpVersion32Bit, false},
+		{-7, false},
+	} {
+		model := NewDefaultLargeTrigramModel()
+		model.SetLocation(writeDMP(t, test.version, test.wide))
+		if err := model.Allocate(); err == nil {
+			t.Errorf("version %d, 32 bits %v: no error", test.version, test.wide)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package ngram
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+const (
+	trieHeader = "Trie Language Model"
+
+	// The number of bits of the quantized probabilities and backoffs.
+	quantProbBits = 16
+	quantBoBits   = 16
+
+	unigramSize = 12
+)
+
+// A unigram of the trie: its probability and backoff weight, and the first of its entries in the bigram array.
+type trieUnigram struct {
+	prob, backoff float32
+	next          uint32
+}
+
+// An array of bit-packed entries, each made of a word id, quantized weights and, but for the longest order, the
+// index of the first of its children in the next array.
+type bitArray struct {
+	data      []byte
+	wordBits  uint
+	quantBits uint
+	nextBits  uint
+	totalBits uint
+	entries   uint32
+}
+
+// Returns the number of bits needed to store values up to maxValue.
+func requiredBits(maxValue uint32) uint {
+	bits := uint(0)
+	for ; maxValue > 0; maxValue >>= 1 {
+		bits++
+	}
+	return bits
+}
+
+// Returns the size in bytes of an array of entries, including the sentinel entry and the padding that lets any
+// field be read as a 64 bit word.
+func bitArraySize(entries, maxVocab uint32, remainingBits uint) int {
+	totalBits := uint64(requiredBits(maxVocab) + remainingBits)
+	return int((uint64(1+entries)*totalBits+7)/8 + 8)
+}
+
+func newBitArray(data []byte, entries, maxVocab uint32, quantBits uint, maxNext uint32, hasNext bool) *bitArray {
+	a := &bitArray{data: data, wordBits: requiredBits(maxVocab), quantBits: quantBits, entries: entries}
+	if hasNext {
+		a.nextBits = requiredBits(maxNext)
+	}
+	a.totalBits = a.wordBits + a.quantBits + a.nextBits
+	return a
+}
+
+// Reads the bits at the given bit offset.
+func (a *bitArray) read(offset uint64, bits uint) uint32 {
+	if bits == 0 {
+		return 0
+	}
+	value := binary.LittleEndian.Uint64(a.data[offset>>3:]) >> (offset & 7)
+	return uint32(value & (1<<bits - 1))
+}
+
+func (a *bitArray) word(index uint32) uint32 {
+	return a.read(uint64(index)*uint64(a.totalBits), a.wordBits)
+}
+
+// Returns the quantized backoff and probability of the entry, the backoff being stored first.
+func (a *bitArray) quant(index uint32) (backoff, prob uint32) {
+	offset := uint64(index)*uint64(a.totalBits) + uint64(a.wordBits)
+	if a.quantBits == quantProbBits {
+		return 0, a.read(offset, quantProbBits)
+	}
+	return a.read(offset, quantBoBits), a.read(offset+quantBoBits, quantProbBits)
+}
+
+// Returns the range of the children of the entry in the next array.
+func (a *bitArray) children(index uint32) (begin, end uint32) {
+	offset := uint64(index)*uint64(a.totalBits) + uint64(a.wordBits+a.quantBits)
+	return a.read(offset, a.nextBits), a.read(offset+uint64(a.totalBits), a.nextBits)
+}
+
+// Finds the entry of the word among the entries [begin, end), which are sorted by word id.
+func (a *bitArray) find(word, begin, end uint32) (uint32, bool) {
+	if end > a.entries {
+		end = a.entries
+	}
+	for begin < end {
+		middle := begin + (end-begin)/2
+		switch w := a.word(middle); {
+		case w == word:
+			return middle, true
+		case w < word:
+			begin = middle + 1
+		default:
+			end = middle
+		}
+	}
+	return 0, false
+}
+
+// The binary trie of a sphinxbase language model, as written by sphinx_lm_convert. The n-grams are keyed by their
+// newest word first, followed by the words of their history from the newest to the oldest, so that the walk from a
+// unigram finds the longest n-gram of a history. Probabilities and backoffs are log10, quantized to 16 bits for the
+// n-grams above unigrams.
+type ngramTrie struct {
+	order    int
+	counts   []uint32
+	unigrams []trieUnigram
+	// the quantization tables, probabilities and backoffs of each middle order followed by the probabilities of the
+	// longest one
+	probBins    [][]float32
+	backoffBins [][]float32
+	middles     []*bitArray
+	longest     *bitArray
+	words       []string
+}
+
+type trieReader struct {
+	data   []byte
+	offset int
+	err    error
+}
+
+func (r *trieReader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || r.offset+n > len(r.data) {
+		r.err = errors.New("truncated trie language model")
+		return nil
+	}
+	b := r.data[r.offset : r.offset+n]
+	r.offset += n
+	return b
+}
+
+func (r *trieReader) uint32() uint32 {
+	if b := r.bytes(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *trieReader) float32() float32 {
+	return math.Float32frombits(r.uint32())
+}
+
+func (r *trieReader) floats(n int) []float32 {
+	floats := make([]float32, n)
+	for i := range floats {
+		floats[i] = r.float32()
+	}
+	return floats
+}
+
+// Reads a trie from the bytes of a binary language model. The bit-packed arrays are not copied.
+func readNgramTrie(data []byte) (*ngramTrie, error) {
+	r := &trieReader{data: data}
+	if header := r.bytes(len(trieHeader)); r.err != nil || string(header) != trieHeader {
+		return nil, errors.New("not a trie language model")
+	}
+
+	t := &ngramTrie{}
+	if b := r.bytes(1); b != nil {
+		t.order = int(b[0])
+	}
+	if r.err == nil && t.order == 0 {
+		return nil, errors.New("language model of order 0")
+	}
+	t.counts = make([]uint32, t.order)
+	for i := range t.counts {
+		t.counts[i] = r.uint32()
+	}
+
+	if t.order > 1 {
+		r.uint32() // quantization type
+		t.probBins = make([][]float32, t.order-1)
+		t.backoffBins = make([][]float32, t.order-2)
+		for i := 0; i < t.order-2; i++ {
+			t.probBins[i] = r.floats(1 << quantProbBits)
+			t.backoffBins[i] = r.floats(1 << quantBoBits)
+		}
+		t.probBins[t.order-2] = r.floats(1 << quantProbBits)
+	}
+
+	t.unigrams = make([]trieUnigram, t.counts[0]+1)
+	unigrams := r.bytes(len(t.unigrams) * unigramSize)
+	for i := range t.unigrams {
+		if unigrams == nil {
+			break
+		}
+		b := unigrams[i*unigramSize:]
+		t.unigrams[i] = trieUnigram{
+			prob:    math.Float32frombits(binary.LittleEndian.Uint32(b)),
+			backoff: math.Float32frombits(binary.LittleEndian.Uint32(b[4:])),
+			next:    binary.LittleEndian.Uint32(b[8:]),
+		}
+	}
+
+	for order := 2; order < t.order; order++ {
+		entries, next := t.counts[order-1], t.counts[order]
+		size := bitArraySize(entries, t.counts[0], quantProbBits+quantBoBits+requiredBits(next))
+		t.middles = append(t.middles, newBitArray(r.bytes(size), entries, t.counts[0], quantProbBits+quantBoBits, next, true))
+	}
+	if t.order > 1 {
+		entries := t.counts[t.order-1]
+		t.longest = newBitArray(r.bytes(bitArraySize(entries, t.counts[0], quantProbBits)), entries, t.counts[0], quantProbBits, 0, false)
+	}
+
+	length := int(r.uint32())
+	words := r.bytes(length)
+	if r.err != nil {
+		return nil, r.err
+	}
+	t.words = make([]string, 0, t.counts[0])
+	for start, i := 0, 0; i < len(words) && len(t.words) < int(t.counts[0]); i++ {
+		if words[i] == 0 {
+			t.words = append(t.words, string(words[start:i]))
+			start = i + 1
+		}
+	}
+	if len(t.words) != int(t.counts[0]) {
+		return nil, fmt.Errorf("%d words for %d unigrams", len(t.words), t.counts[0])
+	}
+	return t, nil
+}
+
+// Returns the log10 probability of the word after the history, given as word ids from the newest to the oldest,
+// and the number of words of the history it used.
+func (t *ngramTrie) histScore(word uint32, history []uint32) (float32, int) {
+	prob := t.unigrams[word].prob
+	begin, end := t.unigrams[word].next, t.unigrams[word+1].next
+	for i, h := range history {
+		if i == len(t.middles) {
+			index, ok := t.longest.find(h, begin, end)
+			if !ok {
+				return prob, i
+			}
+			_, quantProb := t.longest.quant(index)
+			return t.probBins[t.order-2][quantProb], i + 1
+		}
+		middle := t.middles[i]
+		index, ok := middle.find(h, begin, end)
+		if !ok {
+			return prob, i
+		}
+		_, quantProb := middle.quant(index)
+		prob = t.probBins[i][quantProb]
+		begin, end = middle.children(index)
+	}
+	return prob, len(history)
+}
+
+// Returns the sum of the log10 backoffs of the histories longer than the used words, the history given as word ids
+// from the newest to the oldest.
+func (t *ngramTrie) backoff(history []uint32, used int) float32 {
+	backoff := float32(0)
+	unigram := t.unigrams[history[0]]
+	if used == 0 {
+		backoff += unigram.backoff
+	}
+	begin, end := unigram.next, t.unigrams[history[0]+1].next
+	for i := 1; i < len(history) && i-1 < len(t.middles); i++ {
+		middle := t.middles[i-1]
+		index, ok := middle.find(history[i], begin, end)
+		if !ok {
+			break
+		}
+		if i >= used {
+			quantBackoff, _ := middle.quant(index)
+			backoff += t.backoffBins[i-1][quantBackoff]
+		}
+		begin, end = middle.children(index)
+	}
+	return backoff
+}
@@ -0,0 +1,304 @@
+package ngram
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jtejido/go-sphinx/linguist"
+	"github.com/jtejido/go-sphinx/linguist/dictionary"
+	"github.com/jtejido/go-sphinx/util"
+)
+
+// The probability and backoff weight of an n-gram, in the log domain.
+type probability struct {
+	logProbability float32
+	logBackoff     float32
+}
+
+// An ASCII ARPA language model of any order, held in memory. It is meant for small models: every n-gram of the file
+// is kept in a map.
+//
+// The n-grams missing from the model are backed off to shorter ones, weighted by the backoff weight of their
+// history. Words are folded to lower case, as the dictionary does by default, and the words out of the vocabulary
+// of the model are looked up as <unk> if the model has it.
+type SimpleNGramModel struct {
+	location        string
+	unigramWeight   float64
+	desiredMaxDepth int
+	logger          util.Logger
+
+	logMath   *util.LogMath
+	allocated bool
+	maxNGram  int
+	ngramLogs map[string]*probability
+	counts    []int
+	hasUnk    bool
+	smears    map[string]float32
+
+	// the unigrams, ordered by spelling, as GetVocabulary and the smear terms need them
+	vocabulary []string
+}
+
+// Creates a SimpleNGramModel without a location, to be set with SetLocation before allocation.
+func NewDefaultSimpleNGramModel() *SimpleNGramModel {
+	return NewSimpleNGramModel("", DEFAULT_UNIGRAM_WEIGHT, DEFAULT_MAX_DEPTH, nil)
+}
+
+// Creates a SimpleNGramModel.
+//
+// Accepts the path of the ARPA file, the weight of the unigrams, the maximum depth used, -1 for the order of the
+// model, and an optional logger.
+func NewSimpleNGramModel(location string, unigramWeight float64, desiredMaxDepth int, logger util.Logger) *SimpleNGramModel {
+	return &SimpleNGramModel{
+		location:        location,
+		unigramWeight:   unigramWeight,
+		desiredMaxDepth: desiredMaxDepth,
+		logger:          logger,
+		logMath:         util.GetLogMath(),
+	}
+}
+
+// Sets the path of the ARPA file, read at the next allocation.
+func (m *SimpleNGramModel) SetLocation(location string) {
+	m.location = location
+}
+
+// Reads the ARPA file. Does nothing if the model is already allocated.
+func (m *SimpleNGramModel) Allocate() error {
+	if m.allocated {
+		return nil
+	}
+	if m.location == "" {
+		return errors.New("no language model location set")
+	}
+
+	file, err := os.Open(m.location)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	if err := m.load(file); err != nil {
+		return fmt.Errorf("%s: %w", m.location, err)
+	}
+	m.vocabulary = make([]string, 0, m.unigramCount())
+	for ngram := range m.ngramLogs {
+		if !strings.Contains(ngram, " ") {
+			m.vocabulary = append(m.vocabulary, ngram)
+		}
+	}
+	sort.Strings(m.vocabulary)
+	m.allocated = true
+	return nil
+}
+
+// Releases the n-grams of the model.
+func (m *SimpleNGramModel) Deallocate() {
+	m.ngramLogs = nil
+	m.smears = nil
+	m.vocabulary = nil
+	m.allocated = false
+}
+
+// Returns the maximum depth of the language model.
+func (m *SimpleNGramModel) GetMaxDepth() int {
+	if m.desiredMaxDepth > 0 && m.desiredMaxDepth < m.maxNGram {
+		return m.desiredMaxDepth
+	}
+	return m.maxNGram
+}
+
+// Returns the number of n-grams of each order, unigrams first.
+func (m *SimpleNGramModel) Counts() []int {
+	return m.counts
+}
+
+// Returns the words of the model, ordered by spelling. The slice is shared and must not be modified.
+func (m *SimpleNGramModel) GetVocabulary() []string {
+	return m.vocabulary
+}
+
+// Gets the probability of the newest word of the sequence given the words before it, backing off to shorter
+// histories. Only the newest words are used if the sequence is longer than the maximum depth.
+func (m *SimpleNGramModel) GetProbability(wordSequence *linguist.WordSequence) float32 {
+	return m.probability(m.spellings(wordSequence))
+}
+
+// Gets the smear term of the newest word of the sequence, the average log probability of the words following it,
+// weighted by their probability. The smear term of the empty sequence is the one of the unigrams.
+func (m *SimpleNGramModel) GetSmear(wordSequence *linguist.WordSequence) float32 {
+	history := ""
+	if wordSequence.Size() > 0 && m.maxNGram > 1 {
+		history = m.spelling(wordSequence.GetWord(wordSequence.Size() - 1))
+	}
+	if smear, ok := m.smears[history]; ok {
+		return smear
+	}
+
+	smear := smearTerm(m.logMath, m.vocabulary, func(word string) float32 {
+		if history == "" {
+			return m.probability([]string{word})
+		}
+		return m.probability([]string{history, word})
+	})
+	if m.smears == nil {
+		m.smears = make(map[string]float32)
+	}
+	m.smears[history] = smear
+	return smear
+}
+
+// Does nothing, the model has no cache to clear.
+func (m *SimpleNGramModel) OnUtteranceEnd() {}
+
+// Returns the spellings of the newest words of the sequence, the words out of the vocabulary replaced by <unk>.
+func (m *SimpleNGramModel) spellings(wordSequence *linguist.WordSequence) []string {
+	if depth := m.GetMaxDepth(); wordSequence.Size() > depth {
+		wordSequence = wordSequence.Trim(depth)
+	}
+	spellings := make([]string, wordSequence.Size())
+	for i, word := range wordSequence.GetWords() {
+		spellings[i] = m.spelling(word)
+	}
+	return spellings
+}
+
+func (m *SimpleNGramModel) spelling(word *dictionary.Word) string {
+	spelling := strings.ToLower(word.GetSpelling())
+	if _, ok := m.ngramLogs[spelling]; !ok && m.hasUnk {
+		return UNKNOWN_SPELLING
+	}
+	return spelling
+}
+
+func (m *SimpleNGramModel) probability(words []string) float32 {
+	if len(words) == 0 {
+		return util.LOG_ZERO
+	}
+	if ngram, ok := m.ngramLogs[strings.Join(words, " ")]; ok {
+		return ngram.logProbability
+	}
+	if len(words) == 1 {
+		return util.LOG_ZERO
+	}
+	return m.backoff(words[:len(words)-1]) + m.probability(words[1:])
+}
+
+func (m *SimpleNGramModel) backoff(words []string) float32 {
+	if ngram, ok := m.ngramLogs[strings.Join(words, " ")]; ok {
+		return ngram.logBackoff
+	}
+	return util.LOG_ONE
+}
+
+func (m *SimpleNGramModel) unigramCount() int {
+	if len(m.counts) == 0 {
+		return 0
+	}
+	return m.counts[0]
+}
+
+// Reads an ARPA language model: the counts of the \data\ section, followed by a section of "log10(p) w1 ... wn
+// [log10(backoff)]" lines for each order, up to \end\.
+func (m *SimpleNGramModel) load(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	lineNumber := 0
+	next := func() (string, bool) {
+		for scanner.Scan() {
+			lineNumber++
+			if line := strings.TrimSpace(scanner.Text()); line != "" {
+				return line, true
+			}
+		}
+		return "", false
+	}
+
+	// skip the header up to \data\
+	line, ok := next()
+	for ok && line != "\\data\\" {
+		line, ok = next()
+	}
+	if !ok {
+		return errors.New("no \\data\\ section")
+	}
+
+	m.counts = nil
+	for line, ok = next(); ok && strings.HasPrefix(line, "ngram "); line, ok = next() {
+		var order, count int
+		if _, err := fmt.Sscanf(line, "ngram %d=%d", &order, &count); err != nil || order != len(m.counts)+1 {
+			return fmt.Errorf("line %d: bad ngram count %q", lineNumber, line)
+		}
+		m.counts = append(m.counts, count)
+	}
+	if len(m.counts) == 0 {
+		return errors.New("no ngram counts")
+	}
+
+	m.maxNGram = len(m.counts)
+	m.ngramLogs = make(map[string]*probability)
+	m.smears = nil
+	for order := 1; order <= m.maxNGram; order++ {
+		if !ok || line != fmt.Sprintf("\\%d-grams:", order) {
+			return fmt.Errorf("line %d: expected the \\%d-grams: section, got %q", lineNumber, order, line)
+		}
+		read := 0
+		for line, ok = next(); ok && !strings.HasPrefix(line, "\\"); line, ok = next() {
+			fields := strings.Fields(line)
+			if len(fields) != order+1 && len(fields) != order+2 {
+				return fmt.Errorf("line %d: bad %d-gram %q", lineNumber, order, line)
+			}
+			ngram := &probability{}
+			logProbability, err := strconv.ParseFloat(fields[0], 32)
+			if err != nil {
+				return fmt.Errorf("line %d: %v", lineNumber, err)
+			}
+			ngram.logProbability = m.logMath.Log10ToLog(float32(logProbability))
+			if len(fields) == order+2 {
+				logBackoff, err := strconv.ParseFloat(fields[order+1], 32)
+				if err != nil {
+					return fmt.Errorf("line %d: %v", lineNumber, err)
+				}
+				ngram.logBackoff = m.logMath.Log10ToLog(float32(logBackoff))
+			}
+			m.ngramLogs[strings.ToLower(strings.Join(fields[1:order+1], " "))] = ngram
+			read++
+		}
+		if read != m.counts[order-1] {
+			return fmt.Errorf("%d %d-grams read, %d expected", read, order, m.counts[order-1])
+		}
+	}
+	if !ok || line != "\\end\\" {
+		return fmt.Errorf("line %d: expected \\end\\", lineNumber)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	_, m.hasUnk = m.ngramLogs[UNKNOWN_SPELLING]
+	m.applyUnigramWeight()
+	if m.logger != nil {
+		m.logger.Infof("Loaded %d-gram language model %s with %v n-grams", m.maxNGram, m.location, m.counts)
+	}
+	return nil
+}
+
+// Interpolates the unigram probabilities with a uniform distribution over the vocabulary, according to the unigram
+// weight. The sentence start word, which is never predicted, is left untouched.
+func (m *SimpleNGramModel) applyUnigramWeight() {
+	if m.unigramWeight == 1.0 {
+		return
+	}
+	weight := unigramWeighting(m.logMath, m.unigramWeight, m.unigramCount())
+	for spelling, ngram := range m.ngramLogs {
+		if !strings.Contains(spelling, " ") && spelling != dictionary.SENTENCE_START_SPELLING {
+			ngram.logProbability = weight(ngram.logProbability)
+		}
+	}
+}
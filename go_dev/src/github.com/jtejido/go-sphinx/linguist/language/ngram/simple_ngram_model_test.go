@@ -0,0 +1,83 @@
+package ngram
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jtejido/go-sphinx/linguist"
+	"github.com/jtejido/go-sphinx/linguist/dictionary"
+	"github.com/jtejido/go-sphinx/util"
+)
+
+const arpa = `This is a header
+\data\
+ngram 1=5
+ngram 2=3
+
+\1-grams:
+-1.0 <s> -0.5
+-0.5 </s>
+-0.7 HELLO -0.2
+-0.8 world -0.3
+-1.5 <unk>
+
+\2-grams:
+-0.3 <s> hello
+-0.1 hello world
+-0.2 world </s>
+
+\end\
+`
+
+func sequence(spellings ...string) *linguist.WordSequence {
+	words := make([]*dictionary.Word, len(spellings))
+	for i, spelling := range spellings {
+		words[i] = dictionary.NewWord(spelling, nil, false)
+	}
+	return linguist.NewWordSequenceByWordSlice(words)
+}
+
+func TestSimpleNGramModel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.lm")
+	if err := os.WriteFile(path, []byte(arpa), 0644); err != nil {
+		t.Fatal(err)
+	}
+	model := NewDefaultSimpleNGramModel()
+	model.SetLocation(path)
+	if err := model.Allocate(); err != nil {
+		t.Fatal(err)
+	}
+	if model.GetMaxDepth() != 2 {
+		t.Fatalf("max depth %d, expected 2", model.GetMaxDepth())
+	}
+	if vocabulary := model.GetVocabulary(); len(vocabulary) != 5 || vocabulary[2] != "<unk>" || vocabulary[3] != "hello" {
+		t.Fatalf("unexpected vocabulary %v", vocabulary)
+	}
+
+	logMath := util.GetLogMath()
+	for _, test := range []struct {
+		words []string
+		log10 float64
+	}{
+		{[]string{"hello"}, -0.7},
+		{[]string{"<s>", "Hello"}, -0.3},
+		{[]string{"hello", "world"}, -0.1},
+		// backed off with the weight of hello
+		{[]string{"hello", "</s>"}, -0.2 - 0.5},
+		// unknown words are <unk>
+		{[]string{"hello", "sphinx"}, -0.2 - 1.5},
+		// only the newest words count
+		{[]string{"<s>", "hello", "world"}, -0.1},
+	} {
+		expected := logMath.Log10ToLog(float32(test.log10))
+		if p := model.GetProbability(sequence(test.words...)); math.Abs(float64(p-expected)) > 1 {
+			t.Errorf("probability of %v is %v, expected %v", test.words, p, expected)
+		}
+	}
+
+	if smear := model.GetSmear(sequence("hello")); smear >= 0 || smear < logMath.Log10ToLog(-1.5) {
+		t.Errorf("unexpected smear %v", smear)
+	}
+}
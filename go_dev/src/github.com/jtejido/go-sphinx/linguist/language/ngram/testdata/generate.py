@@ -0,0 +1,177 @@
+#!/usr/bin/env python3
+"""Generates test.lm.bin, the binary trie of the ARPA model test.lm.
+
+The trie is written the way sphinx_lm_convert of sphinxbase writes it (ngram_model_trie.c, lm_trie.c and
+lm_trie_quant.c), ported below from the description of the format rather than from the Go reader:
+
+    "Trie Language Model"          the header, without a terminating zero
+    uint8 order, uint32 counts[order]
+    int32 quantization type         ignored by the readers
+    float32 tables                  for every middle order the probability then the backoff bins, then the
+                                    probability bins of the longest order, 2^16 bins each
+    unigrams[count + 1]             float32 prob, float32 backoff, uint32 first child; the last one a sentinel
+    middle arrays, longest array    bit-packed entries, least significant bit first
+    uint32 length, words            the words by id, each followed by a zero
+
+The n-grams are reversed, keyed by their newest word, so that the children of a unigram are the bigrams ending with
+it. An entry holds the id of its word, the quantized backoff and probability, the backoff in the low bits, and but for
+the longest order the index of its first child; every array but the longest ends with a sentinel child index. Words
+take their ids from their order in the \\1-grams: section. Probabilities and backoffs stay log10.
+
+The quantization follows make_bins and bins_encode of lm_trie_quant.c: the sorted values are split into 2^16 bins of
+equal counts, each the mean of its values, and a value is encoded as the nearest bin. With fewer values than bins,
+every value gets a bin of its own and decodes exactly.
+
+sphinx_lm_convert was not at hand when the file was made. To check the port against a real sphinxbase, regenerate
+the model with
+
+    sphinx_lm_convert -i test.lm -o test.lm.bin
+"""
+
+import struct
+
+PROB_BITS = 16
+BO_BITS = 16
+
+
+def f32(x):
+    return struct.unpack('<f', struct.pack('<f', x))[0]
+
+
+def read_arpa(path):
+    """Returns the words in file order and, for each order, the n-grams as (words, prob, backoff)."""
+    orders = []
+    section = None
+    with open(path) as f:
+        for line in f:
+            line = line.strip()
+            if not line or line.startswith('ngram ') or line in ('\\data\\', '\\end\\'):
+                continue
+            if line.endswith('-grams:'):
+                section = []
+                orders.append(section)
+                continue
+            fields = line.split()
+            n = len(orders)
+            words = tuple(fields[1:n + 1])
+            backoff = float(fields[n + 1]) if len(fields) == n + 2 else 0.0
+            section.append((words, float(fields[0]), backoff))
+    return [ngram[0][0] for ngram in orders[0]], orders
+
+
+def make_bins(values, bins):
+    values = sorted(f32(v) for v in values)
+    centers = []
+    start = 0
+    for i in range(bins):
+        finish = len(values) * (i + 1) // bins
+        if finish == start:
+            centers.append(centers[-1] if i else -3.4028234663852886e38)
+        else:
+            centers.append(f32(sum(values[start:finish]) / (finish - start)))
+        start = finish
+    return centers
+
+
+def bins_encode(centers, value):
+    value = f32(value)
+    lo, hi = 0, len(centers)
+    while lo < hi:  # lower_bound
+        mid = (lo + hi) // 2
+        if centers[mid] < value:
+            lo = mid + 1
+        else:
+            hi = mid
+    if lo == 0:
+        return 0
+    if lo == len(centers):
+        return len(centers) - 1
+    return lo - (1 if value - centers[lo - 1] < centers[lo] - value else 0)
+
+
+def required_bits(max_value):
+    bits = 0
+    while max_value:
+        bits += 1
+        max_value >>= 1
+    return bits
+
+
+class BitArray:
+    def __init__(self, entries, total_bits):
+        # one more entry for the sentinel, and a word of padding
+        self.data = bytearray(((1 + entries) * total_bits + 7) // 8 + 8)
+
+    def write(self, offset, bits, value):
+        for i in range(bits):
+            if value >> i & 1:
+                bit = offset + i
+                self.data[bit >> 3] |= 1 << (bit & 7)
+
+
+def main():
+    words, orders = read_arpa('test.lm')
+    ids = {word: i for i, word in enumerate(words)}
+    order = len(orders)
+    counts = [len(ngrams) for ngrams in orders]
+
+    # reversed n-grams, sorted by their keys
+    keyed = []
+    for ngrams in orders:
+        keyed.append(sorted((tuple(ids[w] for w in reversed(ngram)), prob, backoff)
+                            for ngram, prob, backoff in ngrams))
+
+    tables = []
+    for n in range(2, order):
+        tables.append(make_bins([prob for _, prob, _ in keyed[n - 1]], 1 << PROB_BITS))
+        tables.append(make_bins([backoff for _, _, backoff in keyed[n - 1]], 1 << BO_BITS))
+    tables.append(make_bins([prob for _, prob, _ in keyed[order - 1]], 1 << PROB_BITS))
+
+    def first_child(n, key):
+        """The index of the first (n + 1)-gram extending the key of an n-gram."""
+        return sum(1 for child, _, _ in keyed[n] if child[:n] < key)
+
+    out = bytearray(b'Trie Language Model')
+    out += struct.pack('<B', order)
+    out += struct.pack('<%dI' % order, *counts)
+    out += struct.pack('<i', 1)
+    for table in tables:
+        out += struct.pack('<%df' % len(table), *table)
+
+    unigrams = {key[0]: (prob, backoff) for key, prob, backoff in keyed[0]}
+    for word in range(len(words)):
+        prob, backoff = unigrams[word]
+        out += struct.pack('<ffI', prob, backoff, first_child(1, (word,)) if order > 1 else 0)
+    out += struct.pack('<ffI', 0, 0, counts[1] if order > 1 else 0)
+
+    word_bits = required_bits(counts[0])
+    for n in range(2, order + 1):
+        longest = n == order
+        quant_bits = PROB_BITS if longest else PROB_BITS + BO_BITS
+        next_bits = 0 if longest else required_bits(counts[n])
+        total_bits = word_bits + quant_bits + next_bits
+        array = BitArray(counts[n - 1], total_bits)
+        prob_bins = tables[-1] if longest else tables[2 * (n - 2)]
+        for i, (key, prob, backoff) in enumerate(keyed[n - 1]):
+            offset = i * total_bits
+            array.write(offset, word_bits, key[-1])
+            if longest:
+                array.write(offset + word_bits, PROB_BITS, bins_encode(prob_bins, prob))
+                continue
+            quant = bins_encode(prob_bins, prob) << BO_BITS | bins_encode(tables[2 * (n - 2) + 1], backoff)
+            array.write(offset + word_bits, quant_bits, quant)
+            array.write(offset + word_bits + quant_bits, next_bits, first_child(n, key))
+        if not longest:
+            array.write(counts[n - 1] * total_bits + word_bits + quant_bits, next_bits, counts[n])
+        out += array.data
+
+    vocabulary = b''.join(word.encode() + b'\0' for word in words)
+    out += struct.pack('<I', len(vocabulary))
+    out += vocabulary
+
+    with open('test.lm.bin', 'wb') as f:
+        f.write(out)
+
+
+if __name__ == '__main__':
+    main()
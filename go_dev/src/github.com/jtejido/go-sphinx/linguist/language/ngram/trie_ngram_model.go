@@ -0,0 +1,270 @@
+package ngram
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/jtejido/go-sphinx/linguist"
+	"github.com/jtejido/go-sphinx/linguist/dictionary"
+	"github.com/jtejido/go-sphinx/util"
+)
+
+const (
+	// The default maximum number of n-gram probabilities cached between two utterances.
+	DEFAULT_NGRAM_CACHE_SIZE = 100000
+)
+
+// A language model in the binary trie format of sphinxbase, as produced by sphinx_lm_convert from an ARPA file. It
+// is meant for the large models that do not fit a SimpleNGramModel.
+//
+// The file is memory-mapped and the n-grams stay in their bit-packed arrays: only the unigrams and the quantization
+// tables are decoded at allocation. A lookup walks the trie from the newest word through its history, which takes
+// O(order · log V). The probabilities of the n-grams looked up are cached until the end of the utterance.
+//
+// As with the SimpleNGramModel, words are folded to lower case and the words out of the vocabulary are looked up as
+// <unk> if the model has it.
+type TrieNgramModel struct {
+	location        string
+	unigramWeight   float64
+	desiredMaxDepth int
+	ngramCacheSize  int
+	logger          util.Logger
+
+	logMath   *util.LogMath
+	allocated bool
+	unmap     func() error
+	trie      *ngramTrie
+	unigrams  []float32
+	wordIDs   map[string]uint32
+	unknownID int64
+	cache     map[string]float32
+	smears    map[string]float32
+
+	// the words, ordered by spelling, as GetVocabulary and the smear terms need them
+	vocabulary []string
+}
+
+// Creates a TrieNgramModel without a location, to be set with SetLocation before allocation.
+func NewDefaultTrieNgramModel() *TrieNgramModel {
+	return NewTrieNgramModel("", DEFAULT_UNIGRAM_WEIGHT, DEFAULT_MAX_DEPTH, DEFAULT_NGRAM_CACHE_SIZE, nil)
+}
+
+// Creates a TrieNgramModel.
+//
+// Accepts the path of the binary model, the weight of the unigrams, the maximum depth used, -1 for the order of the
+// model, the maximum number of cached probabilities and an optional logger.
+func NewTrieNgramModel(location string, unigramWeight float64, desiredMaxDepth, ngramCacheSize int, logger util.Logger) *TrieNgramModel {
+	return &TrieNgramModel{
+		location:        location,
+		unigramWeight:   unigramWeight,
+		desiredMaxDepth: desiredMaxDepth,
+		ngramCacheSize:  ngramCacheSize,
+		logger:          logger,
+		logMath:         util.GetLogMath(),
+	}
+}
+
+// Sets the path of the binary model, mapped at the next allocation.
+func (m *TrieNgramModel) SetLocation(location string) {
+	m.location = location
+}
+
+// Maps the binary model into memory. Does nothing if the model is already allocated.
+func (m *TrieNgramModel) Allocate() error {
+	if m.allocated {
+		return nil
+	}
+	if m.location == "" {
+		return errors.New("no language model location set")
+	}
+
+	file, err := os.Open(m.location)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	data, unmap, err := mapFile(file)
+	if err != nil {
+		return fmt.Errorf("%s: %w", m.location, err)
+	}
+	trie, err := readNgramTrie(data)
+	if err != nil {
+		unmap()
+		return fmt.Errorf("%s: %w", m.location, err)
+	}
+
+	m.trie = trie
+	m.unmap = unmap
+	m.wordIDs = make(map[string]uint32, len(trie.words))
+	for id, word := range trie.words {
+		m.wordIDs[strings.ToLower(word)] = uint32(id)
+	}
+	m.vocabulary = make([]string, 0, len(m.wordIDs))
+	for word := range m.wordIDs {
+		m.vocabulary = append(m.vocabulary, word)
+	}
+	sort.Strings(m.vocabulary)
+	m.unknownID = -1
+	if id, ok := m.wordIDs[UNKNOWN_SPELLING]; ok {
+		m.unknownID = int64(id)
+	}
+
+	weight := func(logProbability float32) float32 { return logProbability }
+	if m.unigramWeight != 1.0 {
+		weight = unigramWeighting(m.logMath, m.unigramWeight, len(trie.words))
+	}
+	m.unigrams = make([]float32, len(trie.words))
+	for id, word := range trie.words {
+		m.unigrams[id] = m.logMath.Log10ToLog(trie.unigrams[id].prob)
+		if word != dictionary.SENTENCE_START_SPELLING {
+			m.unigrams[id] = weight(m.unigrams[id])
+		}
+	}
+
+	m.cache = make(map[string]float32)
+	m.smears = make(map[string]float32)
+	m.allocated = true
+	if m.logger != nil {
+		m.logger.Infof("Mapped %d-gram language model %s with %v n-grams", trie.order, m.location, trie.counts)
+	}
+	return nil
+}
+
+// Unmaps the model.
+func (m *TrieNgramModel) Deallocate() {
+	if !m.allocated {
+		return
+	}
+	if err := m.unmap(); err != nil && m.logger != nil {
+		m.logger.Warnf("Unmapping %s: %v", m.location, err)
+	}
+	m.trie = nil
+	m.unigrams = nil
+	m.wordIDs = nil
+	m.vocabulary = nil
+	m.cache = nil
+	m.smears = nil
+	m.allocated = false
+}
+
+// Returns the maximum depth of the language model.
+func (m *TrieNgramModel) GetMaxDepth() int {
+	if m.trie == nil {
+		return 0
+	}
+	if m.desiredMaxDepth > 0 && m.desiredMaxDepth < m.trie.order {
+		return m.desiredMaxDepth
+	}
+	return m.trie.order
+}
+
+// Returns the number of n-grams of each order, unigrams first.
+func (m *TrieNgramModel) Counts() []uint32 {
+	return m.trie.counts
+}
+
+// Returns the words of the model, ordered by spelling. The slice is shared and must not be modified.
+func (m *TrieNgramModel) GetVocabulary() []string {
+	return m.vocabulary
+}
+
+// Gets the probability of the newest word of the sequence given the words before it, backing off to shorter
+// histories. Only the newest words are used if the sequence is longer than the maximum depth.
+func (m *TrieNgramModel) GetProbability(wordSequence *linguist.WordSequence) float32 {
+	if depth := m.GetMaxDepth(); wordSequence.Size() > depth {
+		wordSequence = wordSequence.Trim(depth)
+	}
+	if wordSequence.Size() == 0 {
+		return util.LOG_ZERO
+	}
+	ids := make([]uint32, wordSequence.Size())
+	for i, word := range wordSequence.GetWords() {
+		id, ok := m.wordID(word.GetSpelling())
+		if !ok {
+			return util.LOG_ZERO
+		}
+		ids[i] = id
+	}
+	return m.probability(ids)
+}
+
+// Gets the smear term of the newest word of the sequence, the average log probability of the words following it,
+// weighted by their probability. The smear term of the empty sequence is the one of the unigrams.
+func (m *TrieNgramModel) GetSmear(wordSequence *linguist.WordSequence) float32 {
+	history, historyID := "", uint32(0)
+	if wordSequence.Size() > 0 && m.GetMaxDepth() > 1 {
+		id, ok := m.wordID(wordSequence.GetWord(wordSequence.Size() - 1).GetSpelling())
+		if !ok {
+			return 0
+		}
+		history, historyID = m.trie.words[id], id
+	}
+	if smear, ok := m.smears[history]; ok {
+		return smear
+	}
+
+	smear := smearTerm(m.logMath, m.vocabulary, func(word string) float32 {
+		if history == "" {
+			return m.probability([]uint32{m.wordIDs[word]})
+		}
+		return m.probability([]uint32{historyID, m.wordIDs[word]})
+	})
+	m.smears[history] = smear
+	return smear
+}
+
+// Clears the cache of n-gram probabilities.
+func (m *TrieNgramModel) OnUtteranceEnd() {
+	if m.allocated {
+		m.cache = make(map[string]float32)
+	}
+}
+
+func (m *TrieNgramModel) wordID(spelling string) (uint32, bool) {
+	if id, ok := m.wordIDs[strings.ToLower(spelling)]; ok {
+		return id, true
+	}
+	if m.unknownID >= 0 {
+		return uint32(m.unknownID), true
+	}
+	return 0, false
+}
+
+// Returns the probability of the n-gram of word ids, ordered from the oldest to the newest.
+func (m *TrieNgramModel) probability(ids []uint32) float32 {
+	if len(ids) == 1 {
+		return m.unigrams[ids[0]]
+	}
+
+	key := fmt.Sprint(ids)
+	if logProbability, ok := m.cache[key]; ok {
+		return logProbability
+	}
+
+	history := make([]uint32, len(ids)-1)
+	for i := range history {
+		history[i] = ids[len(ids)-2-i]
+	}
+	word := ids[len(ids)-1]
+	prob, used := m.trie.histScore(word, history)
+	var logProbability float32
+	if used == 0 {
+		// the unigram probability carries the unigram weight
+		logProbability = m.unigrams[word]
+	} else {
+		logProbability = m.logMath.Log10ToLog(prob)
+	}
+	if used < len(history) {
+		logProbability += m.logMath.Log10ToLog(m.trie.backoff(history, used))
+	}
+
+	if len(m.cache) >= m.ngramCacheSize {
+		m.cache = make(map[string]float32)
+	}
+	m.cache[key] = logProbability
+	return logProbability
+}
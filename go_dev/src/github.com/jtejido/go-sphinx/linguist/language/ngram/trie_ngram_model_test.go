@@ -0,0 +1,85 @@
+package ngram
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/jtejido/go-sphinx/util"
+)
+
+// testdata/test.lm.bin is the trie of testdata/test.lm, see testdata/generate.py.
+func TestTrieNgramModel(t *testing.T) {
+	model := NewDefaultTrieNgramModel()
+	model.SetLocation(filepath.Join("testdata", "test.lm.bin"))
+	if err := model.Allocate(); err != nil {
+		t.Fatal(err)
+	}
+	defer model.Deallocate()
+
+	if model.GetMaxDepth() != 3 {
+		t.Fatalf("max depth %d, expected 3", model.GetMaxDepth())
+	}
+	if counts := model.Counts(); len(counts) != 3 || counts[0] != 6 || counts[1] != 6 || counts[2] != 3 {
+		t.Fatalf("counts %v", counts)
+	}
+	if vocabulary := model.GetVocabulary(); len(vocabulary) != 6 || vocabulary[5] != "world" {
+		t.Fatalf("unexpected vocabulary %v", vocabulary)
+	}
+
+	logMath := util.GetLogMath()
+	for _, test := range []struct {
+		words []string
+		log10 float64
+	}{
+		{[]string{"world"}, -0.75},
+		{[]string{"hello", "world"}, -0.2},
+		{[]string{"<s>", "hello", "world"}, -0.1},
+		// backed off with the weights of sphinx and <s> sphinx
+		{[]string{"<s>", "sphinx", "hello"}, -0.1 - 0.2 - 0.69897},
+		// unknown words are <unk>
+		{[]string{"Hello", "there"}, -0.25 - 1.5},
+	} {
+		expected := logMath.Log10ToLog(float32(test.log10))
+		for i := 0; i < 2; i++ { // computed, then cached
+			if p := model.GetProbability(sequence(test.words...)); math.Abs(float64(p-expected)) > 1 {
+				t.Errorf("probability of %v is %v, expected %v", test.words, p, expected)
+			}
+		}
+	}
+}
+
+func TestTrieNgramModelMatchesArpa(t *testing.T) {
+	trie := NewDefaultTrieNgramModel()
+	trie.SetLocation(filepath.Join("testdata", "test.lm.bin"))
+	if err := trie.Allocate(); err != nil {
+		t.Fatal(err)
+	}
+	defer trie.Deallocate()
+	arpa := NewDefaultSimpleNGramModel()
+	arpa.SetLocation(filepath.Join("testdata", "test.lm"))
+	if err := arpa.Allocate(); err != nil {
+		t.Fatal(err)
+	}
+	defer arpa.Deallocate()
+
+	// every sequence of up to three words of the vocabulary, and an unknown one
+	words := append(arpa.GetVocabulary(), "unknown")
+	var sequences [][]string
+	for _, w1 := range words {
+		sequences = append(sequences, []string{w1})
+		for _, w2 := range words {
+			sequences = append(sequences, []string{w1, w2})
+			for _, w3 := range words {
+				sequences = append(sequences, []string{w1, w2, w3})
+			}
+		}
+	}
+	for _, words := range sequences {
+		expected := arpa.GetProbability(sequence(words...))
+		// the log probability of <s> is large enough for the rounding of float32 to matter
+		if p := trie.GetProbability(sequence(words...)); math.Abs(float64(p-expected)) > math.Max(1, 1e-6*math.Abs(float64(expected))) {
+			t.Errorf("probability of %v is %v, the ARPA model gives %v", words, p, expected)
+		}
+	}
+}
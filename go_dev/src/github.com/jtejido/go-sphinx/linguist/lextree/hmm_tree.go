@@ -0,0 +1,340 @@
+package lextree
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/jtejido/go-sphinx/linguist"
+	"github.com/jtejido/go-sphinx/linguist/acoustic"
+	"github.com/jtejido/go-sphinx/linguist/dictionary"
+	"github.com/jtejido/go-sphinx/linguist/language/ngram"
+	"github.com/jtejido/go-sphinx/util"
+)
+
+// A node of the lex tree.
+type Node interface {
+	// Returns the nodes following this one.
+	GetSuccessors() []Node
+
+	// Returns the best unigram log probability of the words below this node, the unigram smear of the node.
+	GetUnigramProbability() float32
+}
+
+type node struct {
+	successors            []Node
+	logUnigramProbability float32
+}
+
+func newNode() node {
+	return node{logUnigramProbability: util.LOG_ZERO}
+}
+
+func (n *node) GetSuccessors() []Node {
+	return n.successors
+}
+
+func (n *node) GetUnigramProbability() float32 {
+	return n.logUnigramProbability
+}
+
+func (n *node) updateUnigramProbability(logProbability float32) {
+	if logProbability > n.logUnigramProbability {
+		n.logUnigramProbability = logProbability
+	}
+}
+
+// A unit of the tree that isn't the last of its pronunciations. The HMM of an internal unit is known from the units
+// around it; the HMM of the first unit of a word depends on the word before it, and is only known during the search.
+type HMMNode struct {
+	node
+	baseUnit, leftUnit, rightUnit *acoustic.Unit
+	position                      acoustic.HMMPosition
+
+	children map[string]*HMMNode
+	ends     map[string]*EndNode
+}
+
+func newHMMNode(baseUnit, leftUnit, rightUnit *acoustic.Unit, position acoustic.HMMPosition) *HMMNode {
+	return &HMMNode{
+		node:      newNode(),
+		baseUnit:  baseUnit,
+		leftUnit:  leftUnit,
+		rightUnit: rightUnit,
+		position:  position,
+		children:  make(map[string]*HMMNode),
+		ends:      make(map[string]*EndNode),
+	}
+}
+
+// Returns the context independent unit of the node.
+func (n *HMMNode) GetBaseUnit() *acoustic.Unit {
+	return n.baseUnit
+}
+
+// Returns the position of the unit in its words.
+func (n *HMMNode) GetPosition() acoustic.HMMPosition {
+	return n.position
+}
+
+// Returns the internal unit following this one, creating it if needed.
+func (n *HMMNode) addChild(baseUnit, rightUnit *acoustic.Unit) *HMMNode {
+	key := baseUnit.Name() + " " + rightUnit.Name()
+	child, ok := n.children[key]
+	if !ok {
+		child = newHMMNode(baseUnit, n.baseUnit, rightUnit, acoustic.INTERNAL)
+		n.children[key] = child
+		n.successors = append(n.successors, child)
+	}
+	return child
+}
+
+// Returns the last unit following this one, creating it if needed.
+func (n *HMMNode) addEnd(baseUnit *acoustic.Unit) *EndNode {
+	end, ok := n.ends[baseUnit.Name()]
+	if !ok {
+		end = newEndNode(baseUnit, n.baseUnit)
+		n.ends[baseUnit.Name()] = end
+		n.successors = append(n.successors, end)
+	}
+	return end
+}
+
+// The last unit of pronunciations, followed by their words. Its HMM depends on the word after it: the search fans it
+// out into one HMM per right context.
+type EndNode struct {
+	node
+	baseUnit, leftUnit *acoustic.Unit
+	words              map[*dictionary.Pronunciation]*WordNode
+}
+
+func newEndNode(baseUnit, leftUnit *acoustic.Unit) *EndNode {
+	return &EndNode{node: newNode(), baseUnit: baseUnit, leftUnit: leftUnit,
+		words: make(map[*dictionary.Pronunciation]*WordNode)}
+}
+
+// Returns the context independent unit of the node.
+func (n *EndNode) GetBaseUnit() *acoustic.Unit {
+	return n.baseUnit
+}
+
+// Returns the unit before this one, nil if this is the only unit of its words.
+func (n *EndNode) GetLeftUnit() *acoustic.Unit {
+	return n.leftUnit
+}
+
+// Returns the position of the unit in its words.
+func (n *EndNode) GetPosition() acoustic.HMMPosition {
+	if n.leftUnit == nil {
+		return acoustic.SINGLE
+	}
+	return acoustic.END
+}
+
+func (n *EndNode) addWord(pronunciation *dictionary.Pronunciation, isFinal bool) *WordNode {
+	word, ok := n.words[pronunciation]
+	if !ok {
+		word = &WordNode{node: newNode(), pronunciation: pronunciation, isFinal: isFinal}
+		n.words[pronunciation] = word
+		n.successors = append(n.successors, word)
+	}
+	return word
+}
+
+// The end of a pronunciation of a word.
+type WordNode struct {
+	node
+	pronunciation *dictionary.Pronunciation
+	isFinal       bool
+}
+
+// Returns the pronunciation of the word.
+func (n *WordNode) GetPronunciation() *dictionary.Pronunciation {
+	return n.pronunciation
+}
+
+// Returns the word.
+func (n *WordNode) GetWord() *dictionary.Word {
+	return n.pronunciation.GetWord()
+}
+
+// Returns the last unit of the pronunciation.
+func (n *WordNode) GetLastUnit() *acoustic.Unit {
+	units := n.pronunciation.GetUnits()
+	return units[len(units)-1]
+}
+
+// Returns whether the word ends the sentence.
+func (n *WordNode) IsFinal() bool {
+	return n.isFinal
+}
+
+// The lexical prefix tree of the words of the language model: the pronunciations sharing their first units share
+// the nodes of these units. The first units of the words are the entry points of the tree, grouped by unit, so that
+// the search enters only the words starting with the right context a word was ended in. Filler words, and the
+// sentence end word, are entered through silence.
+type HMMTree struct {
+	pool           *acoustic.HMMPool
+	dictionary     dictionary.Dictionary
+	languageModel  ngram.LanguageModel
+	addFillerWords bool
+
+	initialNode   *WordNode
+	entryPoints   map[string][]Node
+	entryNames    []string
+	entryUnits    map[string]*acoustic.Unit
+	firstUnits    map[string]*HMMNode
+	singleUnits   map[string]*EndNode
+	rightContexts []*acoustic.Unit
+	numWords      int
+}
+
+// Builds the HMMTree of the vocabulary of the language model.
+//
+// Accepts the pool of the HMMs, the dictionary of the words, the language model, and whether the filler words of the
+// dictionary are added to the tree, besides silence.
+//
+// Returns an error if the dictionary lacks the sentence start or end word.
+func NewHMMTree(pool *acoustic.HMMPool, dictionary dictionary.Dictionary, languageModel ngram.LanguageModel,
+	addFillerWords bool) (*HMMTree, error) {
+	t := &HMMTree{
+		pool:           pool,
+		dictionary:     dictionary,
+		languageModel:  languageModel,
+		addFillerWords: addFillerWords,
+		entryPoints:    make(map[string][]Node),
+		entryUnits:     make(map[string]*acoustic.Unit),
+		firstUnits:     make(map[string]*HMMNode),
+		singleUnits:    make(map[string]*EndNode),
+	}
+	if err := t.compile(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *HMMTree) compile() error {
+	startWord, endWord := t.dictionary.GetSentenceStartWord(), t.dictionary.GetSentenceEndWord()
+	if startWord == nil || endWord == nil {
+		return errors.New("the dictionary has no sentence start or end word")
+	}
+	start := startWord.GetPronunciations()[0]
+	t.initialNode = &WordNode{node: newNode(), pronunciation: start}
+
+	for _, spelling := range t.languageModel.GetVocabulary() {
+		word := t.dictionary.GetWord(spelling)
+		if word == nil || word.IsFiller() {
+			continue
+		}
+		t.addWord(word, t.unigramProbability(word), false)
+	}
+
+	t.addWord(endWord, t.unigramProbability(endWord), true)
+	if silence := t.dictionary.GetSilenceWord(); silence != nil {
+		t.addWord(silence, 0, false)
+	}
+	if t.addFillerWords {
+		for _, word := range t.dictionary.GetFillerWords() {
+			if !word.IsSentenceStartWord() && !word.IsSentenceEndWord() &&
+				word.GetSpelling() != dictionary.SILENCE_SPELLING {
+				t.addWord(word, 0, false)
+			}
+		}
+	}
+
+	// the words can be entered after any right context a word was ended in
+	for name := range t.entryPoints {
+		t.entryNames = append(t.entryNames, name)
+	}
+	sort.Strings(t.entryNames)
+	for _, name := range t.entryNames {
+		t.rightContexts = append(t.rightContexts, t.pool.ContextUnit(t.entryUnits[name], false))
+	}
+	if len(t.rightContexts) > 0 && t.rightContexts[0] == nil {
+		t.rightContexts = []*acoustic.Unit{nil}
+	}
+	return nil
+}
+
+func (t *HMMTree) unigramProbability(word *dictionary.Word) float32 {
+	return t.languageModel.GetProbability(linguist.NewWordSequenceByWordSlice([]*dictionary.Word{word}))
+}
+
+// Returns the name of the entry point of a word starting with the given unit.
+func entryName(unit *acoustic.Unit) string {
+	if unit.IsFiller() {
+		return acoustic.SILENCE_NAME
+	}
+	return unit.Name()
+}
+
+// Adds the pronunciations of a word to the tree.
+func (t *HMMTree) addWord(word *dictionary.Word, logUnigramProbability float32, isFinal bool) {
+	t.numWords++
+	for _, pronunciation := range word.GetPronunciations() {
+		units := pronunciation.GetUnits()
+		if len(units) == 0 {
+			continue
+		}
+		entry := entryName(units[0])
+		t.entryUnits[entry] = units[0]
+
+		var path []interface{ updateUnigramProbability(float32) }
+		var end *EndNode
+		if len(units) == 1 {
+			key := units[0].Name()
+			if end = t.singleUnits[key]; end == nil {
+				end = newEndNode(units[0], nil)
+				t.singleUnits[key] = end
+				t.entryPoints[entry] = append(t.entryPoints[entry], end)
+			}
+		} else {
+			key := units[0].Name() + " " + units[1].Name()
+			current := t.firstUnits[key]
+			if current == nil {
+				current = newHMMNode(units[0], nil, units[1], acoustic.BEGIN)
+				t.firstUnits[key] = current
+				t.entryPoints[entry] = append(t.entryPoints[entry], current)
+			}
+			path = append(path, current)
+			for i := 1; i < len(units)-1; i++ {
+				current = current.addChild(units[i], units[i+1])
+				path = append(path, current)
+			}
+			end = current.addEnd(units[len(units)-1])
+		}
+
+		wordNode := end.addWord(pronunciation, isFinal)
+		for _, n := range append(path, end, wordNode) {
+			n.updateUnigramProbability(logUnigramProbability)
+		}
+	}
+}
+
+// Returns the word node of the sentence start word, which starts the search.
+func (t *HMMTree) GetInitialNode() *WordNode {
+	return t.initialNode
+}
+
+// Returns the first nodes of the words that can follow a word ended in the given right context; all the words if the
+// context is nil.
+func (t *HMMTree) GetEntryPoints(rightContext *acoustic.Unit) []Node {
+	if rightContext == nil {
+		var nodes []Node
+		for _, name := range t.entryNames {
+			nodes = append(nodes, t.entryPoints[name]...)
+		}
+		return nodes
+	}
+	return t.entryPoints[entryName(rightContext)]
+}
+
+// Returns the right contexts the words are ended in: the units the words of the tree start with, silence standing
+// for the fillers. Without right contexts in the acoustic model, the only context is nil.
+func (t *HMMTree) GetRightContexts() []*acoustic.Unit {
+	return t.rightContexts
+}
+
+// Returns the number of words in the tree.
+func (t *HMMTree) GetNumWords() int {
+	return t.numWords
+}
@@ -1,13 +1,13 @@
 package lextree
 
 import (
+	"errors"
+
 	"github.com/jtejido/go-sphinx/linguist"
 	"github.com/jtejido/go-sphinx/linguist/acoustic"
 	"github.com/jtejido/go-sphinx/linguist/dictionary"
 	"github.com/jtejido/go-sphinx/linguist/language/ngram"
-	"github.com/jtejido/go-sphinx/linguist/tiedstate"
-	"github.com/jtejido/go-sphinx/linguist/util"
-	"github.com/jtejido/go-sphinx/utils"
+	"github.com/jtejido/go-sphinx/util"
 )
 
 const (
@@ -19,176 +19,404 @@ const (
 	DEFAULT_UNIGRAM_SMEAR_WEIGHT float64 = 1.0
 )
 
+// A linguist for large vocabularies with n-gram language models. The words of the language model are organized in a
+// lexical prefix tree of their units, the HMMTree, so that the words starting alike share their first HMMs. The
+// search graph is not built ahead: its states are generated from the tree as the search reaches them, each with the
+// word history it is reached with.
+//
+// Units are triphones when the acoustic model has left and right contexts. The first units of words take their left
+// context from the last unit of the word before them. The last unit of a word is expanded in every right context the
+// words of the tree start with, and the word is only followed by the words starting with its right context.
+//
+// With a tree, the word is only known at the end of its units, where its language probability is given. The search
+// can however prune better if the probability is spread along the tree: with unigram smearing, entering a node gives
+// the difference between the best unigram probability of the words below it and the one below its parent, corrected
+// by how likely the words are after the current history. The difference from the language probability of the word is
+// given at its end, so that the path of the word sums up to its language probability.
+//
+// Successors of the states can be kept in an LRU cache, cleared after every recognition.
 type LexTreeLinguist struct {
-
-	// ----------------------------------
-	// Subcomponents that are configured
-	// by the property sheet
-	// -----------------------------------
-	languageModel ngram.LanguageModel
 	acousticModel acoustic.AcousticModel
+	unitManager   *acoustic.UnitManager
+	languageModel ngram.LanguageModel
 	dictionary    dictionary.Dictionary
-	unitManager   acoustic.UnitManager
-
-	// ------------------------------------
-	// Data that is configured by the
-	// property sheet
-	// ------------------------------------
-	addFillerWords, generateUnitStates, wantUnigramSmear       bool
-	unigramSmearWeight                                         float64
-	cacheEnabled                                               bool
-	maxArcCacheSize                                            int
-	languageWeight, logWordInsertionProbability                float64
-	logUnitInsertionProbability, logFillerInsertionProbability float64
-	logSilenceInsertionProbability, logOne                     float64
-
-	// ------------------------------------
-	// Data used for building and maintaining
-	// the search graph
-	// -------------------------------------
-	sentenceEndWord        *dictionary.Word
-	sentenceStartWordArray []*dictionary.Word
-	searchGraph            linguist.SearchGraph
-	hmmPool                *acoustic.HMMPool
-	arcCache               *util.LRUCache
-	maxDepth               int
-	hmmTree                HMMTree
-	cacheTrys, cacheHits   int
-}
-
-func NewLexTreeLinguist(acousticModel acoustic.AcousticModel, unitManager acoustic.UnitManager,
-	languageModel language.LanguageModel, dictionary dictionary.Dictionary, fullWordHistories, wantUnigramSmear bool,
-	wordInsertionProbability, silenceInsertionProbability, fillerInsertionProbability,
-	unitInsertionProbability, languageWeight float64, addFillerWords, generateUnitStates bool,
-	unigramSmearWeight float64, maxArcCacheSize int) *LexTreeLinguist {
-
-	ltl := new(LexTreeLinguist)
-	ltl.acousticModel = acousticModel
-	ltl.unitManager = unitManager
-	ltl.languageModel = languageModel
-	ltl.dictionary = dictionary
-
-	ltl.wantUnigramSmear = wantUnigramSmear
-	ltl.logWordInsertionProbability = logMath.LinearToLog(wordInsertionProbability)
-	ltl.logSilenceInsertionProbability = logMath.LinearToLog(silenceInsertionProbability)
-	ltl.logFillerInsertionProbability = logMath.LinearToLog(fillerInsertionProbability)
-	ltl.logUnitInsertionProbability = logMath.LinearToLog(unitInsertionProbability)
-	ltl.languageWeight = languageWeight
-	ltl.addFillerWords = addFillerWords
-	ltl.generateUnitStates = generateUnitStates
-	ltl.unigramSmearWeight = unigramSmearWeight
-	ltl.maxArcCacheSize = maxArcCacheSize
+	logger        util.Logger
+
+	fullWordHistories, addFillerWords, generateUnitStates, wantUnigramSmear bool
+	unigramSmearWeight                                                      float32
+	maxArcCacheSize                                                         int
+	languageWeight, logWordInsertionProbability                             float64
+	logUnitInsertionProbability, logFillerInsertionProbability              float64
+	logSilenceInsertionProbability                                          float64
+
+	sentenceStartWord *dictionary.Word
+	searchGraph       *LexTreeSearchGraph
+	hmmPool           *acoustic.HMMPool
+	hmmTree           *HMMTree
+	maxDepth          int
+	emptySmear        float32
+
+	// the word histories of the current recognition, so that states of the same history share it
+	wordSequences        map[string]*linguist.WordSequence
+	arcCache             *util.LRUCache[linguist.SearchState, []linguist.SearchStateArc]
+	cacheTrys, cacheHits int
+}
 
-	cacheEnabled := ltl.maxArcCacheSize > 0
+// Creates a LexTreeLinguist with the default probabilities, language weight and options.
+func NewDefaultLexTreeLinguist(acousticModel acoustic.AcousticModel, unitManager *acoustic.UnitManager,
+	languageModel ngram.LanguageModel, dictionary dictionary.Dictionary) *LexTreeLinguist {
+	return NewLexTreeLinguist(acousticModel, unitManager, languageModel, dictionary, DEFAULT_FULL_WORD_HISTORIES,
+		DEFAULT_WANT_UNIGRAM_SMEAR, linguist.DEFAULT_WORD_INSERTION_PROBABILITY,
+		linguist.DEFAULT_SILENCE_INSERTION_PROBABILITY, linguist.DEFAULT_FILLER_INSERTION_PROBABILITY,
+		linguist.DEFAULT_UNIT_INSERTION_PROBABILITY, linguist.DEFAULT_LANGUAGE_WEIGHT, DEFAULT_ADD_FILLER_WORDS,
+		DEFAULT_GENERATE_UNIT_STATES, DEFAULT_UNIGRAM_SMEAR_WEIGHT, DEFAULT_CACHE_SIZE, nil)
+}
 
-	if cacheEnabled {
-		ltl.arcCache = util.NewLRUCache(maxArcCacheSize)
+// Creates a LexTreeLinguist.
+//
+// Accepts the acoustic model providing the HMMs, the unit manager of the dictionary, the language model, the
+// dictionary, whether the word histories are as long as the language model allows or only hold the last word, whether
+// the language probabilities are smeared along the tree, the linear insertion probabilities of words, silences,
+// fillers and units, the language weight, whether the filler words of the dictionary are added to the tree besides
+// silence, whether unit states are generated before the HMMs, the weight of the unigram smear, the size of the arc
+// cache, none if 0, and an optional logger.
+func NewLexTreeLinguist(acousticModel acoustic.AcousticModel, unitManager *acoustic.UnitManager,
+	languageModel ngram.LanguageModel, dictionary dictionary.Dictionary, fullWordHistories, wantUnigramSmear bool,
+	wordInsertionProbability, silenceInsertionProbability, fillerInsertionProbability,
+	unitInsertionProbability, languageWeight float64, addFillerWords, generateUnitStates bool,
+	unigramSmearWeight float64, maxArcCacheSize int, logger util.Logger) *LexTreeLinguist {
+	logMath := util.GetLogMath()
+	return &LexTreeLinguist{
+		acousticModel:                  acousticModel,
+		unitManager:                    unitManager,
+		languageModel:                  languageModel,
+		dictionary:                     dictionary,
+		logger:                         logger,
+		fullWordHistories:              fullWordHistories,
+		addFillerWords:                 addFillerWords,
+		generateUnitStates:             generateUnitStates,
+		wantUnigramSmear:               wantUnigramSmear,
+		unigramSmearWeight:             float32(unigramSmearWeight),
+		maxArcCacheSize:                maxArcCacheSize,
+		languageWeight:                 languageWeight,
+		logWordInsertionProbability:    float64(logMath.LinearToLog(wordInsertionProbability)),
+		logSilenceInsertionProbability: float64(logMath.LinearToLog(silenceInsertionProbability)),
+		logFillerInsertionProbability:  float64(logMath.LinearToLog(fillerInsertionProbability)),
+		logUnitInsertionProbability:    float64(logMath.LinearToLog(unitInsertionProbability)),
 	}
-
-	return ltl
 }
 
-func NewDefaultLexTreeLinguist() *LexTreeLinguist {
+// Loads the dictionary, the acoustic model and the language model, and builds the lex tree.
+func (l *LexTreeLinguist) Allocate() error {
+	if err := l.dictionary.Allocate(); err != nil {
+		return err
+	}
+	if err := l.acousticModel.Allocate(); err != nil {
+		return err
+	}
+	if err := l.languageModel.Allocate(); err != nil {
+		return err
+	}
+	return l.compileGrammar()
+}
 
-	ltl := new(LexTreeLinguist)
-	ltl.acousticModel = tiedstate.NewDefaultTiedStateAcousticModel()
-	ltl.unitManager = acoustic.NewDefaultUnitManager()
-	ltl.languageModel = ngram.NewDefaultSimpleNGramModel()
-	ltl.dictionary = dictionary.NewDefaultTextDictionary()
+// Releases the models and the lex tree.
+func (l *LexTreeLinguist) Deallocate() {
+	if l.acousticModel != nil {
+		l.acousticModel.Deallocate()
+	}
+	if l.dictionary != nil {
+		l.dictionary.Deallocate()
+	}
+	if l.languageModel != nil {
+		l.languageModel.Deallocate()
+	}
+	l.hmmTree = nil
+	l.hmmPool = nil
+	l.searchGraph = nil
+	l.clearCaches()
+}
 
-	ltl.wantUnigramSmear = true
-	ltl.logWordInsertionProbability = logMath.LinearToLog(0.1)
-	ltl.logSilenceInsertionProbability = logMath.LinearToLog(0.1)
-	ltl.logFillerInsertionProbability = logMath.LinearToLog(1e-2)
-	ltl.logUnitInsertionProbability = logMath.LinearToLog(linguist.DEFAULT_UNIT_INSERTION_PROBABILITY)
-	ltl.languageWeight = 8.0
-	ltl.addFillerWords = true
-	ltl.generateUnitStates = false
-	ltl.unigramSmearWeight = 1.
-	ltl.maxArcCacheSize = DEFAULT_CACHE_SIZE
+// Retrieves the search graph, or nil before allocation.
+func (l *LexTreeLinguist) GetSearchGraph() linguist.SearchGraph {
+	if l.searchGraph == nil {
+		return nil
+	}
+	return l.searchGraph
+}
 
-	cacheEnabled := ltl.maxArcCacheSize > 0
+// Nothing is done before a recognition.
+func (l *LexTreeLinguist) StartRecognition() {}
 
-	if cacheEnabled {
-		ltl.arcCache = util.NewLRUCache(maxArcCacheSize)
+// Forgets the word histories and the cached arcs of the recognition, and tells the language model the utterance
+// ended.
+func (l *LexTreeLinguist) StopRecognition() {
+	if l.logger != nil && l.cacheTrys > 0 {
+		l.logger.Infof("Arc cache: %d hits in %d tries", l.cacheHits, l.cacheTrys)
 	}
+	l.clearCaches()
+	l.languageModel.OnUtteranceEnd()
+}
 
-	return ltl
+// Returns the language model of the linguist.
+func (l *LexTreeLinguist) GetLanguageModel() ngram.LanguageModel {
+	return l.languageModel
 }
 
-func (ltl *LexTreeLinguist) Allocate() {
-	ltl.dictionary.Allocate()
-	ltl.acousticModel.Allocate()
-	ltl.languageModel.Allocate()
-	ltl.compileGrammar()
+// Returns the dictionary of the linguist.
+func (l *LexTreeLinguist) GetDictionary() dictionary.Dictionary {
+	return l.dictionary
 }
 
-func (ltl *LexTreeLinguist) Deallocate() {
-	if ltl.acousticModel != nil {
-		ltl.acousticModel.Deallocate()
+// Returns the lex tree, or nil before allocation.
+func (l *LexTreeLinguist) GetHMMTree() *HMMTree {
+	return l.hmmTree
+}
+
+func (l *LexTreeLinguist) clearCaches() {
+	l.wordSequences = make(map[string]*linguist.WordSequence)
+	if l.searchGraph != nil {
+		initialState := l.searchGraph.GetInitialState()
+		l.internWordSequence(initialState.GetWordHistory())
+	}
+	l.arcCache = nil
+	if l.maxArcCacheSize > 0 {
+		l.arcCache = util.NewLRUCache[linguist.SearchState, []linguist.SearchStateArc](l.maxArcCacheSize)
 	}
+	l.cacheTrys, l.cacheHits = 0, 0
+}
 
-	if ltl.dictionary != nil {
-		ltl.dictionary.Deallocate()
+// Builds the lex tree and the initial state of the search graph.
+func (l *LexTreeLinguist) compileGrammar() error {
+	l.sentenceStartWord = l.dictionary.GetSentenceStartWord()
+	if l.sentenceStartWord == nil {
+		return errors.New("the dictionary has no sentence start word")
 	}
+	l.maxDepth = l.languageModel.GetMaxDepth()
+	l.searchGraph = nil
+	l.clearCaches()
 
-	if ltl.languageModel != nil {
-		ltl.languageModel.Deallocate()
+	pool, err := acoustic.NewHMMPool(l.acousticModel, l.unitManager, l.logger)
+	if err != nil {
+		return err
+	}
+	tree, err := NewHMMTree(pool, l.dictionary, l.languageModel, l.addFillerWords)
+	if err != nil {
+		return err
 	}
+	l.hmmPool, l.hmmTree = pool, tree
+	l.emptySmear = l.languageModel.GetSmear(linguist.NewEmptyWordSequence())
+	l.searchGraph = NewLexTreeSearchGraph(l.getInitialSearchState())
 
-	ltl.hmmTree = nil
+	if l.logger != nil {
+		l.logger.Infof("Lex tree of %d words, %d right contexts", tree.GetNumWords(), len(tree.GetRightContexts()))
+	}
+	l.hmmPool.DumpInfo()
+	return nil
 }
 
-func (ltl LexTreeLinguist) GetSearchGraph() {
-	return ltl.searchGraph
+// Retrieves the initial language state: the end of the sentence start word, followed by all the words.
+func (l *LexTreeLinguist) getInitialSearchState() linguist.SearchState {
+	wordSequence := l.internWordSequence(
+		linguist.NewWordSequenceByWordSlice([]*dictionary.Word{l.sentenceStartWord}).Trim(l.historySize()))
+	return LexTreeWordState{
+		LexTreeState: LexTreeState{
+			lexTreeLinguist: l,
+			node:            l.hmmTree.GetInitialNode(),
+			wordSequence:    wordSequence,
+			smearTerm:       l.getSmearTerm(wordSequence),
+		},
+	}
 }
 
-func (ltl *LexTreeLinguist) StartRecognition() {}
-
-func (ltl *LexTreeLinguist) StopRecognition() {
-	ltl.languageModel.OnUtteranceEnd()
+// Returns the number of words the histories of the states keep.
+func (l *LexTreeLinguist) historySize() int {
+	if !l.fullWordHistories {
+		return 1
+	}
+	return l.maxDepth - 1
 }
 
-func (ltl LexTreeLinguist) GetLanguageModel() ngram.LanguageModel {
-	return ltl.languageModel
+// Returns the shared word sequence equal to the given one.
+func (l *LexTreeLinguist) internWordSequence(wordSequence *linguist.WordSequence) *linguist.WordSequence {
+	key := wordSequence.String()
+	if shared, ok := l.wordSequences[key]; ok {
+		return shared
+	}
+	l.wordSequences[key] = wordSequence
+	return wordSequence
 }
 
-func (ltl LexTreeLinguist) GetDictionary() dictionary.Dictionary {
-	return ltl.dictionary
+// Returns how much more likely the words are after the given history than after none.
+func (l *LexTreeLinguist) getSmearTerm(wordSequence *linguist.WordSequence) float32 {
+	if !l.wantUnigramSmear {
+		return util.LOG_ONE
+	}
+	return l.languageModel.GetSmear(wordSequence) - l.emptySmear
 }
 
-// retrieves the initial language state
-func (ltl LexTreeLinguist) getInitialSearchState() linguist.SearchState {
-	node := ltl.hmmTree.GetInitialNode()
-
-	if node == nil {
-		panic("Language model has no entry for initial word <s>")
+// Returns the language probability given once the node is entered.
+func (l *LexTreeLinguist) getSmearProb(node Node, smearTerm float32) float32 {
+	if !l.wantUnigramSmear {
+		return util.LOG_ONE
+	}
+	unigram := node.GetUnigramProbability()
+	if unigram == util.LOG_ZERO {
+		return util.LOG_ZERO
 	}
+	return unigram*l.unigramSmearWeight + smearTerm
+}
 
-	return NewLexTreeWordState(node, node.GetParent(), (NewWordSequenceByWordSlice(ltl.sentenceStartWordArray)).Trim(ltl.maxDepth-1), 0, ltl.logOne, ltl.logOne)
+// Returns the successors of a state, from the arc cache if enabled.
+func (l *LexTreeLinguist) getSuccessors(state linguist.SearchState) []linguist.SearchStateArc {
+	if l.arcCache == nil {
+		return l.generateSuccessors(state)
+	}
+	l.cacheTrys++
+	if arcs, ok := l.arcCache.Get(state); ok {
+		l.cacheHits++
+		return arcs
+	}
+	arcs := l.generateSuccessors(state)
+	l.arcCache.Put(state, arcs)
+	return arcs
 }
 
-func (ltl *LexTreeLinguist) compileGrammar() {
+func (l *LexTreeLinguist) generateSuccessors(state linguist.SearchState) []linguist.SearchStateArc {
+	var arcs []linguist.SearchStateArc
+	switch s := state.(type) {
+	case LexTreeWordState:
+		if s.IsFinal() {
+			return nil
+		}
+		leftContext := s.node.(*WordNode).GetLastUnit()
+		for _, node := range l.hmmTree.GetEntryPoints(s.rightContext) {
+			arcs = l.enterNode(arcs, s.LexTreeState, node, leftContext)
+		}
+	case LexTreeEndUnitState:
+		end := s.node.(*EndNode)
+		for _, rightContext := range l.hmmTree.GetRightContexts() {
+			hmm := l.hmmPool.GetHMM(end.baseUnit, s.leftContext, rightContext, end.GetPosition())
+			if hmm != nil {
+				arcs = l.enterHMM(arcs, s.LexTreeState, hmm, rightContext, 0)
+			}
+		}
+	case LexTreeUnitState:
+		arcs = append(arcs, l.newArc(l.newHMMState(s.LexTreeState, s.hmm.InitialState(), s.rightContext),
+			0, l.logUnitInsertionProbability, 0))
+	case LexTreeNonEmittingHMMState:
+		switch node := s.node.(type) {
+		case *HMMNode:
+			for _, child := range node.GetSuccessors() {
+				arcs = l.enterNode(arcs, s.LexTreeState, child, nil)
+			}
+		case *EndNode:
+			for _, word := range node.GetSuccessors() {
+				arcs = append(arcs, l.enterWord(s.LexTreeState, word.(*WordNode), s.rightContext))
+			}
+		}
+	case LexTreeHMMState:
+		for _, arc := range s.hmmState.Successors() {
+			arcs = append(arcs, l.newArc(l.newHMMState(s.LexTreeState, arc.HMMState(), s.rightContext),
+				0, 0, float64(arc.LogProbability())))
+		}
+	}
+	return arcs
+}
 
-	timer := utils.NewTimer("Compile")
-	timer.Start()
+// Adds the arc entering a node of the tree below the given state, in the given left context for the first units of
+// words.
+func (l *LexTreeLinguist) enterNode(arcs []linguist.SearchStateArc, from LexTreeState, node Node,
+	leftContext *acoustic.Unit) []linguist.SearchStateArc {
+	smearProb := l.getSmearProb(node, from.smearTerm)
+	state := LexTreeState{
+		lexTreeLinguist: l,
+		node:            node,
+		wordSequence:    from.wordSequence,
+		smearTerm:       from.smearTerm,
+		smearProb:       smearProb,
+	}
+	logLanguageProbability := float64(smearProb - from.smearProb)
+
+	switch n := node.(type) {
+	case *HMMNode:
+		left := n.leftUnit
+		if left == nil {
+			left = leftContext
+		}
+		if hmm := l.hmmPool.GetHMM(n.baseUnit, left, n.rightUnit, n.position); hmm != nil {
+			arcs = l.enterHMM(arcs, state, hmm, nil, logLanguageProbability)
+		}
+	case *EndNode:
+		left := n.leftUnit
+		if left == nil {
+			left = leftContext
+		}
+		arcs = append(arcs, l.newArc(LexTreeEndUnitState{state, l.hmmPool.ContextUnit(left, true)},
+			logLanguageProbability, 0, 0))
+	}
+	return arcs
+}
 
-	ltl.sentenceEndWord = dictionary.getSentenceEndWord()
-	ltl.sentenceStartWordArray = []*dictionary.Word{ltl.dictionary.GetSentenceStartWord()}
-	ltl.maxDepth = ltl.languageModel.GetMaxDepth()
+// Adds the arc entering an HMM, through its unit state if unit states are generated.
+func (l *LexTreeLinguist) enterHMM(arcs []linguist.SearchStateArc, state LexTreeState, hmm acoustic.HMM,
+	rightContext *acoustic.Unit, logLanguageProbability float64) []linguist.SearchStateArc {
+	if l.generateUnitStates {
+		return append(arcs, l.newArc(LexTreeUnitState{state, hmm, rightContext}, logLanguageProbability, 0, 0))
+	}
+	return append(arcs, l.newArc(l.newHMMState(state, hmm.InitialState(), rightContext),
+		logLanguageProbability, l.logUnitInsertionProbability, 0))
+}
 
-	ltl.generateHmmTree()
+func (l *LexTreeLinguist) newHMMState(state LexTreeState, hmmState acoustic.HMMState,
+	rightContext *acoustic.Unit) linguist.SearchState {
+	hmmSearchState := LexTreeHMMState{state, hmmState, rightContext}
+	if hmmState.IsExitState() {
+		return LexTreeNonEmittingHMMState{hmmSearchState}
+	}
+	return hmmSearchState
+}
 
-	timer.Stop()
-	// Now that we are all done, dump out some interesting
-	// information about the process
+// Returns the arc ending a word in the given right context. The language probability of the word is given, less what
+// the smear gave on the path to it. Filler words don't change the history.
+func (l *LexTreeLinguist) enterWord(from LexTreeState, node *WordNode, rightContext *acoustic.Unit) linguist.SearchStateArc {
+	word := node.GetWord()
+	wordSequence := from.wordSequence
+	var logProbability float32
+	var logInsertionProbability float64
+
+	switch {
+	case word.IsFiller() && !word.IsSentenceEndWord():
+		logProbability = util.LOG_ONE
+		logInsertionProbability = l.logFillerInsertionProbability
+		if word.GetSpelling() == dictionary.SILENCE_SPELLING {
+			logInsertionProbability = l.logSilenceInsertionProbability
+		}
+	default:
+		logProbability = l.languageModel.GetProbability(wordSequence.AddWord(word, l.maxDepth))
+		logInsertionProbability = l.logWordInsertionProbability
+		wordSequence = l.internWordSequence(wordSequence.AddWord(word, l.historySize()))
+	}
 
-	ltl.searchGraph = NewLexTreeSearchGraph(ltl.getInitialSearchState())
+	state := LexTreeWordState{
+		LexTreeState: LexTreeState{
+			lexTreeLinguist: l,
+			node:            node,
+			wordSequence:    wordSequence,
+			smearTerm:       l.getSmearTerm(wordSequence),
+		},
+		rightContext: rightContext,
+	}
+	return l.newArc(state, float64(logProbability-from.smearProb), logInsertionProbability, 0)
 }
 
-func (ltl *LexTreeLinguist) generateHmmTree() {
-	ltl.hmmPool = acoustic.NewHMMPool(ltl.acousticModel, ltl.unitManager)
-	ltl.hmmTree = NewHMMTree(ltl.hmmPool, ltl.dictionary, ltl.languageModel, ltl.addFillerWords, ltl.languageWeight)
-
-	ltl.hmmPool.DumpInfo()
+func (l *LexTreeLinguist) newArc(state linguist.SearchState, logLanguageProbability, logInsertionProbability,
+	logAcousticProbability float64) linguist.SearchStateArc {
+	return &lexTreeStateArc{
+		state:                   state,
+		logLanguageProbability:  logLanguageProbability,
+		logInsertionProbability: logInsertionProbability,
+		logAcousticProbability:  logAcousticProbability,
+		languageWeight:          l.languageWeight,
+	}
 }
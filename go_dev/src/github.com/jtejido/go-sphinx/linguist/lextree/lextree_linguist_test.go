@@ -0,0 +1,206 @@
+package lextree
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/jtejido/go-sphinx/linguist"
+	"github.com/jtejido/go-sphinx/linguist/acoustic"
+	"github.com/jtejido/go-sphinx/linguist/acoustic/acoustictest"
+	"github.com/jtejido/go-sphinx/linguist/dictionary"
+	"github.com/jtejido/go-sphinx/linguist/language/ngram"
+)
+
+const arpa = `\data\
+ngram 1=7
+ngram 2=4
+
+\1-grams:
+-1.0 <s> -0.5
+-1.2 </s>
+-1.1 call -0.3
+-1.5 calls -0.3
+-1.3 cat -0.3
+-0.9 home
+-1.4 work
+
+\2-grams:
+-0.2 <s> call
+-0.4 call home
+-0.6 call work
+-0.1 home </s>
+
+\end\
+`
+
+func newTestLinguist(t *testing.T, contextSize int, wantUnigramSmear, generateUnitStates bool,
+	maxArcCacheSize int) *LexTreeLinguist {
+	d := acoustictest.NewDictionary(t, "call K AO L\ncalls K AO L Z\ncat K AE T\nhome HH OW M\nwork W ER K\n")
+	lm := ngram.NewDefaultSimpleNGramModel()
+	lm.SetLocation(filepath.Join(acoustictest.WriteFiles(t, map[string]string{"test.lm": arpa}), "test.lm"))
+
+	l := NewLexTreeLinguist(&acoustictest.AcousticModel{ContextSize: contextSize}, d.UnitManager(), lm, d, true,
+		wantUnigramSmear, 0.1, 0.5, 0.01, 1, 8, false, generateUnitStates, 1, maxArcCacheSize, nil)
+	if err := l.Allocate(); err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+type wordArc struct {
+	state                  LexTreeWordState
+	logLanguageProbability float64
+}
+
+// nextWords returns the word states following a state, with the language probability summed along the path to
+// them. Every other state is visited once.
+func nextWords(t *testing.T, state linguist.SearchState) map[string][]wordArc {
+	words := make(map[string][]wordArc)
+	visited := make(map[linguist.SearchState]bool)
+	var visit func(state linguist.SearchState, logLanguageProbability float64)
+	visit = func(state linguist.SearchState, logLanguageProbability float64) {
+		for _, arc := range state.GetSuccessors() {
+			next := arc.GetState()
+			probability := logLanguageProbability + arc.GetLanguageProbability()
+			if word, ok := next.(LexTreeWordState); ok {
+				spelling := word.GetPronunciation().GetWord().GetSpelling()
+				words[spelling] = append(words[spelling], wordArc{word, probability})
+				continue
+			}
+			if next.GetOrder() >= numStateOrder || next.GetOrder() < 0 {
+				t.Fatalf("state %v has order %d", next, next.GetOrder())
+			}
+			if !visited[next] {
+				visited[next] = true
+				visit(next, probability)
+			}
+		}
+	}
+	visit(state, 0)
+	return words
+}
+
+func logProbability(lm ngram.LanguageModel, d dictionary.Dictionary, spellings ...string) float64 {
+	words := make([]*dictionary.Word, len(spellings))
+	for i, spelling := range spellings {
+		words[i] = d.GetWord(spelling)
+	}
+	return float64(lm.GetProbability(linguist.NewWordSequenceByWordSlice(words)))
+}
+
+func TestLexTreeLinguistTriphones(t *testing.T) {
+	l := newTestLinguist(t, 1, true, false, 0)
+	tree := l.GetHMMTree()
+	if tree.GetNumWords() != 7 {
+		t.Fatalf("tree of %d words, expected 7", tree.GetNumWords())
+	}
+	// call and calls share K AO then AO L, cat only starts with K
+	k := tree.GetEntryPoints(l.dictionary.GetWord("call").GetPronunciations()[0].GetUnits()[0])
+	if len(k) != 2 {
+		t.Fatalf("%d entry points for K, expected 2", len(k))
+	}
+	if contexts := tree.GetRightContexts(); len(contexts) != 4 {
+		t.Fatalf("right contexts %v, expected HH K SIL W", contexts)
+	}
+
+	initialState := l.GetSearchGraph().GetInitialState()
+	if initialState.IsFinal() || initialState.GetWordHistory().String() != "<s>" {
+		t.Fatalf("unexpected initial state %v", initialState)
+	}
+
+	for _, arc := range initialState.GetSuccessors() {
+		if arc.GetLanguageProbability() == 0 {
+			t.Fatalf("no smear entering %v", arc.GetState())
+		}
+	}
+
+	words := nextWords(t, initialState)
+	for _, spelling := range []string{"call", "calls", "cat", "home", "work", "</s>", "<sil>"} {
+		if len(words[spelling]) == 0 {
+			t.Fatalf("%s doesn't follow <s>: %v", spelling, words)
+		}
+	}
+	// the path to a word sums up to its language probability, whatever the smear gave on the way
+	for spelling, arcs := range words {
+		expected := 0.0
+		if spelling != "<sil>" {
+			expected = logProbability(l.languageModel, l.dictionary, "<s>", spelling)
+		}
+		for _, arc := range arcs {
+			if math.Abs(arc.logLanguageProbability-expected) > 1 {
+				t.Errorf("language probability of %s is %v, expected %v", spelling, arc.logLanguageProbability, expected)
+			}
+		}
+	}
+	if !words["</s>"][0].state.IsFinal() || words["call"][0].state.IsFinal() {
+		t.Fatal("only </s> must be final")
+	}
+	if len(words["</s>"][0].state.GetSuccessors()) != 0 {
+		t.Fatal("</s> must have no successors")
+	}
+
+	// call ends once per right context, and is only followed by the words starting with it
+	if len(words["call"]) != 4 {
+		t.Fatalf("call ends in %d right contexts, expected 4", len(words["call"]))
+	}
+	for _, arc := range words["call"] {
+		if arc.state.GetWordHistory().String() != "call" {
+			t.Fatalf("history of call is %v", arc.state.GetWordHistory())
+		}
+		rightContext := arc.state.rightContext.Name()
+		for spelling, next := range nextWords(t, arc.state) {
+			first := next[0].state.GetPronunciation().GetUnits()[0]
+			if first.IsFiller() && rightContext != acoustic.SILENCE_NAME || !first.IsFiller() && first.Name() != rightContext {
+				t.Errorf("%s follows call ended in %s", spelling, rightContext)
+			}
+			if rightContext == "HH" && spelling == "home" {
+				expected := logProbability(l.languageModel, l.dictionary, "call", "home")
+				if math.Abs(next[0].logLanguageProbability-expected) > 1 {
+					t.Errorf("language probability of home is %v, expected %v", next[0].logLanguageProbability, expected)
+				}
+			}
+		}
+	}
+
+	// silence doesn't change the history
+	for _, arc := range words["<sil>"] {
+		if arc.state.GetWordHistory() != initialState.GetWordHistory() {
+			t.Fatalf("history after <sil> is %v", arc.state.GetWordHistory())
+		}
+	}
+}
+
+func TestLexTreeLinguistUnitStates(t *testing.T) {
+	l := newTestLinguist(t, 0, false, true, 100)
+	if contexts := l.GetHMMTree().GetRightContexts(); len(contexts) != 1 || contexts[0] != nil {
+		t.Fatalf("right contexts %v, expected none", contexts)
+	}
+
+	initialState := l.GetSearchGraph().GetInitialState()
+	arcs := initialState.GetSuccessors()
+	if again := initialState.GetSuccessors(); &again[0] != &arcs[0] || l.cacheHits != 1 {
+		t.Fatal("successors must come from the arc cache")
+	}
+	for _, arc := range arcs {
+		if _, ok := arc.GetState().(linguist.UnitSearchState); !ok {
+			t.Fatalf("%v follows <s>, expected a unit state", arc.GetState())
+		}
+		if arc.GetLanguageProbability() != 0 {
+			t.Fatalf("language probability %v without smear", arc.GetLanguageProbability())
+		}
+	}
+
+	words := nextWords(t, initialState)
+	if len(words["call"]) != 1 {
+		t.Fatalf("call ends %d times, expected once", len(words["call"]))
+	}
+	if len(nextWords(t, words["call"][0].state)) != 7 {
+		t.Fatal("every word must follow call")
+	}
+
+	l.StopRecognition()
+	if l.arcCache.Len() != 0 || l.cacheHits != 0 {
+		t.Fatal("the arc cache must be cleared after the recognition")
+	}
+}
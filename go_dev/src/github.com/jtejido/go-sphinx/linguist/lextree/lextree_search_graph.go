@@ -4,25 +4,26 @@ import (
 	"github.com/jtejido/go-sphinx/linguist"
 )
 
+// The search graph of the LexTreeLinguist, whose states are generated as the search reaches them.
 type LexTreeSearchGraph struct {
 	initialState linguist.SearchState
 }
 
 func NewLexTreeSearchGraph(initialState linguist.SearchState) *LexTreeSearchGraph {
-	ltsg := new(LexTreeSearchGraph)
-	ltsg.initialState = initialState
-
-	return ltsg
+	return &LexTreeSearchGraph{initialState: initialState}
 }
 
-func (ltsg LexTreeSearchGraph) GetInitialState() linguist.SearchState {
-	return initialState
+// Retrieves initial search state
+func (g *LexTreeSearchGraph) GetInitialState() linguist.SearchState {
+	return g.initialState
 }
 
-func (ltsg LexTreeSearchGraph) GetNumStateOrder() int {
-	return 6
+// Returns the number of different state types maintained in the search graph
+func (g *LexTreeSearchGraph) GetNumStateOrder() int {
+	return numStateOrder
 }
 
-func (ltsg LexTreeSearchGraph) GetWordTokenFirst() bool {
+// Order of words and data tokens: words are scored after their units.
+func (g *LexTreeSearchGraph) GetWordTokenFirst() bool {
 	return false
 }
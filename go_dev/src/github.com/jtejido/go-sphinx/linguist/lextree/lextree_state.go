@@ -2,59 +2,295 @@ package lextree
 
 import (
 	"fmt"
+
 	"github.com/jtejido/go-sphinx/linguist"
+	"github.com/jtejido/go-sphinx/linguist/acoustic"
+	"github.com/jtejido/go-sphinx/linguist/dictionary"
+)
+
+const (
+	// The orders of the states of the search graph. Within a frame, the non-emitting states are always entered in
+	// increasing order, emitting HMM states last.
+	nonEmittingHMMStateOrder = 0
+	wordStateOrder           = 1
+	endUnitStateOrder        = 3
+	unitStateOrder           = 4
+	hmmStateOrder            = 5
+	numStateOrder            = 6
 )
 
-// The LexTreeLinguist returns language states to the search manager. This class forms the base implementation for
-// all language states returned. This LexTreeState keeps track of the probability of entering this state (a
-// language+insertion probability) as well as the unit history. The unit history consists of the LexTree nodes that
-// correspond to the left, center and right contexts.
+// An arc of the search graph, with its probabilities in the LogMath log domain.
+type lexTreeStateArc struct {
+	state                   linguist.SearchState
+	logLanguageProbability  float64
+	logInsertionProbability float64
+	logAcousticProbability  float64
+	languageWeight          float64
+}
+
+// Gets a successor to this search state
+func (arc *lexTreeStateArc) GetState() linguist.SearchState {
+	return arc.state
+}
+
+// Gets the composite probability of entering this state: the language probability scaled by the language weight,
+// the insertion probability and the HMM transition probability.
+func (arc *lexTreeStateArc) GetProbability() float64 {
+	return arc.logLanguageProbability*arc.languageWeight + arc.logInsertionProbability + arc.logAcousticProbability
+}
+
+// Gets the language probability of entering this state
+func (arc *lexTreeStateArc) GetLanguageProbability() float64 {
+	return arc.logLanguageProbability
+}
+
+// Gets the insertion probability of entering this state
+func (arc *lexTreeStateArc) GetInsertionProbability() float64 {
+	return arc.logInsertionProbability
+}
+
+// The LexTreeLinguist returns language states to the search manager. This is what all the language states returned
+// share: the node of the lex tree they stand for, the word history, and the smear of the language probability of the
+// words below the node already given on the path from the last word.
+//
+// The states are values created on demand. Two states of the same node, history and contexts are equal, so that the
+// search merges the paths reaching them.
 type LexTreeState struct {
-	node                               Node
-	wordSequence                       *linguist.WordSequence
-	currentSmearTerm, currentSmearProb float64
+	lexTreeLinguist *LexTreeLinguist
+	node            Node
+	wordSequence    *linguist.WordSequence
+	smearTerm       float32
+	smearProb       float32
+}
+
+// Returns the node of the lex tree
+func (s LexTreeState) GetLexState() interface{} {
+	return s.node
+}
+
+// Gets the word history for this state
+func (s LexTreeState) GetWordHistory() *linguist.WordSequence {
+	return s.wordSequence
+}
+
+// Returns the smear term of the word history: how much more likely the words are after this history than after none.
+func (s LexTreeState) GetSmearTerm() float32 {
+	return s.smearTerm
+}
+
+// Returns the language probability given on the path from the last word to this state.
+func (s LexTreeState) GetSmearProb() float32 {
+	return s.smearProb
+}
+
+// Determines if this is a final state
+func (s LexTreeState) IsFinal() bool {
+	return false
+}
+
+func (s LexTreeState) signature(kind string) string {
+	return fmt.Sprintf("%s-%p-%p", kind, s.node, s.wordSequence)
+}
+
+// The end of a word. The words that can follow it are the ones starting with the right context the last unit of the
+// word was scored in.
+type LexTreeWordState struct {
+	LexTreeState
+	rightContext *acoustic.Unit
+}
+
+// Gets a successor to this search state
+func (s LexTreeWordState) GetSuccessors() []linguist.SearchStateArc {
+	return s.lexTreeLinguist.getSuccessors(s)
+}
+
+// Determines if this is an emitting state
+func (s LexTreeWordState) IsEmitting() bool {
+	return false
+}
+
+// Determines if this is a final state: the end of the sentence end word.
+func (s LexTreeWordState) IsFinal() bool {
+	return s.node.(*WordNode).IsFinal()
+}
+
+// Gets the word (as a pronunciation)
+func (s LexTreeWordState) GetPronunciation() *dictionary.Pronunciation {
+	return s.node.(*WordNode).GetPronunciation()
+}
+
+// Returns false, the state being the end of the word.
+func (s LexTreeWordState) IsWordStart() bool {
+	return false
+}
+
+// Returns the order of this particular state
+func (s LexTreeWordState) GetOrder() int {
+	return wordStateOrder
+}
+
+// Returns a unique signature for this state
+func (s LexTreeWordState) GetSignature() string {
+	return s.signature("lexTreeWordState") + "-" + contextName(s.rightContext)
+}
+
+// Returns a pretty version of the string representation for this object
+func (s LexTreeWordState) ToPrettyString() string {
+	return s.String()
+}
+
+func (s LexTreeWordState) String() string {
+	return fmt.Sprintf("%s[%s]", s.GetPronunciation().GetWord().GetSpelling(), contextName(s.rightContext))
+}
+
+// The last unit of words, before its right context is known. Its successors are the HMMs of the unit in every
+// right context.
+type LexTreeEndUnitState struct {
+	LexTreeState
+	leftContext *acoustic.Unit
+}
+
+// Gets a successor to this search state
+func (s LexTreeEndUnitState) GetSuccessors() []linguist.SearchStateArc {
+	return s.lexTreeLinguist.getSuccessors(s)
+}
+
+// Determines if this is an emitting state
+func (s LexTreeEndUnitState) IsEmitting() bool {
+	return false
+}
+
+// Gets the unit
+func (s LexTreeEndUnitState) GetUnit() *acoustic.Unit {
+	return s.node.(*EndNode).GetBaseUnit()
+}
+
+// Returns the order of this particular state
+func (s LexTreeEndUnitState) GetOrder() int {
+	return endUnitStateOrder
+}
+
+// Returns a unique signature for this state
+func (s LexTreeEndUnitState) GetSignature() string {
+	return s.signature("lexTreeEndUnitState") + "-" + contextName(s.leftContext)
+}
+
+// Returns a pretty version of the string representation for this object
+func (s LexTreeEndUnitState) ToPrettyString() string {
+	return s.String()
+}
+
+func (s LexTreeEndUnitState) String() string {
+	return fmt.Sprintf("%s[%s,?]", s.GetUnit(), contextName(s.leftContext))
+}
+
+// A unit in its contexts, entered before its HMM. The linguist only creates unit states if configured to. The right
+// context is the one the HMM of the last unit of a word was chosen for, nil for the other units.
+type LexTreeUnitState struct {
+	LexTreeState
+	hmm          acoustic.HMM
+	rightContext *acoustic.Unit
 }
 
-func NewLexTreeState(node Node, wordSequence *linguist.WordSequence, smearTerm, smearProb float64) *LexTreeState {
-	lts := new(LexTreeState)
-	lts.node = node
-	lts.wordSequence = wordSequence
-	lts.currentSmearProb = smearProb
-	lts.currentSmearTerm = smearTerm
+// Gets a successor to this search state
+func (s LexTreeUnitState) GetSuccessors() []linguist.SearchStateArc {
+	return s.lexTreeLinguist.getSuccessors(s)
+}
+
+// Determines if this is an emitting state
+func (s LexTreeUnitState) IsEmitting() bool {
+	return false
+}
+
+// Gets the unit
+func (s LexTreeUnitState) GetUnit() *acoustic.Unit {
+	return s.hmm.Unit()
+}
+
+// Returns the order of this particular state
+func (s LexTreeUnitState) GetOrder() int {
+	return unitStateOrder
+}
+
+// Returns a unique signature for this state
+func (s LexTreeUnitState) GetSignature() string {
+	return fmt.Sprintf("%s-%p-%s", s.signature("lexTreeUnitState"), s.hmm, contextName(s.rightContext))
+}
+
+// Returns a pretty version of the string representation for this object
+func (s LexTreeUnitState) ToPrettyString() string {
+	return s.String()
+}
+
+func (s LexTreeUnitState) String() string {
+	return s.GetUnit().String()
+}
+
+// A state of the HMM of a unit. The right context is the one the HMM of the last unit of a word was chosen for, nil
+// for the other units.
+type LexTreeHMMState struct {
+	LexTreeState
+	hmmState     acoustic.HMMState
+	rightContext *acoustic.Unit
+}
+
+// Gets a successor to this search state
+func (s LexTreeHMMState) GetSuccessors() []linguist.SearchStateArc {
+	return s.lexTreeLinguist.getSuccessors(s)
+}
+
+// Determines if this is an emitting state
+func (s LexTreeHMMState) IsEmitting() bool {
+	return s.hmmState.IsEmitting()
+}
+
+// Gets the HMM state
+func (s LexTreeHMMState) GetHMMState() acoustic.HMMState {
+	return s.hmmState
+}
 
-	return lts
+// Returns the order of this particular state
+func (s LexTreeHMMState) GetOrder() int {
+	return hmmStateOrder
 }
 
-func (lts *LexTreeState) GetSignature() string {
-	return fmt.Sprintf("lts-%d-ws-%d", lts.node.HashCode(), wordSequence)
+// Returns a unique signature for this state
+func (s LexTreeHMMState) GetSignature() string {
+	return fmt.Sprintf("%s-%p-%s", s.signature("lexTreeHMMState"), s.hmmState, contextName(s.rightContext))
 }
 
-func (lts *LexTreeState) GetSmearTerm() float64 {
-	return lts.currentSmearTerm
+// Returns a pretty version of the string representation for this object
+func (s LexTreeHMMState) ToPrettyString() string {
+	return s.String()
 }
 
-func (lts *LexTreeState) GetSmearProb() float64 {
-	return lts.currentSmearProb
+func (s LexTreeHMMState) String() string {
+	return fmt.Sprintf("%s:%d", s.hmmState.HMM().Unit(), s.hmmState.State())
 }
 
-func (lts *LexTreeState) HashCode() int {
-	hashCode := lts.wordSequence.HashCode() * 37
-	hashCode += lts.node.HashCode()
-	return hashCode
+// The exit state of the HMM of a unit, leading to the units following it in the tree, or to the words it ends.
+type LexTreeNonEmittingHMMState struct {
+	LexTreeHMMState
 }
 
-func (lts *LexTreeState) GetState() linguist.SearchState {
-	return lts
+// Gets a successor to this search state
+func (s LexTreeNonEmittingHMMState) GetSuccessors() []linguist.SearchStateArc {
+	return s.lexTreeLinguist.getSuccessors(s)
 }
 
-func (lts *LexTreeState) GetProbability() float64 {
-	return lts.getLanguageProbability() + lts.getInsertionProbability()
+// Determines if this is an emitting state
+func (s LexTreeNonEmittingHMMState) IsEmitting() bool {
+	return false
 }
 
-func (lts *LexTreeState) GetLanguageProbability() float64 {
-	return lts.logOne
+// Returns the order of this particular state
+func (s LexTreeNonEmittingHMMState) GetOrder() int {
+	return nonEmittingHMMStateOrder
 }
 
-func (lts *LexTreeState) GetInsertionProbability() float64 {
-	return lts.logOne
+func contextName(unit *acoustic.Unit) string {
+	if unit == nil {
+		return "*"
+	}
+	return unit.Name()
 }
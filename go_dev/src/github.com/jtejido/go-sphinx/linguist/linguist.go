@@ -30,8 +30,8 @@ type Linguist interface {
 	StopRecognition()
 
 	// Allocates the linguist. Resources allocated by the linguist are allocated here. This method may take many seconds
-	// to complete depending upon the linguist.
-	Allocate()
+	// to complete depending upon the linguist, and returns an error if the resources can't be loaded.
+	Allocate() error
 
 	// Deallocates the linguist. Any resources allocated by this linguist are released.
 	Deallocate()
@@ -9,5 +9,5 @@ type UnitSearchState interface {
 	SearchState
 
 	// Gets the unit
-	GetUnit() acoustic.Unit
+	GetUnit() *acoustic.Unit
 }
@@ -1,14 +1,15 @@
 package linguist
 
 import (
+	"strings"
+
 	"github.com/jtejido/go-sphinx/linguist/dictionary"
-	"math"
 )
 
 // This class can be used to keep track of a word sequence.
 type WordSequence struct {
 	words    []*dictionary.Word // making this private ensures immutability
-	hashCode int64
+	hashCode int
 }
 
 func NewEmptyWordSequence() *WordSequence {
@@ -66,7 +67,7 @@ func (ws *WordSequence) AddWord(word *dictionary.Word, maxSize int) *WordSequenc
 	nextIndex--
 
 	for nextIndex >= 0 && thisIndex >= 0 {
-		next.words[nextIndex] = this.words[thisIndex]
+		next.words[nextIndex] = ws.words[thisIndex]
 		nextIndex--
 		thisIndex--
 	}
@@ -118,7 +119,7 @@ func (ws *WordSequence) Trim(maxSize int) *WordSequence {
 	nextIndex := len(next.words) - 1
 
 	for i := 0; i < maxSize; i++ {
-		next.words[nextIndex] = this.words[thisIndex]
+		next.words[nextIndex] = ws.words[thisIndex]
 		nextIndex--
 		thisIndex--
 	}
@@ -152,7 +153,7 @@ func (ws *WordSequence) HashCode() int {
 	return ws.hashCode
 }
 
-//  Returns a subsequence with both startIndex and stopIndex exclusive.
+// Returns a subsequence with both startIndex and stopIndex exclusive.
 func (ws WordSequence) GetSubSequence(startIndex, stopIndex int) *WordSequence {
 
 	subseqWords := make([]*dictionary.Word, 0)
@@ -175,10 +176,19 @@ func (ws WordSequence) Equals(other *WordSequence) bool {
 	}
 
 	for i := 0; i < len(ws.words); i++ {
-		if !words[i].Equals(other.words[i]) {
+		if !ws.words[i].Equals(other.words[i]) {
 			return false
 		}
 	}
 
 	return true
 }
+
+// Returns the spellings of the words, separated by spaces
+func (ws WordSequence) String() string {
+	spellings := make([]string, len(ws.words))
+	for i, word := range ws.words {
+		spellings[i] = word.GetSpelling()
+	}
+	return strings.Join(spellings, " ")
+}
@@ -61,9 +61,10 @@ type Recognizer struct {
 	monitors          []instrumentation.Monitor
 }
 
-func NewDefaultRecognizer() *Recognizer {
+// Creates a recognizer of the given decoder, without monitors.
+func NewDefaultRecognizer(decoder *decoder.Decoder) *Recognizer {
 	rec := new(Recognizer)
-	rec.decoder = decoder.NewDefaultDecoder()
+	rec.decoder = decoder
 	rec.monitors = nil
 	return rec
 }
@@ -77,22 +78,23 @@ func NewRecognizer(decoder *decoder.Decoder, monitors []instrumentation.Monitor)
 }
 
 // Performs recognition for the given number of input frames, or until a 'final' result is generated. This method
-// should only be called when the recognizer is in the allocated state.
-func (dr *Recognizer) Recognize(referenceText io.Reader) (result *result.Result) {
+// should only be called when the recognizer is in the allocated state. Returns the error which stopped the search, if
+// any.
+func (dr *Recognizer) Recognize(referenceText io.Reader) (result *result.Result, err error) {
 
 	dr.checkState(READY)
 
 	block{
 		try: func() {
 			dr.setState(RECOGNIZING)
-			result = dr.decoder.Decode(referenceText)
+			result, err = dr.decoder.Decode(referenceText)
 		},
 		finally: func() {
 			dr.setState(READY)
 		},
 	}.do()
 
-	return result
+	return result, err
 }
 
 // Checks to ensure that the recognizer is in the given state.
@@ -113,13 +115,18 @@ func (dr *Recognizer) setState(newState State) {
 }
 
 // Allocate the resources needed for the recognizer. Note this method make take some time to complete. This method
-// should only be called when the recognizer is in the deallocated state.
-func (dr *Recognizer) Allocate() {
+// should only be called when the recognizer is in the deallocated state. Returns the error which left the decoder
+// unallocated, if any, in which case the recognizer stays deallocated.
+func (dr *Recognizer) Allocate() error {
 	dr.checkState(DEALLOCATED)
 	dr.setState(ALLOCATING)
-	dr.decoder.Allocate()
+	if err := dr.decoder.Allocate(); err != nil {
+		dr.setState(DEALLOCATED)
+		return err
+	}
 	dr.setState(ALLOCATED)
 	dr.setState(READY)
+	return nil
 }
 
 // Deallocates the recognizer. This method should only be called if the recognizer is in the allocated state.
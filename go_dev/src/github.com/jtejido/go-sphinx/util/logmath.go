@@ -12,6 +12,14 @@ type LogMath struct {
 	theAddTable           []float32
 }
 
+const (
+	// The log of zero, the lowest value of the log domain.
+	LOG_ZERO float32 = -math.MaxFloat32
+
+	// The log of one.
+	LOG_ONE float32 = 0
+)
+
 var (
 	logBase  = 1.0001
 	useTable = true
@@ -102,6 +110,45 @@ func (lm *LogMath) LnToLog(logSource float32) float32 {
 	return (logSource * lm.inverseNaturalLogBase)
 }
 
+/**
+ * Converts the source, which is a number in base 10, to a log value which base is the LogBase of this LogMath.
+ *
+ * @return converted value
+ * @param logSource the number in base 10 to convert
+ */
+func (lm *LogMath) Log10ToLog(logSource float32) float32 {
+	if logSource == LOG_ZERO {
+		return LOG_ZERO
+	}
+	return lm.LnToLog(logSource * math.Ln10)
+}
+
+/**
+ * Returns the summation of two numbers when the arguments and the result are in log. That is, it returns
+ * log(a + b) given log(a) and log(b).
+ *
+ * @param logVal1 value in log domain (i.e. log(val1)) to add
+ * @param logVal2 value in log domain (i.e. log(val2)) to add
+ * @return sum of val1 and val2 in the log domain
+ */
+func (lm *LogMath) AddAsLinear(logVal1, logVal2 float32) float32 {
+	highest, lowest := logVal1, logVal2
+	if lowest > highest {
+		highest, lowest = lowest, highest
+	}
+	if lowest == LOG_ZERO {
+		return highest
+	}
+	index := float64(highest - lowest)
+	if lm.theAddTable == nil {
+		return highest + lm.LinearToLog(1.0+lm.LogToLinear(-float32(index)))
+	}
+	if i := int(index + 0.5); i < len(lm.theAddTable) {
+		return highest + lm.theAddTable[i]
+	}
+	return highest
+}
+
 // linearToLog converts linear scale to log scale
 func (lm *LogMath) LinearToLog(linear float64) float32 {
 	return float32(math.Log(linear)) * lm.inverseNaturalLogBase
@@ -0,0 +1,65 @@
+package util
+
+import "container/list"
+
+// An LRU cache: a map holding at most a given number of entries, which drops the least recently used entry when a
+// new one doesn't fit.
+type LRUCache[K comparable, V any] struct {
+	maxSize int
+	entries map[K]*list.Element
+	order   *list.List
+}
+
+type lruEntry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// Creates an LRUCache holding at most maxSize entries.
+func NewLRUCache[K comparable, V any](maxSize int) *LRUCache[K, V] {
+	return &LRUCache[K, V]{
+		maxSize: maxSize,
+		entries: make(map[K]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// Get returns the value of the key, and whether it is in the cache. The entry becomes the most recently used.
+func (c *LRUCache[K, V]) Get(key K) (V, bool) {
+	element, ok := c.entries[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*lruEntry[K, V]).value, true
+}
+
+// Put sets the value of the key, dropping the least recently used entry if the cache is full.
+func (c *LRUCache[K, V]) Put(key K, value V) {
+	if element, ok := c.entries[key]; ok {
+		element.Value.(*lruEntry[K, V]).value = value
+		c.order.MoveToFront(element)
+		return
+	}
+	if c.order.Len() >= c.maxSize {
+		oldest := c.order.Back()
+		if oldest == nil {
+			return
+		}
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry[K, V]).key)
+	}
+	c.entries[key] = c.order.PushFront(&lruEntry[K, V]{key, value})
+}
+
+// Len returns the number of entries in the cache.
+func (c *LRUCache[K, V]) Len() int {
+	return c.order.Len()
+}
+
+// Clear removes all the entries.
+func (c *LRUCache[K, V]) Clear() {
+	c.entries = make(map[K]*list.Element)
+	c.order.Init()
+}
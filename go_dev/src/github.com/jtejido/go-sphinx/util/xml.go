@@ -70,17 +70,18 @@ func (p *Parser) SetHTMLMode() {
 
 // Parse calls handler's methods
 // when the parser encount a start-element,a end-element, a comment and so on.
-func (p *Parser) Parse() (err error) {
+// It stops at the first syntax error, which is passed to the handler and returned.
+func (p *Parser) Parse() error {
 	p.handler.StartDocument()
 
 	for {
 		token, err := p.Token()
 		if err == io.EOF {
-			err = nil
 			break
 		}
 		if err != nil {
 			p.handler.Error(err)
+			return err
 		}
 
 		switch token.(type) {
@@ -108,5 +109,5 @@ func (p *Parser) Parse() (err error) {
 	}
 
 	p.handler.EndDocument()
-	return
+	return nil
 }